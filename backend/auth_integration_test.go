@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"go-chat/backend/config"
 	"go-chat/backend/database"
@@ -31,7 +32,7 @@ func TestRegisterUser_Integration(t *testing.T) {
 	cfg := &config.Config{} // 在這個測試中，RegisterUser 不會用到 cfg，所以可以給空值
 
 	// 3. 建立我們要測試的 AuthHandler，並注入「真實」的 store
-	authHandler := handlers.NewAuthHandler(userStore, cfg)
+	authHandler := handlers.NewAuthHandler(userStore, store.NewMongoRefreshTokenStore(), store.NewMongoSessionStore(), store.NewRedisTokenDenylist(cfg.AccessTokenTTL), cfg)
 
 	// --- 測試案例: 成功註冊一個新使用者 ---
 	t.Run("成功註冊", func(t *testing.T) {
@@ -111,9 +112,11 @@ func TestRegisterUser_Integration(t *testing.T) {
 func TestLoginUser_Integration(t *testing.T) {
 	userStore := store.NewMongoUserStore()
 	cfg := &config.Config{
-		JWTSecret: "integration-test-secret",
+		JWTSecret:       "integration-test-secret",
+		AccessTokenTTL:  15 * time.Minute,
+		RefreshTokenTTL: time.Hour,
 	}
-	authHandler := handlers.NewAuthHandler(userStore, cfg)
+	authHandler := handlers.NewAuthHandler(userStore, store.NewMongoRefreshTokenStore(), store.NewMongoSessionStore(), store.NewRedisTokenDenylist(cfg.AccessTokenTTL), cfg)
 	usersCollection := database.GetCollection("users")
 	ctx := context.Background()
 
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"go-chat/backend/database"
+	"go-chat/backend/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TestListPublicChannels_Integration 測試頻道目錄由資料庫計算成員數與是否已加入
+func TestListPublicChannels_Integration(t *testing.T) {
+	room, userIDs := insertRoomMembers(t, "directory-owner", "directory-member")
+	_, err := database.GetCollection("chatrooms").UpdateOne(context.Background(), bson.M{"_id": room.ID}, bson.M{"$set": bson.M{
+		"kind":   models.RoomKindChannel,
+		"public": true,
+		"name":   "directory-公開頻道",
+		"topic":  "每週同步",
+	}})
+	require.NoError(t, err)
+
+	channels, total, err := database.ListPublicChannels(userIDs[1], "directory-", 0, 20)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), total)
+	require.Len(t, channels, 1)
+	assert.Equal(t, models.ChannelDirectoryEntry{ID: room.ID, Name: "directory-公開頻道", Topic: "每週同步", MemberCount: 2, Joined: true}, channels[0])
+
+	channels, _, err = database.ListPublicChannels(primitive.NewObjectID(), "每週", 0, 20)
+	require.NoError(t, err)
+	require.Len(t, channels, 1)
+	assert.False(t, channels[0].Joined, "不是成員的使用者看到的頻道不應該標示為已加入")
+	assert.Equal(t, 2, channels[0].MemberCount)
+}
@@ -1,26 +1,102 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
 	"github.com/joho/godotenv" // 引入這個庫來讀取 .env 檔案
 	"log"
+	"net/netip"
 	"os"
 	"strconv"
 	"strings"
 	"time"
 )
 
+// defaultJWTSecret 是 JWT_SECRET 未設定時的預設值，只能在開發模式使用
+const defaultJWTSecret = "your_super_secret_jwt_key_please_change_this_in_production"
+
+// JWT 簽章演算法
+const (
+	JWTAlgorithmHS256 = "HS256" // 以 JWT_SECRET 簽署，只有本服務能驗證
+	JWTAlgorithmRS256 = "RS256"
+	JWTAlgorithmEdDSA = "EdDSA"
+)
+
 // Config 結構體用於儲存應用程式的配置
 type Config struct {
 	MongoDBURI           string
 	DBName               string
 	Port                 string
 	JWTSecret            string
+	AccessTokenTTL       time.Duration // access token 的有效時間
+	RefreshTokenTTL      time.Duration // refresh token 閒置多久後失效，每次換發都會重新計算
 	GoogleClientID       string
 	GoogleClientSecret   string
 	GoogleRedirectURL    string
 	RedisAddr            string
 	RedisCacheExpiration time.Duration
+	MessageRetention     time.Duration // 訊息保留多久後自動清除，封存聊天室的訊息不會被清除
 	LoadtestMode         bool
+	PublicBaseURL        string // 對外可存取的後端網址，用於產生 incoming webhook URL
+	FrontendBaseURL      string // 前端網址，用於產生郵件中的連結
+	PasswordResetTTL     time.Duration
+	EmailVerificationTTL time.Duration
+	SMTPHost             string // 未設定時郵件只保存在記憶體中，不會寄出
+	SMTPPort             string
+	SMTPUsername         string
+	SMTPPassword         string
+	MailFrom             string
+	// RequireEmailVerification 為 true 時，Email 尚未驗證的帳號不能登入，也不能建立 WebSocket 連線
+	RequireEmailVerification bool
+	// TwoFactorEncryptionKey 是加密 TOTP 密鑰的 AES-256 金鑰
+	TwoFactorEncryptionKey []byte
+	// 密碼登入的限流與帳號鎖定
+	LoginIPLimit       int // 同一個 IP 在 LoginAttemptWindow 內最多嘗試的次數
+	LoginAccountLimit  int // 同一個帳號在 LoginAttemptWindow 內最多嘗試的次數
+	LoginAttemptWindow time.Duration
+	LoginMaxFailures   int           // 連續失敗幾次後鎖定帳號
+	LoginLockoutBase   time.Duration // 第一次鎖定的時間，24 小時內再次鎖定時加倍
+	LoginLockoutMax    time.Duration
+	// OIDCProviders 是可用於登入的 OpenID Connect 提供者，設定 GOOGLE_CLIENT_ID 時會包含 Google
+	OIDCProviders []OIDCProviderConfig
+	// OAuthStateKey 是簽署外部登入 state cookie 的 HMAC 金鑰
+	OAuthStateKey []byte
+	// PostLoginRedirectURL 是外部登入成功後預設導向的網址
+	PostLoginRedirectURL string
+	// AllowedRedirectOrigins 是登入後允許導向的來源 (scheme://host[:port])，預設只有 FrontendBaseURL
+	AllowedRedirectOrigins []string
+	// DevMode 為 true 時允許使用預設的 JWT_SECRET 等只適用於開發環境的設定
+	DevMode bool
+	// JWTAlgorithm 是簽發 token 的演算法，RS256 與 EdDSA 的金鑰會自動輪替並由 /.well-known/jwks.json 公開
+	JWTAlgorithm string
+	// JWTKeyEncryptionKey 是加密保存 RS256/EdDSA 私鑰的 AES-256 金鑰
+	JWTKeyEncryptionKey []byte
+	// JWTKeyRotationInterval 是簽章金鑰使用多久後輪替
+	JWTKeyRotationInterval time.Duration
+	// JWTKeyGracePeriod 是輪替後舊金鑰仍可用於驗證的時間，不能短於 AccessTokenTTL
+	JWTKeyGracePeriod time.Duration
+	// TrustedProxies 是可信任的反向代理 (IP 或 CIDR)，只有來自這些位址的請求才會讀取 X-Forwarded-For
+	TrustedProxies []netip.Prefix
+
+	// invalidTrustedProxies 是 TRUSTED_PROXIES 中無法解析的項目，由 Validate 回報
+	invalidTrustedProxies []string
+	// missingEncryptionKeys 是必須設定卻未設定、暫時由 JWT_SECRET 推導的加密金鑰，只有開發模式允許
+	missingEncryptionKeys []string
+	// invalidEncryptionKeys 是格式錯誤的加密金鑰，一律拒絕啟動
+	invalidEncryptionKeys []string
+}
+
+// OIDCProviderConfig 是單一 OpenID Connect 提供者的設定
+type OIDCProviderConfig struct {
+	ID           string // 出現在 /auth/{id}/login 網址中的識別名稱
+	DisplayName  string // 顯示在登入按鈕上的名稱
+	Issuer       string // 用於 discovery 的 issuer 網址，也是 ID token 的 iss
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
 }
 
 // LoadConfig 載入配置，優先從環境變數讀取，其次從 .env 檔案讀取
@@ -41,20 +117,82 @@ func LoadConfig() *Config {
 	cacheExpiration := time.Duration(expMinutes) * time.Minute
 
 	cfg := &Config{
-		MongoDBURI:           getEnv("MONGODB_URI", "mongodb://localhost:27017"),
-		DBName:               getEnv("DB_NAME", "chat_app_db"),
-		Port:                 getEnv("PORT", "8080"),
-		JWTSecret:            getEnv("JWT_SECRET", "your_super_secret_jwt_key_please_change_this_in_production"),
-		GoogleClientID:       getEnv("GOOGLE_CLIENT_ID", ""),
-		GoogleClientSecret:   getEnv("GOOGLE_CLIENT_SECRET", ""),
-		GoogleRedirectURL:    getEnv("GOOGLE_REDIRECT_URL", ""),
-		RedisAddr:            getEnv("REDIS_ADDR", "localhost:6379"),
-		RedisCacheExpiration: cacheExpiration,
-		LoadtestMode:         getEnvBool("LOADTEST_MODE", false),
+		MongoDBURI:               getEnv("MONGODB_URI", "mongodb://localhost:27017"),
+		DBName:                   getEnv("DB_NAME", "chat_app_db"),
+		Port:                     getEnv("PORT", "8080"),
+		JWTSecret:                getEnv("JWT_SECRET", defaultJWTSecret),
+		AccessTokenTTL:           getEnvMinutes("ACCESS_TOKEN_TTL_MINUTES", 15),
+		RefreshTokenTTL:          getEnvMinutes("REFRESH_TOKEN_TTL_MINUTES", 30*24*60),
+		GoogleClientID:           getEnv("GOOGLE_CLIENT_ID", ""),
+		GoogleClientSecret:       getEnv("GOOGLE_CLIENT_SECRET", ""),
+		GoogleRedirectURL:        getEnv("GOOGLE_REDIRECT_URL", ""),
+		RedisAddr:                getEnv("REDIS_ADDR", "localhost:6379"),
+		RedisCacheExpiration:     cacheExpiration,
+		MessageRetention:         getEnvMinutes("MESSAGE_RETENTION_MINUTES", 30),
+		LoadtestMode:             getEnvBool("LOADTEST_MODE", false),
+		PublicBaseURL:            strings.TrimRight(getEnv("PUBLIC_BASE_URL", "http://localhost:8080"), "/"),
+		FrontendBaseURL:          strings.TrimRight(getEnv("FRONTEND_BASE_URL", "http://localhost:5173"), "/"),
+		PasswordResetTTL:         getEnvMinutes("PASSWORD_RESET_TTL_MINUTES", 30),
+		EmailVerificationTTL:     getEnvMinutes("EMAIL_VERIFICATION_TTL_MINUTES", 24*60),
+		RequireEmailVerification: getEnvBool("REQUIRE_EMAIL_VERIFICATION", false),
+		SMTPHost:                 getEnv("SMTP_HOST", ""),
+		SMTPPort:                 getEnv("SMTP_PORT", "587"),
+		SMTPUsername:             getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:             getEnv("SMTP_PASSWORD", ""),
+		MailFrom:                 getEnv("MAIL_FROM", "GoChat <no-reply@localhost>"),
+	}
+	cfg.TwoFactorEncryptionKey = cfg.loadEncryptionKey("TOTP_ENCRYPTION_KEY", true)
+	cfg.LoginIPLimit = getEnvInt("LOGIN_IP_LIMIT", 30)
+	cfg.LoginAccountLimit = getEnvInt("LOGIN_ACCOUNT_LIMIT", 10)
+	cfg.LoginAttemptWindow = getEnvMinutes("LOGIN_ATTEMPT_WINDOW_MINUTES", 15)
+	cfg.LoginMaxFailures = getEnvInt("LOGIN_MAX_FAILURES", 5)
+	cfg.LoginLockoutBase = getEnvMinutes("LOGIN_LOCKOUT_MINUTES", 1)
+	cfg.LoginLockoutMax = getEnvMinutes("LOGIN_LOCKOUT_MAX_MINUTES", 60)
+	cfg.OIDCProviders = loadOIDCProviders(cfg)
+	cfg.OAuthStateKey = cfg.loadEncryptionKey("OAUTH_STATE_KEY", true)
+	cfg.PostLoginRedirectURL = getEnv("POST_LOGIN_REDIRECT_URL", cfg.FrontendBaseURL+"/home")
+	for _, origin := range strings.Split(getEnv("ALLOWED_REDIRECT_ORIGINS", cfg.FrontendBaseURL), ",") {
+		if origin = strings.ToLower(strings.TrimRight(strings.TrimSpace(origin), "/")); origin != "" {
+			cfg.AllowedRedirectOrigins = append(cfg.AllowedRedirectOrigins, origin)
+		}
 	}
+	cfg.DevMode = getEnvBool("DEV_MODE", false)
+	cfg.JWTAlgorithm = getEnv("JWT_ALGORITHM", JWTAlgorithmHS256)
+	// HS256 不會產生需要加密保存的私鑰，只有 RS256 與 EdDSA 必須設定
+	cfg.JWTKeyEncryptionKey = cfg.loadEncryptionKey("JWT_KEY_ENCRYPTION_KEY", cfg.JWTAlgorithm != JWTAlgorithmHS256)
+	cfg.JWTKeyRotationInterval = getEnvMinutes("JWT_KEY_ROTATION_MINUTES", 30*24*60)
+	cfg.JWTKeyGracePeriod = getEnvMinutes("JWT_KEY_GRACE_MINUTES", 24*60)
+	cfg.TrustedProxies, cfg.invalidTrustedProxies = parseTrustedProxies(getEnv("TRUSTED_PROXIES", ""))
 	return cfg
 }
 
+// Validate 檢查不能安全啟動的設定，main 在啟動前呼叫
+func (c *Config) Validate() error {
+	if c.JWTSecret == defaultJWTSecret && !c.DevMode {
+		return errors.New("JWT_SECRET is using the built-in default; set JWT_SECRET (or DEV_MODE=true for local development)")
+	}
+	switch c.JWTAlgorithm {
+	case JWTAlgorithmHS256, JWTAlgorithmRS256, JWTAlgorithmEdDSA:
+	default:
+		return fmt.Errorf("unsupported JWT_ALGORITHM %q, use %s, %s or %s", c.JWTAlgorithm, JWTAlgorithmHS256, JWTAlgorithmRS256, JWTAlgorithmEdDSA)
+	}
+	// 舊金鑰在 access token 過期前被移除時，已簽發的 token 會提早失效
+	if c.JWTKeyGracePeriod < c.AccessTokenTTL {
+		return fmt.Errorf("JWT_KEY_GRACE_MINUTES (%s) must not be shorter than ACCESS_TOKEN_TTL_MINUTES (%s)", c.JWTKeyGracePeriod, c.AccessTokenTTL)
+	}
+	if len(c.invalidTrustedProxies) > 0 {
+		return fmt.Errorf("TRUSTED_PROXIES contains invalid IP or CIDR: %s", strings.Join(c.invalidTrustedProxies, ", "))
+	}
+	if len(c.invalidEncryptionKeys) > 0 {
+		return fmt.Errorf("%s must be 32 bytes encoded in base64", strings.Join(c.invalidEncryptionKeys, ", "))
+	}
+	// 由 JWT_SECRET 推導的金鑰會在更換 JWT_SECRET 時一起改變，已加密的資料將無法解密
+	if len(c.missingEncryptionKeys) > 0 && !c.DevMode {
+		return fmt.Errorf("%s must be set (32 bytes encoded in base64, e.g. `openssl rand -base64 32`), or set DEV_MODE=true to derive them from JWT_SECRET", strings.Join(c.missingEncryptionKeys, ", "))
+	}
+	return nil
+}
+
 // getEnv 輔助函數，用於從環境變數獲取值，如果不存在則使用預設值
 func getEnv(key, defaultValue string) string {
 	if value, exists := os.LookupEnv(key); exists {
@@ -79,3 +217,108 @@ func getEnvBool(key string, defaultValue bool) bool {
 		return defaultValue
 	}
 }
+
+// getEnvMinutes 讀取以分鐘為單位的環境變數，格式錯誤時使用預設值
+func getEnvMinutes(key string, defaultMinutes int) time.Duration {
+	minutes, err := strconv.Atoi(getEnv(key, strconv.Itoa(defaultMinutes)))
+	if err != nil || minutes <= 0 {
+		log.Printf("Invalid %s, defaulting to %d minutes", key, defaultMinutes)
+		minutes = defaultMinutes
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// loadOIDCProviders 讀取 OIDC 提供者設定
+// Google 沿用 GOOGLE_CLIENT_ID 等設定；其他提供者列在 OIDC_PROVIDERS (以逗號分隔)，
+// 每個提供者再以 OIDC_<ID>_ISSUER、OIDC_<ID>_CLIENT_ID、OIDC_<ID>_CLIENT_SECRET 等變數設定
+func loadOIDCProviders(cfg *Config) []OIDCProviderConfig {
+	var providers []OIDCProviderConfig
+	if cfg.GoogleClientID != "" {
+		redirectURL := cfg.GoogleRedirectURL
+		if redirectURL == "" {
+			redirectURL = cfg.PublicBaseURL + "/auth/google/callback"
+		}
+		providers = append(providers, OIDCProviderConfig{
+			ID:           "google",
+			DisplayName:  "Google",
+			Issuer:       "https://accounts.google.com",
+			ClientID:     cfg.GoogleClientID,
+			ClientSecret: cfg.GoogleClientSecret,
+			RedirectURL:  redirectURL,
+			Scopes:       []string{"openid", "email", "profile"},
+		})
+	}
+
+	for _, id := range strings.Split(getEnv("OIDC_PROVIDERS", ""), ",") {
+		id = strings.ToLower(strings.TrimSpace(id))
+		if id == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(id, "-", "_")) + "_"
+		provider := OIDCProviderConfig{
+			ID:           id,
+			DisplayName:  getEnv(prefix+"DISPLAY_NAME", id),
+			Issuer:       strings.TrimRight(getEnv(prefix+"ISSUER", ""), "/"),
+			ClientID:     getEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret: getEnv(prefix+"CLIENT_SECRET", ""),
+			RedirectURL:  getEnv(prefix+"REDIRECT_URL", cfg.PublicBaseURL+"/auth/"+id+"/callback"),
+			Scopes:       strings.Fields(getEnv(prefix+"SCOPES", "openid email profile")),
+		}
+		if provider.Issuer == "" || provider.ClientID == "" {
+			log.Printf("OIDC provider %s is missing %sISSUER or %sCLIENT_ID, skipping", id, prefix, prefix)
+			continue
+		}
+		providers = append(providers, provider)
+	}
+	return providers
+}
+
+// parseTrustedProxies 解析以逗號分隔的 IP 或 CIDR，單一 IP 視為只包含自己的網段
+func parseTrustedProxies(value string) (prefixes []netip.Prefix, invalid []string) {
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		if addr, err := netip.ParseAddr(entry); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		invalid = append(invalid, entry)
+	}
+	return prefixes, invalid
+}
+
+// getEnvInt 讀取正整數環境變數，格式錯誤時使用預設值
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(getEnv(key, strconv.Itoa(defaultValue)))
+	if err != nil || value <= 0 {
+		log.Printf("Invalid %s, defaulting to %d", key, defaultValue)
+		return defaultValue
+	}
+	return value
+}
+
+// loadEncryptionKey 讀取 base64 編碼的 32 bytes 金鑰
+// 未設定時由 JWT_SECRET 推導，required 為 true 時記錄下來；格式錯誤時記錄後回傳 nil，兩者都由 Validate 回報
+func (c *Config) loadEncryptionKey(key string, required bool) []byte {
+	value := getEnv(key, "")
+	if value == "" {
+		if required {
+			c.missingEncryptionKeys = append(c.missingEncryptionKeys, key)
+		}
+		sum := sha256.Sum256([]byte(key + ":" + c.JWTSecret))
+		return sum[:]
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(value)
+	if err != nil || len(decoded) != 32 {
+		c.invalidEncryptionKeys = append(c.invalidEncryptionKeys, key)
+		return nil
+	}
+	return decoded
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testEncryptionKey = "dP7X46GXClUiMBywIHi/JTAkr5tVGPbepur0cJQ/mmo="
+	testStateKey      = "RpTwfU+4yH29YQz4GPQCoZRmvk0uckAc4c2gWF6rUpM="
+)
+
+// setTestEnv 設定一組可以通過檢查的環境變數，再以 overrides 覆寫；值為空字串代表未設定
+func setTestEnv(t *testing.T, overrides map[string]string) {
+	t.Helper()
+	env := map[string]string{
+		"JWT_SECRET":             "test-secret",
+		"DEV_MODE":               "false",
+		"JWT_ALGORITHM":          JWTAlgorithmHS256,
+		"TOTP_ENCRYPTION_KEY":    testEncryptionKey,
+		"OAUTH_STATE_KEY":        testStateKey,
+		"JWT_KEY_ENCRYPTION_KEY": "",
+		"TRUSTED_PROXIES":        "",
+	}
+	for key, value := range overrides {
+		env[key] = value
+	}
+	for key, value := range env {
+		t.Setenv(key, value)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("設定 JWT_SECRET、TOTP_ENCRYPTION_KEY 與 OAUTH_STATE_KEY 即可啟動", func(t *testing.T) {
+		setTestEnv(t, nil)
+
+		assert.NoError(t, LoadConfig().Validate())
+	})
+
+	t.Run("使用內建的 JWT_SECRET 時拒絕啟動", func(t *testing.T) {
+		setTestEnv(t, map[string]string{"JWT_SECRET": defaultJWTSecret})
+
+		assert.ErrorContains(t, LoadConfig().Validate(), "JWT_SECRET")
+	})
+
+	t.Run("未設定 TOTP_ENCRYPTION_KEY 時只有開發模式可以啟動", func(t *testing.T) {
+		setTestEnv(t, map[string]string{"TOTP_ENCRYPTION_KEY": ""})
+		assert.ErrorContains(t, LoadConfig().Validate(), "TOTP_ENCRYPTION_KEY")
+
+		setTestEnv(t, map[string]string{"TOTP_ENCRYPTION_KEY": "", "DEV_MODE": "true"})
+		cfg := LoadConfig()
+		require.NoError(t, cfg.Validate())
+		assert.Len(t, cfg.TwoFactorEncryptionKey, 32, "開發模式由 JWT_SECRET 推導金鑰")
+	})
+
+	t.Run("未設定 OAUTH_STATE_KEY 時只有開發模式可以啟動", func(t *testing.T) {
+		setTestEnv(t, map[string]string{"OAUTH_STATE_KEY": ""})
+		assert.ErrorContains(t, LoadConfig().Validate(), "OAUTH_STATE_KEY")
+
+		setTestEnv(t, map[string]string{"OAUTH_STATE_KEY": "", "DEV_MODE": "true"})
+		assert.NoError(t, LoadConfig().Validate())
+	})
+
+	t.Run("JWT_KEY_ENCRYPTION_KEY 只有 RS256 與 EdDSA 需要設定", func(t *testing.T) {
+		setTestEnv(t, nil)
+		assert.NoError(t, LoadConfig().Validate(), "HS256 不需要加密私鑰")
+
+		for _, algorithm := range []string{JWTAlgorithmRS256, JWTAlgorithmEdDSA} {
+			setTestEnv(t, map[string]string{"JWT_ALGORITHM": algorithm})
+			assert.ErrorContains(t, LoadConfig().Validate(), "JWT_KEY_ENCRYPTION_KEY", algorithm)
+
+			setTestEnv(t, map[string]string{"JWT_ALGORITHM": algorithm, "JWT_KEY_ENCRYPTION_KEY": testEncryptionKey})
+			assert.NoError(t, LoadConfig().Validate(), algorithm)
+		}
+	})
+
+	t.Run("格式錯誤的金鑰即使在開發模式也拒絕啟動", func(t *testing.T) {
+		for _, key := range []string{"TOTP_ENCRYPTION_KEY", "OAUTH_STATE_KEY", "JWT_KEY_ENCRYPTION_KEY"} {
+			setTestEnv(t, map[string]string{key: "c2hvcnQ=", "DEV_MODE": "true"})
+
+			assert.ErrorContains(t, LoadConfig().Validate(), key)
+		}
+	})
+
+	t.Run("TRUSTED_PROXIES 格式錯誤時拒絕啟動", func(t *testing.T) {
+		setTestEnv(t, map[string]string{"TRUSTED_PROXIES": "10.0.0.0/8, not-an-ip"})
+
+		assert.ErrorContains(t, LoadConfig().Validate(), "not-an-ip")
+	})
+}
@@ -0,0 +1,158 @@
+package database
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"log"
+	"time"
+
+	"go-chat/backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// APITokenPrefix 是機器人 API 金鑰的固定前綴，方便辨識與掃描外洩
+const APITokenPrefix = "bot_"
+
+// hashAPIToken 計算 API 金鑰的雜湊值，資料庫只保存雜湊
+func hashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateAPIToken 為使用者產生新的 API 金鑰並撤銷舊的金鑰，回傳只會出現這一次的明文金鑰
+func CreateAPIToken(userID primitive.ObjectID) (string, error) {
+	collection := GetCollection("api_tokens")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := APITokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	// 每個機器人同時只保留一組有效金鑰，輪替時舊金鑰立即失效
+	if _, err := collection.DeleteMany(ctx, bson.M{"userId": userID}); err != nil {
+		log.Printf("Error revoking old API tokens for user %s: %v", userID.Hex(), err)
+		return "", err
+	}
+
+	apiToken := models.APIToken{
+		UserID:    userID,
+		TokenHash: hashAPIToken(token),
+		CreatedAt: time.Now(),
+	}
+	if _, err := collection.InsertOne(ctx, apiToken); err != nil {
+		log.Printf("Error inserting API token for user %s: %v", userID.Hex(), err)
+		return "", err
+	}
+	return token, nil
+}
+
+// FindUserByAPIToken 根據 API 金鑰查找對應的使用者，金鑰無效時回傳 nil
+func FindUserByAPIToken(token string) (*models.User, error) {
+	collection := GetCollection("api_tokens")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var apiToken models.APIToken
+	err := collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"tokenHash": hashAPIToken(token)},
+		bson.M{"$set": bson.M{"lastUsedAt": time.Now()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&apiToken)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		log.Printf("Error finding API token: %v", err)
+		return nil, err
+	}
+
+	user, err := GetUserByID(apiToken.UserID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// InsertBotUser 建立新的機器人帳號
+func InsertBotUser(username string, ownerID primitive.ObjectID) (*models.User, error) {
+	collection := GetCollection("users")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	bot := models.User{
+		Username:   username,
+		IsBot:      true,
+		BotOwnerID: ownerID,
+	}
+	result, err := collection.InsertOne(ctx, bot)
+	if err != nil {
+		log.Printf("Error inserting bot user %s: %v", username, err)
+		return nil, err
+	}
+	bot.ID = result.InsertedID.(primitive.ObjectID)
+	return &bot, nil
+}
+
+// GetBotsByOwner 獲取使用者建立的所有機器人帳號
+func GetBotsByOwner(ownerID primitive.ObjectID) ([]models.User, error) {
+	collection := GetCollection("users")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := collection.Find(ctx, bson.M{"isBot": true, "botOwnerId": ownerID})
+	if err != nil {
+		log.Printf("Error finding bots for owner %s: %v", ownerID.Hex(), err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	bots := []models.User{}
+	if err = cursor.All(ctx, &bots); err != nil {
+		log.Printf("Error decoding bots for owner %s: %v", ownerID.Hex(), err)
+		return nil, err
+	}
+	return bots, nil
+}
+
+// SetChatRoomBotAllowed 將機器人加入或移出聊天室的允許清單
+func SetChatRoomBotAllowed(roomID, botID primitive.ObjectID, allowed bool) (*models.ChatRoom, error) {
+	collection := GetCollection("chatrooms")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	operator := "$pull"
+	if allowed {
+		operator = "$addToSet"
+	}
+	update := bson.M{
+		operator: bson.M{"allowedBots": botID},
+		"$set":   bson.M{"updatedAt": time.Now()},
+	}
+
+	var updatedRoom models.ChatRoom
+	err := collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": roomID},
+		update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updatedRoom)
+	if err != nil {
+		log.Printf("Error updating allowed bots for room %s: %v", roomID.Hex(), err)
+		return nil, err
+	}
+	return &updatedRoom, nil
+}
@@ -23,10 +23,18 @@ func InvalidateUserChatRoomsCache(userID primitive.ObjectID) {
 }
 
 // InvalidateMultipleUserChatRoomsCache 刪除多個使用者的聊天室列表快取
+// 大型聊天室的成員變動會影響很多使用者，所以用一次 DEL 刪除所有的鍵，而不是逐一刪除
 func InvalidateMultipleUserChatRoomsCache(userIDs []primitive.ObjectID) {
-	// 遍歷所有需要被更新的使用者 ID
-
+	if len(userIDs) == 0 {
+		return
+	}
+	cacheKeys := make([]string, 0, len(userIDs))
 	for _, userID := range userIDs {
-		InvalidateUserChatRoomsCache(userID)
+		cacheKeys = append(cacheKeys, fmt.Sprintf("user-chatrooms:%s", userID.Hex()))
+	}
+
+	if err := RedisClient.Del(context.Background(), cacheKeys...).Err(); err != nil {
+		// 與單一使用者相同，快取失效失敗只記錄錯誤
+		log.Printf("Failed to invalidate cache for %d users: %v", len(userIDs), err)
 	}
 }
@@ -0,0 +1,90 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// loginLockoutLevelTTL 是鎖定級數保留的時間，這段期間內再次被鎖定時鎖定時間會加倍
+const loginLockoutLevelTTL = 24 * time.Hour
+
+// loginFailuresKey 回傳帳號連續登入失敗次數的快取鍵
+func loginFailuresKey(account string) string {
+	return "login-failures:" + account
+}
+
+// loginLockoutKey 回傳帳號鎖定狀態的快取鍵，鍵的存活時間就是剩餘的鎖定時間
+func loginLockoutKey(account string) string {
+	return "login-lockout:" + account
+}
+
+// loginLockoutLevelKey 回傳帳號已被鎖定幾次的快取鍵
+func loginLockoutLevelKey(account string) string {
+	return "login-lockout-level:" + account
+}
+
+// GetLoginLockout 回傳帳號剩餘的鎖定時間，未鎖定時回傳 0
+func GetLoginLockout(account string) (time.Duration, error) {
+	ttl, err := RedisClient.PTTL(context.Background(), loginLockoutKey(account)).Result()
+	if err != nil {
+		return 0, err
+	}
+	if ttl <= 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+// RecordLoginFailure 累計帳號連續登入失敗的次數，達到 maxFailures 時鎖定帳號並回傳鎖定時間
+// 鎖定時間從 base 開始，24 小時內每次再被鎖定就加倍，最長為 max；failureWindow 內沒有新的失敗時次數會歸零
+func RecordLoginFailure(account string, maxFailures int, failureWindow, base, max time.Duration) (time.Duration, error) {
+	ctx := context.Background()
+	pipe := RedisClient.TxPipeline()
+	countCmd := pipe.Incr(ctx, loginFailuresKey(account))
+	pipe.PExpire(ctx, loginFailuresKey(account), failureWindow)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	if countCmd.Val() < int64(maxFailures) {
+		return 0, nil
+	}
+
+	pipe = RedisClient.TxPipeline()
+	levelCmd := pipe.Incr(ctx, loginLockoutLevelKey(account))
+	pipe.Expire(ctx, loginLockoutLevelKey(account), loginLockoutLevelTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+
+	lockout := loginLockoutDuration(levelCmd.Val(), base, max)
+	pipe = RedisClient.TxPipeline()
+	pipe.Set(ctx, loginLockoutKey(account), 1, lockout)
+	pipe.Del(ctx, loginFailuresKey(account))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return lockout, nil
+}
+
+// loginLockoutDuration 回傳第 level 次被鎖定時的鎖定時間：base 每一級加倍，達到 max 後就不再加倍，
+// 級數再高也不會因位移溢位而變成負數 (負數的存活時間會寫入永不過期、卻被當成未鎖定的鍵)
+func loginLockoutDuration(level int64, base, max time.Duration) time.Duration {
+	lockout := base
+	for i := int64(1); i < level && lockout < max; i++ {
+		if lockout > max/2 {
+			return max
+		}
+		lockout *= 2
+	}
+	return min(lockout, max)
+}
+
+// ClearLoginFailures 登入成功時清除帳號的失敗次數與鎖定級數
+func ClearLoginFailures(account string) error {
+	return RedisClient.Del(context.Background(), loginFailuresKey(account), loginLockoutLevelKey(account)).Err()
+}
+
+// UnlockLoginAccount 立即解除帳號的鎖定並清除所有失敗紀錄
+func UnlockLoginAccount(account string) error {
+	return RedisClient.Del(context.Background(), loginFailuresKey(account), loginLockoutKey(account), loginLockoutLevelKey(account)).Err()
+}
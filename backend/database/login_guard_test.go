@@ -0,0 +1,24 @@
+package database
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginLockoutDuration(t *testing.T) {
+	base, max := time.Minute, time.Hour
+
+	assert.Equal(t, time.Minute, loginLockoutDuration(1, base, max))
+	assert.Equal(t, 2*time.Minute, loginLockoutDuration(2, base, max))
+	assert.Equal(t, 32*time.Minute, loginLockoutDuration(6, base, max))
+	assert.Equal(t, time.Hour, loginLockoutDuration(7, base, max), "超過上限時使用 max")
+
+	// 24 小時內持續被鎖定時級數會一直增加，鎖定時間不能因溢位變成負數或零
+	for _, level := range []int64{28, 29, 30, 64, 1 << 40} {
+		assert.Equal(t, time.Hour, loginLockoutDuration(level, base, max), "level=%d", level)
+	}
+	assert.Equal(t, time.Duration(math.MaxInt64), loginLockoutDuration(100, time.Nanosecond, math.MaxInt64))
+}
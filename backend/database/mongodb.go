@@ -5,12 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"regexp"
 	"time"
-	"go-chat/backend/config"
-	"github.com/redis/go-redis/v9"
 
+	"go-chat/backend/config"
 	"go-chat/backend/models"
 
+	"github.com/redis/go-redis/v9"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -21,6 +22,15 @@ import (
 var MongoClient *mongo.Client
 var dbName string // 儲存資料庫名稱
 
+// MessageRetention 是訊息保留的時間，需在 ConnectMongoDB 之前設定
+var MessageRetention = 30 * time.Minute
+
+// archivedRoomRecheckDelay 是封存後再次清除訊息 expiresAt 的延遲，需大於發送訊息時檢查封存狀態到寫入完成的時間
+var archivedRoomRecheckDelay = 15 * time.Second
+
+// SessionRetention 是 session 閒置多久後失效並自動刪除 (與 refresh token 的效期相同)，需在 ConnectMongoDB 之前設定
+var SessionRetention = 30 * 24 * time.Hour
+
 // GetUserByID 根據用戶ID獲取用戶信息
 func GetUserByID(userID primitive.ObjectID) (*models.User, error) {
 	collection := GetCollection("users")
@@ -32,6 +42,24 @@ func GetUserByID(userID primitive.ObjectID) (*models.User, error) {
 	return &user, nil
 }
 
+// GetUserByUsername 根據使用者名稱獲取用戶信息，找不到時回傳 nil
+func GetUserByUsername(username string) (*models.User, error) {
+	collection := GetCollection("users")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var user models.User
+	err := collection.FindOne(ctx, bson.M{"username": username}).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		log.Printf("Error finding user by username %s: %v", username, err)
+		return nil, err
+	}
+	return &user, nil
+}
+
 // GetUsersByIDs 根據多個用戶ID獲取用戶信息列表
 func GetUsersByIDs(userIDs []primitive.ObjectID) ([]models.User, error) {
 	if len(userIDs) == 0 {
@@ -85,6 +113,173 @@ func UpdateChatRoom(roomID primitive.ObjectID, participants []primitive.ObjectID
 	return &updatedRoom, nil
 }
 
+// UpdateChatRoomTopic 更新聊天室主題
+func UpdateChatRoomTopic(roomID primitive.ObjectID, topic string) (*models.ChatRoom, error) {
+	collection := GetCollection("chatrooms")
+	update := bson.M{
+		"$set": bson.M{
+			"topic":     topic,
+			"updatedAt": time.Now(),
+		},
+	}
+
+	var updatedRoom models.ChatRoom
+	err := collection.FindOneAndUpdate(
+		context.Background(),
+		bson.M{"_id": roomID},
+		update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updatedRoom)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &updatedRoom, nil
+}
+
+// UpdateChatRoomDetails 更新聊天室的自訂名稱、顯示名稱、主題與說明
+func UpdateChatRoomDetails(roomID primitive.ObjectID, customName, name, topic, description string) (*models.ChatRoom, error) {
+	collection := GetCollection("chatrooms")
+	update := bson.M{
+		"$set": bson.M{
+			"customName":  customName,
+			"name":        name,
+			"topic":       topic,
+			"description": description,
+			"updatedAt":   time.Now(),
+		},
+	}
+
+	var updatedRoom models.ChatRoom
+	err := collection.FindOneAndUpdate(
+		context.Background(),
+		bson.M{"_id": roomID},
+		update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updatedRoom)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &updatedRoom, nil
+}
+
+// migrateMessageTTL 替既有訊息補上 expiresAt 後，才移除舊版以 timestamp 固定保留 30 分鐘的 TTL 索引，
+// 讓新的 TTL 索引接手清除；補值失敗時保留舊索引，下次啟動會重新遷移
+func migrateMessageTTL(ctx context.Context, collection *mongo.Collection) {
+	specs, err := collection.Indexes().ListSpecifications(ctx)
+	if err != nil {
+		log.Fatalf("Failed to list indexes of messages collection: %v", err)
+	}
+	legacy := false
+	for _, spec := range specs {
+		if spec.Name == "timestamp_1" {
+			legacy = true
+			break
+		}
+	}
+	if !legacy {
+		// 索引不存在代表已經遷移過 (或是新的資料庫)
+		return
+	}
+
+	backfill := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"expiresAt": bson.M{"$add": bson.A{"$timestamp", MessageRetention.Milliseconds()}},
+	}}}}
+	result, err := collection.UpdateMany(ctx, bson.M{"expiresAt": bson.M{"$exists": false}}, backfill)
+	if err != nil {
+		log.Fatalf("Failed to backfill message expiry: %v", err)
+	}
+	if _, err := collection.Indexes().DropOne(ctx, "timestamp_1"); err != nil {
+		log.Fatalf("Failed to drop legacy message TTL index: %v", err)
+	}
+	log.Printf("Migrated message TTL index, backfilled expiry for %d messages.", result.ModifiedCount)
+}
+
+// backfillUserVerified 將 Email 驗證功能上線前註冊、沒有 verified 欄位的使用者視為已驗證，
+// 避免開啟 REQUIRE_EMAIL_VERIFICATION 後既有使用者無法登入；之後註冊的使用者一定有這個欄位
+func backfillUserVerified(ctx context.Context, collection *mongo.Collection) {
+	result, err := collection.UpdateMany(ctx, bson.M{"verified": bson.M{"$exists": false}}, bson.M{"$set": bson.M{"verified": true}})
+	if err != nil {
+		log.Fatalf("Failed to backfill verified flag for existing users: %v", err)
+	}
+	if result.ModifiedCount > 0 {
+		log.Printf("Marked %d existing users as verified.", result.ModifiedCount)
+	}
+}
+
+// backfillDirectMessageKind 將改版前建立、只有兩位成員且沒有 kind 的聊天室升級為私訊，
+// 避免這些私訊被當成群組而可以邀請其他成員；需要在 dmKey 唯一索引建立後執行，
+// 同一對使用者有多個舊聊天室時只有最早的會成為私訊，其餘標記為群組
+func backfillDirectMessageKind(ctx context.Context, collection *mongo.Collection) {
+	filter := bson.M{"kind": bson.M{"$exists": false}, "participants": bson.M{"$size": 2}}
+	cursor, err := collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}).SetProjection(bson.M{"participants": 1}))
+	if err != nil {
+		log.Fatalf("Failed to list legacy chatrooms: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	upgraded := 0
+	for cursor.Next(ctx) {
+		var room models.ChatRoom
+		if err := cursor.Decode(&room); err != nil {
+			log.Fatalf("Failed to decode legacy chatroom: %v", err)
+		}
+		dmKey := models.DirectMessageKey(room.Participants[0], room.Participants[1])
+		update := bson.M{"$set": bson.M{"kind": models.RoomKindDM, "dmKey": dmKey}}
+		_, err := collection.UpdateOne(ctx, bson.M{"_id": room.ID, "kind": bson.M{"$exists": false}}, update)
+		if mongo.IsDuplicateKeyError(err) {
+			// 標記為群組，下次啟動不會再嘗試升級
+			_, err = collection.UpdateOne(ctx, bson.M{"_id": room.ID}, bson.M{"$set": bson.M{"kind": models.RoomKindGroup}})
+			if err != nil {
+				log.Fatalf("Failed to mark legacy chatroom %s as a group: %v", room.ID.Hex(), err)
+			}
+			log.Printf("Legacy chatroom %s kept as a group: direct message %s already exists", room.ID.Hex(), dmKey)
+			continue
+		}
+		if err != nil {
+			log.Fatalf("Failed to upgrade legacy chatroom %s to direct message: %v", room.ID.Hex(), err)
+		}
+		upgraded++
+	}
+	if err := cursor.Err(); err != nil {
+		log.Fatalf("Failed to list legacy chatrooms: %v", err)
+	}
+	if upgraded > 0 {
+		log.Printf("Upgraded %d legacy chatrooms to direct messages.", upgraded)
+	}
+}
+
+// ensureTTLIndex 建立在 field 超過 ttl 後自動刪除文件的 TTL 索引，ttl 改變時以新的設定重建索引
+func ensureTTLIndex(ctx context.Context, collection *mongo.Collection, field string, ttl time.Duration) {
+	name := field + "_ttl"
+	seconds := int32(ttl.Seconds())
+	specs, err := collection.Indexes().ListSpecifications(ctx)
+	if err != nil {
+		log.Fatalf("Failed to list indexes of %s collection: %v", collection.Name(), err)
+	}
+	for _, spec := range specs {
+		if spec.Name != name {
+			continue
+		}
+		if spec.ExpireAfterSeconds != nil && *spec.ExpireAfterSeconds == seconds {
+			return
+		}
+		if _, err := collection.Indexes().DropOne(ctx, name); err != nil {
+			log.Fatalf("Failed to drop outdated TTL index of %s collection: %v", collection.Name(), err)
+		}
+	}
+	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: field, Value: 1}},
+		Options: options.Index().SetName(name).SetExpireAfterSeconds(seconds),
+	})
+	if err != nil {
+		log.Fatalf("Failed to create TTL index for %s collection: %v", collection.Name(), err)
+	}
+}
+
 // ConnectMongoDB 建立並初始化 MongoDB 連線
 func ConnectMongoDB(uri, name string) {
 	clientOptions := options.Client().ApplyURI(uri)
@@ -105,11 +300,14 @@ func ConnectMongoDB(uri, name string) {
 	MongoClient = client
 	dbName = name
 
+	backfillUserVerified(ctx, MongoClient.Database(dbName).Collection("users"))
+
 	messagesCollection := MongoClient.Database(dbName).Collection("messages")
-	// 設定規則:自動清理超過30分鐘的訊息
+	migrateMessageTTL(ctx, messagesCollection)
+	// 設定規則:自動清理超過 expiresAt 的訊息，沒有 expiresAt 的訊息 (封存的聊天室) 不會被清除
 	indexModel := mongo.IndexModel{
-		Keys:    bson.D{{Key: "timestamp", Value: 1}},        // value:1代表升序(由舊到新)
-		Options: options.Index().SetExpireAfterSeconds(1800), // 設定 30 分鐘 (1800 秒) 後過期
+		Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
 	}
 
 	// 套用規則
@@ -117,7 +315,120 @@ func ConnectMongoDB(uri, name string) {
 	if err != nil {
 		log.Fatalf("Failed to create TTL index for messages collection: %v", err)
 	}
-	log.Println("TTL index created for messages collection (30 minutes).")
+	log.Printf("TTL index created for messages collection (%s).", MessageRetention)
+
+	// 聊天記錄與未讀數都依聊天室與時間查詢訊息
+	_, err = messagesCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "roomId", Value: 1}, {Key: "timestamp", Value: 1}},
+	})
+	if err != nil {
+		log.Fatalf("Failed to create room index for messages collection: %v", err)
+	}
+
+	// 同一個提供者的外部身分只能連結到一個使用者
+	identitiesCollection := MongoClient.Database(dbName).Collection("identities")
+	_, err = identitiesCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "provider", Value: 1}, {Key: "subject", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Fatalf("Failed to create unique index for identities collection: %v", err)
+	}
+
+	// 每對使用者只有一個私訊，群組與頻道沒有 dmKey 不受影響
+	chatroomsCollection := MongoClient.Database(dbName).Collection("chatrooms")
+	_, err = chatroomsCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "dmKey", Value: 1}},
+		Options: options.Index().SetUnique(true).SetSparse(true),
+	})
+	if err != nil {
+		log.Fatalf("Failed to create unique index for chatrooms collection: %v", err)
+	}
+	backfillDirectMessageKind(ctx, chatroomsCollection)
+
+	// 頻道目錄依名稱排序列出公開頻道
+	_, err = chatroomsCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "kind", Value: 1}, {Key: "public", Value: 1}, {Key: "name", Value: 1}},
+	})
+	if err != nil {
+		log.Fatalf("Failed to create channel directory index for chatrooms collection: %v", err)
+	}
+
+	// 兌換時依邀請碼雜湊查找邀請，過期的邀請自動刪除，沒有 expiresAt 的邀請不受影響
+	roomInvitesCollection := MongoClient.Database(dbName).Collection("room_invites")
+	_, err = roomInvitesCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "codeHash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "roomId", Value: 1}}},
+		{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	if err != nil {
+		log.Fatalf("Failed to create indexes for room_invites collection: %v", err)
+	}
+
+	// 每個聊天室對同一使用者的封鎖與禁言各只有一筆，到期的紀錄自動刪除
+	roomSanctionsCollection := MongoClient.Database(dbName).Collection("room_sanctions")
+	_, err = roomSanctionsCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "roomId", Value: 1}, {Key: "kind", Value: 1}, {Key: "userId", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	if err != nil {
+		log.Fatalf("Failed to create indexes for room_sanctions collection: %v", err)
+	}
+
+	// Webhook worker 依狀態與下次嘗試時間輪詢到期的投遞
+	webhookDeliveriesCollection := MongoClient.Database(dbName).Collection("webhook_deliveries")
+	_, err = webhookDeliveriesCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "status", Value: 1}, {Key: "nextAttemptAt", Value: 1}},
+	})
+	if err != nil {
+		log.Fatalf("Failed to create due index for webhook_deliveries collection: %v", err)
+	}
+
+	// 輪替後過了寬限期的 JWT 簽章金鑰自動刪除，使用中的金鑰沒有 expiresAt 不受影響
+	jwtKeysCollection := MongoClient.Database(dbName).Collection("jwt_keys")
+	_, err = jwtKeysCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		log.Fatalf("Failed to create TTL index for jwt_keys collection: %v", err)
+	}
+
+	// 換發時依雜湊查找 refresh token，撤銷時依 family 與使用者更新；過期的 token 自動刪除
+	refreshTokensCollection := MongoClient.Database(dbName).Collection("refresh_tokens")
+	_, err = refreshTokensCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "tokenHash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "familyId", Value: 1}}},
+		{Keys: bson.D{{Key: "userId", Value: 1}}},
+		{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	if err != nil {
+		log.Fatalf("Failed to create indexes for refresh_tokens collection: %v", err)
+	}
+
+	// 裝置列表依使用者查詢 session，閒置超過 refresh token 效期的 session 已無法使用，自動刪除
+	sessionsCollection := MongoClient.Database(dbName).Collection("sessions")
+	_, err = sessionsCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "userId", Value: 1}, {Key: "lastSeenAt", Value: -1}},
+	})
+	if err != nil {
+		log.Fatalf("Failed to create user index for sessions collection: %v", err)
+	}
+	ensureTTLIndex(ctx, sessionsCollection, "lastSeenAt", SessionRetention)
+
+	// 重設密碼時依雜湊查找連結，過期的連結自動刪除
+	passwordResetsCollection := MongoClient.Database(dbName).Collection("password_resets")
+	_, err = passwordResetsCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "tokenHash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "userId", Value: 1}}},
+		{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	if err != nil {
+		log.Fatalf("Failed to create indexes for password_resets collection: %v", err)
+	}
 }
 
 // GetCollection 獲取指定資料庫的集合
@@ -132,22 +443,84 @@ func GetCollection(collectionName string) *mongo.Collection {
 }
 
 // InsertMessage 將新的聊天訊息插入到 MongoDB
+// 聊天室可能已經封存時 (例如封存公告或封存後成員離開的系統訊息) 使用，寫入後會確認是否需要保留訊息
 func InsertMessage(message models.Message) (*mongo.InsertOneResult, error) {
+	return insertMessage(message, true)
+}
+
+// InsertActiveRoomMessage 將已確認聊天室未封存的訊息插入到 MongoDB，省去每則訊息查詢封存狀態
+// 確認之後聊天室才被封存的訊息由 SetChatRoomArchived 的第二次清除保留
+func InsertActiveRoomMessage(message models.Message) (*mongo.InsertOneResult, error) {
+	return insertMessage(message, false)
+}
+
+func insertMessage(message models.Message, mayBeArchived bool) (*mongo.InsertOneResult, error) {
 	collection := GetCollection("messages")
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	// 確保新訊息的 IsRead 預設為 false
 	message.IsRead = false
+	assignedExpiry := message.ExpiresAt.IsZero()
+	if assignedExpiry {
+		createdAt := message.Timestamp
+		if createdAt.IsZero() {
+			createdAt = time.Now()
+		}
+		message.ExpiresAt = createdAt.Add(MessageRetention)
+	}
 
 	result, err := collection.InsertOne(ctx, message)
 	if err != nil {
 		log.Printf("Error inserting message: %v", err)
 		return nil, err
 	}
+
+	// 封存的聊天室訊息不會被清除，例如封存公告或封存後成員離開的系統訊息
+	if assignedExpiry && mayBeArchived {
+		retained, err := retainIfArchived(ctx, message.RoomID, result.InsertedID)
+		if err != nil {
+			log.Printf("Error retaining message in archived room %s: %v", message.RoomID, err)
+		}
+		if retained {
+			message.ExpiresAt = time.Time{}
+		}
+	}
+
+	// 訊息已經寫入，聊天室列表的預覽更新失敗只記錄錯誤
+	if message.Type.ShownInRoomList() {
+		if id, ok := result.InsertedID.(primitive.ObjectID); ok {
+			message.ID = id
+		}
+		if message.Timestamp.IsZero() {
+			message.Timestamp = time.Now()
+		}
+		if err := recordRoomMessage(ctx, message); err != nil {
+			log.Printf("Error updating last message of room %s: %v", message.RoomID, err)
+		}
+	}
 	return result, nil
 }
 
+// retainIfArchived 在聊天室已封存時移除剛寫入訊息的 expiresAt
+// 寫入後才檢查：封存時先設定 archivedAt 再清除既有訊息的 expiresAt，在兩者之間寫入的訊息也不會漏掉
+// 只有 InsertMessage 會檢查，成員發送的一般訊息已經確認聊天室未封存
+func retainIfArchived(ctx context.Context, roomIDHex string, messageID interface{}) (bool, error) {
+	roomID, err := primitive.ObjectIDFromHex(roomIDHex)
+	if err != nil {
+		// 不屬於任何聊天室的訊息照常過期
+		return false, nil
+	}
+
+	filter := bson.M{"_id": roomID, "archivedAt": bson.M{"$exists": true}}
+	count, err := GetCollection("chatrooms").CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil || count == 0 {
+		return false, err
+	}
+	_, err = GetCollection("messages").UpdateOne(ctx, bson.M{"_id": messageID}, bson.M{"$unset": bson.M{"expiresAt": ""}})
+	return err == nil, err
+}
+
 // GetChatHistory 獲取指定聊天室的歷史訊息
 func GetChatHistory(roomID string) ([]models.Message, error) {
 	collection := GetCollection("messages")
@@ -221,47 +594,375 @@ func FindChatRoomByID(roomID primitive.ObjectID) (*models.ChatRoom, error) {
 	return &room, nil
 }
 
-// FindChatRoomByParticipants 根據參與者 ID 查找聊天室 (用於兩人聊天室)
-func FindChatRoomByParticipants(participantIDs []primitive.ObjectID) (*models.ChatRoom, error) {
+// FindDirectMessageRoom 查找兩位使用者之間的私訊
+// 改版前建立、只有這兩位成員且沒有 kind 的聊天室會被升級為私訊並回傳，保留原本的聊天記錄
+func FindDirectMessageRoom(userA, userB primitive.ObjectID) (*models.ChatRoom, error) {
 	collection := GetCollection("chatrooms")
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	filter := bson.M{
+	dmKey := models.DirectMessageKey(userA, userB)
+	var room models.ChatRoom
+	err := collection.FindOne(ctx, bson.M{"dmKey": dmKey}).Decode(&room)
+	if err == nil {
+		return &room, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		log.Printf("Error finding direct message %s: %v", dmKey, err)
+		return nil, err
+	}
+
+	legacyFilter := bson.M{
+		"kind": bson.M{"$exists": false},
 		"participants": bson.M{
-			"$size": len(participantIDs),
-			"$all":  participantIDs,
+			"$size": 2,
+			"$all":  []primitive.ObjectID{userA, userB},
 		},
 	}
-
-	var room models.ChatRoom
-	err := collection.FindOne(ctx, filter).Decode(&room)
+	update := bson.M{"$set": bson.M{"kind": models.RoomKindDM, "dmKey": dmKey}}
+	err = collection.FindOneAndUpdate(ctx, legacyFilter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&room)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			return nil, nil
-		}
-		log.Printf("Error finding chatroom by participants %v: %v", participantIDs, err)
+		log.Printf("Error upgrading legacy chatroom to direct message %s: %v", dmKey, err)
 		return nil, err
 	}
 	return &room, nil
 }
 
-// DeleteChatRoom 刪除指定的聊天室
-func DeleteChatRoom(roomID primitive.ObjectID) error {
+// SetChatRoomPublic 設定頻道是否公開在頻道目錄中
+func SetChatRoomPublic(roomID primitive.ObjectID, public bool) (*models.ChatRoom, error) {
+	collection := GetCollection("chatrooms")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	update := bson.M{"$set": bson.M{"public": public, "updatedAt": time.Now()}}
+	var updatedRoom models.ChatRoom
+	err := collection.FindOneAndUpdate(ctx, bson.M{"_id": roomID}, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&updatedRoom)
+	if err != nil {
+		log.Printf("Error updating visibility for room %s: %v", roomID.Hex(), err)
+		return nil, err
+	}
+	return &updatedRoom, nil
+}
+
+// ListPublicChannels 依名稱排序列出公開頻道，query 不為空時只列出名稱或主題包含 query 的頻道 (不分大小寫)
+// 回傳這一頁的頻道與符合條件的頻道總數；成員數與 userID 是否已加入由資料庫計算，不會讀取完整的成員列表
+func ListPublicChannels(userID primitive.ObjectID, query string, skip, limit int64) ([]models.ChannelDirectoryEntry, int64, error) {
+	collection := GetCollection("chatrooms")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"kind": models.RoomKindChannel, "public": true, "archivedAt": bson.M{"$exists": false}}
+	if query != "" {
+		pattern := primitive.Regex{Pattern: regexp.QuoteMeta(query), Options: "i"}
+		filter["$or"] = bson.A{bson.M{"name": pattern}, bson.M{"topic": pattern}}
+	}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		log.Printf("Error counting public channels: %v", err)
+		return nil, 0, err
+	}
+
+	projection := bson.M{
+		"name":        1,
+		"topic":       1,
+		"description": 1,
+		"memberCount": bson.M{"$size": "$participants"},
+		"joined":      bson.M{"$in": bson.A{userID, "$participants"}},
+	}
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}}).
+		SetSkip(skip).
+		SetLimit(limit).
+		SetProjection(projection)
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		log.Printf("Error listing public channels: %v", err)
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	channels := []models.ChannelDirectoryEntry{}
+	if err = cursor.All(ctx, &channels); err != nil {
+		log.Printf("Error decoding public channels: %v", err)
+		return nil, 0, err
+	}
+	return channels, total, nil
+}
+
+// JoinPublicChannel 將使用者加入公開頻道，回傳更新後的頻道
+// 頻道不存在、不是公開頻道或已封存時回傳 nil
+func JoinPublicChannel(roomID, userID primitive.ObjectID) (*models.ChatRoom, error) {
 	collection := GetCollection("chatrooms")
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	_, err := collection.DeleteOne(ctx, bson.M{"_id": roomID})
+	now := time.Now()
+	filter := bson.M{"_id": roomID, "kind": models.RoomKindChannel, "public": true, "archivedAt": bson.M{"$exists": false}}
+	update := bson.M{
+		"$addToSet": bson.M{"participants": userID},
+		"$set":      bson.M{"memberSince." + userID.Hex(): now, "updatedAt": now},
+	}
+
+	var updatedRoom models.ChatRoom
+	err := collection.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&updatedRoom)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
 	if err != nil {
+		log.Printf("Error joining channel %s: %v", roomID.Hex(), err)
+		return nil, err
+	}
+	return &updatedRoom, nil
+}
+
+// AddChatRoomParticipants 將多位使用者加入聊天室並更新顯示名稱，參與者列表維持依 ID 排序
+func AddChatRoomParticipants(roomID primitive.ObjectID, userIDs []primitive.ObjectID, name string) (*models.ChatRoom, error) {
+	collection := GetCollection("chatrooms")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	set := bson.M{"name": name, "updatedAt": now}
+	for _, userID := range userIDs {
+		set["memberSince."+userID.Hex()] = now
+	}
+	filter := bson.M{"_id": roomID, "participants": bson.M{"$nin": userIDs}}
+	update := bson.M{
+		"$push": bson.M{"participants": bson.M{"$each": userIDs, "$sort": 1}},
+		"$set":  set,
+	}
+
+	var updatedRoom models.ChatRoom
+	err := collection.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&updatedRoom)
+	if err != nil {
+		log.Printf("Error adding participants to room %s: %v", roomID.Hex(), err)
+		return nil, err
+	}
+	return &updatedRoom, nil
+}
+
+// AddChatRoomMember 將使用者加入聊天室，admin 為 true 時同時將使用者設為管理員
+// 顯示名稱由呼叫端依加入後的成員以 SetGeneratedChatRoomName 更新
+// 私訊不能加入其他成員，聊天室不存在、是私訊或已封存時回傳 nil
+func AddChatRoomMember(roomID, userID primitive.ObjectID, admin bool) (*models.ChatRoom, error) {
+	collection := GetCollection("chatrooms")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	addToSet := bson.M{"participants": userID}
+	if admin {
+		addToSet["admins"] = userID
+	}
+	now := time.Now()
+	filter := bson.M{"_id": roomID, "kind": bson.M{"$ne": models.RoomKindDM}, "archivedAt": bson.M{"$exists": false}}
+	update := bson.M{
+		"$addToSet": addToSet,
+		"$set":      bson.M{"memberSince." + userID.Hex(): now, "updatedAt": now},
+	}
+
+	var updatedRoom models.ChatRoom
+	err := collection.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&updatedRoom)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("Error adding member %s to room %s: %v", userID.Hex(), roomID.Hex(), err)
+		return nil, err
+	}
+	return &updatedRoom, nil
+}
+
+// SetGeneratedChatRoomName 在聊天室沒有自訂名稱、且成員仍是 participants 時更新自動產生的名稱
+// 成員在這之間又有變動時回傳 nil，由較晚的變動寫入對應的名稱
+func SetGeneratedChatRoomName(roomID primitive.ObjectID, participants []primitive.ObjectID, name string) (*models.ChatRoom, error) {
+	collection := GetCollection("chatrooms")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": roomID, "participants": participants, "customName": bson.M{"$in": bson.A{nil, ""}}}
+	var updatedRoom models.ChatRoom
+	err := collection.FindOneAndUpdate(ctx, filter, bson.M{"$set": bson.M{"name": name}}, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&updatedRoom)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("Error renaming room %s: %v", roomID.Hex(), err)
+		return nil, err
+	}
+	return &updatedRoom, nil
+}
+
+// RemoveChatRoomMember 將使用者移出聊天室 (同時移除管理員身分) 並更新顯示名稱
+// newOwnerID 不為零值時同時將擁有者轉移給該成員，用於擁有者離開聊天室
+func RemoveChatRoomMember(roomID, userID primitive.ObjectID, name string, newOwnerID primitive.ObjectID) (*models.ChatRoom, error) {
+	collection := GetCollection("chatrooms")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	set := bson.M{"name": name, "updatedAt": time.Now()}
+	if !newOwnerID.IsZero() {
+		set["ownerId"] = newOwnerID
+	}
+	update := bson.M{
+		"$pull":  bson.M{"participants": userID, "admins": userID},
+		"$unset": bson.M{"memberSince." + userID.Hex(): "", "memberSettings." + userID.Hex(): "", "lastReadAt." + userID.Hex(): ""},
+		"$set":   set,
+	}
+
+	var updatedRoom models.ChatRoom
+	err := collection.FindOneAndUpdate(ctx, bson.M{"_id": roomID}, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&updatedRoom)
+	if err != nil {
+		log.Printf("Error removing member %s from room %s: %v", userID.Hex(), roomID.Hex(), err)
+		return nil, err
+	}
+	return &updatedRoom, nil
+}
+
+// TransferChatRoomOwnership 將擁有者從 fromID 轉移給 toID，原擁有者成為管理員
+// fromID 已不是擁有者時 (例如同時有另一個轉移請求) 回傳 nil
+func TransferChatRoomOwnership(roomID, fromID, toID primitive.ObjectID) (*models.ChatRoom, error) {
+	collection := GetCollection("chatrooms")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 沒有 ownerId 的舊聊天室以建立者為擁有者
+	filter := bson.M{
+		"_id":          roomID,
+		"participants": toID,
+		"$or": bson.A{
+			bson.M{"ownerId": fromID},
+			bson.M{"ownerId": bson.M{"$exists": false}, "creatorId": fromID},
+		},
+	}
+	update := bson.M{
+		"$set":      bson.M{"ownerId": toID, "updatedAt": time.Now()},
+		"$addToSet": bson.M{"admins": fromID},
+	}
+
+	var updatedRoom models.ChatRoom
+	err := collection.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&updatedRoom)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("Error transferring ownership of room %s: %v", roomID.Hex(), err)
+		return nil, err
+	}
+	return &updatedRoom, nil
+}
+
+// SetChatRoomArchived 封存或取消封存聊天室
+// 封存時一併刪除聊天室的邀請連結，並讓現有訊息不再被自動清除；取消封存後訊息重新開始計算保留時間
+func SetChatRoomArchived(roomID primitive.ObjectID, archived bool) (*models.ChatRoom, error) {
+	collection := GetCollection("chatrooms")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	update := bson.M{"$set": bson.M{"archivedAt": now, "updatedAt": now}}
+	if !archived {
+		update = bson.M{"$set": bson.M{"updatedAt": now}, "$unset": bson.M{"archivedAt": ""}}
+	}
+
+	var updatedRoom models.ChatRoom
+	err := collection.FindOneAndUpdate(ctx, bson.M{"_id": roomID}, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&updatedRoom)
+	if err != nil {
+		log.Printf("Error updating archive state of room %s: %v", roomID.Hex(), err)
+		return nil, err
+	}
+
+	messages := GetCollection("messages")
+	if archived {
+		if _, err := GetCollection("room_invites").DeleteMany(ctx, bson.M{"roomId": roomID}); err != nil {
+			log.Printf("Error deleting invites of archived room %s: %v", roomID.Hex(), err)
+		}
+		retainArchivedRoomMessages(ctx, roomID)
+		// 封存前已通過檢查的訊息可能在第一次清除之後才寫入 (InsertActiveRoomMessage)，等這些寫入結束後再清除一次
+		time.AfterFunc(archivedRoomRecheckDelay, func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			retainArchivedRoomMessages(ctx, roomID)
+		})
+	} else {
+		expiresAt := now.Add(MessageRetention)
+		filter := bson.M{"roomId": roomID.Hex(), "expiresAt": bson.M{"$exists": false}}
+		if _, err := messages.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"expiresAt": expiresAt}}); err != nil {
+			log.Printf("Error restoring expiry of messages in room %s: %v", roomID.Hex(), err)
+		}
+		filter = bson.M{"_id": roomID, "lastMessage": bson.M{"$exists": true}}
+		if _, err := collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"lastMessage.expiresAt": expiresAt}}); err != nil {
+			log.Printf("Error restoring expiry of last message in room %s: %v", roomID.Hex(), err)
+		}
+	}
+	return &updatedRoom, nil
+}
+
+// retainArchivedRoomMessages 移除封存聊天室訊息與最後一則訊息預覽的 expiresAt
+func retainArchivedRoomMessages(ctx context.Context, roomID primitive.ObjectID) {
+	filter := bson.M{"roomId": roomID.Hex(), "expiresAt": bson.M{"$exists": true}}
+	if _, err := GetCollection("messages").UpdateMany(ctx, filter, bson.M{"$unset": bson.M{"expiresAt": ""}}); err != nil {
+		log.Printf("Error retaining messages of archived room %s: %v", roomID.Hex(), err)
+	}
+	// 最後一則訊息的預覽跟著訊息一起保留
+	if _, err := GetCollection("chatrooms").UpdateOne(ctx, bson.M{"_id": roomID}, bson.M{"$unset": bson.M{"lastMessage.expiresAt": ""}}); err != nil {
+		log.Printf("Error retaining last message of archived room %s: %v", roomID.Hex(), err)
+	}
+}
+
+// DeleteChatRoom 刪除指定的聊天室，並一併刪除聊天室的訊息、邀請連結、封鎖與禁言紀錄及 incoming webhook
+// 關聯資料先刪除，全部成功後才刪除聊天室本身；中途失敗時聊天室仍存在，擁有者可以重新刪除
+// 外送 Webhook 由 webhooks.EmitRoomDeleted 在 room.deleted 事件排入投遞後刪除
+func DeleteChatRoom(roomID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	cascades := []struct {
+		collection string
+		filter     bson.M
+	}{
+		{"messages", bson.M{"roomId": roomID.Hex()}}, // 訊息以字串保存聊天室 ID
+		{"room_invites", bson.M{"roomId": roomID}},
+		{"room_sanctions", bson.M{"roomId": roomID}},
+		{"incoming_webhooks", bson.M{"roomId": roomID}},
+	}
+	for _, cascade := range cascades {
+		if _, err := GetCollection(cascade.collection).DeleteMany(ctx, cascade.filter); err != nil {
+			log.Printf("Error deleting %s of chatroom %s: %v", cascade.collection, roomID.Hex(), err)
+			return fmt.Errorf("delete %s of chatroom %s: %w", cascade.collection, roomID.Hex(), err)
+		}
+	}
+
+	if _, err := GetCollection("chatrooms").DeleteOne(ctx, bson.M{"_id": roomID}); err != nil {
 		log.Printf("Error deleting chatroom %s: %v", roomID.Hex(), err)
 		return err
 	}
+	InvalidateRoomMuteCache(roomID)
 	return nil
 }
 
-// GetUserChatRooms 獲取使用者所參與的所有聊天室
+// GetUserChatRooms 獲取使用者所參與的所有聊天室，包含最後一則訊息與使用者的未讀數
 func GetUserChatRooms(userID primitive.ObjectID, cfg *config.Config) ([]models.ChatRoom, error) {
+	chatRooms, err := getCachedUserChatRooms(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := applyRoomActivity(ctx, userID, chatRooms); err != nil {
+		log.Printf("Error loading room activity for user %s: %v", userID.Hex(), err)
+		return nil, err
+	}
+	return chatRooms, nil
+}
+
+// getCachedUserChatRooms 從 Redis 快取讀取使用者的聊天室列表，快取未命中時從 MongoDB 讀取並寫回快取
+// 快取只保存聊天室資訊與個人設定，不包含隨每則訊息改變的最後訊息與未讀數
+func getCachedUserChatRooms(userID primitive.ObjectID) ([]models.ChatRoom, error) {
 	cacheKey := fmt.Sprintf("user-chatrooms:%s", userID.Hex())
 	ctx := context.Background()
 	result, err := RedisClient.Get(ctx, cacheKey).Result()
@@ -302,6 +1003,13 @@ func GetUserChatRooms(userID primitive.ObjectID, cfg *config.Config) ([]models.C
 		log.Printf("Error decoding chatrooms for user %s: %v", userID.Hex(), err)
 		return nil, err
 	}
+	// 個人設定不會序列化到 JSON，快取前先取出這位使用者自己的設定
+	// 最後一則訊息在讀取列表時才填入，不能存進快取
+	for i := range chatRooms {
+		settings := chatRooms[i].SettingsFor(userID)
+		chatRooms[i].Settings = &settings
+		chatRooms[i].LastMessage = nil
+	}
 
 	// --- 步驟 3: 將從 MongoDB 拿到的結果，寫回 Redis 快取 ---
 	// 先將 Go 的 []ChatRoom 結構，編碼成 JSON 字串
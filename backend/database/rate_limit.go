@@ -0,0 +1,62 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// slidingWindowScript 在同一個 Lua 腳本中清除過期紀錄、計數並新增這次的紀錄
+// 計數與新增必須是原子操作，否則同時到達的請求都會通過檢查而超過上限
+// 回傳 {1} 表示允許；{0, 最早一筆紀錄的分數} 表示超過上限
+var slidingWindowScript = redis.NewScript(`
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', ARGV[1])
+if redis.call('ZCARD', KEYS[1]) >= tonumber(ARGV[3]) then
+	local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+	return {0, oldest[2] or ''}
+end
+redis.call('ZADD', KEYS[1], ARGV[2], ARGV[4])
+redis.call('PEXPIRE', KEYS[1], ARGV[5])
+return {1}
+`)
+
+// AllowSlidingWindow 以 Redis sorted set 實作滑動視窗限流
+// 在 window 期間內最多允許 limit 次，超過時回傳 false 以及最早可以再試的等待時間
+func AllowSlidingWindow(key string, limit int, window time.Duration) (bool, time.Duration, error) {
+	ctx := context.Background()
+	now := time.Now()
+	windowStart := now.Add(-window)
+
+	// member 加上亂數後綴，避免同一奈秒內的請求互相覆蓋
+	member := fmt.Sprintf("%d-%d", now.UnixNano(), rand.Uint32())
+	result, err := slidingWindowScript.Run(ctx, RedisClient, []string{key},
+		strconv.FormatInt(windowStart.UnixNano(), 10),
+		strconv.FormatInt(now.UnixNano(), 10),
+		limit,
+		member,
+		window.Milliseconds(),
+	).Slice()
+	if err != nil {
+		return false, 0, err
+	}
+
+	if allowed, _ := result[0].(int64); allowed == 1 {
+		return true, 0, nil
+	}
+	retryAfter := window
+	if len(result) > 1 {
+		if oldest, err := strconv.ParseFloat(fmt.Sprint(result[1]), 64); err == nil {
+			retryAfter = time.Unix(0, int64(oldest)).Add(window).Sub(now)
+		}
+	}
+	return false, retryAfter, nil
+}
+
+// ResetRateLimit 清除限流紀錄
+func ResetRateLimit(key string) error {
+	return RedisClient.Del(context.Background(), key).Err()
+}
@@ -0,0 +1,133 @@
+package database
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go-chat/backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// recordRoomMessage 在訊息寫入後更新聊天室的最後一則訊息
+// 只寫入一次聊天室文件；聊天室列表快取不包含最後訊息與未讀數，所以不需要讓快取失效
+func recordRoomMessage(ctx context.Context, message models.Message) error {
+	roomID, err := primitive.ObjectIDFromHex(message.RoomID)
+	if err != nil {
+		// 不屬於任何聊天室的訊息沒有列表可更新
+		return nil
+	}
+
+	// 同時寫入的訊息可能晚到，不能用較舊的訊息覆蓋較新的預覽
+	filter := bson.M{
+		"_id": roomID,
+		"$or": bson.A{
+			bson.M{"lastMessage": bson.M{"$exists": false}},
+			bson.M{"lastMessage.timestamp": bson.M{"$lte": message.Timestamp}},
+		},
+	}
+	update := bson.M{"$set": bson.M{"lastMessage": models.NewMessagePreview(message)}}
+	_, err = GetCollection("chatrooms").UpdateOne(ctx, filter, update)
+	return err
+}
+
+// MarkChatRoomRead 將聊天室目前所有的訊息標記為使用者已讀，使用者不是成員時回傳 false
+func MarkChatRoomRead(roomID, userID primitive.ObjectID) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": roomID, "participants": userID}
+	update := bson.M{"$max": bson.M{"lastReadAt." + userID.Hex(): time.Now()}}
+	result, err := GetCollection("chatrooms").UpdateOne(ctx, filter, update)
+	if err != nil {
+		log.Printf("Error marking room %s as read for user %s: %v", roomID.Hex(), userID.Hex(), err)
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// applyRoomActivity 替使用者的聊天室列表填入最後一則訊息與未讀數
+// 這些欄位隨每則訊息改變，不放在快取中，而是每次讀取列表時以一次聊天室查詢與一次訊息聚合取得
+// 未讀數直接從訊息集合計算，被 TTL 清除的訊息自然不會計入
+func applyRoomActivity(ctx context.Context, userID primitive.ObjectID, chatRooms []models.ChatRoom) error {
+	if len(chatRooms) == 0 {
+		return nil
+	}
+	roomIDs := make([]primitive.ObjectID, len(chatRooms))
+	for i, room := range chatRooms {
+		roomIDs[i] = room.ID
+	}
+
+	// 只取出這位使用者需要的欄位，避免讀取其他成員的資料
+	projection := bson.M{
+		"lastMessage":                 1,
+		"createdAt":                   1,
+		"lastReadAt." + userID.Hex():  1,
+		"memberSince." + userID.Hex(): 1,
+	}
+	cursor, err := GetCollection("chatrooms").Find(ctx, bson.M{"_id": bson.M{"$in": roomIDs}}, options.Find().SetProjection(projection))
+	if err != nil {
+		return err
+	}
+	var activities []models.ChatRoom
+	if err := cursor.All(ctx, &activities); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	activityByID := make(map[primitive.ObjectID]models.ChatRoom, len(activities))
+	// 只有最後一則訊息晚於已讀時間的聊天室才可能有未讀訊息
+	var unreadConditions bson.A
+	for _, activity := range activities {
+		if activity.LastMessage != nil && activity.LastMessage.Expired(now) {
+			activity.LastMessage = nil
+		}
+		activityByID[activity.ID] = activity
+		if activity.LastMessage != nil && activity.LastMessage.Timestamp.After(activity.LastReadAtFor(userID)) {
+			unreadConditions = append(unreadConditions, bson.M{
+				"roomId":    activity.ID.Hex(),
+				"timestamp": bson.M{"$gt": activity.LastReadAtFor(userID)},
+			})
+		}
+	}
+
+	unreadByRoom := make(map[string]int64)
+	if len(unreadConditions) > 0 {
+		pipeline := bson.A{
+			bson.M{"$match": bson.M{
+				"$or":      unreadConditions,
+				"type":     bson.M{"$in": bson.A{models.MessageTypeNormal, models.MessageTypeSystem}},
+				"senderId": bson.M{"$ne": userID},
+				// TTL 索引每分鐘才清除一次，已過期但尚未清除的訊息不算未讀
+				"$and": bson.A{bson.M{"$or": bson.A{
+					bson.M{"expiresAt": bson.M{"$exists": false}},
+					bson.M{"expiresAt": bson.M{"$gt": now}},
+				}}},
+			}},
+			bson.M{"$group": bson.M{"_id": "$roomId", "count": bson.M{"$sum": 1}}},
+		}
+		cursor, err := GetCollection("messages").Aggregate(ctx, pipeline)
+		if err != nil {
+			return err
+		}
+		var counts []struct {
+			RoomID string `bson:"_id"`
+			Count  int64  `bson:"count"`
+		}
+		if err := cursor.All(ctx, &counts); err != nil {
+			return err
+		}
+		for _, count := range counts {
+			unreadByRoom[count.RoomID] = count.Count
+		}
+	}
+
+	for i := range chatRooms {
+		chatRooms[i].LastMessage = activityByID[chatRooms[i].ID].LastMessage
+		chatRooms[i].UnreadCount = unreadByRoom[chatRooms[i].ID.Hex()]
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+package database
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+
+	"go-chat/backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// activeSanctionFilter 篩選尚未到期的處分；TTL 索引每分鐘才清除一次，不能只依賴它
+func activeSanctionFilter(roomID primitive.ObjectID, kind models.RoomSanctionKind) bson.M {
+	return bson.M{
+		"roomId": roomID,
+		"kind":   kind,
+		"$or": bson.A{
+			bson.M{"expiresAt": bson.M{"$exists": false}},
+			bson.M{"expiresAt": bson.M{"$gt": time.Now()}},
+		},
+	}
+}
+
+// SetRoomSanction 新增或取代使用者在聊天室的封鎖或禁言紀錄
+func SetRoomSanction(sanction models.RoomSanction) (*models.RoomSanction, error) {
+	collection := GetCollection("room_sanctions")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"roomId": sanction.RoomID, "userId": sanction.UserID, "kind": sanction.Kind}
+	set := bson.M{
+		"reason":    sanction.Reason,
+		"createdBy": sanction.CreatedBy,
+		"createdAt": sanction.CreatedAt,
+	}
+	update := bson.M{"$set": set}
+	if sanction.ExpiresAt.IsZero() {
+		update["$unset"] = bson.M{"expiresAt": ""}
+	} else {
+		set["expiresAt"] = sanction.ExpiresAt
+	}
+
+	var saved models.RoomSanction
+	err := collection.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)).Decode(&saved)
+	if err != nil {
+		log.Printf("Error saving %s of user %s in room %s: %v", sanction.Kind, sanction.UserID.Hex(), sanction.RoomID.Hex(), err)
+		return nil, err
+	}
+	if sanction.Kind == models.RoomSanctionMute {
+		InvalidateRoomMuteCache(sanction.RoomID)
+	}
+	return &saved, nil
+}
+
+// FindActiveRoomSanction 查詢使用者在聊天室尚未到期的處分，沒有時回傳 nil
+func FindActiveRoomSanction(roomID, userID primitive.ObjectID, kind models.RoomSanctionKind) (*models.RoomSanction, error) {
+	collection := GetCollection("room_sanctions")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := activeSanctionFilter(roomID, kind)
+	filter["userId"] = userID
+
+	var sanction models.RoomSanction
+	err := collection.FindOne(ctx, filter).Decode(&sanction)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("Error finding %s of user %s in room %s: %v", kind, userID.Hex(), roomID.Hex(), err)
+		return nil, err
+	}
+	return &sanction, nil
+}
+
+// FindSanctionedUserIDs 回傳 userIDs 中在聊天室有尚未到期處分的使用者
+func FindSanctionedUserIDs(roomID primitive.ObjectID, kind models.RoomSanctionKind, userIDs []primitive.ObjectID) ([]primitive.ObjectID, error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+	sanctions, err := findRoomSanctions(roomID, kind, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, 0, len(sanctions))
+	for _, sanction := range sanctions {
+		ids = append(ids, sanction.UserID)
+	}
+	return ids, nil
+}
+
+// ListRoomSanctions 依建立時間列出聊天室尚未到期的封鎖或禁言紀錄
+func ListRoomSanctions(roomID primitive.ObjectID, kind models.RoomSanctionKind) ([]models.RoomSanction, error) {
+	return findRoomSanctions(roomID, kind, nil)
+}
+
+func findRoomSanctions(roomID primitive.ObjectID, kind models.RoomSanctionKind, userIDs []primitive.ObjectID) ([]models.RoomSanction, error) {
+	collection := GetCollection("room_sanctions")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := activeSanctionFilter(roomID, kind)
+	if userIDs != nil {
+		filter["userId"] = bson.M{"$in": userIDs}
+	}
+	cursor, err := collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}))
+	if err != nil {
+		log.Printf("Error listing %s records of room %s: %v", kind, roomID.Hex(), err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	sanctions := []models.RoomSanction{}
+	if err = cursor.All(ctx, &sanctions); err != nil {
+		log.Printf("Error decoding %s records of room %s: %v", kind, roomID.Hex(), err)
+		return nil, err
+	}
+	return sanctions, nil
+}
+
+// DeleteRoomSanction 解除使用者在聊天室的封鎖或禁言，回傳是否真的有紀錄被刪除
+func DeleteRoomSanction(roomID, userID primitive.ObjectID, kind models.RoomSanctionKind) (bool, error) {
+	collection := GetCollection("room_sanctions")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := collection.DeleteOne(ctx, bson.M{"roomId": roomID, "userId": userID, "kind": kind})
+	if err != nil {
+		log.Printf("Error deleting %s of user %s in room %s: %v", kind, userID.Hex(), roomID.Hex(), err)
+		return false, err
+	}
+	if kind == models.RoomSanctionMute {
+		InvalidateRoomMuteCache(roomID)
+	}
+	return result.DeletedCount > 0, nil
+}
+
+// roomMuteCacheTTL 是聊天室禁言名單快取的保存時間
+// 禁言與解除禁言都會讓快取失效，保存時間只是為了限制與資料庫不一致的最長時間
+const roomMuteCacheTTL = time.Minute
+
+// roomMuteCacheLoaded 是禁言名單快取中表示「已從資料庫載入」的欄位，沒有任何禁言的聊天室也會有這個欄位
+const roomMuteCacheLoaded = "_loaded"
+
+func roomMuteCacheKey(roomID primitive.ObjectID) string {
+	return "room-mutes:" + roomID.Hex()
+}
+
+// IsRoomMemberMuted 檢查使用者目前是否在聊天室被禁言
+// 每則訊息發送前都會呼叫，所以聊天室的禁言名單快取在 Redis 的 hash 中 (成員 ID → 到期時間，0 代表沒有期限)，
+// 快取不存在時才查詢資料庫；Redis 無法使用時直接查詢資料庫
+func IsRoomMemberMuted(roomID, userID primitive.ObjectID) (bool, error) {
+	ctx := context.Background()
+	key := roomMuteCacheKey(roomID)
+
+	cached, err := RedisClient.HMGet(ctx, key, roomMuteCacheLoaded, userID.Hex()).Result()
+	if err != nil {
+		log.Printf("Redis error reading mutes of room %s: %v. Falling back to MongoDB.", roomID.Hex(), err)
+		mute, err := FindActiveRoomSanction(roomID, userID, models.RoomSanctionMute)
+		return mute != nil, err
+	}
+	if cached[0] != nil {
+		expiresAt, ok := cached[1].(string)
+		return ok && roomMuteActive(expiresAt, time.Now()), nil
+	}
+
+	// --- 快取未命中，載入整個聊天室的禁言名單 ---
+	mutes, err := ListRoomSanctions(roomID, models.RoomSanctionMute)
+	if err != nil {
+		return false, err
+	}
+	fields := map[string]interface{}{roomMuteCacheLoaded: "1"}
+	muted := false
+	for _, mute := range mutes {
+		expiresAt := int64(0)
+		if !mute.ExpiresAt.IsZero() {
+			expiresAt = mute.ExpiresAt.UnixMilli()
+		}
+		fields[mute.UserID.Hex()] = strconv.FormatInt(expiresAt, 10)
+		if mute.UserID == userID {
+			muted = true
+		}
+	}
+	pipe := RedisClient.TxPipeline()
+	pipe.Del(ctx, key)
+	pipe.HSet(ctx, key, fields)
+	pipe.Expire(ctx, key, roomMuteCacheTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("Failed to cache mutes of room %s: %v", roomID.Hex(), err)
+	}
+	return muted, nil
+}
+
+// roomMuteActive 檢查快取中的到期時間 (毫秒，0 代表沒有期限) 是否尚未到期
+func roomMuteActive(expiresAt string, now time.Time) bool {
+	millis, err := strconv.ParseInt(expiresAt, 10, 64)
+	if err != nil {
+		return false
+	}
+	return millis == 0 || now.UnixMilli() < millis
+}
+
+// InvalidateRoomMuteCache 刪除聊天室的禁言名單快取，禁言變動或聊天室刪除時呼叫
+func InvalidateRoomMuteCache(roomID primitive.ObjectID) {
+	if err := RedisClient.Del(context.Background(), roomMuteCacheKey(roomID)).Err(); err != nil {
+		log.Printf("Failed to invalidate mute cache of room %s: %v", roomID.Hex(), err)
+	}
+}
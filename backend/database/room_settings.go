@@ -0,0 +1,109 @@
+package database
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go-chat/backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// UpdateRoomMemberSettings 只儲存使用者對聊天室個人設定中 fields 列出的欄位 (bson 名稱)，使用者不是成員時回傳 nil
+// 其他欄位維持資料庫中的值，同時送出的兩個請求 (例如一個設定靜音、一個設定我的最愛) 不會互相覆蓋
+func UpdateRoomMemberSettings(roomID, userID primitive.ObjectID, settings models.RoomMemberSettings, fields []string) (*models.ChatRoom, error) {
+	collection := GetCollection("chatrooms")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 設定欄位都是 omitempty，編碼後沒有出現的欄位是零值，改為移除
+	raw, err := bson.Marshal(settings)
+	if err != nil {
+		return nil, err
+	}
+	var values bson.M
+	if err := bson.Unmarshal(raw, &values); err != nil {
+		return nil, err
+	}
+	prefix := "memberSettings." + userID.Hex() + "."
+	set, unset := bson.M{}, bson.M{}
+	for _, field := range fields {
+		if value, ok := values[field]; ok {
+			set[prefix+field] = value
+		} else {
+			unset[prefix+field] = ""
+		}
+	}
+
+	// 個人設定不影響其他成員，不更新 updatedAt
+	filter := bson.M{"_id": roomID, "participants": userID}
+	update := bson.M{}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	var updatedRoom models.ChatRoom
+	if len(update) == 0 {
+		err = collection.FindOne(ctx, filter).Decode(&updatedRoom)
+	} else {
+		err = collection.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&updatedRoom)
+	}
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("Error updating settings of room %s for user %s: %v", roomID.Hex(), userID.Hex(), err)
+		return nil, err
+	}
+	return &updatedRoom, nil
+}
+
+// SetRoomMute 將使用者對聊天室的通知設為靜音，duration 為 0 代表直到取消靜音
+func SetRoomMute(roomID, userID primitive.ObjectID, duration time.Duration) error {
+	settings := bson.M{"memberSettings." + userID.Hex() + ".muted": true}
+	unset := bson.M{}
+	if duration > 0 {
+		settings["memberSettings."+userID.Hex()+".mutedUntil"] = time.Now().Add(duration)
+	} else {
+		unset["memberSettings."+userID.Hex()+".mutedUntil"] = ""
+	}
+	update := bson.M{"$set": settings}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	if err := updateRoomMute(roomID, userID, update); err != nil {
+		log.Printf("Failed to mute room %s for user %s: %v", roomID.Hex(), userID.Hex(), err)
+		return err
+	}
+	return nil
+}
+
+// ClearRoomMute 取消使用者對聊天室的靜音
+func ClearRoomMute(roomID, userID primitive.ObjectID) error {
+	update := bson.M{"$unset": bson.M{
+		"memberSettings." + userID.Hex() + ".muted":      "",
+		"memberSettings." + userID.Hex() + ".mutedUntil": "",
+	}}
+	if err := updateRoomMute(roomID, userID, update); err != nil {
+		log.Printf("Failed to unmute room %s for user %s: %v", roomID.Hex(), userID.Hex(), err)
+		return err
+	}
+	return nil
+}
+
+// updateRoomMute 更新成員的靜音設定，只有聊天室成員的設定會被寫入
+func updateRoomMute(roomID, userID primitive.ObjectID, update bson.M) error {
+	collection := GetCollection("chatrooms")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": roomID, "participants": userID}, update)
+	return err
+}
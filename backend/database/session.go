@@ -0,0 +1,24 @@
+package database
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TouchSession 更新登入 session 的最後活動時間，讓裝置列表能顯示 WebSocket 連線的活動
+func TouchSession(sessionID primitive.ObjectID) error {
+	collection := GetCollection("sessions")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": sessionID, "revokedAt": bson.M{"$exists": false}}
+	if _, err := collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"lastSeenAt": time.Now()}}); err != nil {
+		log.Printf("Error updating last seen time of session %s: %v", sessionID.Hex(), err)
+		return err
+	}
+	return nil
+}
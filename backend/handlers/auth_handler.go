@@ -2,23 +2,21 @@ package handlers
 
 import (
 	"context"
-	"crypto/rand"
-	"encoding/base64"
 	"encoding/json"
-	"io"
 	"log"
+	"math"
 	"net/http"
-	"net/url"
+	"net/mail"
+	"strconv"
+	"strings"
 	"time"
 
-	"golang.org/x/oauth2"
-	"golang.org/x/oauth2/google"
-
 	"go-chat/backend/config"
 	"go-chat/backend/database"
 	"go-chat/backend/models"
 	"go-chat/backend/store"
 	"go-chat/backend/utils"
+	"go-chat/backend/websocket"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -28,16 +26,40 @@ import (
 
 // AuthHandler 包含處理認證請求的所有依賴
 type AuthHandler struct {
-	UserStore store.UserStorer // <<<--- 依賴於介面，而不是具體實作
-	Cfg       *config.Config
+	UserStore    store.UserStorer // <<<--- 依賴於介面，而不是具體實作
+	TokenStore   store.RefreshTokenStorer
+	SessionStore store.SessionStorer
+	Denylist     store.TokenDenylist
+	Cfg          *config.Config
+	// Verification 負責在註冊後寄出驗證信，未設定時不寄送
+	Verification *EmailVerificationHandler
+	// TwoFactor 負責密碼登入的第二步驗證，未設定時登入不檢查兩步驟驗證
+	TwoFactor *TwoFactorHandler
+	// Throttle 限制密碼登入的嘗試次數並在連續失敗後鎖定帳號，未設定時不限流
+	Throttle store.LoginThrottler
+	// AuditLogs 保存帳號鎖定與解鎖的稽核紀錄，未設定時只寫入 log
+	AuditLogs store.AuditLogStorer
+	// Keys 簽發與驗證 access token 與 challenge token，未設定時使用 Cfg.JWTSecret 的 HS256
+	Keys utils.TokenKeys
 }
 
 // NewAuthHandler 是一個工廠函式，用於建立新的 AuthHandler
-func NewAuthHandler(userStore store.UserStorer, cfg *config.Config) *AuthHandler {
+func NewAuthHandler(userStore store.UserStorer, tokenStore store.RefreshTokenStorer, sessionStore store.SessionStorer, denylist store.TokenDenylist, cfg *config.Config) *AuthHandler {
 	return &AuthHandler{
-		UserStore: userStore,
-		Cfg:       cfg,
+		UserStore:    userStore,
+		TokenStore:   tokenStore,
+		SessionStore: sessionStore,
+		Denylist:     denylist,
+		Cfg:          cfg,
+	}
+}
+
+// tokenKeys 回傳簽發與驗證 JWT 使用的金鑰
+func (h *AuthHandler) tokenKeys() utils.TokenKeys {
+	if h.Keys != nil {
+		return h.Keys
 	}
+	return utils.NewHMACKeys(h.Cfg.JWTSecret)
 }
 
 // sendJSONError 統一發送 JSON 格式錯誤響應
@@ -63,6 +85,11 @@ func (h *AuthHandler) RegisterUser(w http.ResponseWriter, r *http.Request) {
 		sendJSONError(w, "Email, username, and password are required", http.StatusBadRequest)
 		return
 	}
+	// 只接受單純的地址，不接受 "名稱 <地址>" 這類格式
+	if addr, err := mail.ParseAddress(registerReq.Email); err != nil || addr.Address != registerReq.Email {
+		sendJSONError(w, "Invalid email address", http.StatusBadRequest)
+		return
+	}
 
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
@@ -105,6 +132,14 @@ func (h *AuthHandler) RegisterUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	log.Printf("User registered successfully: %v", insertedID.Hex())
+
+	if h.Verification != nil {
+		user.ID = insertedID
+		if err := h.Verification.SendVerification(ctx, &user); err != nil {
+			// 註冊已經完成，使用者之後仍可以重新寄送驗證信
+			log.Printf("Error sending verification for user %s: %v", insertedID.Hex(), err)
+		}
+	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(models.RegisterResponse{
@@ -133,42 +168,60 @@ func (h *AuthHandler) LoginUser(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
+	// 限流與鎖定在查詢使用者之前進行，帳號是否存在的回應都相同
+	account := strings.ToLower(strings.TrimSpace(credentials.Email))
+	ip := utils.GetClientIP(r)
+	if !h.checkLoginAllowed(ctx, w, ip, account) {
+		return
+	}
+
 	// --- 使用 UserStore 介面進行操作 ---
 	user, err := h.UserStore.FindUserByEmail(ctx, credentials.Email)
-	if err != nil {
-		// 根據錯誤類型回傳不同訊息
-		if err == mongo.ErrNoDocuments {
-			sendJSONError(w, "Invalid credentials", http.StatusUnauthorized)
-		} else {
-			log.Printf("Error finding user by email: %v", err)
-			sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+	if err != nil && err != mongo.ErrNoDocuments {
+		log.Printf("Error finding user by email: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if err == mongo.ErrNoDocuments || bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(credentials.Password)) != nil {
+		h.recordLoginFailure(ctx, w, account, ip, user)
+		return
+	}
+	if h.Throttle != nil {
+		if err := h.Throttle.RecordSuccess(ctx, account); err != nil {
+			log.Printf("Error clearing login failures: %v", err)
 		}
+	}
+	// 密碼正確後才檢查驗證狀態，避免被用來探測帳號
+	if h.Cfg.RequireEmailVerification && !user.Verified {
+		sendJSONError(w, "Email not verified", http.StatusForbidden)
 		return
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(credentials.Password)); err != nil {
-		sendJSONError(w, "Invalid credentials", http.StatusUnauthorized)
+	// 啟用兩步驟驗證時先不簽發 cookie，改回傳 challenge token 等待 /login/2fa
+	challenge, err := h.twoFactorChallenge(ctx, user)
+	if err != nil {
+		log.Printf("Error preparing 2FA challenge for user %s: %v", user.ID.Hex(), err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if challenge != "" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(models.LoginResponse{
+			Message:           "Two-factor authentication required",
+			TwoFactorRequired: true,
+			ChallengeToken:    challenge,
+		})
 		return
 	}
 
-	token, err := utils.GenerateJWT(user.ID, user.Username, h.Cfg.JWTSecret)
-	if err != nil {
-		log.Printf("Error generating JWT token for user %s: %v", user.ID.Hex(), err)
+	// 每次登入都開始一個新的 session 與 refresh token family
+	if err := h.startSession(ctx, w, r, user); err != nil {
+		log.Printf("Error issuing session for user %s: %v", user.ID.Hex(), err)
 		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 	log.Printf("User logged in successfully: %s", user.Email)
 
-	http.SetCookie(w, &http.Cookie{
-		Name:     "token",
-		Value:    token,
-		Path:     "/",
-		Expires:  time.Now().Add(time.Hour * 24),
-		HttpOnly: true, // true表示JS無法讀取
-		Secure:   true, //只在 HTTPS 連線下傳送
-		SameSite: http.SameSiteStrictMode, // 當請求完全來自自己的網站時，瀏覽器才會帶上這個cookie
-	})
-
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(models.LoginResponse{
@@ -179,6 +232,72 @@ func (h *AuthHandler) LoginUser(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// checkLoginAllowed 檢查來源 IP 與帳號的登入限流與鎖定，不允許時已寫入錯誤回應並回傳 false
+func (h *AuthHandler) checkLoginAllowed(ctx context.Context, w http.ResponseWriter, ip, account string) bool {
+	if h.Throttle == nil {
+		return true
+	}
+	allowed, retryAfter, err := h.Throttle.CheckLogin(ctx, ip, account)
+	if err != nil {
+		log.Printf("Error checking login rate limit: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return false
+	}
+	if !allowed {
+		sendTooManyLoginAttempts(w, retryAfter)
+		return false
+	}
+	return true
+}
+
+// recordLoginFailure 記錄一次密碼登入失敗並回應錯誤，這次失敗觸發鎖定時寫入稽核紀錄並回應 429
+// 帳號不存在時 user 為 nil，仍然以相同方式計數
+func (h *AuthHandler) recordLoginFailure(ctx context.Context, w http.ResponseWriter, account, ip string, user *models.User) {
+	if h.Throttle == nil {
+		sendJSONError(w, "Invalid credentials", http.StatusUnauthorized)
+		return
+	}
+	lockout, err := h.Throttle.RecordFailure(ctx, account)
+	if err != nil {
+		log.Printf("Error recording login failure: %v", err)
+	}
+	if lockout <= 0 {
+		sendJSONError(w, "Invalid credentials", http.StatusUnauthorized)
+		return
+	}
+
+	entry := models.AuditLog{
+		ID:             primitive.NewObjectID(),
+		Action:         models.AuditActionAccountLocked,
+		Email:          account,
+		IP:             ip,
+		LockoutSeconds: int64(lockout.Seconds()),
+		CreatedAt:      time.Now(),
+	}
+	if user != nil {
+		entry.UserID = user.ID
+	}
+	h.writeAuditLog(ctx, entry)
+	sendTooManyLoginAttempts(w, lockout)
+}
+
+// writeAuditLog 寫入稽核紀錄，寫入失敗不影響原本的請求
+func (h *AuthHandler) writeAuditLog(ctx context.Context, entry models.AuditLog) {
+	log.Printf("Audit: %s email=%s ip=%s lockout=%ds actor=%s", entry.Action, entry.Email, entry.IP, entry.LockoutSeconds, entry.ActorID.Hex())
+	if h.AuditLogs == nil {
+		return
+	}
+	if err := h.AuditLogs.CreateAuditLog(ctx, entry); err != nil {
+		log.Printf("Error writing audit log: %v", err)
+	}
+}
+
+// sendTooManyLoginAttempts 回應 429 並以 Retry-After 告知需要等待的秒數
+func sendTooManyLoginAttempts(w http.ResponseWriter, retryAfter time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	sendJSONError(w, "Too many login attempts, please try again later", http.StatusTooManyRequests)
+}
+
 // GetAllUsers 處理獲取所有使用者列表的請求
 func GetAllUsers(w http.ResponseWriter, r *http.Request) {
 	usersCollection := database.GetCollection("users")
@@ -218,168 +337,42 @@ func GetAllUsers(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-var googleOAuthConfig *oauth2.Config
-var oauthStateString string
-
-// 初始化 Google OAuth 設定
-func InitializeOAuthGoogle() {
-	cfg := config.LoadConfig()
-	googleOAuthConfig = &oauth2.Config{
-		RedirectURL:  cfg.GoogleRedirectURL,
-		ClientID:     cfg.GoogleClientID,
-		ClientSecret: cfg.GoogleClientSecret,
-		Scopes:       []string{"https://www.googleapis.com/auth/userinfo.email", "https://www.googleapis.com/auth/userinfo.profile"},
-		Endpoint:     google.Endpoint,
-	}
-}
-
-// HandleGoogleLogin 處理導向 Google 登入頁面的請求
-func HandleGoogleLogin(w http.ResponseWriter, r *http.Request) {
-	// 產生一個隨機 state 字串，用於 CSRF 保護
-	b := make([]byte, 16)
-	rand.Read(b)
-	oauthStateString = base64.URLEncoding.EncodeToString(b)
-
-	// 將 state 存入 cookie
-	http.SetCookie(w, &http.Cookie{
-		Name:    "oauthstate",
-		Value:   oauthStateString,
-		Expires: time.Now().Add(10 * time.Minute),
-	})
-
-	url := googleOAuthConfig.AuthCodeURL(oauthStateString)
-	http.Redirect(w, r, url, http.StatusTemporaryRedirect)
-}
-
-// HandleGoogleCallback 處理 Google 重新導向回來的請求
-func HandleGoogleCallback(w http.ResponseWriter, r *http.Request) {
-	// 檢查 state 是否相符
-	oauthState, _ := r.Cookie("oauthstate")
-	if r.FormValue("state") != oauthState.Value {
-		log.Println("invalid oauth google state")
-		http.Redirect(w, r, "/", http.StatusTemporaryRedirect)
-		return
-	}
-
-	// 用授權碼交換 token
-	token, err := googleOAuthConfig.Exchange(context.Background(), r.FormValue("code"))
-	if err != nil {
-		log.Printf("code exchange wrong: %s\n", err.Error())
-		http.Redirect(w, r, "/", http.StatusTemporaryRedirect)
-		return
-	}
-
-	// 用 token 獲取使用者資訊
-	response, err := http.Get("https://www.googleapis.com/oauth2/v2/userinfo?access_token=" + token.AccessToken)
-	if err != nil {
-		log.Printf("failed getting user info: %s\n", err.Error())
-		http.Redirect(w, r, "/", http.StatusTemporaryRedirect)
-		return
-	}
-	defer response.Body.Close()
-
-	contents, err := io.ReadAll(response.Body)
-	if err != nil {
-		log.Printf("failed read user info: %s\n", err.Error())
-		http.Redirect(w, r, "/", http.StatusTemporaryRedirect)
-		return
-	}
-
-	var googleUser struct {
-		ID    string `json:"id"`
-		Email string `json:"email"`
-		Name  string `json:"name"`
-	}
-	json.Unmarshal(contents, &googleUser)
-
-	usersCollection := database.GetCollection("users")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+// LogoutUser 處理使用者登出請求
+// 除了清除 cookie，也會撤銷目前的 access token 與這次登入的 refresh token family，並中斷同一個 session 的 WebSocket 連線
+func (h *AuthHandler) LogoutUser(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
-	// 檢查使用者是否存在
-	var user models.User
-	err = usersCollection.FindOne(ctx, bson.M{"googleId": googleUser.ID}).Decode(&user)
-
-	// 如果使用者不存在，就建立新使用者
-	if err == mongo.ErrNoDocuments {
-		newUser := models.User{
-			GoogleID: googleUser.ID,
-			Email:    googleUser.Email,
-			Username: googleUser.Name, // 直接使用 Google 名稱作為使用者名稱
-		}
-		res, err := usersCollection.InsertOne(ctx, newUser)
-		if err != nil {
-			log.Printf("Error inserting new Google user: %v", err)
-			http.Redirect(w, r, "/auth?error=registration_failed", http.StatusTemporaryRedirect)
-			return
+	var userID, sessionID primitive.ObjectID
+	if cookie, err := r.Cookie(accessTokenCookieName); err == nil && cookie.Value != "" {
+		// 已過期或無效的 token 本來就不能使用，不需要撤銷
+		if claims, err := utils.ParseAccessToken(cookie.Value, h.tokenKeys()); err == nil {
+			userID, sessionID = claims.UserID, claims.SessionID
+			if err := h.Denylist.RevokeAccessToken(ctx, claims.TokenID, claims.ExpiresAt); err != nil {
+				log.Printf("Error revoking access token for user %s: %v", userID.Hex(), err)
+			}
 		}
-		user.ID = res.InsertedID.(primitive.ObjectID)
-		user.Username = newUser.Username
-		user.Email = newUser.Email
-	} else if err != nil {
-		log.Printf("Error finding user by Google ID: %v", err)
-		http.Redirect(w, r, "/auth?error=db_error", http.StatusTemporaryRedirect)
-		return
-	}
-
-	// 產生 JWT
-	cfg := config.LoadConfig()
-	jwtToken, err := utils.GenerateJWT(user.ID, user.Username, cfg.JWTSecret)
-	if err != nil {
-		log.Printf("Error generating JWT for Google user: %v", err)
-		http.Redirect(w, r, "/auth?error=token_generation_failed", http.StatusTemporaryRedirect)
-		return
 	}
 
-	// 將 JWT 存入 cookie 並導回前端首頁
-	http.SetCookie(w, &http.Cookie{
-		Name:     "token",
-		Value:    jwtToken,
-		Path:     "/",
-		Expires:  time.Now().Add(24 * time.Hour),
-		HttpOnly: true, // 建議設為 true 增加安全性
-		Secure: true,
-		SameSite: http.SameSiteStrictMode,
-	})
-
-	userInfo := map[string]string{
-		"id": user.ID.Hex(),
-		"username": user.Username,
+	// refresh token 的效期比 access token 長，access token 過期後仍要靠它找出要撤銷的 session
+	if cookie, err := r.Cookie(refreshTokenCookieName); err == nil && cookie.Value != "" {
+		token, err := h.TokenStore.FindRefreshTokenByHash(ctx, utils.HashToken(cookie.Value))
+		if err == nil {
+			userID, sessionID = token.UserID, token.FamilyID
+		} else if err != mongo.ErrNoDocuments {
+			log.Printf("Error finding refresh token on logout: %v", err)
+		}
 	}
 
-	userInfoBytes, err := json.Marshal(userInfo)
-	if err!= nil{
-		log.Printf("Error marshalling user_info for cookie: %v", err)
+	if !sessionID.IsZero() {
+		h.revokeSession(ctx, userID, sessionID, "您已登出。")
+	} else if !userID.IsZero() {
+		// 改版前簽發的 token 沒有 session，只能中斷使用者目前的連線
+		websocket.DisconnectUser(userID, primitive.NilObjectID, "您已登出。")
 	}
 
-	http.SetCookie(w, &http.Cookie{
-		Name: "user_info",
-		Value: url.QueryEscape(string(userInfoBytes)),
-		Path: "/",
-		Expires: time.Now().Add(time.Hour * 24),
-	})
-
-	http.Redirect(w, r, "http://localhost:5173/home", http.StatusTemporaryRedirect)
-}
-
-// LogoutUser 處理使用者登出請求
-func (h *AuthHandler) LogoutUser(w http.ResponseWriter, r *http.Request) {
-	// 1. 設定一個 http.Cookie 物件，其名稱與登入時設定的相同 ("token")
-	// 2. 將其 MaxAge 設為 -1，這是一個標準指令，告訴瀏覽器立即刪除這個 cookie
-	http.SetCookie(w, &http.Cookie{
-		Name:   "token",
-		Value:  "", // 值可以為空
-		Path:   "/",
-		MaxAge: -1, // 【核心】立即過期
-	})
-
-	// 同時也刪除 user_info cookie
-	http.SetCookie(w, &http.Cookie{
-		Name:   "user_info",
-		Value:  "",
-		Path:   "/",
-		MaxAge: -1,
-	})
+	// 將 token、refresh_token 與 user_info cookie 的 MaxAge 設為 -1，告訴瀏覽器立即刪除
+	clearAuthCookies(w)
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{"message": "Logout successful"})
@@ -3,10 +3,12 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"go-chat/backend/config"
 	"go-chat/backend/models"
@@ -30,13 +32,18 @@ func TestLoginUser(t *testing.T) {
 	// 建立一個 mock UserStore
 	mockUserStore := mocks.NewMockUserStorer(ctrl)
 
+	mockTokenStore := mocks.NewMockRefreshTokenStorer(ctrl)
+	mockSessionStore := mocks.NewMockSessionStorer(ctrl)
+
 	// 建立一個假的 config
 	cfg := &config.Config{
-		JWTSecret: "test-secret-for-login",
+		JWTSecret:       "test-secret-for-login",
+		AccessTokenTTL:  15 * time.Minute,
+		RefreshTokenTTL: 24 * time.Hour,
 	}
 
 	// 建立我們要測試的 AuthHandler，並注入 mock store 和假 config
-	authHandler := NewAuthHandler(mockUserStore, cfg)
+	authHandler := NewAuthHandler(mockUserStore, mockTokenStore, mockSessionStore, mocks.NewMockTokenDenylist(ctrl), cfg)
 
 	// --- 測試案例 1: 登入失敗 - 使用者不存在 ---
 	t.Run("登入失敗 - 使用者不存在", func(t *testing.T) {
@@ -124,6 +131,7 @@ func TestLoginUser(t *testing.T) {
 		body, _ := json.Marshal(loginCredentials)
 
 		req := httptest.NewRequest("POST", "/login", bytes.NewReader(body))
+		req.Header.Set("User-Agent", "test-browser/1.0")
 		rr := httptest.NewRecorder()
 
 		// 準備假的使用者資料
@@ -141,6 +149,28 @@ func TestLoginUser(t *testing.T) {
 			Return(mockUser, nil).
 			Times(1)
 
+		// 登入成功時會建立一筆 session 紀錄，以及屬於這個 session 的 refresh token
+		var session models.Session
+		mockSessionStore.EXPECT().
+			CreateSession(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, s models.Session) error {
+				assert.Equal(t, mockUser.ID, s.UserID)
+				assert.Equal(t, "test-browser/1.0", s.UserAgent)
+				assert.NotEmpty(t, s.IP)
+				session = s
+				return nil
+			}).
+			Times(1)
+		mockTokenStore.EXPECT().
+			CreateRefreshToken(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, token models.RefreshToken) error {
+				assert.Equal(t, mockUser.ID, token.UserID)
+				assert.Equal(t, session.ID, token.FamilyID, "refresh token family 應該與 session 相同")
+				assert.NotEmpty(t, token.TokenHash)
+				return nil
+			}).
+			Times(1)
+
 		// --- 執行 handler ---
 		authHandler.LoginUser(rr, req)
 
@@ -160,6 +190,7 @@ func TestLoginUser(t *testing.T) {
 		assert.Contains(t, setCookieHeader, "token=", "Set-Cookie 標頭應該包含 token")
 		assert.Contains(t, setCookieHeader, "HttpOnly", "Cookie 應該被設定為 HttpOnly")
 		assert.Contains(t, setCookieHeader, "SameSite=Strict", "Cookie 應該被設定為 SameSite=Strict")
+		assert.NotNil(t, findCookie(rr, refreshTokenCookieName), "登入時應該同時設定 refresh token cookie")
 	})
 }
 
@@ -172,7 +203,7 @@ func TestRegisterUser(t *testing.T) {
 	mockUserStore := mocks.NewMockUserStorer(ctrl)
 
 	cfg := &config.Config{}
-	authHandler := NewAuthHandler(mockUserStore, cfg)
+	authHandler := NewAuthHandler(mockUserStore, mocks.NewMockRefreshTokenStorer(ctrl), mocks.NewMockSessionStorer(ctrl), mocks.NewMockTokenDenylist(ctrl), cfg)
 
 	t.Run("成功註冊", func(t *testing.T) {
 		registerCredentials := models.RegisterRequest{
@@ -261,6 +292,18 @@ func TestRegisterUser(t *testing.T) {
 
 	})
 
+	t.Run("註冊失敗 - Email 格式錯誤", func(t *testing.T) {
+		for _, email := range []string{"not-an-email", "Name <name@example.com>"} {
+			body, _ := json.Marshal(models.RegisterRequest{Email: email, Username: "newUser", Password: "123456"})
+			req := httptest.NewRequest("POST", "/register", bytes.NewReader(body))
+			rr := httptest.NewRecorder()
+
+			authHandler.RegisterUser(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code, "Email %q 應該被拒絕", email)
+		}
+	})
+
 	t.Run("註冊失敗 - 缺少密碼", func(t *testing.T) {
 		registerCredentials := models.RegisterRequest{
 			Email:    "existing@gmail.com",
@@ -284,3 +327,27 @@ func TestRegisterUser(t *testing.T) {
 	})
 
 }
+
+func TestLoginUser_RequireEmailVerification(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserStore := mocks.NewMockUserStorer(ctrl)
+	cfg := &config.Config{JWTSecret: "test-secret", RequireEmailVerification: true}
+	authHandler := NewAuthHandler(mockUserStore, mocks.NewMockRefreshTokenStorer(ctrl), mocks.NewMockSessionStorer(ctrl), mocks.NewMockTokenDenylist(ctrl), cfg)
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("correct-password"), bcrypt.DefaultCost)
+	mockUserStore.EXPECT().
+		FindUserByEmail(gomock.Any(), "unverified@example.com").
+		Return(&models.User{ID: primitive.NewObjectID(), Email: "unverified@example.com", Password: string(hashedPassword)}, nil).
+		Times(1)
+
+	body, _ := json.Marshal(map[string]string{"email": "unverified@example.com", "password": "correct-password"})
+	req := httptest.NewRequest("POST", "/login", bytes.NewReader(body))
+	rr := httptest.NewRecorder()
+
+	authHandler.LoginUser(rr, req)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code, "Email 尚未驗證時不能登入")
+	assert.Nil(t, findCookie(rr, accessTokenCookieName), "不應該簽發任何 token")
+}
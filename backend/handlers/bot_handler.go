@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"go-chat/backend/database"
+	"go-chat/backend/models"
+	"go-chat/backend/utils"
+	"go-chat/backend/websocket"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CreateBotRequest 定義建立機器人帳號的請求體
+type CreateBotRequest struct {
+	Username string `json:"username"`
+}
+
+// BotTokenResponse 包含機器人的 API 金鑰，金鑰只會在建立或輪替時回傳一次
+type BotTokenResponse struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	Token    string `json:"token"`
+}
+
+// SetRoomBotRequest 定義管理員允許或禁止機器人加入聊天室的請求體
+type SetRoomBotRequest struct {
+	BotID   string `json:"botId"`
+	Allowed bool   `json:"allowed"`
+}
+
+// BotMessageRequest 定義機器人透過 REST 發送訊息的請求體
+type BotMessageRequest struct {
+	RoomID  string `json:"roomId"`
+	Content string `json:"content"`
+}
+
+// CreateBot 處理建立機器人帳號的請求
+// 這個 API 端點會是 POST /bots
+func CreateBot(w http.ResponseWriter, r *http.Request) {
+	ownerID, err := utils.GetUserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req CreateBotRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.Username = strings.TrimSpace(req.Username)
+	if req.Username == "" {
+		http.Error(w, "Bot username is required", http.StatusBadRequest)
+		return
+	}
+
+	owner, err := database.GetUserByID(ownerID)
+	if err != nil {
+		log.Printf("Error getting bot owner %s: %v", ownerID.Hex(), err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if owner.IsBot {
+		http.Error(w, "Bots cannot create other bots", http.StatusForbidden)
+		return
+	}
+
+	existing, err := database.GetUserByUsername(req.Username)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if existing != nil {
+		http.Error(w, "Username already taken", http.StatusConflict)
+		return
+	}
+
+	bot, err := database.InsertBotUser(req.Username, ownerID)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	token, err := database.CreateAPIToken(bot.ID)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Bot %s (%s) created by user %s", bot.Username, bot.ID.Hex(), ownerID.Hex())
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(BotTokenResponse{
+		ID:       bot.ID.Hex(),
+		Username: bot.Username,
+		Token:    token,
+	})
+}
+
+// GetMyBots 處理列出目前使用者所建立機器人的請求
+// 這個 API 端點會是 GET /bots
+func GetMyBots(w http.ResponseWriter, r *http.Request) {
+	ownerID, err := utils.GetUserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	bots, err := database.GetBotsByOwner(ownerID)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	publicBots := make([]models.PublicUser, 0, len(bots))
+	for _, bot := range bots {
+		publicBots = append(publicBots, models.PublicUser{
+			ID:       bot.ID.Hex(),
+			Username: bot.Username,
+			IsBot:    true,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(publicBots)
+}
+
+// RotateBotToken 處理輪替機器人 API 金鑰的請求，只有機器人的建立者可以操作
+// 這個 API 端點會是 POST /bots/{id}/token
+func RotateBotToken(w http.ResponseWriter, r *http.Request) {
+	ownerID, err := utils.GetUserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	botID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid bot ID format", http.StatusBadRequest)
+		return
+	}
+
+	bot, err := database.GetUserByID(botID)
+	if err != nil || !bot.IsBot || bot.BotOwnerID != ownerID {
+		// 不區分「不存在」與「不是你的」，避免洩漏其他人的機器人
+		http.Error(w, "Bot not found", http.StatusNotFound)
+		return
+	}
+
+	token, err := database.CreateAPIToken(bot.ID)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(BotTokenResponse{
+		ID:       bot.ID.Hex(),
+		Username: bot.Username,
+		Token:    token,
+	})
+}
+
+// SetRoomBotAccess 處理聊天室管理員允許或禁止機器人加入的請求
+// 禁止一個已在聊天室中的機器人時，會同時將它移出聊天室
+// 這個 API 端點會是 PUT /chatrooms/{id}/bots
+func SetRoomBotAccess(w http.ResponseWriter, r *http.Request) {
+	userID, err := utils.GetUserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	roomID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid room ID format", http.StatusBadRequest)
+		return
+	}
+
+	var req SetRoomBotRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	botID, err := primitive.ObjectIDFromHex(req.BotID)
+	if err != nil {
+		http.Error(w, "Invalid bot ID format", http.StatusBadRequest)
+		return
+	}
+
+	room, err := database.FindChatRoomByID(roomID)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if room == nil {
+		http.Error(w, "Chat room not found", http.StatusNotFound)
+		return
+	}
+	if !room.IsAdmin(userID) {
+		http.Error(w, "Only room admins can manage bots", http.StatusForbidden)
+		return
+	}
+
+	bot, err := database.GetUserByID(botID)
+	if err != nil || !bot.IsBot {
+		http.Error(w, "Bot not found", http.StatusNotFound)
+		return
+	}
+
+	updatedRoom, err := database.SetChatRoomBotAllowed(roomID, botID, req.Allowed)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if !req.Allowed && isRoomParticipant(updatedRoom, botID) {
+		if err := leaveChatRoom(updatedRoom, botID); err != nil {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		updatedRoom, err = database.FindChatRoomByID(roomID)
+		if err != nil || updatedRoom == nil {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updatedRoom)
+}
+
+// PostBotMessage 處理機器人透過 REST 發送訊息的請求，與 SendChatMessage 共用同一個訊息流程
+// 這個 API 端點會是 POST /bots/messages，需以 Authorization: Bearer <API 金鑰> 驗證
+func PostBotMessage(w http.ResponseWriter, r *http.Request) {
+	botID, err := utils.GetUserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	bot, err := database.GetUserByID(botID)
+	if err != nil || !bot.IsBot {
+		http.Error(w, "Only bot accounts can use this endpoint", http.StatusForbidden)
+		return
+	}
+
+	var req BotMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	sender := websocket.MessageSender{ID: bot.ID, Username: bot.Username, IsBot: true}
+	msg, err := websocket.SendMessage(sender, req.RoomID, req.Content)
+	writeSendMessageResponse(w, msg, err)
+}
+
+// isRoomParticipant 檢查使用者是否為聊天室成員
+func isRoomParticipant(room *models.ChatRoom, userID primitive.ObjectID) bool {
+	for _, participantID := range room.Participants {
+		if participantID == userID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go-chat/backend/database"
+	"go-chat/backend/models"
+	"go-chat/backend/utils"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// 頻道目錄每頁的預設與最大筆數，以及可以查詢的最大頁數 (避免計算 skip 時溢位)
+const (
+	defaultChannelPageSize = 20
+	maxChannelPageSize     = 100
+	maxChannelPage         = 10000
+)
+
+// ListPublicChannels 列出所有使用者都能加入的公開頻道
+// 可用 ?q= 搜尋名稱或主題，?page= 從 1 開始、最多 10000，?limit= 預設 20、最多 100
+// 這個 API 端點會是 GET /channels
+func ListPublicChannels(w http.ResponseWriter, r *http.Request) {
+	userID, err := utils.GetUserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	page, limit := int64(1), int64(defaultChannelPageSize)
+	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
+		parsed, err := strconv.ParseInt(pageStr, 10, 64)
+		if err != nil || parsed < 1 || parsed > maxChannelPage {
+			http.Error(w, "Invalid page", http.StatusBadRequest)
+			return
+		}
+		page = parsed
+	}
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.ParseInt(limitStr, 10, 64)
+		if err != nil || parsed < 1 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		if parsed > maxChannelPageSize {
+			parsed = maxChannelPageSize
+		}
+		limit = parsed
+	}
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+
+	channels, total, err := database.ListPublicChannels(userID, query, (page-1)*limit, limit)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	result := models.ChannelDirectoryPage{Channels: channels, Total: total, Page: page, Limit: limit}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// JoinChannel 讓使用者不需要邀請就加入公開頻道
+// 不是公開頻道時回應 404，不透露私人聊天室是否存在
+// 這個 API 端點會是 POST /chatrooms/{id}/join
+func JoinChannel(w http.ResponseWriter, r *http.Request) {
+	userID, err := utils.GetUserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	roomID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid room ID format", http.StatusBadRequest)
+		return
+	}
+
+	room, err := database.FindChatRoomByID(roomID)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if room == nil || room.EffectiveKind() != models.RoomKindChannel || !room.Public {
+		http.Error(w, "Channel not found", http.StatusNotFound)
+		return
+	}
+	if isRoomParticipant(room, userID) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(room)
+		return
+	}
+	ban, err := database.FindActiveRoomSanction(room.ID, userID, models.RoomSanctionBan)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if ban != nil {
+		http.Error(w, "You are banned from this channel", http.StatusForbidden)
+		return
+	}
+
+	updatedRoom, err := joinPublicChannel(room.ID, userID)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if updatedRoom == nil {
+		// 頻道在查詢後被刪除或改為不公開
+		http.Error(w, "Channel not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updatedRoom)
+}
+
+// joinPublicChannel 將使用者加入公開頻道並通知頻道成員
+func joinPublicChannel(roomID, userID primitive.ObjectID) (*models.ChatRoom, error) {
+	updatedRoom, err := database.JoinPublicChannel(roomID, userID)
+	if err != nil || updatedRoom == nil {
+		return updatedRoom, err
+	}
+
+	announceMemberJoined(updatedRoom, userID)
+	return updatedRoom, nil
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestListPublicChannels_InvalidParams(t *testing.T) {
+	userID := primitive.NewObjectID()
+
+	for _, target := range []string{"/channels?page=0", "/channels?page=abc", "/channels?limit=0", "/channels?limit=-5", "/channels?page=9223372036854775807"} {
+		rr := httptest.NewRecorder()
+		ListPublicChannels(rr, withSession(httptest.NewRequest("GET", target, nil), userID, primitive.NilObjectID))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code, target)
+	}
+}
+
+func TestJoinChannel_InvalidRoomID(t *testing.T) {
+	req := httptest.NewRequest("POST", "/chatrooms/not-an-id/join", nil)
+	req = mux.SetURLVars(withSession(req, primitive.NewObjectID(), primitive.NilObjectID), map[string]string{"id": "not-an-id"})
+	rr := httptest.NewRecorder()
+
+	JoinChannel(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"strings"
+
+	"go-chat/backend/database"
+	"go-chat/backend/websocket"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RegisterChatCommands 註冊需要聊天室成員管理流程的斜線指令
+// 這些指令與 REST API 共用同一套邏輯，因此放在 handlers 套件中註冊到 websocket 的指令表
+func RegisterChatCommands() {
+	websocket.RegisterCommand(websocket.Command{
+		Name:        "invite",
+		Usage:       "/invite @使用者名稱",
+		Description: "邀請使用者加入目前的聊天室",
+		Handler:     inviteCommand,
+	})
+	websocket.RegisterCommand(websocket.Command{
+		Name:        "leave",
+		Usage:       "/leave",
+		Description: "離開目前的聊天室",
+		Handler:     leaveCommand,
+	})
+}
+
+// inviteCommand 處理 /invite @user [@user...]
+func inviteCommand(ctx *websocket.CommandContext) (*websocket.CommandResult, error) {
+	names := strings.Fields(ctx.Args)
+	if len(names) == 0 {
+		return nil, errors.New("用法：/invite @使用者名稱")
+	}
+
+	var invitedIDs []primitive.ObjectID
+	for _, name := range names {
+		username := strings.TrimPrefix(name, "@")
+		user, err := database.GetUserByUsername(username)
+		if err != nil {
+			return nil, errors.New("查詢使用者失敗，請稍後再試")
+		}
+		if user == nil {
+			return nil, errors.New("找不到使用者 " + username)
+		}
+		invitedIDs = append(invitedIDs, user.ID)
+	}
+
+	_, added, err := addParticipantsToRoom(ctx.Room, ctx.SenderID, invitedIDs)
+	if err == errBotNotAllowed {
+		return nil, errors.New("機器人需要先由管理員加入允許清單才能被邀請")
+	}
+	if err == errDirectMessageClosed {
+		return nil, errors.New("私訊不能加入其他成員，請另外建立群組")
+	}
+	if err == errInviteNotAllowed {
+		return nil, errors.New("只有管理員可以邀請成員加入頻道")
+	}
+	if err == errUserBanned {
+		return nil, errors.New("被封鎖的使用者不能再被邀請加入此聊天室")
+	}
+	if err != nil {
+		log.Printf("Error inviting participants via command: %v", err)
+		return nil, errors.New("邀請失敗，請稍後再試")
+	}
+	if len(added) == 0 {
+		return websocket.ReplyResult("被邀請的使用者已經在聊天室中"), nil
+	}
+	// 邀請成功時 addParticipantsToRoom 已經廣播系統訊息
+	return nil, nil
+}
+
+// leaveCommand 處理 /leave
+func leaveCommand(ctx *websocket.CommandContext) (*websocket.CommandResult, error) {
+	if err := leaveChatRoom(ctx.Room, ctx.SenderID); err != nil {
+		return nil, errors.New("離開聊天室失敗，請稍後再試")
+	}
+	// 離開的系統訊息已由 leaveChatRoom 廣播
+	return nil, nil
+}
@@ -2,31 +2,42 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"sort" // 引入 sort 套件用於排序使用者名稱
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"go-chat/backend/config"
 	"go-chat/backend/database"
 	"go-chat/backend/models"
 	"go-chat/backend/utils" // 引入 utils 套件
+	"go-chat/backend/webhooks"
 	"go-chat/backend/websocket"
 
 	"github.com/gorilla/mux"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // CreateChatRoomRequest 定義創建聊天室的請求體
 type CreateChatRoomRequest struct {
-	ParticipantIDs []string `json:"participantIds"` // 參與者的使用者 ID 字串列表
+	ParticipantIDs []string        `json:"participantIds"`   // 參與者的使用者 ID 字串列表
+	Kind           models.RoomKind `json:"kind,omitempty"`   // 省略時，連同建立者共兩人視為私訊，否則為群組
+	Name           string          `json:"name,omitempty"`   // 群組與頻道的自訂名稱，頻道必填
+	Public         bool            `json:"public,omitempty"` // 公開頻道，只適用於頻道
 }
 
-// UpdateChatRoomRequest 定義更新聊天室的請求體
-// 此結構體在當前命名邏輯下可能不會直接用於更新名稱，但保留以防其他用途
+// UpdateChatRoomRequest 定義更新聊天室的請求體，省略的欄位維持不變
 type UpdateChatRoomRequest struct {
-	ParticipantIDs []string `json:"participantIds"` // 新的參與者列表
+	ParticipantIDs []string `json:"participantIds,omitempty"` // 已不支援，成員變動需經過邀請與移除的流程
+	Name           *string  `json:"name,omitempty"`           // 自訂名稱，空字串表示改回自動產生的名稱 (僅管理員)
+	Topic          *string  `json:"topic,omitempty"`          // 主題，所有成員都可以設定，與 /topic 指令相同
+	Description    *string  `json:"description,omitempty"`    // 說明 (僅管理員)
+	Public         *bool    `json:"public,omitempty"`         // 是否為公開頻道，只適用於頻道 (僅管理員)
 }
 
 // AddParticipantsRequest 定義邀請參與者的請求體
@@ -55,14 +66,97 @@ func getUsernames(userIDs []primitive.ObjectID) ([]string, error) {
 	return usernames, nil
 }
 
-// generateRoomName 根據參與者生成聊天室名稱
+// errBotNotAllowed 表示要加入的機器人不在聊天室的允許清單中
+var errBotNotAllowed = errors.New("bot is not allowed in this chat room")
+
+// errDirectMessageClosed 表示私訊不能加入其他成員
+var errDirectMessageClosed = errors.New("direct messages cannot accept new participants")
+
+// errInviteNotAllowed 表示邀請者沒有權限邀請成員 (頻道只有管理員可以邀請)
+var errInviteNotAllowed = errors.New("only room admins can invite to this chat room")
+
+// errUserBanned 表示要加入的使用者已被聊天室封鎖
+var errUserBanned = errors.New("user is banned from this chat room")
+
+// errRoomArchived 表示聊天室已封存，只能閱讀不能變更
+var errRoomArchived = errors.New("chat room is archived")
+
+// filterBotIDs 從使用者 ID 列表中找出機器人帳號
+func filterBotIDs(userIDs []primitive.ObjectID) ([]primitive.ObjectID, error) {
+	users, err := database.GetUsersByIDs(userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var botIDs []primitive.ObjectID
+	for _, user := range users {
+		if user.IsBot {
+			botIDs = append(botIDs, user.ID)
+		}
+	}
+	return botIDs, nil
+}
+
+// maxGeneratedNameUsernames 是自動產生的聊天室名稱最多列出的使用者名稱數量
+const maxGeneratedNameUsernames = 5
+
+// generateRoomName 根據參與者生成聊天室名稱，成員較多時只列出前幾位
 func generateRoomName(participantUsernames []string) string {
 	if len(participantUsernames) == 0 {
 		return "空聊天室" // 或者其他預設名稱
 	}
+	if len(participantUsernames) > maxGeneratedNameUsernames {
+		return fmt.Sprintf("%s 等 %d 人的聊天室", strings.Join(participantUsernames[:maxGeneratedNameUsernames], "、"), len(participantUsernames))
+	}
 	return strings.Join(participantUsernames, "、") + " 的聊天室"
 }
 
+// roomNameForParticipants 回傳成員變動後聊天室的顯示名稱
+// 有自訂名稱時沿用自訂名稱，否則依新的成員重新產生
+func roomNameForParticipants(room *models.ChatRoom, participants []primitive.ObjectID) (string, error) {
+	if room.CustomName != "" {
+		return room.CustomName, nil
+	}
+	return generateRoomNameFor(participants)
+}
+
+// generateRoomNameFor 依參與者的使用者名稱產生聊天室名稱
+func generateRoomNameFor(participants []primitive.ObjectID) (string, error) {
+	usernames, err := getUsernames(participants)
+	if err != nil {
+		return "", err
+	}
+	return generateRoomName(usernames), nil
+}
+
+// findDirectMessage 查找兩位使用者的私訊，曾經離開私訊的成員會被加回來
+func findDirectMessage(userA, userB primitive.ObjectID) (*models.ChatRoom, error) {
+	room, err := database.FindDirectMessageRoom(userA, userB)
+	if err != nil {
+		log.Printf("Error checking for existing direct message: %v", err)
+		return nil, err
+	}
+	if room == nil || len(room.Participants) == 2 {
+		return room, nil
+	}
+
+	participants := []primitive.ObjectID{userA, userB}
+	utils.SortObjectIDs(participants)
+	name, err := generateRoomNameFor(participants)
+	if err != nil {
+		log.Printf("Error getting usernames: %v", err)
+		return nil, err
+	}
+	restored, err := database.UpdateChatRoom(room.ID, participants, name)
+	if err != nil {
+		log.Printf("Error restoring direct message participants: %v", err)
+		return nil, err
+	}
+	database.InvalidateMultipleUserChatRoomsCache(participants)
+	websocket.BroadcastRoomStateUpdate(restored)
+	return restored, nil
+}
+
 // CreateChatRoom 處理創建聊天室的請求
 func CreateChatRoom(w http.ResponseWriter, r *http.Request) {
 	var req CreateChatRoomRequest
@@ -82,57 +176,120 @@ func CreateChatRoom(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var participantObjectIDs []primitive.ObjectID
+	// 建立者一定是參與者，重複的 ID 只保留一個
+	participantObjectIDs := []primitive.ObjectID{creatorID}
+	seen := map[primitive.ObjectID]bool{creatorID: true}
 	for _, idStr := range req.ParticipantIDs {
 		objID, err := primitive.ObjectIDFromHex(idStr)
 		if err != nil {
 			http.Error(w, "Invalid participant ID format", http.StatusBadRequest)
 			return
 		}
-		participantObjectIDs = append(participantObjectIDs, objID)
+		if !seen[objID] {
+			seen[objID] = true
+			participantObjectIDs = append(participantObjectIDs, objID)
+		}
 	}
+	utils.SortObjectIDs(participantObjectIDs)
 
-	foundCreator := false
-	for _, pID := range participantObjectIDs {
-		if pID == creatorID {
-			foundCreator = true
-			break
+	kind := req.Kind
+	if kind == "" {
+		kind = models.RoomKindGroup
+		if len(participantObjectIDs) == 2 {
+			kind = models.RoomKindDM
 		}
 	}
-	if !foundCreator {
-		participantObjectIDs = append(participantObjectIDs, creatorID)
+	customName := strings.TrimSpace(req.Name)
+	switch kind {
+	case models.RoomKindDM:
+		if len(participantObjectIDs) != 2 {
+			http.Error(w, "A direct message needs exactly one other participant", http.StatusBadRequest)
+			return
+		}
+		if customName != "" {
+			http.Error(w, "Direct messages cannot have a custom name", http.StatusBadRequest)
+			return
+		}
+	case models.RoomKindGroup:
+	case models.RoomKindChannel:
+		if customName == "" {
+			http.Error(w, "A channel needs a name", http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, "Invalid room kind", http.StatusBadRequest)
+		return
 	}
-
-	utils.SortObjectIDs(participantObjectIDs)
-
-	existingRoom, err := database.FindChatRoomByParticipants(participantObjectIDs)
-	if err != nil {
-		log.Printf("Error checking for existing chatroom: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	if req.Public && kind != models.RoomKindChannel {
+		http.Error(w, "Only channels can be public", http.StatusBadRequest)
 		return
 	}
-	if existingRoom != nil {
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(existingRoom)
+	if utf8.RuneCountInString(customName) > models.MaxRoomNameLength {
+		http.Error(w, fmt.Sprintf("Name must be at most %d characters", models.MaxRoomNameLength), http.StatusBadRequest)
 		return
 	}
 
-	usernames, err := getUsernames(participantObjectIDs)
+	// 每對使用者只有一個私訊，已存在時直接回傳；群組與頻道每次都建立新的聊天室
+	if kind == models.RoomKindDM {
+		existingRoom, err := findDirectMessage(participantObjectIDs[0], participantObjectIDs[1])
+		if err != nil {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if existingRoom != nil {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(existingRoom)
+			return
+		}
+	}
+
+	roomName := customName
+	if roomName == "" {
+		roomName, err = generateRoomNameFor(participantObjectIDs)
+		if err != nil {
+			log.Printf("Error getting usernames: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// 建立者就是新聊天室的管理員，建立時一併加入的機器人視為已被管理員允許
+	botIDs, err := filterBotIDs(participantObjectIDs)
 	if err != nil {
-		log.Printf("Error getting usernames: %v", err)
+		log.Printf("Error checking bot participants: %v", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
 	newChatRoom := models.ChatRoom{
-		Name:         generateRoomName(usernames),
+		Name:         roomName,
+		CustomName:   customName,
+		Kind:         kind,
+		Public:       req.Public,
 		CreatorID:    creatorID,
+		OwnerID:      creatorID,
 		Participants: participantObjectIDs,
+		AllowedBots:  botIDs,
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
 	}
 
+	if kind == models.RoomKindDM {
+		newChatRoom.DMKey = models.DirectMessageKey(participantObjectIDs[0], participantObjectIDs[1])
+	}
+
 	result, err := database.InsertChatRoom(newChatRoom)
+	if mongo.IsDuplicateKeyError(err) {
+		// 另一個請求剛建立了同一對使用者的私訊
+		existingRoom, findErr := findDirectMessage(participantObjectIDs[0], participantObjectIDs[1])
+		if findErr != nil || existingRoom == nil {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(existingRoom)
+		return
+	}
 	if err != nil {
 		log.Printf("Error inserting new chatroom: %v", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
@@ -176,6 +333,8 @@ func CreateChatRoom(w http.ResponseWriter, r *http.Request) {
 }
 
 // GetUserChatRooms 處理獲取使用者所有聊天室的請求
+// 預設不包含已封存與使用者隱藏的聊天室，加上 ?includeArchived=true 或 ?includeHidden=true 時一併回傳
+// 使用者的我的最愛會依自訂順序置頂，其餘依最後更新時間排序
 func GetUserChatRooms(w http.ResponseWriter, r *http.Request) {
 	// 1. 檢查database在找使用者擁有的聊天室時，有沒有出現錯誤
 	userID, err := utils.GetUserIDFromContext(r.Context())
@@ -191,12 +350,51 @@ func GetUserChatRooms(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
+	// 快取保存完整的列表，封存與隱藏的聊天室在這裡才過濾
+	query := r.URL.Query()
+	chatRooms = visibleRooms(chatRooms, query.Get("includeArchived") == "true", query.Get("includeHidden") == "true")
+	sortRoomList(chatRooms)
 
 	// 2. 將結果轉換成JSON格式透過回覆工具 w，寫入到 HTTP 的回應 Body 中，最終傳送回給前端（瀏覽器）。
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(chatRooms)
 }
 
+// visibleRooms 回傳使用者聊天室列表中要顯示的聊天室，預設去掉已封存與使用者隱藏的聊天室
+func visibleRooms(rooms []models.ChatRoom, includeArchived, includeHidden bool) []models.ChatRoom {
+	visible := make([]models.ChatRoom, 0, len(rooms))
+	for _, room := range rooms {
+		if room.IsArchived() && !includeArchived {
+			continue
+		}
+		if room.Settings != nil && room.Settings.Hidden && !includeHidden {
+			continue
+		}
+		visible = append(visible, room)
+	}
+	return visible
+}
+
+// sortRoomList 將我的最愛依自訂順序排在最前面，其餘依最後活動時間 (最後一則訊息或更新時間) 排序
+func sortRoomList(rooms []models.ChatRoom) {
+	settingsOf := func(room models.ChatRoom) models.RoomMemberSettings {
+		if room.Settings == nil {
+			return models.RoomMemberSettings{}
+		}
+		return *room.Settings
+	}
+	sort.SliceStable(rooms, func(i, j int) bool {
+		a, b := settingsOf(rooms[i]), settingsOf(rooms[j])
+		if a.Favorite != b.Favorite {
+			return a.Favorite
+		}
+		if a.Favorite && a.SortOrder != b.SortOrder {
+			return a.SortOrder < b.SortOrder
+		}
+		return rooms[i].LastActivityAt().After(rooms[j].LastActivityAt())
+	})
+}
+
 // LeaveChatRoom 處理使用者退出聊天室的請求
 func LeaveChatRoom(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -231,6 +429,34 @@ func LeaveChatRoom(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := leaveChatRoom(room, userID); err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	// 返回成功狀態
+	response := map[string]bool{"success": true}
+	json.NewEncoder(w).Encode(response)
+}
+
+// leaveChatRoom 將使用者移出聊天室並通知其餘成員，聊天室沒有成員時會被刪除
+// HTTP 的 LeaveChatRoom 與 /leave 指令共用此流程
+func leaveChatRoom(room *models.ChatRoom, userID primitive.ObjectID) error {
+	// 獲取退出使用者的用戶名作為系統消息內容
+	exitingUser, err := database.GetUserByID(userID)
+	if err != nil {
+		log.Printf("Error getting exiting user: %v", err)
+		exitingUser = &models.User{Username: "某人"} // 如果無法獲取用戶名，使用備用訊息
+	}
+	return removeRoomMember(room, userID, userID, exitingUser.Username+" 已離開聊天室")
+}
+
+// removeRoomMember 將使用者移出聊天室 (同時移除管理員身分)，以 content 作為系統訊息通知其餘成員
+// 擁有者離開時擁有權自動交給最資深的管理員或成員；聊天室沒有成員時會被刪除
+// 離開聊天室與管理員移出成員共用此流程
+func removeRoomMember(room *models.ChatRoom, userID, actorID primitive.ObjectID, content string) error {
+	roomID := room.ID
+
 	// 從參與者列表中移除使用者
 	newParticipants := make([]primitive.ObjectID, 0)
 	for _, participantID := range room.Participants {
@@ -241,35 +467,44 @@ func LeaveChatRoom(w http.ResponseWriter, r *http.Request) {
 
 	// 創建用於系統訊息的聊天室名稱變數
 	var finalRoomNameForMessage string = room.Name // 預設為舊名稱
+	var updatedRoom *models.ChatRoom
+	var newOwnerID primitive.ObjectID
 
 	// 更新聊天室的參與者列表
 	if len(newParticipants) > 0 {
-		// 獲取剩餘參與者的用戶名
-		usernames, err := getUsernames(newParticipants)
+		// 沒有自訂名稱時，依剩餘參與者的用戶名產生新名稱
+		newRoomName, err := roomNameForParticipants(room, newParticipants)
 		if err != nil {
 			log.Printf("Error getting usernames for updated room name: %v", err)
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
-			return
+			return err
 		}
-		newRoomName := generateRoomName(usernames)
 		finalRoomNameForMessage = newRoomName // 如果更新，使用新名稱
 
+		// 私訊沒有擁有者的概念，其他聊天室的擁有者離開時要選出繼任者
+		if userID == room.Owner() && room.EffectiveKind() != models.RoomKindDM {
+			botIDs, err := filterBotIDs(newParticipants)
+			if err != nil {
+				log.Printf("Error checking bot participants: %v", err)
+				return err
+			}
+			newOwnerID = chooseNextOwner(room, newParticipants, botIDs)
+		}
+
 		// 更新聊天室
-		_, err = database.UpdateChatRoom(roomID, newParticipants, newRoomName)
+		updatedRoom, err = database.RemoveChatRoomMember(roomID, userID, newRoomName, newOwnerID)
 		if err != nil {
 			log.Printf("Error updating chatroom: %v", err)
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
-			return
+			return err
 		}
 	} else {
 		// 如果沒有參與者了，刪除聊天室
-		err = database.DeleteChatRoom(roomID)
+		err := database.DeleteChatRoom(roomID)
 		if err != nil {
 			log.Printf("Error deleting empty chatroom: %v", err)
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
-			return
+			return err
 		}
 		// 如果聊天室被刪除，finalRoomNameForMessage 仍使用舊名稱表示離開了哪個房間
+		webhooks.EmitRoomDeleted(roomID, room)
 	}
 
 	// 1. 刪除「正在離開的」這個使用者的快取
@@ -279,21 +514,14 @@ func LeaveChatRoom(w http.ResponseWriter, r *http.Request) {
 	//    確保他們能看到最新的成員列表。
 	database.InvalidateMultipleUserChatRoomsCache(newParticipants)
 
-	// 獲取退出使用者的用戶名和創建系統消息
-	exitingUser, err := database.GetUserByID(userID)
-	if err != nil {
-		log.Printf("Error getting exiting user: %v", err)
-		exitingUser = &models.User{Username: "某人"} // 如果無法獲取用戶名，使用備用訊息
-	}
-
 	// 創建並發送系統消息
 	systemMessage := models.Message{
 		Type:           models.MessageTypeSystem,
-		SenderID:       userID,
+		SenderID:       actorID,
 		SenderUsername: "系統訊息",
 		RoomID:         roomID.Hex(),
 		RoomName:       finalRoomNameForMessage, // 使用更新或舊的聊天室名稱
-		Content:        exitingUser.Username + " 已離開聊天室",
+		Content:        content,
 		Timestamp:      time.Now(),
 		IsRead:         true,
 	}
@@ -302,7 +530,7 @@ func LeaveChatRoom(w http.ResponseWriter, r *http.Request) {
 	websocket.BroadcastMessage(systemMessage)
 
 	// 存儲系統消息
-	_, err = database.InsertMessage(systemMessage)
+	_, err := database.InsertMessage(systemMessage)
 	if err != nil {
 		log.Printf("Error inserting system message: %v", err)
 		// 繼續執行，不中斷流程
@@ -328,9 +556,54 @@ func LeaveChatRoom(w http.ResponseWriter, r *http.Request) {
 	}
 	websocket.GlobalHub.Broadcast <- roomUpdateMessage // 廣播隱藏的更新消息
 
-	// 返回成功狀態
-	response := map[string]bool{"success": true}
-	json.NewEncoder(w).Encode(response)
+	if !newOwnerID.IsZero() && updatedRoom != nil {
+		newOwner, err := database.GetUserByID(newOwnerID)
+		if err != nil {
+			log.Printf("Error getting new owner %s: %v", newOwnerID.Hex(), err)
+			newOwner = &models.User{Username: "某人"}
+		}
+		announceRoomChange(updatedRoom, primitive.NilObjectID, roomOwnerChangeMessage(newOwner.Username))
+	}
+
+	return nil
+}
+
+// chooseNextOwner 從剩餘成員中選出擁有者的繼任者
+// 優先選擇最早成為管理員的成員，其次是最早加入的成員，機器人只有在沒有其他成員時才會成為擁有者
+func chooseNextOwner(room *models.ChatRoom, remaining, botIDs []primitive.ObjectID) primitive.ObjectID {
+	remainingSet := make(map[primitive.ObjectID]bool, len(remaining))
+	for _, id := range remaining {
+		remainingSet[id] = true
+	}
+	for _, id := range botIDs {
+		delete(remainingSet, id)
+	}
+
+	// 管理員以 $addToSet 依序加入，列表前面的就是較早的管理員
+	for _, adminID := range room.Admins {
+		if remainingSet[adminID] {
+			return adminID
+		}
+	}
+
+	candidates := make([]primitive.ObjectID, 0, len(remainingSet))
+	for _, id := range remaining {
+		if remainingSet[id] {
+			candidates = append(candidates, id)
+		}
+	}
+	if len(candidates) == 0 {
+		return remaining[0]
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return room.JoinedAt(candidates[i]).Before(room.JoinedAt(candidates[j]))
+	})
+	return candidates[0]
+}
+
+// roomOwnerChangeMessage 產生擁有者變更的系統訊息內容
+func roomOwnerChangeMessage(ownerUsername string) string {
+	return ownerUsername + " 已成為聊天室擁有者"
 }
 
 // AddParticipants 處理將新使用者加入聊天室的請求
@@ -375,6 +648,10 @@ func AddParticipants(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Chat room not found", http.StatusNotFound)
 		return
 	}
+	if !isRoomParticipant(existingRoom, userID) {
+		http.Error(w, "You are not a participant of this chat room", http.StatusForbidden)
+		return
+	}
 
 	// 將新的參與者ID字串轉換為ObjectID
 	var newParticipantObjectIDs []primitive.ObjectID
@@ -387,6 +664,49 @@ func AddParticipants(w http.ResponseWriter, r *http.Request) {
 		newParticipantObjectIDs = append(newParticipantObjectIDs, objID)
 	}
 
+	updatedRoom, addedParticipants, err := addParticipantsToRoom(existingRoom, userID, newParticipantObjectIDs)
+	if err == errBotNotAllowed {
+		http.Error(w, "Bot is not allowed in this chat room", http.StatusForbidden)
+		return
+	}
+	if err == errInviteNotAllowed {
+		http.Error(w, "Only room admins can invite to this channel", http.StatusForbidden)
+		return
+	}
+	if err == errDirectMessageClosed {
+		http.Error(w, "Direct messages cannot accept new participants, create a group instead", http.StatusConflict)
+		return
+	}
+	if err == errUserBanned {
+		http.Error(w, "User is banned from this chat room", http.StatusForbidden)
+		return
+	}
+	if err == errRoomArchived {
+		http.Error(w, "Chat room is archived", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if len(addedParticipants) == 0 {
+		// 如果沒有新的參與者需要添加，則直接返回成功
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{"message": "No new participants to add"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(updatedRoom)
+}
+
+// addParticipantsToRoom 將新成員加入聊天室、更新名稱並廣播邀請通知
+// 回傳更新後的聊天室與實際新加入的成員；HTTP 的 AddParticipants 與 /invite 指令共用此流程
+func addParticipantsToRoom(existingRoom *models.ChatRoom, inviterID primitive.ObjectID, newParticipantObjectIDs []primitive.ObjectID) (*models.ChatRoom, []primitive.ObjectID, error) {
+	roomID := existingRoom.ID
+
 	// 過濾掉已經在聊天室中的參與者
 	var actualNewParticipants []primitive.ObjectID
 	existingParticipantMap := make(map[primitive.ObjectID]bool)
@@ -396,44 +716,72 @@ func AddParticipants(w http.ResponseWriter, r *http.Request) {
 
 	for _, newID := range newParticipantObjectIDs {
 		if !existingParticipantMap[newID] {
+			existingParticipantMap[newID] = true
 			actualNewParticipants = append(actualNewParticipants, newID)
 		}
 	}
 
 	if len(actualNewParticipants) == 0 {
-		// 如果沒有新的參與者需要添加，則直接返回成功
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(map[string]string{"message": "No new participants to add"})
-		return
+		// 沒有新的參與者需要添加
+		return existingRoom, nil, nil
+	}
+	if existingRoom.IsArchived() {
+		return nil, nil, errRoomArchived
+	}
+
+	switch existingRoom.EffectiveKind() {
+	case models.RoomKindDM:
+		return nil, nil, errDirectMessageClosed
+	case models.RoomKindChannel:
+		if !existingRoom.IsAdmin(inviterID) {
+			return nil, nil, errInviteNotAllowed
+		}
+	}
+
+	// 被封鎖的使用者在封鎖到期或解除前不能再被加入
+	bannedIDs, err := database.FindSanctionedUserIDs(roomID, models.RoomSanctionBan, actualNewParticipants)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(bannedIDs) > 0 {
+		return nil, nil, errUserBanned
+	}
+
+	// 機器人必須先由管理員加入允許清單才能被邀請
+	botIDs, err := filterBotIDs(actualNewParticipants)
+	if err != nil {
+		log.Printf("Error checking bot participants: %v", err)
+		return nil, nil, err
+	}
+	for _, botID := range botIDs {
+		if !existingRoom.AllowsBot(botID) {
+			return nil, nil, errBotNotAllowed
+		}
 	}
 
 	// 合併現有參與者和實際新加入的參與者
-	updatedParticipants := append(existingRoom.Participants, actualNewParticipants...)
+	updatedParticipants := append(append([]primitive.ObjectID{}, existingRoom.Participants...), actualNewParticipants...)
 	utils.SortObjectIDs(updatedParticipants) // 保持參與者列表有序
 
-	// 生成新的聊天室名稱
-	allParticipantsUsernames, err := getUsernames(updatedParticipants)
+	// 沒有自訂名稱時生成新的聊天室名稱
+	newRoomName, err := roomNameForParticipants(existingRoom, updatedParticipants)
 	if err != nil {
 		log.Printf("Error getting usernames for new room name: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
+		return nil, nil, err
 	}
-	newRoomName := generateRoomName(allParticipantsUsernames)
 
-	// 更新聊天室到資料庫（更新參與者列表、名稱和 updatedAt）
-	updatedRoom, err := database.UpdateChatRoom(roomID, updatedParticipants, newRoomName) // 傳遞新的名稱
+	// 更新聊天室到資料庫（加入新成員並記錄加入時間、更新名稱和 updatedAt）
+	updatedRoom, err := database.AddChatRoomParticipants(roomID, actualNewParticipants, newRoomName) // 傳遞新的名稱
 	if err != nil {
 		log.Printf("Error updating chatroom with new participants: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
+		return nil, nil, err
 	}
 
 	database.InvalidateMultipleUserChatRoomsCache(updatedParticipants)
 	// --- 處理系統訊息 ---
 	// 獲取邀請者和新參與者的用戶名
 	var allUserIDsForMessage []primitive.ObjectID
-	allUserIDsForMessage = append(allUserIDsForMessage, userID)                   // 邀請者 ID
+	allUserIDsForMessage = append(allUserIDsForMessage, inviterID)                // 邀請者 ID
 	allUserIDsForMessage = append(allUserIDsForMessage, actualNewParticipants...) // 被邀請者 ID
 
 	users, err := database.GetUsersByIDs(allUserIDsForMessage)
@@ -445,7 +793,7 @@ func AddParticipants(w http.ResponseWriter, r *http.Request) {
 	inviterUsername := "未知使用者"
 	newParticipantUsernames := []string{}
 	for _, user := range users {
-		if user.ID == userID {
+		if user.ID == inviterID {
 			inviterUsername = user.Username
 		} else {
 			// 確保只包含實際被添加的用戶名
@@ -512,21 +860,33 @@ func AddParticipants(w http.ResponseWriter, r *http.Request) {
 		websocket.GlobalHub.Broadcast <- systemMessage
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(updatedRoom)
+	return updatedRoom, actualNewParticipants, nil
 }
 
-// UpdateChatRoom 處理更新聊天室的請求
-// 在此新命名邏輯下，此函數可能不再用於直接更新名稱，但可用於更新其他屬性
+// UpdateChatRoom 處理更新聊天室資訊的請求
+// 所有成員都可以設定主題；自訂名稱與說明只有聊天室管理員可以修改
+// 成員只能透過邀請 (PUT /chatrooms/{id}/participants) 與移除 (POST /chatrooms/{id}/members/{userId}/kick) 變動，
+// 這兩個流程才會檢查機器人白名單、封鎖與擁有者，並發送系統訊息與 room_removed 通知
+// 名稱與主題變更時會在聊天室中留下系統訊息
+// 這個 API 端點會是 PUT /chatrooms/{id}/update
 func UpdateChatRoom(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
+	userID, err := utils.GetUserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
 	var req UpdateChatRoomRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
+	if req.ParticipantIDs != nil {
+		http.Error(w, "participantIds cannot be replaced here; use PUT /chatrooms/{id}/participants to invite and POST /chatrooms/{id}/members/{userId}/kick to remove", http.StatusBadRequest)
+		return
+	}
 
 	vars := mux.Vars(r)
 	roomIDStr := vars["id"]
@@ -551,33 +911,161 @@ func UpdateChatRoom(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Chat room not found", http.StatusNotFound)
 		return
 	}
+	if !isRoomParticipant(existingRoom, userID) {
+		http.Error(w, "You are not a participant of this chat room", http.StatusForbidden)
+		return
+	}
+	if existingRoom.IsArchived() {
+		http.Error(w, errRoomArchived.Error(), http.StatusConflict)
+		return
+	}
+	if existingRoom.EffectiveKind() == models.RoomKindDM && req.Name != nil {
+		http.Error(w, "Direct messages cannot change name", http.StatusConflict)
+		return
+	}
+	if (req.Name != nil || req.Description != nil || req.Public != nil) && !existingRoom.IsAdmin(userID) {
+		http.Error(w, "Only room admins can change the name, description or visibility", http.StatusForbidden)
+		return
+	}
+	if req.Public != nil && *req.Public && existingRoom.EffectiveKind() != models.RoomKindChannel {
+		http.Error(w, "Only channels can be public", http.StatusBadRequest)
+		return
+	}
 
-	var participantObjectIDs []primitive.ObjectID
-	for _, idStr := range req.ParticipantIDs {
-		objID, err := primitive.ObjectIDFromHex(idStr)
+	customName, topic, description := existingRoom.CustomName, existingRoom.Topic, existingRoom.Description
+	if req.Name != nil {
+		customName = strings.TrimSpace(*req.Name)
+	}
+	if req.Topic != nil {
+		topic = strings.TrimSpace(*req.Topic)
+	}
+	if req.Description != nil {
+		description = strings.TrimSpace(*req.Description)
+	}
+	if customName == "" && existingRoom.EffectiveKind() == models.RoomKindChannel {
+		http.Error(w, "A channel needs a name", http.StatusBadRequest)
+		return
+	}
+	if utf8.RuneCountInString(customName) > models.MaxRoomNameLength {
+		http.Error(w, fmt.Sprintf("Name must be at most %d characters", models.MaxRoomNameLength), http.StatusBadRequest)
+		return
+	}
+	if utf8.RuneCountInString(topic) > models.MaxRoomTopicLength {
+		http.Error(w, fmt.Sprintf("Topic must be at most %d characters", models.MaxRoomTopicLength), http.StatusBadRequest)
+		return
+	}
+	if utf8.RuneCountInString(description) > models.MaxRoomDescriptionLength {
+		http.Error(w, fmt.Sprintf("Description must be at most %d characters", models.MaxRoomDescriptionLength), http.StatusBadRequest)
+		return
+	}
+
+	updatedRoom := existingRoom
+	nameChanged := customName != existingRoom.CustomName
+	topicChanged := topic != existingRoom.Topic
+	if nameChanged || topicChanged || description != existingRoom.Description {
+		// 清除自訂名稱時改回依目前成員自動產生的名稱
+		displayName := customName
+		if displayName == "" {
+			displayName, err = generateRoomNameFor(updatedRoom.Participants)
+			if err != nil {
+				log.Printf("Error getting usernames: %v", err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+		}
+		updatedRoom, err = database.UpdateChatRoomDetails(roomID, customName, displayName, topic, description)
 		if err != nil {
-			http.Error(w, "Invalid participant ID format", http.StatusBadRequest)
+			log.Printf("Error updating chatroom details: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
 			return
 		}
-		participantObjectIDs = append(participantObjectIDs, objID)
 	}
 
-	// 根據新的參與者列表生成名稱
-	usernames, err := getUsernames(participantObjectIDs)
-	if err != nil {
-		log.Printf("Error getting usernames: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
+	if req.Public != nil && *req.Public != existingRoom.Public {
+		updatedRoom, err = database.SetChatRoomPublic(roomID, *req.Public)
+		if err != nil {
+			log.Printf("Error updating chatroom visibility: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
 	}
-	newRoomName := generateRoomName(usernames)
 
-	// 更新聊天室
-	updatedRoom, err := database.UpdateChatRoom(roomID, participantObjectIDs, newRoomName)
-	if err != nil {
-		log.Printf("Error updating chatroom: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
+	if updatedRoom != existingRoom {
+		database.InvalidateMultipleUserChatRoomsCache(updatedRoom.Participants)
+		if nameChanged || topicChanged {
+			editor, err := database.GetUserByID(userID)
+			if err != nil {
+				log.Printf("Error getting user %s: %v", userID.Hex(), err)
+				editor = &models.User{Username: "某人"}
+			}
+			if nameChanged {
+				announceRoomChange(updatedRoom, userID, roomNameChangeMessage(editor.Username, customName, updatedRoom.Name))
+			}
+			if topicChanged {
+				announceRoomChange(updatedRoom, userID, roomTopicChangeMessage(editor.Username, topic))
+			}
+		}
+		websocket.BroadcastRoomStateUpdate(updatedRoom)
 	}
 
 	json.NewEncoder(w).Encode(updatedRoom)
 }
+
+// roomNameChangeMessage 產生名稱變更的系統訊息內容
+func roomNameChangeMessage(editorUsername, customName, displayName string) string {
+	if customName == "" {
+		return editorUsername + " 移除了聊天室的自訂名稱，目前名稱為：" + displayName
+	}
+	return editorUsername + " 將聊天室名稱改為：" + customName
+}
+
+// roomTopicChangeMessage 產生主題變更的系統訊息內容，與 /topic 指令的訊息一致
+func roomTopicChangeMessage(editorUsername, topic string) string {
+	if topic == "" {
+		return editorUsername + " 清除了聊天室主題"
+	}
+	return editorUsername + " 將聊天室主題設為：" + topic
+}
+
+// announceMemberJoined 在使用者自行加入聊天室 (公開頻道或邀請連結) 後讓成員的快取失效，
+// 並發送加入的系統訊息與聊天室狀態更新
+func announceMemberJoined(room *models.ChatRoom, userID primitive.ObjectID) {
+	database.InvalidateMultipleUserChatRoomsCache(room.Participants)
+
+	joiner, err := database.GetUserByID(userID)
+	if err != nil {
+		log.Printf("Error getting joining user %s: %v", userID.Hex(), err)
+		joiner = &models.User{Username: "某人"}
+	}
+	announceRoomChange(room, userID, roomJoinMessage(joiner.Username, room))
+	websocket.BroadcastRoomStateUpdate(room)
+}
+
+// roomJoinMessage 產生使用者加入聊天室的系統訊息內容
+func roomJoinMessage(username string, room *models.ChatRoom) string {
+	if room.EffectiveKind() == models.RoomKindChannel {
+		return username + " 已加入頻道"
+	}
+	return username + " 已加入聊天室"
+}
+
+// announceRoomChange 儲存並廣播聊天室資訊變更的系統訊息
+func announceRoomChange(room *models.ChatRoom, editorID primitive.ObjectID, content string) {
+	systemMessage := models.Message{
+		Type:           models.MessageTypeSystem,
+		SenderID:       editorID,
+		SenderUsername: "系統訊息",
+		RoomID:         room.ID.Hex(),
+		RoomName:       room.Name,
+		Content:        content,
+		Timestamp:      time.Now(),
+		IsRead:         true,
+	}
+	result, err := database.InsertMessage(systemMessage)
+	if err != nil {
+		log.Printf("Error inserting room change message: %v", err)
+	} else {
+		systemMessage.ID = result.InsertedID.(primitive.ObjectID)
+	}
+	websocket.BroadcastMessage(systemMessage)
+}
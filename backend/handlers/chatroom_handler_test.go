@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-chat/backend/models"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestGenerateRoomName(t *testing.T) {
+	assert.Equal(t, "空聊天室", generateRoomName(nil))
+	assert.Equal(t, "alice、bob 的聊天室", generateRoomName([]string{"alice", "bob"}))
+
+	usernames := []string{"u1", "u2", "u3", "u4", "u5", "u6", "u7"}
+	assert.Equal(t, "u1、u2、u3、u4、u5 等 7 人的聊天室", generateRoomName(usernames), "成員很多時只列出前幾位")
+}
+
+func TestRoomNameForParticipants_CustomName(t *testing.T) {
+	room := &models.ChatRoom{Name: "專案討論", CustomName: "專案討論"}
+
+	// 有自訂名稱時不需要查詢成員的使用者名稱
+	name, err := roomNameForParticipants(room, []primitive.ObjectID{primitive.NewObjectID()})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "專案討論", name)
+}
+
+func TestRoomChangeMessages(t *testing.T) {
+	assert.Equal(t, "alice 將聊天室名稱改為：專案討論", roomNameChangeMessage("alice", "專案討論", "專案討論"))
+	assert.Equal(t, "alice 移除了聊天室的自訂名稱，目前名稱為：alice、bob 的聊天室", roomNameChangeMessage("alice", "", "alice、bob 的聊天室"))
+	assert.Equal(t, "alice 將聊天室主題設為：上線計畫", roomTopicChangeMessage("alice", "上線計畫"))
+	assert.Equal(t, "alice 清除了聊天室主題", roomTopicChangeMessage("alice", ""))
+	assert.Equal(t, "alice 已加入頻道", roomJoinMessage("alice", &models.ChatRoom{Kind: models.RoomKindChannel}))
+	assert.Equal(t, "alice 已加入聊天室", roomJoinMessage("alice", &models.ChatRoom{}))
+}
+
+func TestAddParticipantsToRoom_KindRules(t *testing.T) {
+	alice, bob, carol := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+
+	t.Run("私訊不能加入其他成員", func(t *testing.T) {
+		room := &models.ChatRoom{ID: primitive.NewObjectID(), Kind: models.RoomKindDM, CreatorID: alice, Participants: []primitive.ObjectID{alice, bob}}
+
+		_, _, err := addParticipantsToRoom(room, bob, []primitive.ObjectID{carol})
+
+		assert.Equal(t, errDirectMessageClosed, err)
+	})
+
+	t.Run("頻道只有管理員可以邀請", func(t *testing.T) {
+		room := &models.ChatRoom{ID: primitive.NewObjectID(), Kind: models.RoomKindChannel, CreatorID: alice, Participants: []primitive.ObjectID{alice, bob}}
+
+		_, _, err := addParticipantsToRoom(room, bob, []primitive.ObjectID{carol})
+
+		assert.Equal(t, errInviteNotAllowed, err)
+	})
+
+	t.Run("封存的聊天室不能加入成員", func(t *testing.T) {
+		room := &models.ChatRoom{ID: primitive.NewObjectID(), Kind: models.RoomKindGroup, CreatorID: alice, Participants: []primitive.ObjectID{alice, bob}, ArchivedAt: time.Now()}
+
+		_, _, err := addParticipantsToRoom(room, alice, []primitive.ObjectID{carol})
+
+		assert.Equal(t, errRoomArchived, err)
+	})
+
+	t.Run("邀請已在聊天室中的成員不會出錯", func(t *testing.T) {
+		room := &models.ChatRoom{ID: primitive.NewObjectID(), Kind: models.RoomKindDM, CreatorID: alice, Participants: []primitive.ObjectID{alice, bob}}
+
+		updated, added, err := addParticipantsToRoom(room, alice, []primitive.ObjectID{bob})
+
+		assert.NoError(t, err)
+		assert.Empty(t, added)
+		assert.Same(t, room, updated)
+	})
+}
+
+func TestChatRoomKind(t *testing.T) {
+	alice, bob := primitive.NewObjectID(), primitive.NewObjectID()
+
+	legacy := &models.ChatRoom{CreatorID: alice, Participants: []primitive.ObjectID{alice, bob}}
+	assert.Equal(t, models.RoomKindGroup, legacy.EffectiveKind(), "沒有 kind 的舊聊天室視為群組")
+	assert.False(t, legacy.IsAdmin(bob))
+
+	dm := &models.ChatRoom{Kind: models.RoomKindDM, CreatorID: alice, Participants: []primitive.ObjectID{alice, bob}}
+	assert.True(t, dm.IsAdmin(bob), "私訊的兩位成員都是管理員")
+	assert.False(t, dm.IsAdmin(primitive.NewObjectID()))
+
+	assert.Equal(t, models.DirectMessageKey(alice, bob), models.DirectMessageKey(bob, alice), "私訊識別字串與順序無關")
+}
+
+func TestChatRoomOwnership(t *testing.T) {
+	alice, bob := primitive.NewObjectID(), primitive.NewObjectID()
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	legacy := &models.ChatRoom{CreatorID: alice, Participants: []primitive.ObjectID{alice, bob}, CreatedAt: createdAt}
+	assert.Equal(t, alice, legacy.Owner(), "沒有 ownerId 的舊聊天室以建立者為擁有者")
+	assert.Equal(t, createdAt, legacy.JoinedAt(bob), "沒有加入時間的成員視為建立時就已加入")
+
+	transferred := &models.ChatRoom{CreatorID: alice, OwnerID: bob, Participants: []primitive.ObjectID{alice, bob}}
+	assert.Equal(t, bob, transferred.Owner())
+	assert.True(t, transferred.IsAdmin(bob), "擁有者一定是管理員")
+	assert.False(t, transferred.IsAdmin(alice), "轉移後建立者不再自動擁有管理員身分")
+}
+
+func TestChooseNextOwner(t *testing.T) {
+	owner, early, late, bot := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	room := &models.ChatRoom{
+		OwnerID:      owner,
+		Participants: []primitive.ObjectID{owner, late, early, bot},
+		CreatedAt:    createdAt,
+		MemberSince: map[string]time.Time{
+			late.Hex():  createdAt.Add(2 * time.Hour),
+			early.Hex(): createdAt.Add(time.Hour),
+		},
+	}
+	remaining := []primitive.ObjectID{late, early, bot}
+
+	assert.Equal(t, early, chooseNextOwner(room, remaining, []primitive.ObjectID{bot}), "沒有管理員時交給最早加入的成員")
+
+	room.Admins = []primitive.ObjectID{bot, late}
+	assert.Equal(t, late, chooseNextOwner(room, remaining, []primitive.ObjectID{bot}), "管理員優先，機器人除外")
+
+	assert.Equal(t, bot, chooseNextOwner(room, []primitive.ObjectID{bot}, []primitive.ObjectID{bot}), "只剩機器人時仍要有擁有者")
+}
+
+func TestVisibleRooms(t *testing.T) {
+	active := models.ChatRoom{ID: primitive.NewObjectID()}
+	archived := models.ChatRoom{ID: primitive.NewObjectID(), ArchivedAt: time.Now()}
+	hidden := models.ChatRoom{ID: primitive.NewObjectID(), Settings: &models.RoomMemberSettings{Hidden: true}}
+	rooms := []models.ChatRoom{archived, active, hidden}
+
+	assert.Equal(t, []models.ChatRoom{active}, visibleRooms(rooms, false, false))
+	assert.Equal(t, []models.ChatRoom{archived, active}, visibleRooms(rooms, true, false))
+	assert.Equal(t, []models.ChatRoom{active, hidden}, visibleRooms(rooms, false, true))
+	assert.NotNil(t, visibleRooms(nil, false, false), "沒有聊天室時回傳空陣列而不是 null")
+}
+
+func TestSortRoomList(t *testing.T) {
+	recent := models.ChatRoom{Name: "recent"}
+	older := models.ChatRoom{Name: "older", Settings: &models.RoomMemberSettings{}}
+	second := models.ChatRoom{Name: "second", Settings: &models.RoomMemberSettings{Favorite: true, SortOrder: 2}}
+	first := models.ChatRoom{Name: "first", Settings: &models.RoomMemberSettings{Favorite: true, SortOrder: 1}}
+	unordered := models.ChatRoom{Name: "unordered", Settings: &models.RoomMemberSettings{Favorite: true, SortOrder: 1}}
+
+	// 輸入已依最後更新時間排序
+	rooms := []models.ChatRoom{recent, second, first, older, unordered}
+	sortRoomList(rooms)
+
+	var names []string
+	for _, room := range rooms {
+		names = append(names, room.Name)
+	}
+	assert.Equal(t, []string{"first", "unordered", "second", "recent", "older"}, names, "我的最愛依自訂順序置頂，順序相同時維持更新時間順序")
+}
+
+func TestSortRoomList_LastMessage(t *testing.T) {
+	now := time.Now()
+	updated := models.ChatRoom{Name: "updated", UpdatedAt: now.Add(-time.Minute)}
+	messaged := models.ChatRoom{Name: "messaged", UpdatedAt: now.Add(-time.Hour), LastMessage: &models.MessagePreview{Timestamp: now}}
+
+	rooms := []models.ChatRoom{updated, messaged}
+	sortRoomList(rooms)
+
+	assert.Equal(t, "messaged", rooms[0].Name, "最近有新訊息的聊天室排在前面")
+}
+
+func TestUpdateChatRoom_RejectsParticipantReplacement(t *testing.T) {
+	roomID := primitive.NewObjectID().Hex()
+	for _, body := range []string{`{"participantIds": []}`, `{"participantIds": ["` + primitive.NewObjectID().Hex() + `"]}`} {
+		req := httptest.NewRequest("PUT", "/chatrooms/"+roomID+"/update", strings.NewReader(body))
+		req = mux.SetURLVars(withSession(req, primitive.NewObjectID(), primitive.NilObjectID), map[string]string{"id": roomID})
+		rr := httptest.NewRecorder()
+
+		UpdateChatRoom(rr, req)
+
+		// 成員變動必須經過邀請與移除的流程，才會檢查封鎖、機器人白名單與擁有者
+		assert.Equal(t, http.StatusBadRequest, rr.Code, body)
+	}
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-chat/backend/database"
+	"go-chat/backend/mailer"
+	"go-chat/backend/models"
+	"go-chat/backend/store"
+	"go-chat/backend/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// emailVerificationTokenPrefix 是 Email 驗證 token 的固定前綴
+const emailVerificationTokenPrefix = "evt_"
+
+// verificationResentMessage 不論 Email 是否存在或是否已驗證都回傳同樣的訊息，避免被用來探測帳號
+const verificationResentMessage = "If the email is registered and not yet verified, a verification link has been sent"
+
+// 同一個 Email 在時間窗內最多可以重新寄送驗證信的次數
+const (
+	verificationResendLimit  = 3
+	verificationResendWindow = time.Hour
+)
+
+// allowVerificationResend 檢查 Email 是否還能重新寄送驗證信，測試時可替換
+var allowVerificationResend = func(email string) (bool, time.Duration, error) {
+	return database.AllowSlidingWindow("rate-limit:email-verification:"+email, verificationResendLimit, verificationResendWindow)
+}
+
+// EmailVerificationHandler 處理註冊後的 Email 驗證
+type EmailVerificationHandler struct {
+	Auth              *AuthHandler
+	VerificationStore store.EmailVerificationStorer
+	Mailer            mailer.Mailer
+}
+
+// NewEmailVerificationHandler 是一個工廠函式，用於建立新的 EmailVerificationHandler
+func NewEmailVerificationHandler(auth *AuthHandler, verificationStore store.EmailVerificationStorer, m mailer.Mailer) *EmailVerificationHandler {
+	return &EmailVerificationHandler{Auth: auth, VerificationStore: verificationStore, Mailer: m}
+}
+
+// SendVerification 建立驗證 token 並在背景寄出驗證連結
+func (h *EmailVerificationHandler) SendVerification(ctx context.Context, user *models.User) error {
+	token, err := utils.GenerateOpaqueToken(emailVerificationTokenPrefix)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	ttl := h.Auth.Cfg.EmailVerificationTTL
+	err = h.VerificationStore.CreateEmailVerification(ctx, models.EmailVerification{
+		ID:        primitive.NewObjectID(),
+		UserID:    user.ID,
+		Email:     user.Email,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/verify-email?token=%s", h.Auth.Cfg.FrontendBaseURL, url.QueryEscape(token))
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: "GoChat Email 驗證",
+		Body: fmt.Sprintf("%s 您好：\n\n請在 %d 小時內開啟以下連結完成 Email 驗證：\n%s\n\n如果您沒有註冊 GoChat，請忽略這封信。\n",
+			user.Username, int(math.Ceil(ttl.Hours())), link),
+	}
+	go func() {
+		sendCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := h.Mailer.Send(sendCtx, msg); err != nil {
+			log.Printf("Error sending verification email to user %s: %v", user.ID.Hex(), err)
+		}
+	}()
+	return nil
+}
+
+// ConfirmEmailVerification 以信中的 token 完成 Email 驗證
+// 這個 API 端點會是 POST /email-verification/confirm
+func (h *EmailVerificationHandler) ConfirmEmailVerification(w http.ResponseWriter, r *http.Request) {
+	var req models.EmailVerificationConfirmRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	if req.Token == "" {
+		sendJSONError(w, "Token is required", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	verification, err := h.VerificationStore.FindEmailVerificationByHash(ctx, utils.HashToken(req.Token))
+	if err != nil && err != mongo.ErrNoDocuments {
+		log.Printf("Error finding email verification token: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if err == mongo.ErrNoDocuments || !verification.UsedAt.IsZero() || time.Now().After(verification.ExpiresAt) {
+		sendJSONError(w, "Invalid or expired verification token", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.Auth.UserStore.FindUserByID(ctx, verification.UserID)
+	if err == mongo.ErrNoDocuments {
+		sendJSONError(w, "Invalid or expired verification token", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("Error finding user %s for email verification: %v", verification.UserID.Hex(), err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	// token 只能驗證寄出時的地址
+	if !strings.EqualFold(user.Email, verification.Email) {
+		sendJSONError(w, "Invalid or expired verification token", http.StatusBadRequest)
+		return
+	}
+
+	marked, err := h.VerificationStore.MarkEmailVerificationUsed(ctx, verification.ID)
+	if err != nil {
+		log.Printf("Error marking email verification %s used: %v", verification.ID.Hex(), err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if !marked {
+		sendJSONError(w, "Invalid or expired verification token", http.StatusBadRequest)
+		return
+	}
+
+	if !user.Verified {
+		if err := h.Auth.UserStore.MarkUserVerified(ctx, user.ID); err != nil {
+			log.Printf("Error marking user %s verified: %v", user.ID.Hex(), err)
+			sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		log.Printf("Email verified for user %s", user.ID.Hex())
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Email verified"})
+}
+
+// ResendEmailVerification 重新寄送驗證信，同一個 Email 在一小時內最多寄送 verificationResendLimit 次
+// 這個 API 端點會是 POST /email-verification/resend
+func (h *EmailVerificationHandler) ResendEmailVerification(w http.ResponseWriter, r *http.Request) {
+	var req models.EmailVerificationResendRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	email := strings.TrimSpace(req.Email)
+	if email == "" {
+		sendJSONError(w, "Email is required", http.StatusBadRequest)
+		return
+	}
+
+	// 限流在查詢使用者之前進行，回應不會因 Email 是否存在而不同
+	allowed, retryAfter, err := allowVerificationResend(strings.ToLower(email))
+	if err != nil {
+		log.Printf("Error checking rate limit for verification resend: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if !allowed {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		sendJSONError(w, "Too many verification emails requested", http.StatusTooManyRequests)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.Auth.UserStore.FindUserByEmail(ctx, email)
+	switch {
+	case err == mongo.ErrNoDocuments:
+		log.Printf("Verification resend requested for unknown email")
+	case err != nil:
+		log.Printf("Error finding user for verification resend: %v", err)
+	case user.Verified:
+		log.Printf("Verification resend requested for verified user %s", user.ID.Hex())
+	default:
+		if err := h.SendVerification(ctx, user); err != nil {
+			log.Printf("Error resending verification for user %s: %v", user.ID.Hex(), err)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{"message": verificationResentMessage})
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-chat/backend/config"
+	"go-chat/backend/mailer"
+	"go-chat/backend/models"
+	"go-chat/backend/store/mocks"
+	"go-chat/backend/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSendVerification(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockVerificationStore := mocks.NewMockEmailVerificationStorer(ctrl)
+	memoryMailer := mailer.NewMemoryMailer()
+	cfg := &config.Config{EmailVerificationTTL: 24 * time.Hour, FrontendBaseURL: "http://frontend.test"}
+	authHandler := NewAuthHandler(mocks.NewMockUserStorer(ctrl), mocks.NewMockRefreshTokenStorer(ctrl), mocks.NewMockSessionStorer(ctrl), mocks.NewMockTokenDenylist(ctrl), cfg)
+	handler := NewEmailVerificationHandler(authHandler, mockVerificationStore, memoryMailer)
+
+	user := &models.User{ID: primitive.NewObjectID(), Email: "new@example.com", Username: "new"}
+	var stored models.EmailVerification
+	mockVerificationStore.EXPECT().
+		CreateEmailVerification(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, v models.EmailVerification) error {
+			stored = v
+			return nil
+		}).
+		Times(1)
+
+	require.NoError(t, handler.SendVerification(context.Background(), user))
+
+	assert.Equal(t, user.ID, stored.UserID)
+	assert.Equal(t, user.Email, stored.Email)
+	require.Eventually(t, func() bool { return len(memoryMailer.Sent()) == 1 }, time.Second, 10*time.Millisecond)
+	msg := memoryMailer.Sent()[0]
+	assert.Equal(t, user.Email, msg.To)
+	assert.Contains(t, msg.Body, "http://frontend.test/verify-email?token="+emailVerificationTokenPrefix)
+	assert.NotContains(t, msg.Body, stored.TokenHash, "信中不應該出現 token 的雜湊")
+}
+
+func TestConfirmEmailVerification(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserStore := mocks.NewMockUserStorer(ctrl)
+	mockVerificationStore := mocks.NewMockEmailVerificationStorer(ctrl)
+	authHandler := NewAuthHandler(mockUserStore, mocks.NewMockRefreshTokenStorer(ctrl), mocks.NewMockSessionStorer(ctrl), mocks.NewMockTokenDenylist(ctrl), &config.Config{})
+	handler := NewEmailVerificationHandler(authHandler, mockVerificationStore, mailer.NewMemoryMailer())
+
+	confirm := func(token string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		handler.ConfirmEmailVerification(rr, newJSONRequest("POST", "/email-verification/confirm", map[string]string{"token": token}))
+		return rr
+	}
+
+	t.Run("不存在的 token 回 400", func(t *testing.T) {
+		mockVerificationStore.EXPECT().FindEmailVerificationByHash(gomock.Any(), utils.HashToken("evt_unknown")).Return(nil, mongo.ErrNoDocuments).Times(1)
+
+		assert.Equal(t, http.StatusBadRequest, confirm("evt_unknown").Code)
+	})
+
+	t.Run("過期的 token 回 400", func(t *testing.T) {
+		expired := &models.EmailVerification{ID: primitive.NewObjectID(), ExpiresAt: time.Now().Add(-time.Minute)}
+		mockVerificationStore.EXPECT().FindEmailVerificationByHash(gomock.Any(), utils.HashToken("evt_expired")).Return(expired, nil).Times(1)
+
+		assert.Equal(t, http.StatusBadRequest, confirm("evt_expired").Code)
+	})
+
+	t.Run("使用者已更換 Email 時舊 token 無效", func(t *testing.T) {
+		verification := &models.EmailVerification{ID: primitive.NewObjectID(), UserID: primitive.NewObjectID(), Email: "old@example.com", ExpiresAt: time.Now().Add(time.Hour)}
+		user := &models.User{ID: verification.UserID, Email: "new@example.com"}
+		mockVerificationStore.EXPECT().FindEmailVerificationByHash(gomock.Any(), utils.HashToken("evt_old")).Return(verification, nil).Times(1)
+		mockUserStore.EXPECT().FindUserByID(gomock.Any(), user.ID).Return(user, nil).Times(1)
+
+		assert.Equal(t, http.StatusBadRequest, confirm("evt_old").Code)
+	})
+
+	t.Run("驗證成功", func(t *testing.T) {
+		verification := &models.EmailVerification{ID: primitive.NewObjectID(), UserID: primitive.NewObjectID(), Email: "user@example.com", ExpiresAt: time.Now().Add(time.Hour)}
+		user := &models.User{ID: verification.UserID, Email: "user@example.com"}
+		mockVerificationStore.EXPECT().FindEmailVerificationByHash(gomock.Any(), utils.HashToken("evt_valid")).Return(verification, nil).Times(1)
+		mockUserStore.EXPECT().FindUserByID(gomock.Any(), user.ID).Return(user, nil).Times(1)
+		mockVerificationStore.EXPECT().MarkEmailVerificationUsed(gomock.Any(), verification.ID).Return(true, nil).Times(1)
+		mockUserStore.EXPECT().MarkUserVerified(gomock.Any(), user.ID).Return(nil).Times(1)
+
+		assert.Equal(t, http.StatusOK, confirm("evt_valid").Code)
+	})
+}
+
+func TestResendEmailVerification(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserStore := mocks.NewMockUserStorer(ctrl)
+	authHandler := NewAuthHandler(mockUserStore, mocks.NewMockRefreshTokenStorer(ctrl), mocks.NewMockSessionStorer(ctrl), mocks.NewMockTokenDenylist(ctrl), &config.Config{})
+	handler := NewEmailVerificationHandler(authHandler, mocks.NewMockEmailVerificationStorer(ctrl), mailer.NewMemoryMailer())
+
+	// 以記憶體中的計數取代 Redis 限流
+	original := allowVerificationResend
+	defer func() { allowVerificationResend = original }()
+	counts := map[string]int{}
+	allowVerificationResend = func(email string) (bool, time.Duration, error) {
+		counts[email]++
+		return counts[email] <= verificationResendLimit, 30 * time.Minute, nil
+	}
+
+	resend := func(email string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		handler.ResendEmailVerification(rr, newJSONRequest("POST", "/email-verification/resend", map[string]string{"email": email}))
+		return rr
+	}
+
+	t.Run("已驗證的帳號不會再寄信，回應與不存在的 Email 相同", func(t *testing.T) {
+		mockUserStore.EXPECT().FindUserByEmail(gomock.Any(), "verified@example.com").Return(&models.User{ID: primitive.NewObjectID(), Verified: true}, nil).Times(1)
+		mockUserStore.EXPECT().FindUserByEmail(gomock.Any(), "nobody@example.com").Return(nil, mongo.ErrNoDocuments).Times(1)
+
+		verified := resend("verified@example.com")
+		unknown := resend("nobody@example.com")
+
+		assert.Equal(t, http.StatusAccepted, verified.Code)
+		assert.Equal(t, verified.Code, unknown.Code)
+		assert.Equal(t, verified.Body.String(), unknown.Body.String())
+	})
+
+	t.Run("超過次數時回 429 並附上 Retry-After", func(t *testing.T) {
+		mockUserStore.EXPECT().FindUserByEmail(gomock.Any(), "spam@example.com").Return(nil, mongo.ErrNoDocuments).Times(verificationResendLimit)
+		for i := 0; i < verificationResendLimit; i++ {
+			assert.Equal(t, http.StatusAccepted, resend("spam@example.com").Code)
+		}
+
+		rr := resend("Spam@Example.com")
+
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code, "限流不應該因大小寫不同而被繞過")
+		assert.Equal(t, "1800", rr.Header().Get("Retry-After"))
+		assert.True(t, strings.Contains(rr.Body.String(), "Too many"))
+	})
+}
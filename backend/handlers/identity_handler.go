@@ -0,0 +1,367 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go-chat/backend/models"
+	"go-chat/backend/oidc"
+	"go-chat/backend/utils"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// identityLinkTTL 是外部登入發現 Email 已被註冊後，完成帳號連結的期限
+const identityLinkTTL = 10 * time.Minute
+
+// identityLinkTokenType 區分連結請求與同一把金鑰簽署的其他內容 (例如 state cookie)
+const identityLinkTokenType = "identity_link"
+
+// errIdentityLinkRequired 表示外部身分的 Email 已屬於其他使用者，需要先證明擁有該帳號才能連結
+var errIdentityLinkRequired = errors.New("email already registered, identity link required")
+
+// pendingIdentityLink 是等待使用者以原帳號確認的外部身分，以 HMAC 簽署後交給前端
+type pendingIdentityLink struct {
+	Type          string `json:"typ"`
+	ID            string `json:"jti"` // 只能使用一次
+	UserID        string `json:"uid"`
+	Provider      string `json:"provider"`
+	Subject       string `json:"sub"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"emailVerified"`
+	ExpiresAt     int64  `json:"exp"`
+}
+
+// redirectToIdentityLink 導向前端的連結頁面，並帶上簽署過的連結請求
+func (h *OIDCHandler) redirectToIdentityLink(w http.ResponseWriter, r *http.Request, user *models.User, providerID string, claims *oidc.Claims) {
+	linkID, err := randomURLString()
+	if err != nil {
+		log.Printf("Error generating identity link ID: %v", err)
+		h.redirectToAuthPage(w, r, "registration_failed")
+		return
+	}
+	payload, err := json.Marshal(pendingIdentityLink{
+		Type:          identityLinkTokenType,
+		ID:            linkID,
+		UserID:        user.ID.Hex(),
+		Provider:      providerID,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		ExpiresAt:     time.Now().Add(identityLinkTTL).Unix(),
+	})
+	if err != nil {
+		log.Printf("Error encoding identity link: %v", err)
+		h.redirectToAuthPage(w, r, "registration_failed")
+		return
+	}
+
+	query := url.Values{}
+	query.Set("link", utils.SignString(h.Auth.Cfg.OAuthStateKey, string(payload)))
+	query.Set("provider", providerID)
+	http.Redirect(w, r, h.Auth.Cfg.FrontendBaseURL+"/auth?"+query.Encode(), http.StatusTemporaryRedirect)
+}
+
+// readIdentityLink 驗證連結請求的簽章、用途與效期
+func (h *OIDCHandler) readIdentityLink(token string) (*pendingIdentityLink, error) {
+	payload, err := utils.VerifySignedString(h.Auth.Cfg.OAuthStateKey, token)
+	if err != nil {
+		return nil, err
+	}
+	var link pendingIdentityLink
+	if err := json.Unmarshal([]byte(payload), &link); err != nil {
+		return nil, err
+	}
+	if link.Type != identityLinkTokenType || link.ID == "" || link.Subject == "" {
+		return nil, errors.New("not an identity link")
+	}
+	if time.Now().Unix() > link.ExpiresAt {
+		return nil, errors.New("identity link expired")
+	}
+	return &link, nil
+}
+
+// ConfirmIdentityLink 以原帳號的密碼 (與兩步驟驗證碼) 證明擁有帳號後，將外部身分連結到該帳號並登入
+// 這個 API 端點會是 POST /auth/link/confirm
+func (h *OIDCHandler) ConfirmIdentityLink(w http.ResponseWriter, r *http.Request) {
+	var req models.IdentityLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	if req.LinkToken == "" || req.Password == "" {
+		sendJSONError(w, "Link token and password are required", http.StatusBadRequest)
+		return
+	}
+	link, err := h.readIdentityLink(req.LinkToken)
+	if err != nil {
+		sendJSONError(w, "Invalid or expired link request", http.StatusUnauthorized)
+		return
+	}
+	userID, err := primitive.ObjectIDFromHex(link.UserID)
+	if err != nil {
+		sendJSONError(w, "Invalid or expired link request", http.StatusUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.Auth.UserStore.FindUserByID(ctx, userID)
+	if err == mongo.ErrNoDocuments {
+		sendJSONError(w, "Invalid or expired link request", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		log.Printf("Error finding user %s for identity link: %v", userID.Hex(), err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	// 確認密碼與登入共用同一組限流與鎖定
+	account := strings.ToLower(strings.TrimSpace(user.Email))
+	ip := utils.GetClientIP(r)
+	if !h.Auth.checkLoginAllowed(ctx, w, ip, account) {
+		return
+	}
+	if user.Password == "" {
+		sendJSONError(w, "This account has no password. Sign in with a linked provider and link the new one from your account settings", http.StatusConflict)
+		return
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)) != nil {
+		h.Auth.recordLoginFailure(ctx, w, account, ip, user)
+		return
+	}
+	if h.Auth.Throttle != nil {
+		if err := h.Auth.Throttle.RecordSuccess(ctx, account); err != nil {
+			log.Printf("Error clearing login failures: %v", err)
+		}
+	}
+
+	// 原帳號啟用兩步驟驗證時，沒有驗證碼就不能連結
+	if h.Auth.TwoFactor != nil {
+		enabled, err := h.Auth.TwoFactor.isEnabled(ctx, user.ID)
+		if err != nil {
+			log.Printf("Error checking 2FA for user %s: %v", user.ID.Hex(), err)
+			sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if enabled && req.Code == "" && req.RecoveryCode == "" {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(models.LoginResponse{
+				Message:           "Two-factor authentication required",
+				TwoFactorRequired: true,
+			})
+			return
+		}
+		if enabled && !h.Auth.TwoFactor.verifyEnabledFactor(ctx, w, user.ID, req.TwoFactorCodeRequest, true) {
+			return
+		}
+	}
+
+	consumed, err := h.Auth.Denylist.ConsumeToken(ctx, link.ID, time.Unix(link.ExpiresAt, 0))
+	if err != nil {
+		log.Printf("Error consuming identity link for user %s: %v", user.ID.Hex(), err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if !consumed {
+		sendJSONError(w, "Invalid or expired link request", http.StatusUnauthorized)
+		return
+	}
+
+	now := time.Now()
+	err = h.IdentityStore.CreateIdentity(ctx, models.Identity{
+		ID:          primitive.NewObjectID(),
+		UserID:      user.ID,
+		Provider:    link.Provider,
+		Subject:     link.Subject,
+		Email:       link.Email,
+		CreatedAt:   now,
+		LastLoginAt: now,
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		sendJSONError(w, "This identity is already linked to an account", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Printf("Error linking %s identity to user %s: %v", link.Provider, user.ID.Hex(), err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("Linked %s identity to user %s", link.Provider, user.ID.Hex())
+
+	h.markVerifiedByProvider(ctx, link.Provider, user, link.Email, link.EmailVerified)
+	if h.Auth.Cfg.RequireEmailVerification && !user.Verified {
+		sendJSONError(w, "Email not verified", http.StatusForbidden)
+		return
+	}
+
+	if err := h.Auth.startSession(ctx, w, r, user); err != nil {
+		log.Printf("Error issuing session for user %s: %v", user.ID.Hex(), err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.LoginResponse{
+		Message:  "Identity linked",
+		ID:       user.ID.Hex(),
+		Username: user.Username,
+	})
+}
+
+// HandleOIDCLink 讓已登入的使用者導向提供者，授權完成後把該提供者的身分連結到目前的帳號
+// 可用 ?redirect= 指定完成後要回到的網址
+// 這個 API 端點會是 GET /auth/{provider}/link
+func (h *OIDCHandler) HandleOIDCLink(w http.ResponseWriter, r *http.Request) {
+	userID, err := utils.GetUserIDFromContext(r.Context())
+	if err != nil {
+		sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	h.redirectToProvider(w, r, userID.Hex())
+}
+
+// finishIdentityLink 完成已登入使用者的身分連結，不會建立新的 session
+func (h *OIDCHandler) finishIdentityLink(ctx context.Context, w http.ResponseWriter, r *http.Request, providerID string, loginState *oidcLoginState, claims *oidc.Claims) {
+	userID, err := primitive.ObjectIDFromHex(loginState.LinkUserID)
+	if err != nil {
+		h.redirectToAuthPage(w, r, "invalid_state")
+		return
+	}
+
+	identity, err := h.IdentityStore.FindIdentity(ctx, providerID, claims.Subject)
+	switch {
+	case err == nil && identity.UserID != userID:
+		h.redirectToAuthPage(w, r, "identity_in_use")
+		return
+	case err == nil:
+		// 已經連結在同一個帳號上，視為成功
+	case err == mongo.ErrNoDocuments:
+		now := time.Now()
+		err = h.IdentityStore.CreateIdentity(ctx, models.Identity{
+			ID:          primitive.NewObjectID(),
+			UserID:      userID,
+			Provider:    providerID,
+			Subject:     claims.Subject,
+			Email:       claims.Email,
+			CreatedAt:   now,
+			LastLoginAt: now,
+		})
+		if mongo.IsDuplicateKeyError(err) {
+			h.redirectToAuthPage(w, r, "identity_in_use")
+			return
+		}
+		if err != nil {
+			log.Printf("Error linking %s identity to user %s: %v", providerID, userID.Hex(), err)
+			h.redirectToAuthPage(w, r, "link_failed")
+			return
+		}
+		log.Printf("Linked %s identity to user %s", providerID, userID.Hex())
+	default:
+		log.Printf("Error finding %s identity: %v", providerID, err)
+		h.redirectToAuthPage(w, r, "link_failed")
+		return
+	}
+
+	http.Redirect(w, r, loginState.ReturnTo, http.StatusTemporaryRedirect)
+}
+
+// ListIdentities 列出目前使用者連結的外部登入身分
+// 這個 API 端點會是 GET /identities
+func (h *OIDCHandler) ListIdentities(w http.ResponseWriter, r *http.Request) {
+	userID, err := utils.GetUserIDFromContext(r.Context())
+	if err != nil {
+		sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	identities, err := h.IdentityStore.ListUserIdentities(ctx, userID)
+	if err != nil {
+		log.Printf("Error listing identities of user %s: %v", userID.Hex(), err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(identities)
+}
+
+// UnlinkIdentity 解除目前使用者的外部登入身分，沒有密碼的帳號不能移除最後一個身分
+// 這個 API 端點會是 DELETE /identities/{id}
+func (h *OIDCHandler) UnlinkIdentity(w http.ResponseWriter, r *http.Request) {
+	userID, err := utils.GetUserIDFromContext(r.Context())
+	if err != nil {
+		sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	identityID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		sendJSONError(w, "Invalid identity ID format", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.Auth.UserStore.FindUserByID(ctx, userID)
+	if err != nil {
+		log.Printf("Error finding user %s: %v", userID.Hex(), err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	identities, err := h.IdentityStore.ListUserIdentities(ctx, userID)
+	if err != nil {
+		log.Printf("Error listing identities of user %s: %v", userID.Hex(), err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	var target *models.Identity
+	for i := range identities {
+		if identities[i].ID == identityID {
+			target = &identities[i]
+		}
+	}
+	// 不屬於自己的身分一律回 404
+	if target == nil {
+		sendJSONError(w, "Identity not found", http.StatusNotFound)
+		return
+	}
+	if user.Password == "" && len(identities) == 1 {
+		sendJSONError(w, "Cannot unlink the only sign-in method. Link another provider first", http.StatusConflict)
+		return
+	}
+
+	if err := h.IdentityStore.DeleteIdentity(ctx, userID, identityID); err == mongo.ErrNoDocuments {
+		sendJSONError(w, "Identity not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.Printf("Error unlinking identity %s: %v", identityID.Hex(), err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	// 改版前的 Google 帳號會以 googleId 自動連回，解除連結時一併移除
+	if target.Provider == "google" && user.GoogleID != "" {
+		if err := h.Auth.UserStore.ClearGoogleID(ctx, userID); err != nil {
+			log.Printf("Error clearing google ID of user %s: %v", userID.Hex(), err)
+		}
+	}
+	log.Printf("Unlinked %s identity from user %s", target.Provider, userID.Hex())
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Identity unlinked"})
+}
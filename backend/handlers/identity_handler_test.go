@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"go-chat/backend/config"
+	"go-chat/backend/models"
+	"go-chat/backend/oidc"
+	"go-chat/backend/oidc/oidctest"
+	"go-chat/backend/store/mocks"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestIdentityLinking(t *testing.T) {
+	server := oidctest.NewServer()
+	defer server.Close()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserStore := mocks.NewMockUserStorer(ctrl)
+	mockSessionStore := mocks.NewMockSessionStorer(ctrl)
+	mockTokenStore := mocks.NewMockRefreshTokenStorer(ctrl)
+	mockDenylist := mocks.NewMockTokenDenylist(ctrl)
+	mockIdentityStore := mocks.NewMockIdentityStorer(ctrl)
+	cfg := &config.Config{
+		JWTSecret:              "test-secret",
+		AccessTokenTTL:         15 * time.Minute,
+		RefreshTokenTTL:        time.Hour,
+		FrontendBaseURL:        "http://frontend.test",
+		OAuthStateKey:          []byte("state-key"),
+		PostLoginRedirectURL:   "http://frontend.test/home",
+		AllowedRedirectOrigins: []string{"http://frontend.test"},
+	}
+	authHandler := NewAuthHandler(mockUserStore, mockTokenStore, mockSessionStore, mockDenylist, cfg)
+
+	registry := oidc.NewRegistry(nil)
+	registry.Add(oidc.NewProvider(config.OIDCProviderConfig{
+		ID:           "okta",
+		Issuer:       server.Issuer(),
+		ClientID:     server.ClientID,
+		ClientSecret: server.ClientSecret,
+		RedirectURL:  "http://backend.test/auth/okta/callback",
+		Scopes:       []string{"openid", "email"},
+	}, server.Client()))
+	handler := NewOIDCHandler(authHandler, registry, mockIdentityStore)
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("correct-password"), bcrypt.MinCost)
+	existing := &models.User{ID: primitive.NewObjectID(), Email: "taken@example.com", Username: "taken", Password: string(hashedPassword), Verified: true}
+
+	// loginWithExistingEmail 以 Email 已被註冊的外部身分登入，回傳前端連結頁面帶的連結請求
+	loginWithExistingEmail := func(t *testing.T, user *models.User, subject string) string {
+		authURL, cookie := startOIDCLogin(t, handler, "okta")
+		callback, err := server.Authorize(authURL, oidctest.Identity{Subject: subject, Email: user.Email, EmailVerified: true})
+		require.NoError(t, err)
+
+		mockIdentityStore.EXPECT().FindIdentity(gomock.Any(), "okta", subject).Return(nil, mongo.ErrNoDocuments).Times(1)
+		mockUserStore.EXPECT().FindUserByEmail(gomock.Any(), user.Email).Return(user, nil).Times(1)
+
+		rr := finishOIDCLogin(handler, "okta", callback, cookie)
+
+		location, err := url.Parse(rr.Header().Get("Location"))
+		require.NoError(t, err)
+		assert.Equal(t, "/auth", location.Path)
+		assert.Equal(t, "okta", location.Query().Get("provider"))
+		assert.Nil(t, findCookie(rr, accessTokenCookieName), "連結完成前不應該登入")
+		require.NotEmpty(t, location.Query().Get("link"))
+		return location.Query().Get("link")
+	}
+
+	t.Run("Email 已被註冊時不建立重複的使用者，確認密碼後才連結", func(t *testing.T) {
+		linkToken := loginWithExistingEmail(t, existing, "okta-taken")
+
+		mockUserStore.EXPECT().FindUserByID(gomock.Any(), existing.ID).Return(existing, nil).Times(1)
+		rr := httptest.NewRecorder()
+		handler.ConfirmIdentityLink(rr, newJSONRequest("POST", "/auth/link/confirm", models.IdentityLinkRequest{LinkToken: linkToken, Password: "wrong-password"}))
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+		mockUserStore.EXPECT().FindUserByID(gomock.Any(), existing.ID).Return(existing, nil).Times(1)
+		mockDenylist.EXPECT().ConsumeToken(gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil).Times(1)
+		mockIdentityStore.EXPECT().
+			CreateIdentity(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ interface{}, identity models.Identity) error {
+				assert.Equal(t, existing.ID, identity.UserID)
+				assert.Equal(t, "okta", identity.Provider)
+				assert.Equal(t, "okta-taken", identity.Subject)
+				return nil
+			}).
+			Times(1)
+		mockSessionStore.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+		mockTokenStore.EXPECT().CreateRefreshToken(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+		rr = httptest.NewRecorder()
+		handler.ConfirmIdentityLink(rr, newJSONRequest("POST", "/auth/link/confirm", models.IdentityLinkRequest{LinkToken: linkToken, Password: "correct-password"}))
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.NotNil(t, findCookie(rr, accessTokenCookieName))
+
+		// 同一個連結請求不能再使用
+		mockUserStore.EXPECT().FindUserByID(gomock.Any(), existing.ID).Return(existing, nil).Times(1)
+		mockDenylist.EXPECT().ConsumeToken(gomock.Any(), gomock.Any(), gomock.Any()).Return(false, nil).Times(1)
+		rr = httptest.NewRecorder()
+		handler.ConfirmIdentityLink(rr, newJSONRequest("POST", "/auth/link/confirm", models.IdentityLinkRequest{LinkToken: linkToken, Password: "correct-password"}))
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("沒有密碼的帳號無法以密碼確認連結", func(t *testing.T) {
+		passwordless := &models.User{ID: primitive.NewObjectID(), Email: "sso@example.com"}
+		linkToken := loginWithExistingEmail(t, passwordless, "okta-sso")
+
+		mockUserStore.EXPECT().FindUserByID(gomock.Any(), passwordless.ID).Return(passwordless, nil).Times(1)
+		rr := httptest.NewRecorder()
+		handler.ConfirmIdentityLink(rr, newJSONRequest("POST", "/auth/link/confirm", models.IdentityLinkRequest{LinkToken: linkToken, Password: "anything"}))
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
+
+	t.Run("被竄改的連結請求會被拒絕", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.ConfirmIdentityLink(rr, newJSONRequest("POST", "/auth/link/confirm", models.IdentityLinkRequest{LinkToken: "eyJ0eXAiOiJ4In0.forged", Password: "correct-password"}))
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	// startIdentityLink 以已登入的使用者呼叫 /auth/{provider}/link
+	startIdentityLink := func(t *testing.T, userID primitive.ObjectID) (string, *http.Cookie) {
+		req := mux.SetURLVars(httptest.NewRequest("GET", "/auth/okta/link", nil), map[string]string{"provider": "okta"})
+		rr := httptest.NewRecorder()
+		handler.HandleOIDCLink(rr, withSession(req, userID, primitive.NewObjectID()))
+		require.Equal(t, http.StatusTemporaryRedirect, rr.Code)
+		cookie := findCookie(rr, oidcStateCookieName)
+		require.NotNil(t, cookie)
+		return rr.Header().Get("Location"), cookie
+	}
+
+	t.Run("已登入的使用者連結新的身分，不會開始新的 session", func(t *testing.T) {
+		userID := primitive.NewObjectID()
+		authURL, cookie := startIdentityLink(t, userID)
+		callback, err := server.Authorize(authURL, oidctest.Identity{Subject: "okta-new", Email: "other@example.com"})
+		require.NoError(t, err)
+
+		mockIdentityStore.EXPECT().FindIdentity(gomock.Any(), "okta", "okta-new").Return(nil, mongo.ErrNoDocuments).Times(1)
+		mockIdentityStore.EXPECT().
+			CreateIdentity(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ interface{}, identity models.Identity) error {
+				assert.Equal(t, userID, identity.UserID)
+				return nil
+			}).
+			Times(1)
+
+		rr := finishOIDCLogin(handler, "okta", callback, cookie)
+
+		assert.Equal(t, "http://frontend.test/home", rr.Header().Get("Location"))
+		assert.Nil(t, findCookie(rr, accessTokenCookieName))
+	})
+
+	t.Run("已連結到其他使用者的身分不能再連結", func(t *testing.T) {
+		authURL, cookie := startIdentityLink(t, primitive.NewObjectID())
+		callback, err := server.Authorize(authURL, oidctest.Identity{Subject: "okta-owned"})
+		require.NoError(t, err)
+
+		owned := &models.Identity{ID: primitive.NewObjectID(), UserID: primitive.NewObjectID(), Provider: "okta", Subject: "okta-owned"}
+		mockIdentityStore.EXPECT().FindIdentity(gomock.Any(), "okta", "okta-owned").Return(owned, nil).Times(1)
+
+		rr := finishOIDCLogin(handler, "okta", callback, cookie)
+
+		assert.Contains(t, rr.Header().Get("Location"), "error=identity_in_use")
+	})
+}
+
+func TestUnlinkIdentity(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserStore := mocks.NewMockUserStorer(ctrl)
+	mockIdentityStore := mocks.NewMockIdentityStorer(ctrl)
+	authHandler := NewAuthHandler(mockUserStore, mocks.NewMockRefreshTokenStorer(ctrl), mocks.NewMockSessionStorer(ctrl), mocks.NewMockTokenDenylist(ctrl), &config.Config{})
+	handler := NewOIDCHandler(authHandler, oidc.NewRegistry(nil), mockIdentityStore)
+
+	newUnlinkRequest := func(userID, identityID primitive.ObjectID) *http.Request {
+		req := httptest.NewRequest("DELETE", "/identities/"+identityID.Hex(), nil)
+		req = mux.SetURLVars(req, map[string]string{"id": identityID.Hex()})
+		return withSession(req, userID, primitive.NewObjectID())
+	}
+
+	t.Run("不屬於自己的身分回 404", func(t *testing.T) {
+		user := &models.User{ID: primitive.NewObjectID(), Password: "hashed"}
+		mockUserStore.EXPECT().FindUserByID(gomock.Any(), user.ID).Return(user, nil).Times(1)
+		mockIdentityStore.EXPECT().ListUserIdentities(gomock.Any(), user.ID).Return([]models.Identity{}, nil).Times(1)
+		rr := httptest.NewRecorder()
+
+		handler.UnlinkIdentity(rr, newUnlinkRequest(user.ID, primitive.NewObjectID()))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("沒有密碼時不能移除最後一個登入方式", func(t *testing.T) {
+		user := &models.User{ID: primitive.NewObjectID()}
+		identity := models.Identity{ID: primitive.NewObjectID(), UserID: user.ID, Provider: "okta"}
+		mockUserStore.EXPECT().FindUserByID(gomock.Any(), user.ID).Return(user, nil).Times(1)
+		mockIdentityStore.EXPECT().ListUserIdentities(gomock.Any(), user.ID).Return([]models.Identity{identity}, nil).Times(1)
+		rr := httptest.NewRecorder()
+
+		handler.UnlinkIdentity(rr, newUnlinkRequest(user.ID, identity.ID))
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
+
+	t.Run("解除 Google 身分時一併移除改版前的 googleId", func(t *testing.T) {
+		user := &models.User{ID: primitive.NewObjectID(), Password: "hashed", GoogleID: "google-legacy"}
+		identity := models.Identity{ID: primitive.NewObjectID(), UserID: user.ID, Provider: "google"}
+		mockUserStore.EXPECT().FindUserByID(gomock.Any(), user.ID).Return(user, nil).Times(1)
+		mockIdentityStore.EXPECT().ListUserIdentities(gomock.Any(), user.ID).Return([]models.Identity{identity}, nil).Times(1)
+		mockIdentityStore.EXPECT().DeleteIdentity(gomock.Any(), user.ID, identity.ID).Return(nil).Times(1)
+		mockUserStore.EXPECT().ClearGoogleID(gomock.Any(), user.ID).Return(nil).Times(1)
+		rr := httptest.NewRecorder()
+
+		handler.UnlinkIdentity(rr, newUnlinkRequest(user.ID, identity.ID))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+}
@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go-chat/backend/config"
+	"go-chat/backend/database"
+	"go-chat/backend/models"
+	"go-chat/backend/store"
+	"go-chat/backend/utils"
+	"go-chat/backend/websocket"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	incomingWebhookTokenPrefix  = "ihk_"
+	maxIncomingWebhookNameLen   = 50
+	maxIncomingWebhookRateLimit = 600
+	maxIncomingWebhookBodyBytes = 64 * 1024
+)
+
+// IncomingWebhookHandler 包含處理 incoming webhook 請求的所有依賴
+type IncomingWebhookHandler struct {
+	Store store.IncomingWebhookStorer
+	Cfg   *config.Config
+}
+
+// NewIncomingWebhookHandler 是一個工廠函式，用於建立新的 IncomingWebhookHandler
+func NewIncomingWebhookHandler(webhookStore store.IncomingWebhookStorer, cfg *config.Config) *IncomingWebhookHandler {
+	return &IncomingWebhookHandler{Store: webhookStore, Cfg: cfg}
+}
+
+// CreateIncomingWebhookRequest 定義建立 incoming webhook 的請求體
+type CreateIncomingWebhookRequest struct {
+	Name               string `json:"name"`
+	RateLimitPerMinute int    `json:"rateLimitPerMinute"` // 留空使用預設值
+}
+
+// IncomingWebhookURLResponse 包含帶有金鑰的 URL，只會在建立或輪替時回傳一次
+type IncomingWebhookURLResponse struct {
+	models.IncomingWebhook
+	URL string `json:"url"`
+}
+
+// IncomingWebhookPayload 是外部系統送來的訊息，text 欄位與 Slack 格式相容
+type IncomingWebhookPayload struct {
+	Content string `json:"content"`
+	Text    string `json:"text"`
+}
+
+// CreateIncomingWebhook 處理建立 incoming webhook 的請求
+// 這個 API 端點會是 POST /chatrooms/{id}/incoming-webhooks
+func (h *IncomingWebhookHandler) CreateIncomingWebhook(w http.ResponseWriter, r *http.Request) {
+	room, userID, ok := requireRoomAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	var req CreateIncomingWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || utf8.RuneCountInString(req.Name) > maxIncomingWebhookNameLen {
+		http.Error(w, "Integration name must be 1-50 characters", http.StatusBadRequest)
+		return
+	}
+	if req.RateLimitPerMinute == 0 {
+		req.RateLimitPerMinute = models.DefaultIncomingWebhookRateLimit
+	}
+	if req.RateLimitPerMinute < 0 || req.RateLimitPerMinute > maxIncomingWebhookRateLimit {
+		http.Error(w, "rateLimitPerMinute must be between 1 and 600", http.StatusBadRequest)
+		return
+	}
+
+	token, err := newIncomingWebhookToken()
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	webhook := models.IncomingWebhook{
+		RoomID:             room.ID,
+		Name:               req.Name,
+		TokenHash:          hashIncomingWebhookToken(token),
+		RateLimitPerMinute: req.RateLimitPerMinute,
+		CreatedBy:          userID,
+		CreatedAt:          time.Now(),
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	webhook.ID, err = h.Store.CreateIncomingWebhook(ctx, webhook)
+	if err != nil {
+		log.Printf("Error creating incoming webhook for room %s: %v", room.ID.Hex(), err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(IncomingWebhookURLResponse{IncomingWebhook: webhook, URL: h.hookURL(token)})
+}
+
+// ListIncomingWebhooks 處理列出聊天室 incoming webhook 的請求 (不包含 URL)
+// 這個 API 端點會是 GET /chatrooms/{id}/incoming-webhooks
+func (h *IncomingWebhookHandler) ListIncomingWebhooks(w http.ResponseWriter, r *http.Request) {
+	room, _, ok := requireRoomAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	hooks, err := h.Store.ListIncomingWebhooksByRoom(ctx, room.ID)
+	if err != nil {
+		log.Printf("Error listing incoming webhooks for room %s: %v", room.ID.Hex(), err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(hooks)
+}
+
+// RotateIncomingWebhook 處理輪替 incoming webhook URL 的請求，舊的 URL 會立即失效
+// 這個 API 端點會是 POST /chatrooms/{id}/incoming-webhooks/{hookId}/rotate
+func (h *IncomingWebhookHandler) RotateIncomingWebhook(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := h.findRoomIncomingWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	token, err := newIncomingWebhookToken()
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	webhook.TokenHash = hashIncomingWebhookToken(token)
+	if err := h.Store.UpdateIncomingWebhookToken(ctx, webhook.ID, webhook.TokenHash); err != nil {
+		log.Printf("Error rotating incoming webhook %s: %v", webhook.ID.Hex(), err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	webhook.RotatedAt = time.Now()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(IncomingWebhookURLResponse{IncomingWebhook: *webhook, URL: h.hookURL(token)})
+}
+
+// DeleteIncomingWebhook 處理撤銷 incoming webhook 的請求
+// 這個 API 端點會是 DELETE /chatrooms/{id}/incoming-webhooks/{hookId}
+func (h *IncomingWebhookHandler) DeleteIncomingWebhook(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := h.findRoomIncomingWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	if err := h.Store.DeleteIncomingWebhook(ctx, webhook.ID); err != nil {
+		log.Printf("Error deleting incoming webhook %s: %v", webhook.ID.Hex(), err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
+// ReceiveIncomingWebhook 處理外部系統送來的訊息，URL 中的金鑰就是唯一的驗證
+// 訊息以整合名稱作為發送者，經由與一般訊息相同的流程儲存並廣播
+// 這個 API 端點會是 POST /hooks/incoming/{token}
+func (h *IncomingWebhookHandler) ReceiveIncomingWebhook(w http.ResponseWriter, r *http.Request) {
+	token := mux.Vars(r)["token"]
+	if !strings.HasPrefix(token, incomingWebhookTokenPrefix) {
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	webhook, err := h.Store.FindIncomingWebhookByTokenHash(ctx, hashIncomingWebhookToken(token))
+	if err == mongo.ErrNoDocuments {
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error finding incoming webhook: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	var payload IncomingWebhookPayload
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxIncomingWebhookBodyBytes)).Decode(&payload); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	content := payload.Content
+	if content == "" {
+		content = payload.Text
+	}
+	if strings.TrimSpace(content) == "" {
+		http.Error(w, "Message content is required", http.StatusBadRequest)
+		return
+	}
+
+	allowed, retryAfter, err := database.AllowSlidingWindow("rate-limit:incoming-webhook:"+webhook.ID.Hex(), webhook.RateLimitPerMinute, time.Minute)
+	if err != nil {
+		log.Printf("Error checking rate limit for incoming webhook %s: %v", webhook.ID.Hex(), err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if !allowed {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
+		return
+	}
+
+	room, err := database.FindChatRoomByID(webhook.RoomID)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if room == nil {
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return
+	}
+
+	msg, err := websocket.PostIntegrationMessage(room, webhook.ID, webhook.Name, content)
+	writeSendMessageResponse(w, msg, err)
+}
+
+// findRoomIncomingWebhook 確認呼叫者是管理員，並取得屬於該聊天室的 incoming webhook
+func (h *IncomingWebhookHandler) findRoomIncomingWebhook(w http.ResponseWriter, r *http.Request) (*models.IncomingWebhook, bool) {
+	room, _, ok := requireRoomAdmin(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	hookID, err := primitive.ObjectIDFromHex(mux.Vars(r)["hookId"])
+	if err != nil {
+		http.Error(w, "Invalid webhook ID format", http.StatusBadRequest)
+		return nil, false
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	webhook, err := h.Store.FindIncomingWebhookByID(ctx, hookID)
+	if err == mongo.ErrNoDocuments || (err == nil && webhook.RoomID != room.ID) {
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("Error finding incoming webhook %s: %v", hookID.Hex(), err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return nil, false
+	}
+	return webhook, true
+}
+
+// hookURL 組出外部系統要呼叫的完整 URL
+func (h *IncomingWebhookHandler) hookURL(token string) string {
+	return h.Cfg.PublicBaseURL + "/hooks/incoming/" + token
+}
+
+// newIncomingWebhookToken 產生 incoming webhook 的明文金鑰
+func newIncomingWebhookToken() (string, error) {
+	return utils.GenerateOpaqueToken(incomingWebhookTokenPrefix)
+}
+
+// hashIncomingWebhookToken 計算金鑰的雜湊值，資料庫只保存雜湊
+func hashIncomingWebhookToken(token string) string {
+	return utils.HashToken(token)
+}
@@ -0,0 +1,84 @@
+// backend/handlers/incoming_webhook_handler_test.go
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-chat/backend/config"
+	"go-chat/backend/models"
+	"go-chat/backend/store/mocks"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/mock/gomock"
+)
+
+// TestReceiveIncomingWebhook 測試外部系統呼叫 incoming webhook 時不需要資料庫的驗證分支
+func TestReceiveIncomingWebhook(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockIncomingWebhookStorer(ctrl)
+	handler := NewIncomingWebhookHandler(mockStore, &config.Config{PublicBaseURL: "https://chat.example.com"})
+
+	// 透過 router 呼叫，讓 mux.Vars 取得路徑中的金鑰
+	router := mux.NewRouter()
+	router.HandleFunc("/hooks/incoming/{token}", handler.ReceiveIncomingWebhook).Methods("POST")
+
+	t.Run("格式錯誤的金鑰直接回 404，不查詢資料庫", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/hooks/incoming/not-a-token", strings.NewReader(`{"content":"hi"}`))
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("不存在或已撤銷的金鑰回 404", func(t *testing.T) {
+		token := incomingWebhookTokenPrefix + "revoked"
+		mockStore.EXPECT().
+			FindIncomingWebhookByTokenHash(gomock.Any(), hashIncomingWebhookToken(token)).
+			Return(nil, mongo.ErrNoDocuments).
+			Times(1)
+
+		req := httptest.NewRequest("POST", "/hooks/incoming/"+token, strings.NewReader(`{"content":"hi"}`))
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("空白內容回 400", func(t *testing.T) {
+		token := incomingWebhookTokenPrefix + "valid"
+		mockStore.EXPECT().
+			FindIncomingWebhookByTokenHash(gomock.Any(), hashIncomingWebhookToken(token)).
+			Return(&models.IncomingWebhook{ID: primitive.NewObjectID(), Name: "CI", RateLimitPerMinute: 30}, nil).
+			Times(1)
+
+		req := httptest.NewRequest("POST", "/hooks/incoming/"+token, strings.NewReader(`{"text":"   "}`))
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestIncomingWebhookToken(t *testing.T) {
+	token, err := newIncomingWebhookToken()
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(token, incomingWebhookTokenPrefix))
+
+	other, _ := newIncomingWebhookToken()
+	assert.NotEqual(t, token, other, "每次產生的金鑰都應該不同")
+	assert.NotEqual(t, token, hashIncomingWebhookToken(token), "資料庫只應保存雜湊")
+
+	handler := NewIncomingWebhookHandler(nil, &config.Config{PublicBaseURL: "https://chat.example.com"})
+	assert.Equal(t, "https://chat.example.com/hooks/incoming/"+token, handler.hookURL(token))
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"go-chat/backend/jwtkeys"
+)
+
+// JWKSHandler 公開驗證 access token 用的公鑰，讓其他內部服務不需要共用密鑰就能驗證 token
+type JWKSHandler struct {
+	// Keys 為 nil 表示使用 HS256，沒有可以公開的金鑰
+	Keys *jwtkeys.Manager
+}
+
+// NewJWKSHandler 是一個工廠函式，用於建立新的 JWKSHandler
+func NewJWKSHandler(keys *jwtkeys.Manager) *JWKSHandler {
+	return &JWKSHandler{Keys: keys}
+}
+
+// ServeJWKS 回傳目前使用中以及仍在寬限期內的公鑰
+// 這個 API 端點會是 GET /.well-known/jwks.json
+func (h *JWKSHandler) ServeJWKS(w http.ResponseWriter, r *http.Request) {
+	set := jwtkeys.JSONWebKeySet{Keys: []jwtkeys.JSONWebKey{}}
+	if h.Keys != nil {
+		set = h.Keys.JWKS()
+	}
+
+	// 輪替後其他實例最慢一分鐘內才會載入新金鑰，快取時間不宜更長
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(set)
+}
+
+// RotateJWTKey 讓系統管理員立即輪替簽章金鑰，例如懷疑私鑰外洩時
+// 這個 API 端點會是 POST /admin/jwt-keys/rotate
+func (h *JWKSHandler) RotateJWTKey(w http.ResponseWriter, r *http.Request) {
+	if h.Keys == nil {
+		sendJSONError(w, "JWT key rotation requires JWT_ALGORITHM RS256 or EdDSA", http.StatusConflict)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	if err := h.Keys.Rotate(ctx); err != nil {
+		log.Printf("Error rotating JWT signing key: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	signingKey, err := h.Keys.SigningKey()
+	if err != nil {
+		log.Printf("Error loading JWT signing key after rotation: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "JWT signing key rotated", "kid": signingKey.ID})
+}
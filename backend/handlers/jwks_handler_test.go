@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-chat/backend/config"
+	"go-chat/backend/jwtkeys"
+	"go-chat/backend/models"
+	"go-chat/backend/store/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestServeJWKS(t *testing.T) {
+	t.Run("HS256 沒有可公開的金鑰", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		NewJWKSHandler(nil).ServeJWKS(rr, httptest.NewRequest("GET", "/.well-known/jwks.json", nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"keys":[]}`, rr.Body.String())
+	})
+
+	t.Run("回傳使用中的公鑰", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// 第一次載入時沒有金鑰，產生新金鑰後重新載入會讀到它
+		var created []models.JWTKey
+		mockKeyStore := mocks.NewMockJWTKeyStorer(ctrl)
+		mockKeyStore.EXPECT().ListJWTKeys(gomock.Any(), gomock.Any()).DoAndReturn(func(context.Context, time.Time) ([]models.JWTKey, error) {
+			return created, nil
+		}).Times(2)
+		mockKeyStore.EXPECT().CreateJWTKey(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, key models.JWTKey) error {
+			created = append(created, key)
+			return nil
+		}).Times(1)
+		mockKeyStore.EXPECT().RetireJWTKeysBefore(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+
+		manager := jwtkeys.NewManager(mockKeyStore, jwtkeys.Options{
+			Algorithm:        config.JWTAlgorithmEdDSA,
+			EncryptionKey:    []byte("0123456789abcdef0123456789abcdef"),
+			RotationInterval: time.Hour,
+			GracePeriod:      time.Hour,
+		})
+		require.NoError(t, manager.Load(context.Background()))
+
+		rr := httptest.NewRecorder()
+		NewJWKSHandler(manager).ServeJWKS(rr, httptest.NewRequest("GET", "/.well-known/jwks.json", nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "public, max-age=60", rr.Header().Get("Cache-Control"))
+		var set jwtkeys.JSONWebKeySet
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &set))
+		require.Len(t, set.Keys, 1)
+		assert.Equal(t, created[0].ID, set.Keys[0].Kid)
+		assert.Equal(t, "EdDSA", set.Keys[0].Alg)
+		assert.NotContains(t, rr.Body.String(), `"d"`, "不應該公開私鑰")
+	})
+}
+
+func TestRotateJWTKey_HS256(t *testing.T) {
+	rr := httptest.NewRecorder()
+	NewJWKSHandler(nil).RotateJWTKey(rr, httptest.NewRequest("POST", "/admin/jwt-keys/rotate", nil))
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+}
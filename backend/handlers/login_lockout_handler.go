@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-chat/backend/models"
+	"go-chat/backend/utils"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// UnlockUserLogin 讓系統管理員立即解除使用者因連續登入失敗造成的鎖定
+// 這個 API 端點會是 POST /admin/users/{id}/unlock
+func (h *AuthHandler) UnlockUserLogin(w http.ResponseWriter, r *http.Request) {
+	userID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		sendJSONError(w, "Invalid user ID format", http.StatusBadRequest)
+		return
+	}
+	if h.Throttle == nil {
+		sendJSONError(w, "Login throttling is not enabled", http.StatusNotFound)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.UserStore.FindUserByID(ctx, userID)
+	if err == mongo.ErrNoDocuments {
+		sendJSONError(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error finding user %s: %v", userID.Hex(), err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	account := strings.ToLower(strings.TrimSpace(user.Email))
+	if err := h.Throttle.Unlock(ctx, account); err != nil {
+		log.Printf("Error unlocking login for user %s: %v", userID.Hex(), err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	adminID, _ := utils.GetUserIDFromContext(r.Context())
+	h.writeAuditLog(ctx, models.AuditLog{
+		ID:        primitive.NewObjectID(),
+		Action:    models.AuditActionAccountUnlocked,
+		Email:     account,
+		UserID:    user.ID,
+		ActorID:   adminID,
+		IP:        utils.GetClientIP(r),
+		CreatedAt: time.Now(),
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Account unlocked"})
+}
+
+// ListAuditLogs 讓系統管理員查看最近的稽核紀錄
+// 可用 ?action=account_locked 只查看特定事件，?limit= 預設 50、最多 200
+// 這個 API 端點會是 GET /admin/audit-logs
+func (h *AuthHandler) ListAuditLogs(w http.ResponseWriter, r *http.Request) {
+	if h.AuditLogs == nil {
+		sendJSONError(w, "Audit log is not enabled", http.StatusNotFound)
+		return
+	}
+
+	limit := int64(50)
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.ParseInt(limitStr, 10, 64)
+		if err != nil || parsed <= 0 {
+			sendJSONError(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = min(parsed, 200)
+	}
+	action := models.AuditAction(r.URL.Query().Get("action"))
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	entries, err := h.AuditLogs.ListAuditLogs(ctx, action, limit)
+	if err != nil {
+		log.Printf("Error listing audit logs: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-chat/backend/config"
+	"go-chat/backend/models"
+	"go-chat/backend/store/mocks"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestLoginUser_Throttle(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserStore := mocks.NewMockUserStorer(ctrl)
+	mockSessionStore := mocks.NewMockSessionStorer(ctrl)
+	mockTokenStore := mocks.NewMockRefreshTokenStorer(ctrl)
+	mockThrottle := mocks.NewMockLoginThrottler(ctrl)
+	mockAuditLogs := mocks.NewMockAuditLogStorer(ctrl)
+	cfg := &config.Config{JWTSecret: "test-secret", AccessTokenTTL: 15 * time.Minute, RefreshTokenTTL: time.Hour}
+	authHandler := NewAuthHandler(mockUserStore, mockTokenStore, mockSessionStore, mocks.NewMockTokenDenylist(ctrl), cfg)
+	authHandler.Throttle = mockThrottle
+	authHandler.AuditLogs = mockAuditLogs
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("correct-password"), bcrypt.MinCost)
+	user := &models.User{ID: primitive.NewObjectID(), Email: "user@example.com", Username: "user", Password: string(hashedPassword)}
+
+	login := func(email, password string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := newJSONRequest("POST", "/login", map[string]string{"email": email, "password": password})
+		req.RemoteAddr = "203.0.113.7:5555"
+		authHandler.LoginUser(rr, req)
+		return rr
+	}
+
+	t.Run("超過限流或鎖定中時回 429，不會查詢使用者", func(t *testing.T) {
+		mockThrottle.EXPECT().CheckLogin(gomock.Any(), "203.0.113.7", "user@example.com").Return(false, 90*time.Second, nil).Times(1)
+
+		rr := login("User@Example.com", "correct-password")
+
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		assert.Equal(t, "90", rr.Header().Get("Retry-After"))
+	})
+
+	t.Run("密碼錯誤會記錄失敗", func(t *testing.T) {
+		mockThrottle.EXPECT().CheckLogin(gomock.Any(), gomock.Any(), "user@example.com").Return(true, time.Duration(0), nil).Times(1)
+		mockUserStore.EXPECT().FindUserByEmail(gomock.Any(), user.Email).Return(user, nil).Times(1)
+		mockThrottle.EXPECT().RecordFailure(gomock.Any(), "user@example.com").Return(time.Duration(0), nil).Times(1)
+
+		assert.Equal(t, http.StatusUnauthorized, login(user.Email, "wrong-password").Code)
+	})
+
+	t.Run("觸發鎖定時回 429 並寫入稽核紀錄", func(t *testing.T) {
+		mockThrottle.EXPECT().CheckLogin(gomock.Any(), gomock.Any(), "user@example.com").Return(true, time.Duration(0), nil).Times(1)
+		mockUserStore.EXPECT().FindUserByEmail(gomock.Any(), user.Email).Return(user, nil).Times(1)
+		mockThrottle.EXPECT().RecordFailure(gomock.Any(), "user@example.com").Return(2*time.Minute, nil).Times(1)
+		mockAuditLogs.EXPECT().
+			CreateAuditLog(gomock.Any(), gomock.Any()).
+			Do(func(_ interface{}, entry models.AuditLog) {
+				assert.Equal(t, models.AuditActionAccountLocked, entry.Action)
+				assert.Equal(t, user.ID, entry.UserID)
+				assert.Equal(t, "203.0.113.7", entry.IP)
+				assert.Equal(t, int64(120), entry.LockoutSeconds)
+			}).
+			Return(nil).
+			Times(1)
+
+		rr := login(user.Email, "wrong-password")
+
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		assert.Equal(t, "120", rr.Header().Get("Retry-After"))
+	})
+
+	t.Run("不存在的帳號也以相同方式計數", func(t *testing.T) {
+		mockThrottle.EXPECT().CheckLogin(gomock.Any(), gomock.Any(), "nobody@example.com").Return(true, time.Duration(0), nil).Times(1)
+		mockUserStore.EXPECT().FindUserByEmail(gomock.Any(), "nobody@example.com").Return(nil, mongo.ErrNoDocuments).Times(1)
+		mockThrottle.EXPECT().RecordFailure(gomock.Any(), "nobody@example.com").Return(time.Duration(0), nil).Times(1)
+
+		assert.Equal(t, http.StatusUnauthorized, login("nobody@example.com", "whatever-password").Code)
+	})
+
+	t.Run("登入成功會清除失敗紀錄", func(t *testing.T) {
+		mockThrottle.EXPECT().CheckLogin(gomock.Any(), gomock.Any(), "user@example.com").Return(true, time.Duration(0), nil).Times(1)
+		mockUserStore.EXPECT().FindUserByEmail(gomock.Any(), user.Email).Return(user, nil).Times(1)
+		mockThrottle.EXPECT().RecordSuccess(gomock.Any(), "user@example.com").Return(nil).Times(1)
+		mockSessionStore.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+		mockTokenStore.EXPECT().CreateRefreshToken(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+
+		assert.Equal(t, http.StatusOK, login(user.Email, "correct-password").Code)
+	})
+}
+
+func TestUnlockUserLogin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserStore := mocks.NewMockUserStorer(ctrl)
+	mockThrottle := mocks.NewMockLoginThrottler(ctrl)
+	mockAuditLogs := mocks.NewMockAuditLogStorer(ctrl)
+	authHandler := NewAuthHandler(mockUserStore, mocks.NewMockRefreshTokenStorer(ctrl), mocks.NewMockSessionStorer(ctrl), mocks.NewMockTokenDenylist(ctrl), &config.Config{})
+	authHandler.Throttle = mockThrottle
+	authHandler.AuditLogs = mockAuditLogs
+
+	adminID := primitive.NewObjectID()
+	newUnlockRequest := func(userID string) *http.Request {
+		req := httptest.NewRequest("POST", "/admin/users/"+userID+"/unlock", nil)
+		return withSession(mux.SetURLVars(req, map[string]string{"id": userID}), adminID, primitive.NewObjectID())
+	}
+
+	t.Run("使用者不存在回 404", func(t *testing.T) {
+		userID := primitive.NewObjectID()
+		mockUserStore.EXPECT().FindUserByID(gomock.Any(), userID).Return(nil, mongo.ErrNoDocuments).Times(1)
+		rr := httptest.NewRecorder()
+
+		authHandler.UnlockUserLogin(rr, newUnlockRequest(userID.Hex()))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("解除鎖定並記錄執行的管理員", func(t *testing.T) {
+		user := &models.User{ID: primitive.NewObjectID(), Email: "Locked@Example.com"}
+		mockUserStore.EXPECT().FindUserByID(gomock.Any(), user.ID).Return(user, nil).Times(1)
+		mockThrottle.EXPECT().Unlock(gomock.Any(), "locked@example.com").Return(nil).Times(1)
+		mockAuditLogs.EXPECT().
+			CreateAuditLog(gomock.Any(), gomock.Any()).
+			Do(func(_ interface{}, entry models.AuditLog) {
+				assert.Equal(t, models.AuditActionAccountUnlocked, entry.Action)
+				assert.Equal(t, adminID, entry.ActorID)
+			}).
+			Return(nil).
+			Times(1)
+		rr := httptest.NewRecorder()
+
+		authHandler.UnlockUserLogin(rr, newUnlockRequest(user.ID.Hex()))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+}
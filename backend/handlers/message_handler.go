@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"go-chat/backend/database"
+	"go-chat/backend/models"
+	"go-chat/backend/utils"
+	"go-chat/backend/websocket"
+
+	"github.com/gorilla/mux"
+)
+
+// SendMessageRequest 定義透過 REST 發送訊息的請求體
+type SendMessageRequest struct {
+	Content string `json:"content"`
+}
+
+// SendChatMessage 處理透過 REST 發送訊息的請求
+// 與 WebSocket 的 readPump 共用 websocket.SendMessage 的驗證、儲存與廣播流程
+// 這個 API 端點會是 POST /chatrooms/{id}/messages
+func SendChatMessage(w http.ResponseWriter, r *http.Request) {
+	userID, err := utils.GetUserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req SendMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, err := database.GetUserByID(userID)
+	if err != nil {
+		log.Printf("Error getting sender %s: %v", userID.Hex(), err)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	sender := websocket.MessageSender{ID: user.ID, Username: user.Username, IsBot: user.IsBot}
+	msg, err := websocket.SendMessage(sender, mux.Vars(r)["id"], req.Content)
+	writeSendMessageResponse(w, msg, err)
+}
+
+// writeSendMessageResponse 將 websocket.SendMessage 的結果轉換成 HTTP 回應
+func writeSendMessageResponse(w http.ResponseWriter, msg *models.Message, err error) {
+	switch {
+	case errors.Is(err, websocket.ErrInvalidRoomID):
+		http.Error(w, "Invalid room ID format", http.StatusBadRequest)
+		return
+	case errors.Is(err, websocket.ErrEmptyMessage):
+		http.Error(w, "Message content is required", http.StatusBadRequest)
+		return
+	case errors.Is(err, websocket.ErrMessageTooLong):
+		http.Error(w, "Message content is too long", http.StatusBadRequest)
+		return
+	case errors.Is(err, websocket.ErrSenderMuted):
+		http.Error(w, "You are muted in this chat room", http.StatusForbidden)
+		return
+	case errors.Is(err, websocket.ErrRoomArchived):
+		http.Error(w, "Chat room is archived", http.StatusConflict)
+		return
+	case errors.Is(err, websocket.ErrRoomNotFound), errors.Is(err, websocket.ErrNotParticipant):
+		// 非成員一律回 404，避免洩漏聊天室是否存在
+		http.Error(w, "Chat room not found", http.StatusNotFound)
+		return
+	case err != nil:
+		log.Printf("Error sending message: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if msg == nil {
+		// 斜線指令已執行，私人回覆會送到發送者的 WebSocket 連線
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Command executed"})
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(msg)
+}
@@ -0,0 +1,315 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go-chat/backend/database"
+	"go-chat/backend/models"
+	"go-chat/backend/websocket"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxSanctionDurationSeconds 是封鎖與禁言的最長期限 (365 天)
+const maxSanctionDurationSeconds = 365 * 24 * 60 * 60
+
+// ModerationRequest 定義移出、封鎖與禁言的請求體，所有欄位都可以省略
+type ModerationRequest struct {
+	Reason          string `json:"reason"`
+	DurationSeconds int    `json:"durationSeconds"` // 封鎖為 0 表示永久；禁言必須指定期限
+}
+
+// KickMember 處理管理員將成員移出聊天室的請求，被移出的使用者之後仍可再被邀請
+// 這個 API 端點會是 POST /chatrooms/{id}/members/{userId}/kick
+func KickMember(w http.ResponseWriter, r *http.Request) {
+	room, actorID, targetID, ok := requireModerationTarget(w, r)
+	if !ok {
+		return
+	}
+	req, ok := decodeModerationRequest(w, r)
+	if !ok {
+		return
+	}
+	if !isRoomParticipant(room, targetID) {
+		http.Error(w, "User is not a member of this chat room", http.StatusNotFound)
+		return
+	}
+
+	actorName, targetName := moderationUsernames(actorID, targetID)
+	content := withReason(actorName+" 已將 "+targetName+" 移出聊天室", req.Reason)
+	if err := removeRoomMember(room, targetID, actorID, content); err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	websocket.NotifyRoomRemoved(targetID, room, withReason("你已被 "+actorName+" 移出聊天室", req.Reason))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
+// BanMember 處理封鎖使用者的請求；使用者是成員時會一併移出聊天室
+// 封鎖期間不能透過邀請、邀請連結或頻道目錄再加入
+// 這個 API 端點會是 PUT /chatrooms/{id}/bans/{userId}
+func BanMember(w http.ResponseWriter, r *http.Request) {
+	room, actorID, targetID, ok := requireModerationTarget(w, r)
+	if !ok {
+		return
+	}
+	req, ok := decodeModerationRequest(w, r)
+	if !ok {
+		return
+	}
+
+	sanction, err := database.SetRoomSanction(newRoomSanction(room.ID, targetID, actorID, models.RoomSanctionBan, req))
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if isRoomParticipant(room, targetID) {
+		actorName, targetName := moderationUsernames(actorID, targetID)
+		content := withReason(actorName+" 已將 "+targetName+" 封鎖"+durationSuffix(req.DurationSeconds), req.Reason)
+		if err := removeRoomMember(room, targetID, actorID, content); err != nil {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		websocket.NotifyRoomRemoved(targetID, room, withReason("你已被 "+actorName+" 封鎖，無法再加入此聊天室", req.Reason))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sanction)
+}
+
+// UnbanMember 處理解除封鎖的請求
+// 這個 API 端點會是 DELETE /chatrooms/{id}/bans/{userId}
+func UnbanMember(w http.ResponseWriter, r *http.Request) {
+	if _, _, _, ok := deleteRoomSanction(w, r, models.RoomSanctionBan); !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
+// ListBans 處理列出聊天室目前封鎖名單的請求
+// 這個 API 端點會是 GET /chatrooms/{id}/bans
+func ListBans(w http.ResponseWriter, r *http.Request) {
+	listRoomSanctions(w, r, models.RoomSanctionBan)
+}
+
+// MuteMember 處理禁言成員的請求，禁言期間該成員發送的訊息都會被拒絕
+// 這個 API 端點會是 PUT /chatrooms/{id}/mutes/{userId}
+func MuteMember(w http.ResponseWriter, r *http.Request) {
+	room, actorID, targetID, ok := requireModerationTarget(w, r)
+	if !ok {
+		return
+	}
+	req, ok := decodeModerationRequest(w, r)
+	if !ok {
+		return
+	}
+	if req.DurationSeconds == 0 {
+		http.Error(w, "durationSeconds is required for mutes", http.StatusBadRequest)
+		return
+	}
+	if !isRoomParticipant(room, targetID) {
+		http.Error(w, "User is not a member of this chat room", http.StatusNotFound)
+		return
+	}
+
+	sanction, err := database.SetRoomSanction(newRoomSanction(room.ID, targetID, actorID, models.RoomSanctionMute, req))
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	actorName, targetName := moderationUsernames(actorID, targetID)
+	announceRoomChange(room, actorID, withReason(actorName+" 已將 "+targetName+" 禁言"+durationSuffix(req.DurationSeconds), req.Reason))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sanction)
+}
+
+// UnmuteMember 處理解除禁言的請求
+// 這個 API 端點會是 DELETE /chatrooms/{id}/mutes/{userId}
+func UnmuteMember(w http.ResponseWriter, r *http.Request) {
+	room, actorID, targetID, ok := deleteRoomSanction(w, r, models.RoomSanctionMute)
+	if !ok {
+		return
+	}
+	actorName, targetName := moderationUsernames(actorID, targetID)
+	announceRoomChange(room, actorID, actorName+" 已解除 "+targetName+" 的禁言")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
+// ListMutes 處理列出聊天室目前禁言名單的請求
+// 這個 API 端點會是 GET /chatrooms/{id}/mutes
+func ListMutes(w http.ResponseWriter, r *http.Request) {
+	listRoomSanctions(w, r, models.RoomSanctionMute)
+}
+
+// deleteRoomSanction 解除封鎖或禁言，成功時回傳聊天室、管理員與對象，由呼叫者寫入回應
+func deleteRoomSanction(w http.ResponseWriter, r *http.Request, kind models.RoomSanctionKind) (*models.ChatRoom, primitive.ObjectID, primitive.ObjectID, bool) {
+	room, actorID, targetID, ok := requireModerationTarget(w, r)
+	if !ok {
+		return nil, primitive.NilObjectID, primitive.NilObjectID, false
+	}
+
+	deleted, err := database.DeleteRoomSanction(room.ID, targetID, kind)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return nil, primitive.NilObjectID, primitive.NilObjectID, false
+	}
+	if !deleted {
+		http.Error(w, "No active "+string(kind)+" for this user", http.StatusNotFound)
+		return nil, primitive.NilObjectID, primitive.NilObjectID, false
+	}
+	return room, actorID, targetID, true
+}
+
+// listRoomSanctions 列出聊天室尚未到期的封鎖或禁言紀錄 (僅管理員)
+func listRoomSanctions(w http.ResponseWriter, r *http.Request, kind models.RoomSanctionKind) {
+	room, _, ok := requireRoomAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	sanctions, err := database.ListRoomSanctions(room.ID, kind)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sanctions)
+}
+
+// requireModerationTarget 確認呼叫者是管理員，並取得路徑中要處分的使用者
+// 私訊不支援管理操作；不能處分自己與擁有者，只有擁有者可以處分其他管理員
+func requireModerationTarget(w http.ResponseWriter, r *http.Request) (*models.ChatRoom, primitive.ObjectID, primitive.ObjectID, bool) {
+	room, actorID, ok := requireRoomAdmin(w, r)
+	if !ok {
+		return nil, primitive.NilObjectID, primitive.NilObjectID, false
+	}
+
+	targetID, err := primitive.ObjectIDFromHex(mux.Vars(r)["userId"])
+	if err != nil {
+		http.Error(w, "Invalid user ID format", http.StatusBadRequest)
+		return nil, primitive.NilObjectID, primitive.NilObjectID, false
+	}
+	if err := checkModerationAllowed(room, actorID, targetID); err != nil {
+		status := http.StatusForbidden
+		switch err {
+		case errDirectMessageClosed:
+			status = http.StatusConflict
+		case errCannotModerateSelf:
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return nil, primitive.NilObjectID, primitive.NilObjectID, false
+	}
+	return room, actorID, targetID, true
+}
+
+// 管理操作的權限錯誤
+var (
+	errCannotModerateSelf  = errors.New("you cannot moderate yourself")
+	errCannotModerateOwner = errors.New("the room owner cannot be moderated")
+	errCannotModerateAdmin = errors.New("only the room owner can moderate other admins")
+)
+
+// checkModerationAllowed 檢查 actorID 是否可以對 targetID 執行管理操作，呼叫前需確認 actorID 是管理員
+func checkModerationAllowed(room *models.ChatRoom, actorID, targetID primitive.ObjectID) error {
+	switch {
+	case room.EffectiveKind() == models.RoomKindDM:
+		return errDirectMessageClosed
+	case actorID == targetID:
+		return errCannotModerateSelf
+	case targetID == room.Owner():
+		return errCannotModerateOwner
+	case room.IsAdmin(targetID) && actorID != room.Owner():
+		return errCannotModerateAdmin
+	}
+	return nil
+}
+
+// decodeModerationRequest 解析並驗證請求體，沒有請求體時視為所有欄位皆省略
+func decodeModerationRequest(w http.ResponseWriter, r *http.Request) (ModerationRequest, bool) {
+	var req ModerationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return req, false
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if utf8.RuneCountInString(req.Reason) > models.MaxSanctionReasonLength {
+		http.Error(w, "Reason must be at most 200 characters", http.StatusBadRequest)
+		return req, false
+	}
+	if req.DurationSeconds < 0 || req.DurationSeconds > maxSanctionDurationSeconds {
+		http.Error(w, "durationSeconds must be between 0 and 31536000", http.StatusBadRequest)
+		return req, false
+	}
+	return req, true
+}
+
+// newRoomSanction 依請求建立處分紀錄
+func newRoomSanction(roomID, targetID, actorID primitive.ObjectID, kind models.RoomSanctionKind, req ModerationRequest) models.RoomSanction {
+	now := time.Now()
+	sanction := models.RoomSanction{
+		RoomID:    roomID,
+		UserID:    targetID,
+		Kind:      kind,
+		Reason:    req.Reason,
+		CreatedBy: actorID,
+		CreatedAt: now,
+	}
+	if req.DurationSeconds > 0 {
+		sanction.ExpiresAt = now.Add(time.Duration(req.DurationSeconds) * time.Second)
+	}
+	return sanction
+}
+
+// moderationUsernames 取得管理員與對象的使用者名稱，查詢失敗時使用備用名稱
+func moderationUsernames(actorID, targetID primitive.ObjectID) (string, string) {
+	actorName, targetName := "管理員", "某人"
+	users, err := database.GetUsersByIDs([]primitive.ObjectID{actorID, targetID})
+	if err != nil {
+		log.Printf("Error getting users for moderation message: %v", err)
+		return actorName, targetName
+	}
+	for _, user := range users {
+		switch user.ID {
+		case actorID:
+			actorName = user.Username
+		case targetID:
+			targetName = user.Username
+		}
+	}
+	return actorName, targetName
+}
+
+// durationSuffix 產生系統訊息中的期限說明，0 表示永久
+func durationSuffix(seconds int) string {
+	if seconds == 0 {
+		return ""
+	}
+	return " " + (time.Duration(seconds) * time.Second).String()
+}
+
+// withReason 在系統訊息後附上處分原因
+func withReason(content, reason string) string {
+	if reason == "" {
+		return content
+	}
+	return content + "（原因：" + reason + "）"
+}
@@ -0,0 +1,75 @@
+// backend/handlers/moderation_handler_test.go
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-chat/backend/models"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCheckModerationAllowed(t *testing.T) {
+	creator, admin, otherAdmin, member := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	owner := primitive.NewObjectID()
+	room := &models.ChatRoom{
+		Kind:         models.RoomKindGroup,
+		CreatorID:    creator,
+		Participants: []primitive.ObjectID{creator, admin, otherAdmin, member},
+		Admins:       []primitive.ObjectID{admin, otherAdmin},
+	}
+
+	assert.NoError(t, checkModerationAllowed(room, admin, member), "管理員可以處分一般成員")
+	assert.NoError(t, checkModerationAllowed(room, creator, admin), "建立者可以處分其他管理員")
+	assert.NoError(t, checkModerationAllowed(room, admin, primitive.NewObjectID()), "可以預先封鎖不在聊天室中的使用者")
+	assert.Equal(t, errCannotModerateSelf, checkModerationAllowed(room, admin, admin))
+	assert.Equal(t, errCannotModerateOwner, checkModerationAllowed(room, admin, creator))
+	assert.Equal(t, errCannotModerateAdmin, checkModerationAllowed(room, admin, otherAdmin))
+
+	// 擁有權轉移後，保護與處分管理員的權限跟著擁有者走
+	transferred := *room
+	transferred.OwnerID = owner
+	transferred.Participants = append(transferred.Participants, owner)
+	transferred.Admins = append(transferred.Admins, creator)
+	assert.Equal(t, errCannotModerateOwner, checkModerationAllowed(&transferred, admin, owner))
+	assert.Equal(t, errCannotModerateAdmin, checkModerationAllowed(&transferred, creator, admin), "原建立者不再擁有擁有者權限")
+	assert.NoError(t, checkModerationAllowed(&transferred, owner, creator))
+
+	dm := &models.ChatRoom{Kind: models.RoomKindDM, CreatorID: creator, Participants: []primitive.ObjectID{creator, member}}
+	assert.Equal(t, errDirectMessageClosed, checkModerationAllowed(dm, creator, member), "私訊不支援管理操作")
+}
+
+func TestDecodeModerationRequest(t *testing.T) {
+	decode := func(body string) (ModerationRequest, int, bool) {
+		rr := httptest.NewRecorder()
+		req, ok := decodeModerationRequest(rr, httptest.NewRequest("PUT", "/chatrooms/x/bans/y", strings.NewReader(body)))
+		return req, rr.Code, ok
+	}
+
+	req, _, ok := decode("")
+	assert.True(t, ok, "沒有請求體時所有欄位皆省略")
+	assert.Equal(t, ModerationRequest{}, req)
+
+	req, _, ok = decode(`{"reason":"  洗版  ","durationSeconds":600}`)
+	assert.True(t, ok)
+	assert.Equal(t, ModerationRequest{Reason: "洗版", DurationSeconds: 600}, req)
+
+	_, code, ok := decode(`{"durationSeconds":-1}`)
+	assert.False(t, ok)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	_, code, ok = decode(`{"reason":"` + strings.Repeat("長", models.MaxSanctionReasonLength+1) + `"}`)
+	assert.False(t, ok)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestModerationMessages(t *testing.T) {
+	assert.Equal(t, "alice 已將 bob 移出聊天室", withReason("alice 已將 bob 移出聊天室", ""))
+	assert.Equal(t, "alice 已將 bob 封鎖（原因：洗版）", withReason("alice 已將 bob 封鎖", "洗版"))
+	assert.Equal(t, "", durationSuffix(0), "永久處分不顯示期限")
+	assert.Equal(t, " 30m0s", durationSuffix(1800))
+}
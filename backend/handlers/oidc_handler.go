@@ -0,0 +1,388 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go-chat/backend/models"
+	"go-chat/backend/oidc"
+	"go-chat/backend/store"
+	"go-chat/backend/utils"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/oauth2"
+)
+
+// oidcStateCookieName 保存這次登入經過簽署的 state、nonce 與 PKCE verifier，只會送到同一個提供者的 callback
+const oidcStateCookieName = "oauthstate"
+
+// oidcStateTTL 是從導向提供者到 callback 之間允許的時間
+const oidcStateTTL = 10 * time.Minute
+
+// oidcLoginState 是每次登入各自產生、以 HMAC 簽署後存在瀏覽器 cookie 中的狀態
+// state 與 nonce 分別對應授權請求的 state 參數與 ID token 的 nonce，綁定發起登入的瀏覽器
+type oidcLoginState struct {
+	Provider  string `json:"p"`
+	State     string `json:"s"`
+	Nonce     string `json:"n"`
+	Verifier  string `json:"v"`
+	ReturnTo  string `json:"r"`
+	ExpiresAt int64  `json:"e"`
+	// LinkUserID 不為空時，這次授權是已登入的使用者要連結新的身分，而不是登入
+	LinkUserID string `json:"l,omitempty"`
+}
+
+// OIDCHandler 處理以 OpenID Connect 提供者登入
+type OIDCHandler struct {
+	Auth          *AuthHandler
+	Providers     *oidc.Registry
+	IdentityStore store.IdentityStorer
+}
+
+// NewOIDCHandler 是一個工廠函式，用於建立新的 OIDCHandler
+func NewOIDCHandler(auth *AuthHandler, providers *oidc.Registry, identityStore store.IdentityStorer) *OIDCHandler {
+	return &OIDCHandler{Auth: auth, Providers: providers, IdentityStore: identityStore}
+}
+
+// ListProviders 列出可用於登入的提供者，前端依此顯示登入按鈕
+// 這個 API 端點會是 GET /auth/providers
+func (h *OIDCHandler) ListProviders(w http.ResponseWriter, r *http.Request) {
+	providers := []models.OIDCProviderInfo{}
+	for _, provider := range h.Providers.List() {
+		providers = append(providers, models.OIDCProviderInfo{ID: provider.ID, DisplayName: provider.DisplayName})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(providers)
+}
+
+// HandleOIDCLogin 產生這次登入的 state、nonce 與 PKCE verifier，並導向提供者的登入頁面
+// 可用 ?redirect= 指定登入後要回到的網址，只接受 AllowedRedirectOrigins 中的來源
+// 這個 API 端點會是 GET /auth/{provider}/login
+func (h *OIDCHandler) HandleOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	h.redirectToProvider(w, r, "")
+}
+
+// redirectToProvider 將這次授權的狀態存入簽署過的 cookie 並導向提供者，linkUserID 不為空時表示要連結身分
+func (h *OIDCHandler) redirectToProvider(w http.ResponseWriter, r *http.Request, linkUserID string) {
+	provider, err := h.Providers.Get(mux.Vars(r)["provider"])
+	if err != nil {
+		sendJSONError(w, "Unknown login provider", http.StatusNotFound)
+		return
+	}
+
+	state, err := randomURLString()
+	if err != nil {
+		log.Printf("Error generating oauth state: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	nonce, err := randomURLString()
+	if err != nil {
+		log.Printf("Error generating oidc nonce: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	loginState := oidcLoginState{
+		Provider:   provider.ID,
+		State:      state,
+		Nonce:      nonce,
+		Verifier:   oauth2.GenerateVerifier(),
+		ReturnTo:   h.postLoginRedirect(r.URL.Query().Get("redirect")),
+		ExpiresAt:  time.Now().Add(oidcStateTTL).Unix(),
+		LinkUserID: linkUserID,
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	authURL, err := provider.AuthCodeURL(ctx, state, loginState.Verifier, oauth2.SetAuthURLParam("nonce", nonce))
+	if err != nil {
+		log.Printf("Error preparing %s login: %v", provider.ID, err)
+		h.redirectToAuthPage(w, r, "provider_unavailable")
+		return
+	}
+
+	payload, err := json.Marshal(loginState)
+	if err != nil {
+		log.Printf("Error encoding oauth state: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookieName,
+		Value:    utils.SignString(h.Auth.Cfg.OAuthStateKey, string(payload)),
+		Path:     "/auth/" + provider.ID + "/",
+		MaxAge:   int(oidcStateTTL.Seconds()),
+		HttpOnly: true,
+		// cookie 內含 PKCE verifier 與 nonce，只在 HTTPS 連線下傳送；開發環境可能沒有 HTTPS
+		Secure: !h.Auth.Cfg.DevMode,
+		// 提供者導回 callback 是跨站的頂層導覽，Lax 才會帶上 cookie
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, authURL, http.StatusTemporaryRedirect)
+}
+
+// HandleOIDCCallback 處理提供者導回的請求：驗證 state、以 PKCE 換取並驗證 ID token，再登入對應的使用者
+// 這個 API 端點會是 GET /auth/{provider}/callback
+func (h *OIDCHandler) HandleOIDCCallback(w http.ResponseWriter, r *http.Request) {
+	provider, err := h.Providers.Get(mux.Vars(r)["provider"])
+	if err != nil {
+		sendJSONError(w, "Unknown login provider", http.StatusNotFound)
+		return
+	}
+
+	// state cookie 只能使用一次
+	http.SetCookie(w, &http.Cookie{Name: oidcStateCookieName, Value: "", Path: "/auth/" + provider.ID + "/", MaxAge: -1, HttpOnly: true, Secure: !h.Auth.Cfg.DevMode})
+
+	loginState, err := h.readLoginState(r, provider.ID)
+	if err != nil {
+		log.Printf("Invalid oauth state for %s: %v", provider.ID, err)
+		h.redirectToAuthPage(w, r, "invalid_state")
+		return
+	}
+	if providerErr := r.FormValue("error"); providerErr != "" {
+		log.Printf("%s login failed: %s", provider.ID, providerErr)
+		h.redirectToAuthPage(w, r, "login_cancelled")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	claims, err := provider.Exchange(ctx, r.FormValue("code"), loginState.Verifier)
+	if err != nil {
+		log.Printf("Error exchanging %s authorization code: %v", provider.ID, err)
+		h.redirectToAuthPage(w, r, "login_failed")
+		return
+	}
+	// nonce 確保 ID token 是為這次登入簽發的，不是從其他登入重放
+	if subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(loginState.Nonce)) != 1 {
+		log.Printf("Invalid id token nonce from %s", provider.ID)
+		h.redirectToAuthPage(w, r, "login_failed")
+		return
+	}
+
+	if loginState.LinkUserID != "" {
+		h.finishIdentityLink(ctx, w, r, provider.ID, loginState, claims)
+		return
+	}
+
+	user, err := h.resolveUser(ctx, provider.ID, claims)
+	if errors.Is(err, errIdentityLinkRequired) {
+		// 已經有使用者用同一個 Email 註冊，必須先證明擁有該帳號才能連結
+		h.redirectToIdentityLink(w, r, user, provider.ID, claims)
+		return
+	}
+	if err != nil {
+		log.Printf("Error resolving %s identity: %v", provider.ID, err)
+		h.redirectToAuthPage(w, r, "registration_failed")
+		return
+	}
+	if h.Auth.Cfg.RequireEmailVerification && !user.Verified {
+		h.redirectToAuthPage(w, r, "email_not_verified")
+		return
+	}
+
+	// 與密碼登入相同，啟用兩步驟驗證時先不簽發 cookie，帶著 challenge token 回到登入頁完成第二步
+	challenge, err := h.Auth.twoFactorChallenge(ctx, user)
+	if err != nil {
+		log.Printf("Error preparing 2FA challenge for %s user: %v", provider.ID, err)
+		h.redirectToAuthPage(w, r, "login_failed")
+		return
+	}
+	if challenge != "" {
+		http.Redirect(w, r, h.Auth.Cfg.FrontendBaseURL+"/auth?challenge="+url.QueryEscape(challenge), http.StatusTemporaryRedirect)
+		return
+	}
+
+	// 產生 access token 與 refresh token 並存入 cookie
+	if err := h.Auth.startSession(ctx, w, r, user); err != nil {
+		log.Printf("Error issuing session for %s user: %v", provider.ID, err)
+		h.redirectToAuthPage(w, r, "token_generation_failed")
+		return
+	}
+
+	userInfoBytes, err := json.Marshal(map[string]string{
+		"id":       user.ID.Hex(),
+		"username": user.Username,
+	})
+	if err != nil {
+		log.Printf("Error marshalling user_info for cookie: %v", err)
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:    "user_info",
+		Value:   url.QueryEscape(string(userInfoBytes)),
+		Path:    "/",
+		Expires: time.Now().Add(h.Auth.Cfg.RefreshTokenTTL),
+	})
+
+	http.Redirect(w, r, loginState.ReturnTo, http.StatusTemporaryRedirect)
+}
+
+// readLoginState 驗證 state cookie 的簽章、效期與提供者，並確認它與提供者導回的 state 參數相同
+func (h *OIDCHandler) readLoginState(r *http.Request, providerID string) (*oidcLoginState, error) {
+	cookie, err := r.Cookie(oidcStateCookieName)
+	if err != nil {
+		return nil, errors.New("missing state cookie")
+	}
+	payload, err := utils.VerifySignedString(h.Auth.Cfg.OAuthStateKey, cookie.Value)
+	if err != nil {
+		return nil, err
+	}
+	var loginState oidcLoginState
+	if err := json.Unmarshal([]byte(payload), &loginState); err != nil {
+		return nil, err
+	}
+	if time.Now().Unix() > loginState.ExpiresAt {
+		return nil, errors.New("state expired")
+	}
+	if loginState.Provider != providerID {
+		return nil, errors.New("state issued for another provider")
+	}
+	if loginState.State == "" || subtle.ConstantTimeCompare([]byte(loginState.State), []byte(r.FormValue("state"))) != 1 {
+		return nil, errors.New("state mismatch")
+	}
+	return &loginState, nil
+}
+
+// postLoginRedirect 回傳登入後要導向的網址：相對路徑以 FrontendBaseURL 為基準，
+// 絕對網址的來源必須在 AllowedRedirectOrigins 中，否則一律使用 PostLoginRedirectURL，避免被當成開放重新導向
+func (h *OIDCHandler) postLoginRedirect(target string) string {
+	cfg := h.Auth.Cfg
+	if target == "" {
+		return cfg.PostLoginRedirectURL
+	}
+	// 以 // 或 \ 開頭的路徑會被瀏覽器當成其他網站
+	if strings.HasPrefix(target, "/") && !strings.HasPrefix(target, "//") && !strings.Contains(target, "\\") {
+		target = cfg.FrontendBaseURL + target
+	}
+
+	parsed, err := url.Parse(target)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.User != nil {
+		return cfg.PostLoginRedirectURL
+	}
+	origin := strings.ToLower(parsed.Scheme + "://" + parsed.Host)
+	for _, allowed := range cfg.AllowedRedirectOrigins {
+		if origin == allowed {
+			return parsed.String()
+		}
+	}
+	log.Printf("Ignoring post-login redirect to disallowed origin %s", origin)
+	return cfg.PostLoginRedirectURL
+}
+
+// resolveUser 找出外部身分連結的使用者，第一次登入時建立使用者並連結身分
+// Email 已屬於其他使用者時回傳該使用者與 errIdentityLinkRequired，不會自動連結
+func (h *OIDCHandler) resolveUser(ctx context.Context, providerID string, claims *oidc.Claims) (*models.User, error) {
+	identity, err := h.IdentityStore.FindIdentity(ctx, providerID, claims.Subject)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+
+	var user *models.User
+	if err == nil {
+		if user, err = h.Auth.UserStore.FindUserByID(ctx, identity.UserID); err != nil {
+			return nil, err
+		}
+		if err := h.IdentityStore.TouchIdentity(ctx, identity.ID, claims.Email); err != nil {
+			log.Printf("Error updating identity %s: %v", identity.ID.Hex(), err)
+		}
+	} else {
+		if user, err = h.findOrCreateUser(ctx, providerID, claims); err != nil {
+			return user, err
+		}
+		now := time.Now()
+		err = h.IdentityStore.CreateIdentity(ctx, models.Identity{
+			ID:          primitive.NewObjectID(),
+			UserID:      user.ID,
+			Provider:    providerID,
+			Subject:     claims.Subject,
+			Email:       claims.Email,
+			CreatedAt:   now,
+			LastLoginAt: now,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	h.markVerifiedByProvider(ctx, providerID, user, claims.Email, claims.EmailVerified)
+	return user, nil
+}
+
+// markVerifiedByProvider 在提供者確認過使用者的 Email 時，補上使用者的驗證狀態
+func (h *OIDCHandler) markVerifiedByProvider(ctx context.Context, providerID string, user *models.User, email string, emailVerified bool) {
+	if user.Verified || !emailVerified || !strings.EqualFold(user.Email, email) {
+		return
+	}
+	if err := h.Auth.UserStore.MarkUserVerified(ctx, user.ID); err != nil {
+		log.Printf("Error marking %s user %s verified: %v", providerID, user.ID.Hex(), err)
+		return
+	}
+	user.Verified = true
+}
+
+// findOrCreateUser 為還沒有連結身分的外部登入找出或建立使用者
+// 改版前的 Google 帳號把 sub 存在使用者的 googleId，第一次登入時沿用原本的使用者
+// 其他使用者已經使用這個 Email 時不建立重複的帳號，回傳該使用者與 errIdentityLinkRequired
+func (h *OIDCHandler) findOrCreateUser(ctx context.Context, providerID string, claims *oidc.Claims) (*models.User, error) {
+	if providerID == "google" {
+		user, err := h.Auth.UserStore.FindUserByGoogleID(ctx, claims.Subject)
+		if err == nil {
+			return user, nil
+		}
+		if err != mongo.ErrNoDocuments {
+			return nil, err
+		}
+	}
+
+	if claims.Email != "" {
+		existing, err := h.Auth.UserStore.FindUserByEmail(ctx, claims.Email)
+		if err == nil {
+			return existing, errIdentityLinkRequired
+		}
+		if err != mongo.ErrNoDocuments {
+			return nil, err
+		}
+	}
+
+	username := claims.Name
+	if username == "" {
+		username, _, _ = strings.Cut(claims.Email, "@")
+	}
+	user := models.User{
+		Email:    claims.Email,
+		Username: username,
+		Verified: claims.EmailVerified,
+	}
+	insertedID, err := h.Auth.UserStore.CreateUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	user.ID = insertedID
+	return &user, nil
+}
+
+// redirectToAuthPage 登入失敗時導回前端登入頁面並帶上錯誤代碼
+func (h *OIDCHandler) redirectToAuthPage(w http.ResponseWriter, r *http.Request, code string) {
+	http.Redirect(w, r, h.Auth.Cfg.FrontendBaseURL+"/auth?error="+url.QueryEscape(code), http.StatusTemporaryRedirect)
+}
+
+// randomURLString 產生可放在網址中的隨機字串
+func randomURLString() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"go-chat/backend/config"
+	"go-chat/backend/models"
+	"go-chat/backend/oidc"
+	"go-chat/backend/oidc/oidctest"
+	"go-chat/backend/store/mocks"
+	"go-chat/backend/utils"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/mock/gomock"
+)
+
+// startOIDCLogin 呼叫 /auth/{provider}/login，回傳提供者的授權網址與 state cookie
+func startOIDCLogin(t *testing.T, handler *OIDCHandler, providerID string) (string, *http.Cookie) {
+	t.Helper()
+	return startOIDCLoginWithRedirect(t, handler, providerID, "")
+}
+
+// startOIDCLoginWithRedirect 與 startOIDCLogin 相同，但帶上登入後要導向的網址
+func startOIDCLoginWithRedirect(t *testing.T, handler *OIDCHandler, providerID, redirect string) (string, *http.Cookie) {
+	t.Helper()
+	target := "/auth/" + providerID + "/login"
+	if redirect != "" {
+		target += "?redirect=" + url.QueryEscape(redirect)
+	}
+	req := mux.SetURLVars(httptest.NewRequest("GET", target, nil), map[string]string{"provider": providerID})
+	rr := httptest.NewRecorder()
+	handler.HandleOIDCLogin(rr, req)
+	require.Equal(t, http.StatusTemporaryRedirect, rr.Code)
+	cookie := findCookie(rr, oidcStateCookieName)
+	require.NotNil(t, cookie)
+	return rr.Header().Get("Location"), cookie
+}
+
+// finishOIDCLogin 以提供者導回的網址呼叫 /auth/{provider}/callback
+func finishOIDCLogin(handler *OIDCHandler, providerID, callbackURL string, cookie *http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", callbackURL, nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	req = mux.SetURLVars(req, map[string]string{"provider": providerID})
+	rr := httptest.NewRecorder()
+	handler.HandleOIDCCallback(rr, req)
+	return rr
+}
+
+func TestOIDCLogin(t *testing.T) {
+	server := oidctest.NewServer()
+	defer server.Close()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserStore := mocks.NewMockUserStorer(ctrl)
+	mockSessionStore := mocks.NewMockSessionStorer(ctrl)
+	mockTokenStore := mocks.NewMockRefreshTokenStorer(ctrl)
+	mockIdentityStore := mocks.NewMockIdentityStorer(ctrl)
+	cfg := &config.Config{
+		JWTSecret:              "test-secret",
+		AccessTokenTTL:         15 * time.Minute,
+		RefreshTokenTTL:        time.Hour,
+		FrontendBaseURL:        "http://frontend.test",
+		OAuthStateKey:          []byte("state-key"),
+		PostLoginRedirectURL:   "http://frontend.test/home",
+		AllowedRedirectOrigins: []string{"http://frontend.test", "https://admin.frontend.test"},
+	}
+	authHandler := NewAuthHandler(mockUserStore, mockTokenStore, mockSessionStore, mocks.NewMockTokenDenylist(ctrl), cfg)
+
+	registry := oidc.NewRegistry(nil)
+	for _, id := range []string{"okta", "google"} {
+		registry.Add(oidc.NewProvider(config.OIDCProviderConfig{
+			ID:           id,
+			Issuer:       server.Issuer(),
+			ClientID:     server.ClientID,
+			ClientSecret: server.ClientSecret,
+			RedirectURL:  "http://backend.test/auth/" + id + "/callback",
+			Scopes:       []string{"openid", "email", "profile"},
+		}, server.Client()))
+	}
+	handler := NewOIDCHandler(authHandler, registry, mockIdentityStore)
+
+	expectSession := func() {
+		mockSessionStore.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+		mockTokenStore.EXPECT().CreateRefreshToken(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+	}
+
+	t.Run("未設定的提供者回 404", func(t *testing.T) {
+		req := mux.SetURLVars(httptest.NewRequest("GET", "/auth/unknown/login", nil), map[string]string{"provider": "unknown"})
+		rr := httptest.NewRecorder()
+
+		handler.HandleOIDCLogin(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("第一次登入建立使用者並連結身分", func(t *testing.T) {
+		authURL, cookie := startOIDCLogin(t, handler, "okta")
+		assert.True(t, cookie.HttpOnly)
+		assert.True(t, cookie.Secure, "非開發模式下 state cookie 只在 HTTPS 連線下傳送")
+		callback, err := server.Authorize(authURL, oidctest.Identity{Subject: "okta-1", Email: "new@example.com", EmailVerified: true, Name: "New"})
+		require.NoError(t, err)
+
+		newUserID := primitive.NewObjectID()
+		mockIdentityStore.EXPECT().FindIdentity(gomock.Any(), "okta", "okta-1").Return(nil, mongo.ErrNoDocuments).Times(1)
+		mockUserStore.EXPECT().FindUserByEmail(gomock.Any(), "new@example.com").Return(nil, mongo.ErrNoDocuments).Times(1)
+		mockUserStore.EXPECT().
+			CreateUser(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, user models.User) (primitive.ObjectID, error) {
+				assert.Equal(t, "new@example.com", user.Email)
+				assert.True(t, user.Verified)
+				return newUserID, nil
+			}).
+			Times(1)
+		mockIdentityStore.EXPECT().
+			CreateIdentity(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, identity models.Identity) error {
+				assert.Equal(t, newUserID, identity.UserID)
+				assert.Equal(t, "okta", identity.Provider)
+				assert.Equal(t, "okta-1", identity.Subject)
+				return nil
+			}).
+			Times(1)
+		expectSession()
+
+		rr := finishOIDCLogin(handler, "okta", callback, cookie)
+
+		assert.Equal(t, http.StatusTemporaryRedirect, rr.Code)
+		assert.Equal(t, "http://frontend.test/home", rr.Header().Get("Location"))
+		assert.NotNil(t, findCookie(rr, accessTokenCookieName))
+	})
+
+	t.Run("已連結的身分登入同一個使用者", func(t *testing.T) {
+		authURL, cookie := startOIDCLogin(t, handler, "okta")
+		callback, err := server.Authorize(authURL, oidctest.Identity{Subject: "okta-2", Email: "old@example.com"})
+		require.NoError(t, err)
+
+		user := &models.User{ID: primitive.NewObjectID(), Email: "old@example.com", Username: "old", Verified: true}
+		identity := &models.Identity{ID: primitive.NewObjectID(), UserID: user.ID, Provider: "okta", Subject: "okta-2"}
+		mockIdentityStore.EXPECT().FindIdentity(gomock.Any(), "okta", "okta-2").Return(identity, nil).Times(1)
+		mockUserStore.EXPECT().FindUserByID(gomock.Any(), user.ID).Return(user, nil).Times(1)
+		mockIdentityStore.EXPECT().TouchIdentity(gomock.Any(), identity.ID, "old@example.com").Return(nil).Times(1)
+		expectSession()
+
+		rr := finishOIDCLogin(handler, "okta", callback, cookie)
+
+		assert.Equal(t, "http://frontend.test/home", rr.Header().Get("Location"))
+	})
+
+	t.Run("改版前的 Google 帳號沿用原本的使用者", func(t *testing.T) {
+		authURL, cookie := startOIDCLogin(t, handler, "google")
+		callback, err := server.Authorize(authURL, oidctest.Identity{Subject: "google-legacy", Email: "legacy@example.com", EmailVerified: true})
+		require.NoError(t, err)
+
+		user := &models.User{ID: primitive.NewObjectID(), Email: "legacy@example.com", GoogleID: "google-legacy"}
+		mockIdentityStore.EXPECT().FindIdentity(gomock.Any(), "google", "google-legacy").Return(nil, mongo.ErrNoDocuments).Times(1)
+		mockUserStore.EXPECT().FindUserByGoogleID(gomock.Any(), "google-legacy").Return(user, nil).Times(1)
+		mockIdentityStore.EXPECT().CreateIdentity(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+		mockUserStore.EXPECT().MarkUserVerified(gomock.Any(), user.ID).Return(nil).Times(1)
+		expectSession()
+
+		rr := finishOIDCLogin(handler, "google", callback, cookie)
+
+		assert.Equal(t, "http://frontend.test/home", rr.Header().Get("Location"))
+	})
+
+	t.Run("登入後導向允許清單中的網址，其他來源一律導向預設網址", func(t *testing.T) {
+		cases := map[string]string{
+			"/rooms/1":                        "http://frontend.test/rooms/1",
+			"https://admin.frontend.test/ops": "https://admin.frontend.test/ops",
+			"https://evil.example.com/":       "http://frontend.test/home",
+			"//evil.example.com/":             "http://frontend.test/home",
+			"javascript:alert(1)":             "http://frontend.test/home",
+		}
+		for redirect, expected := range cases {
+			authURL, cookie := startOIDCLoginWithRedirect(t, handler, "okta", redirect)
+			callback, err := server.Authorize(authURL, oidctest.Identity{Subject: "okta-2", Email: "old@example.com"})
+			require.NoError(t, err)
+
+			user := &models.User{ID: primitive.NewObjectID(), Email: "old@example.com", Verified: true}
+			identity := &models.Identity{ID: primitive.NewObjectID(), UserID: user.ID}
+			mockIdentityStore.EXPECT().FindIdentity(gomock.Any(), "okta", "okta-2").Return(identity, nil).Times(1)
+			mockUserStore.EXPECT().FindUserByID(gomock.Any(), user.ID).Return(user, nil).Times(1)
+			mockIdentityStore.EXPECT().TouchIdentity(gomock.Any(), identity.ID, gomock.Any()).Return(nil).Times(1)
+			expectSession()
+
+			rr := finishOIDCLogin(handler, "okta", callback, cookie)
+
+			assert.Equal(t, expected, rr.Header().Get("Location"), "redirect=%s", redirect)
+		}
+	})
+
+	t.Run("ID token 的 nonce 與這次登入不同時拒絕", func(t *testing.T) {
+		authURL, cookie := startOIDCLogin(t, handler, "okta")
+		parsed, err := url.Parse(authURL)
+		require.NoError(t, err)
+		query := parsed.Query()
+		query.Set("nonce", "replayed-nonce")
+		parsed.RawQuery = query.Encode()
+		callback, err := server.Authorize(parsed.String(), oidctest.Identity{Subject: "okta-4"})
+		require.NoError(t, err)
+
+		rr := finishOIDCLogin(handler, "okta", callback, cookie)
+
+		assert.Contains(t, rr.Header().Get("Location"), "error=login_failed")
+		assert.Nil(t, findCookie(rr, accessTokenCookieName))
+	})
+
+	t.Run("被竄改或屬於其他提供者的 state cookie 會被拒絕", func(t *testing.T) {
+		authURL, cookie := startOIDCLogin(t, handler, "okta")
+		callback, err := server.Authorize(authURL, oidctest.Identity{Subject: "okta-5"})
+		require.NoError(t, err)
+		payload, _, _ := strings.Cut(cookie.Value, ".")
+		forged := &http.Cookie{Name: cookie.Name, Value: payload + ".forged"}
+
+		rr := finishOIDCLogin(handler, "okta", callback, forged)
+		assert.Contains(t, rr.Header().Get("Location"), "error=invalid_state")
+
+		rr = finishOIDCLogin(handler, "google", strings.Replace(callback, "/okta/", "/google/", 1), cookie)
+		assert.Contains(t, rr.Header().Get("Location"), "error=invalid_state")
+	})
+
+	t.Run("state 不符或缺少 cookie 時不會換取 token", func(t *testing.T) {
+		authURL, cookie := startOIDCLogin(t, handler, "okta")
+		callback, err := server.Authorize(authURL, oidctest.Identity{Subject: "okta-3"})
+		require.NoError(t, err)
+		tampered := strings.Replace(callback, "state=", "state=x", 1)
+
+		for _, rr := range []*httptest.ResponseRecorder{
+			finishOIDCLogin(handler, "okta", tampered, cookie),
+			finishOIDCLogin(handler, "okta", callback, nil),
+		} {
+			location, err := url.Parse(rr.Header().Get("Location"))
+			require.NoError(t, err)
+			assert.Equal(t, "/auth", location.Path)
+			assert.Equal(t, "invalid_state", location.Query().Get("error"))
+		}
+	})
+
+	t.Run("啟用兩步驟驗證時不簽發 cookie，帶 challenge 回到登入頁", func(t *testing.T) {
+		mockTwoFactorStore := mocks.NewMockTwoFactorStorer(ctrl)
+		authHandler.TwoFactor = NewTwoFactorHandler(authHandler, mockTwoFactorStore)
+		defer func() { authHandler.TwoFactor = nil }()
+
+		authURL, cookie := startOIDCLogin(t, handler, "okta")
+		callback, err := server.Authorize(authURL, oidctest.Identity{Subject: "okta-2", Email: "old@example.com"})
+		require.NoError(t, err)
+
+		user := &models.User{ID: primitive.NewObjectID(), Email: "old@example.com", Verified: true}
+		identity := &models.Identity{ID: primitive.NewObjectID(), UserID: user.ID}
+		mockIdentityStore.EXPECT().FindIdentity(gomock.Any(), "okta", "okta-2").Return(identity, nil).Times(1)
+		mockUserStore.EXPECT().FindUserByID(gomock.Any(), user.ID).Return(user, nil).Times(1)
+		mockIdentityStore.EXPECT().TouchIdentity(gomock.Any(), identity.ID, gomock.Any()).Return(nil).Times(1)
+		mockTwoFactorStore.EXPECT().FindTwoFactor(gomock.Any(), user.ID).Return(&models.TwoFactor{UserID: user.ID, Enabled: true}, nil).Times(1)
+
+		rr := finishOIDCLogin(handler, "okta", callback, cookie)
+
+		assert.Equal(t, http.StatusTemporaryRedirect, rr.Code)
+		location, err := url.Parse(rr.Header().Get("Location"))
+		require.NoError(t, err)
+		assert.Equal(t, "/auth", location.Path)
+		claims, err := utils.ParseChallengeToken(location.Query().Get("challenge"), authHandler.tokenKeys())
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, claims.UserID)
+		assert.Nil(t, findCookie(rr, accessTokenCookieName))
+	})
+}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"go-chat/backend/database"
+	"go-chat/backend/models"
+	"go-chat/backend/utils"
+	"go-chat/backend/webhooks"
+	"go-chat/backend/websocket"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TransferOwnershipRequest 定義轉移擁有權的請求體
+type TransferOwnershipRequest struct {
+	UserID string `json:"userId"`
+}
+
+// requireRoomOwner 從路徑取得聊天室並確認呼叫者是擁有者，失敗時已寫入錯誤回應
+// 私訊沒有擁有者，一律回應 409
+func requireRoomOwner(w http.ResponseWriter, r *http.Request) (*models.ChatRoom, primitive.ObjectID, bool) {
+	userID, err := utils.GetUserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return nil, primitive.NilObjectID, false
+	}
+
+	roomID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid room ID format", http.StatusBadRequest)
+		return nil, primitive.NilObjectID, false
+	}
+
+	room, err := database.FindChatRoomByID(roomID)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return nil, primitive.NilObjectID, false
+	}
+	if room == nil {
+		http.Error(w, "Chat room not found", http.StatusNotFound)
+		return nil, primitive.NilObjectID, false
+	}
+	if room.EffectiveKind() == models.RoomKindDM {
+		http.Error(w, "Direct messages have no owner", http.StatusConflict)
+		return nil, primitive.NilObjectID, false
+	}
+	if room.Owner() != userID {
+		http.Error(w, "Only the room owner can perform this action", http.StatusForbidden)
+		return nil, primitive.NilObjectID, false
+	}
+	return room, userID, true
+}
+
+// TransferOwnership 處理轉移聊天室擁有權的請求，原擁有者會保留管理員身分
+// 這個 API 端點會是 PUT /chatrooms/{id}/owner
+func TransferOwnership(w http.ResponseWriter, r *http.Request) {
+	room, ownerID, ok := requireRoomOwner(w, r)
+	if !ok {
+		return
+	}
+
+	var req TransferOwnershipRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	targetID, err := primitive.ObjectIDFromHex(req.UserID)
+	if err != nil {
+		http.Error(w, "Invalid user ID format", http.StatusBadRequest)
+		return
+	}
+	if targetID == ownerID {
+		http.Error(w, "You already own this chat room", http.StatusBadRequest)
+		return
+	}
+	if !isRoomParticipant(room, targetID) {
+		http.Error(w, "User is not a member of this chat room", http.StatusNotFound)
+		return
+	}
+
+	// 機器人不能成為擁有者
+	botIDs, err := filterBotIDs([]primitive.ObjectID{targetID})
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if len(botIDs) > 0 {
+		http.Error(w, "Bots cannot own a chat room", http.StatusBadRequest)
+		return
+	}
+
+	updatedRoom, err := database.TransferChatRoomOwnership(room.ID, ownerID, targetID)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if updatedRoom == nil {
+		// 查詢後擁有權已被轉移，或目標成員已離開
+		http.Error(w, "Ownership changed, please try again", http.StatusConflict)
+		return
+	}
+
+	database.InvalidateMultipleUserChatRoomsCache(updatedRoom.Participants)
+	_, targetName := moderationUsernames(ownerID, targetID)
+	announceRoomChange(updatedRoom, ownerID, roomOwnerChangeMessage(targetName))
+	websocket.BroadcastRoomStateUpdate(updatedRoom)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updatedRoom)
+}
+
+// ArchiveChatRoom 處理封存聊天室的請求
+// 封存後聊天室只能閱讀：不能發送訊息、加入成員或修改資訊，現有的邀請連結會被刪除
+// 這個 API 端點會是 POST /chatrooms/{id}/archive
+func ArchiveChatRoom(w http.ResponseWriter, r *http.Request) {
+	setChatRoomArchived(w, r, true)
+}
+
+// UnarchiveChatRoom 處理取消封存聊天室的請求
+// 這個 API 端點會是 POST /chatrooms/{id}/unarchive
+func UnarchiveChatRoom(w http.ResponseWriter, r *http.Request) {
+	setChatRoomArchived(w, r, false)
+}
+
+// setChatRoomArchived 是封存與取消封存共用的流程
+func setChatRoomArchived(w http.ResponseWriter, r *http.Request, archived bool) {
+	room, ownerID, ok := requireRoomOwner(w, r)
+	if !ok {
+		return
+	}
+	if room.IsArchived() == archived {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(room)
+		return
+	}
+
+	updatedRoom, err := database.SetChatRoomArchived(room.ID, archived)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	database.InvalidateMultipleUserChatRoomsCache(updatedRoom.Participants)
+	ownerName := ownerUsername(ownerID)
+	content := ownerName + " 已封存聊天室"
+	if !archived {
+		content = ownerName + " 已取消封存聊天室"
+	}
+	announceRoomChange(updatedRoom, ownerID, content)
+	websocket.BroadcastRoomStateUpdate(updatedRoom)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updatedRoom)
+}
+
+// DeleteChatRoom 處理刪除聊天室的請求，聊天室的訊息、邀請連結與封鎖紀錄會一併刪除
+// 所有成員會收到 room_removed 通知
+// 這個 API 端點會是 DELETE /chatrooms/{id}
+func DeleteChatRoom(w http.ResponseWriter, r *http.Request) {
+	room, ownerID, ok := requireRoomOwner(w, r)
+	if !ok {
+		return
+	}
+
+	if err := database.DeleteChatRoom(room.ID); err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	webhooks.EmitRoomDeleted(room.ID, room)
+	log.Printf("Chat room %s deleted by owner %s", room.ID.Hex(), ownerID.Hex())
+
+	database.InvalidateMultipleUserChatRoomsCache(room.Participants)
+	ownerName := ownerUsername(ownerID)
+	for _, participantID := range room.Participants {
+		websocket.NotifyRoomRemoved(participantID, room, ownerName+" 已刪除聊天室")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
+// ownerUsername 取得擁有者的用戶名作為系統訊息內容，查詢失敗時使用備用名稱
+func ownerUsername(ownerID primitive.ObjectID) string {
+	owner, err := database.GetUserByID(ownerID)
+	if err != nil {
+		log.Printf("Error getting room owner %s: %v", ownerID.Hex(), err)
+		return "擁有者"
+	}
+	return owner.Username
+}
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-chat/backend/database"
+	"go-chat/backend/mailer"
+	"go-chat/backend/models"
+	"go-chat/backend/store"
+	"go-chat/backend/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// passwordResetTokenPrefix 是密碼重設 token 的固定前綴
+const passwordResetTokenPrefix = "pwr_"
+
+// passwordResetRequestedMessage 不論 Email 是否存在都回傳同樣的訊息，避免被用來探測帳號
+const passwordResetRequestedMessage = "If the email is registered, a password reset link has been sent"
+
+// 同一個 Email 與同一個 IP 在時間窗內最多可以申請重設密碼的次數
+const (
+	passwordResetEmailLimit = 3
+	passwordResetIPLimit    = 10
+	passwordResetWindow     = time.Hour
+)
+
+// allowPasswordResetRequest 檢查 Email 與 IP 是否還能申請重設密碼，先檢查 IP，
+// 被 IP 限流擋下的請求不會用掉該 Email 的次數；測試時可替換
+var allowPasswordResetRequest = func(email, ip string) (bool, time.Duration, error) {
+	allowed, retryAfter, err := database.AllowSlidingWindow("rate-limit:password-reset-ip:"+ip, passwordResetIPLimit, passwordResetWindow)
+	if err != nil || !allowed {
+		return allowed, retryAfter, err
+	}
+	return database.AllowSlidingWindow("rate-limit:password-reset:"+email, passwordResetEmailLimit, passwordResetWindow)
+}
+
+// PasswordResetHandler 處理忘記密碼的申請與重設
+type PasswordResetHandler struct {
+	Auth       *AuthHandler // 重設成功後沿用 AuthHandler 撤銷所有登入
+	ResetStore store.PasswordResetStorer
+	Mailer     mailer.Mailer
+}
+
+// NewPasswordResetHandler 是一個工廠函式，用於建立新的 PasswordResetHandler
+func NewPasswordResetHandler(auth *AuthHandler, resetStore store.PasswordResetStorer, m mailer.Mailer) *PasswordResetHandler {
+	return &PasswordResetHandler{Auth: auth, ResetStore: resetStore, Mailer: m}
+}
+
+// RequestPasswordReset 寄出一次性的密碼重設連結，同一個 Email 與同一個 IP 在一小時內的申請次數有上限
+// 這個 API 端點會是 POST /password-reset/request
+func (h *PasswordResetHandler) RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	var req models.PasswordResetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	email := strings.TrimSpace(req.Email)
+	if email == "" {
+		sendJSONError(w, "Email is required", http.StatusBadRequest)
+		return
+	}
+
+	// 限流在查詢使用者之前進行，回應不會因 Email 是否存在而不同
+	allowed, retryAfter, err := allowPasswordResetRequest(strings.ToLower(email), utils.GetClientIP(r))
+	if err != nil {
+		log.Printf("Error checking rate limit for password reset: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if !allowed {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		sendJSONError(w, "Too many password reset requests", http.StatusTooManyRequests)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.Auth.UserStore.FindUserByEmail(ctx, email)
+	switch {
+	case err == mongo.ErrNoDocuments:
+		log.Printf("Password reset requested for unknown email")
+	case err != nil:
+		// 查詢失敗也回傳相同的回應，錯誤只記錄在日誌中
+		log.Printf("Error finding user for password reset: %v", err)
+	case user.Password == "":
+		// 只用 Google 登入的帳號沒有密碼可以重設
+		log.Printf("Password reset requested for user %s without password", user.ID.Hex())
+	default:
+		if err := h.issuePasswordReset(ctx, user); err != nil {
+			log.Printf("Error issuing password reset for user %s: %v", user.ID.Hex(), err)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{"message": passwordResetRequestedMessage})
+}
+
+// issuePasswordReset 建立重設 token 並在背景寄出連結，寄信的耗時不會反映在回應時間上
+func (h *PasswordResetHandler) issuePasswordReset(ctx context.Context, user *models.User) error {
+	token, err := utils.GenerateOpaqueToken(passwordResetTokenPrefix)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	ttl := h.Auth.Cfg.PasswordResetTTL
+	err = h.ResetStore.CreatePasswordReset(ctx, models.PasswordReset{
+		ID:        primitive.NewObjectID(),
+		UserID:    user.ID,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", h.Auth.Cfg.FrontendBaseURL, url.QueryEscape(token))
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: "GoChat 密碼重設",
+		Body: fmt.Sprintf("%s 您好：\n\n請在 %d 分鐘內開啟以下連結設定新密碼：\n%s\n\n如果您沒有申請重設密碼，請忽略這封信。\n",
+			user.Username, int(ttl.Minutes()), link),
+	}
+	go func() {
+		sendCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := h.Mailer.Send(sendCtx, msg); err != nil {
+			log.Printf("Error sending password reset email to user %s: %v", user.ID.Hex(), err)
+		}
+	}()
+	return nil
+}
+
+// ConfirmPasswordReset 以重設 token 設定新密碼，並撤銷使用者所有的登入
+// 這個 API 端點會是 POST /password-reset/confirm
+func (h *PasswordResetHandler) ConfirmPasswordReset(w http.ResponseWriter, r *http.Request) {
+	var req models.PasswordResetConfirmRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	if req.Token == "" || req.Password == "" {
+		sendJSONError(w, "Token and password are required", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	reset, err := h.ResetStore.FindPasswordResetByHash(ctx, utils.HashToken(req.Token))
+	if err != nil && err != mongo.ErrNoDocuments {
+		log.Printf("Error finding password reset token: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if err == mongo.ErrNoDocuments || !reset.UsedAt.IsZero() || time.Now().After(reset.ExpiresAt) {
+		sendJSONError(w, "Invalid or expired reset token", http.StatusBadRequest)
+		return
+	}
+
+	// 並行的兩個請求拿著同一個 token 時，只有一個能標記成功
+	marked, err := h.ResetStore.MarkPasswordResetUsed(ctx, reset.ID)
+	if err != nil {
+		log.Printf("Error marking password reset %s used: %v", reset.ID.Hex(), err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if !marked {
+		sendJSONError(w, "Invalid or expired reset token", http.StatusBadRequest)
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		log.Printf("Error hashing password: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if err := h.Auth.UserStore.UpdateUserPassword(ctx, reset.UserID, string(hashedPassword)); err != nil {
+		log.Printf("Error updating password of user %s: %v", reset.UserID.Hex(), err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if err := h.ResetStore.InvalidateUserPasswordResets(ctx, reset.UserID); err != nil {
+		log.Printf("Error invalidating password resets of user %s: %v", reset.UserID.Hex(), err)
+	}
+
+	// 密碼可能已外洩，所有裝置都必須用新密碼重新登入
+	if err := h.Auth.revokeAllSessions(ctx, reset.UserID, "您的密碼已重設，請重新登入。"); err != nil {
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	clearAuthCookies(w)
+	log.Printf("Password reset for user %s", reset.UserID.Hex())
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Password has been reset"})
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"go-chat/backend/config"
+	"go-chat/backend/mailer"
+	"go-chat/backend/models"
+	"go-chat/backend/store/mocks"
+	"go-chat/backend/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// newJSONRequest 建立帶有 JSON body 的請求
+func newJSONRequest(method, target string, payload interface{}) *http.Request {
+	body, _ := json.Marshal(payload)
+	return httptest.NewRequest(method, target, bytes.NewReader(body))
+}
+
+func TestRequestPasswordReset(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserStore := mocks.NewMockUserStorer(ctrl)
+	mockResetStore := mocks.NewMockPasswordResetStorer(ctrl)
+	memoryMailer := mailer.NewMemoryMailer()
+	cfg := &config.Config{PasswordResetTTL: 30 * time.Minute, FrontendBaseURL: "http://frontend.test"}
+	authHandler := NewAuthHandler(mockUserStore, mocks.NewMockRefreshTokenStorer(ctrl), mocks.NewMockSessionStorer(ctrl), mocks.NewMockTokenDenylist(ctrl), cfg)
+	handler := NewPasswordResetHandler(authHandler, mockResetStore, memoryMailer)
+
+	// 以記憶體中的計數取代 Redis 限流
+	original := allowPasswordResetRequest
+	defer func() { allowPasswordResetRequest = original }()
+	counts := map[string]int{}
+	allowPasswordResetRequest = func(email, ip string) (bool, time.Duration, error) {
+		counts["ip:"+ip]++
+		if counts["ip:"+ip] > passwordResetIPLimit {
+			return false, 20 * time.Minute, nil
+		}
+		counts[email]++
+		return counts[email] <= passwordResetEmailLimit, 30 * time.Minute, nil
+	}
+
+	t.Run("不存在的 Email 與存在時回應相同且不寄信", func(t *testing.T) {
+		mockUserStore.EXPECT().FindUserByEmail(gomock.Any(), "nobody@example.com").Return(nil, mongo.ErrNoDocuments).Times(1)
+		rr := httptest.NewRecorder()
+
+		handler.RequestPasswordReset(rr, newJSONRequest("POST", "/password-reset/request", map[string]string{"email": "nobody@example.com"}))
+
+		assert.Equal(t, http.StatusAccepted, rr.Code)
+		assert.Contains(t, rr.Body.String(), passwordResetRequestedMessage)
+		assert.Empty(t, memoryMailer.Sent())
+	})
+
+	t.Run("存在的 Email 會建立 token 並寄出重設連結", func(t *testing.T) {
+		user := &models.User{ID: primitive.NewObjectID(), Email: "user@example.com", Username: "user", Password: "hashed"}
+		mockUserStore.EXPECT().FindUserByEmail(gomock.Any(), user.Email).Return(user, nil).Times(1)
+
+		var stored models.PasswordReset
+		mockResetStore.EXPECT().
+			CreatePasswordReset(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, reset models.PasswordReset) error {
+				stored = reset
+				return nil
+			}).
+			Times(1)
+		rr := httptest.NewRecorder()
+
+		handler.RequestPasswordReset(rr, newJSONRequest("POST", "/password-reset/request", map[string]string{"email": user.Email}))
+
+		assert.Equal(t, http.StatusAccepted, rr.Code)
+		assert.Contains(t, rr.Body.String(), passwordResetRequestedMessage)
+		assert.Equal(t, user.ID, stored.UserID)
+		assert.WithinDuration(t, time.Now().Add(30*time.Minute), stored.ExpiresAt, time.Minute)
+
+		// 信件在背景寄出
+		require.Eventually(t, func() bool { return len(memoryMailer.Sent()) == 1 }, time.Second, 10*time.Millisecond)
+		msg := memoryMailer.Sent()[0]
+		assert.Equal(t, user.Email, msg.To)
+
+		// 連結中的 token 只有雜湊會被保存
+		start := strings.Index(msg.Body, "http://frontend.test/reset-password?token=")
+		require.GreaterOrEqual(t, start, 0)
+		link, err := url.Parse(strings.Fields(msg.Body[start:])[0])
+		require.NoError(t, err)
+		token := link.Query().Get("token")
+		assert.True(t, strings.HasPrefix(token, passwordResetTokenPrefix))
+		assert.Equal(t, utils.HashToken(token), stored.TokenHash)
+	})
+
+	t.Run("同一個 Email 超過次數時回 429 並附上 Retry-After", func(t *testing.T) {
+		mockUserStore.EXPECT().FindUserByEmail(gomock.Any(), "victim@example.com").Return(nil, mongo.ErrNoDocuments).Times(passwordResetEmailLimit)
+		request := func(email string) *httptest.ResponseRecorder {
+			req := newJSONRequest("POST", "/password-reset/request", map[string]string{"email": email})
+			req.RemoteAddr = "198.51.100.1:1234"
+			rr := httptest.NewRecorder()
+			handler.RequestPasswordReset(rr, req)
+			return rr
+		}
+		for i := 0; i < passwordResetEmailLimit; i++ {
+			assert.Equal(t, http.StatusAccepted, request("victim@example.com").Code)
+		}
+
+		rr := request("Victim@Example.com")
+
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code, "限流不應該因大小寫不同而被繞過")
+		assert.Equal(t, "1800", rr.Header().Get("Retry-After"))
+	})
+
+	t.Run("同一個 IP 超過次數時不再查詢使用者", func(t *testing.T) {
+		mockUserStore.EXPECT().FindUserByEmail(gomock.Any(), gomock.Any()).Return(nil, mongo.ErrNoDocuments).Times(passwordResetIPLimit)
+		var rr *httptest.ResponseRecorder
+		for i := 0; i <= passwordResetIPLimit; i++ {
+			req := newJSONRequest("POST", "/password-reset/request", map[string]string{"email": fmt.Sprintf("user%d@example.com", i)})
+			req.RemoteAddr = "203.0.113.9:1234"
+			rr = httptest.NewRecorder()
+			handler.RequestPasswordReset(rr, req)
+		}
+
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		assert.Equal(t, "1200", rr.Header().Get("Retry-After"))
+	})
+}
+
+func TestConfirmPasswordReset(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserStore := mocks.NewMockUserStorer(ctrl)
+	mockTokenStore := mocks.NewMockRefreshTokenStorer(ctrl)
+	mockSessionStore := mocks.NewMockSessionStorer(ctrl)
+	mockDenylist := mocks.NewMockTokenDenylist(ctrl)
+	mockResetStore := mocks.NewMockPasswordResetStorer(ctrl)
+	authHandler := NewAuthHandler(mockUserStore, mockTokenStore, mockSessionStore, mockDenylist, &config.Config{})
+	handler := NewPasswordResetHandler(authHandler, mockResetStore, mailer.NewMemoryMailer())
+
+	confirm := func(token, password string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		handler.ConfirmPasswordReset(rr, newJSONRequest("POST", "/password-reset/confirm", map[string]string{"token": token, "password": password}))
+		return rr
+	}
+
+	t.Run("缺少新密碼回 400", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, confirm("pwr_token", "").Code)
+	})
+
+	t.Run("不存在的 token 回 400", func(t *testing.T) {
+		mockResetStore.EXPECT().FindPasswordResetByHash(gomock.Any(), utils.HashToken("pwr_unknown")).Return(nil, mongo.ErrNoDocuments).Times(1)
+
+		assert.Equal(t, http.StatusBadRequest, confirm("pwr_unknown", "new-password").Code)
+	})
+
+	t.Run("過期或已使用的 token 回 400", func(t *testing.T) {
+		expired := &models.PasswordReset{ID: primitive.NewObjectID(), ExpiresAt: time.Now().Add(-time.Minute)}
+		used := &models.PasswordReset{ID: primitive.NewObjectID(), ExpiresAt: time.Now().Add(time.Hour), UsedAt: time.Now()}
+		mockResetStore.EXPECT().FindPasswordResetByHash(gomock.Any(), utils.HashToken("pwr_expired")).Return(expired, nil).Times(1)
+		mockResetStore.EXPECT().FindPasswordResetByHash(gomock.Any(), utils.HashToken("pwr_used")).Return(used, nil).Times(1)
+
+		assert.Equal(t, http.StatusBadRequest, confirm("pwr_expired", "new-password").Code)
+		assert.Equal(t, http.StatusBadRequest, confirm("pwr_used", "new-password").Code)
+	})
+
+	t.Run("並行使用時搶輸的請求回 400", func(t *testing.T) {
+		reset := &models.PasswordReset{ID: primitive.NewObjectID(), ExpiresAt: time.Now().Add(time.Hour)}
+		mockResetStore.EXPECT().FindPasswordResetByHash(gomock.Any(), utils.HashToken("pwr_race")).Return(reset, nil).Times(1)
+		mockResetStore.EXPECT().MarkPasswordResetUsed(gomock.Any(), reset.ID).Return(false, nil).Times(1)
+
+		assert.Equal(t, http.StatusBadRequest, confirm("pwr_race", "new-password").Code)
+	})
+
+	t.Run("成功重設密碼並撤銷所有登入", func(t *testing.T) {
+		reset := &models.PasswordReset{ID: primitive.NewObjectID(), UserID: primitive.NewObjectID(), ExpiresAt: time.Now().Add(time.Hour)}
+		mockResetStore.EXPECT().FindPasswordResetByHash(gomock.Any(), utils.HashToken("pwr_valid")).Return(reset, nil).Times(1)
+		mockResetStore.EXPECT().MarkPasswordResetUsed(gomock.Any(), reset.ID).Return(true, nil).Times(1)
+		mockUserStore.EXPECT().
+			UpdateUserPassword(gomock.Any(), reset.UserID, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ primitive.ObjectID, hashed string) error {
+				assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(hashed), []byte("new-password")), "應該儲存新密碼的 bcrypt 雜湊")
+				return nil
+			}).
+			Times(1)
+		mockResetStore.EXPECT().InvalidateUserPasswordResets(gomock.Any(), reset.UserID).Return(nil).Times(1)
+		mockTokenStore.EXPECT().RevokeUserRefreshTokens(gomock.Any(), reset.UserID).Return(nil).Times(1)
+		mockDenylist.EXPECT().RevokeUser(gomock.Any(), reset.UserID).Return(nil).Times(1)
+		mockSessionStore.EXPECT().RevokeUserSessions(gomock.Any(), reset.UserID).Return(nil).Times(1)
+
+		rr := confirm("pwr_valid", "new-password")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+}
@@ -0,0 +1,317 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-chat/backend/config"
+	"go-chat/backend/database"
+	"go-chat/backend/models"
+	"go-chat/backend/store"
+	"go-chat/backend/utils"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	roomInviteCodePrefix   = "inv_"
+	maxRoomInviteUses      = 1000
+	maxRoomInviteExpiresIn = 30 * 24 * 60 * 60 // 30 天 (秒)
+)
+
+// RoomInviteHandler 包含處理聊天室邀請連結請求的所有依賴
+type RoomInviteHandler struct {
+	Store store.RoomInviteStorer
+	Cfg   *config.Config
+}
+
+// NewRoomInviteHandler 是一個工廠函式，用於建立新的 RoomInviteHandler
+func NewRoomInviteHandler(inviteStore store.RoomInviteStorer, cfg *config.Config) *RoomInviteHandler {
+	return &RoomInviteHandler{Store: inviteStore, Cfg: cfg}
+}
+
+// CreateRoomInviteRequest 定義建立邀請的請求體，所有欄位都可以省略
+type CreateRoomInviteRequest struct {
+	ExpiresInSeconds int             `json:"expiresInSeconds"` // 0 表示永不過期，最多 30 天
+	MaxUses          int             `json:"maxUses"`          // 0 表示不限次數
+	Role             models.RoomRole `json:"role"`             // 預設為 member
+}
+
+// RoomInviteURLResponse 包含邀請碼與分享用的連結，只會在建立時回傳一次
+type RoomInviteURLResponse struct {
+	models.RoomInvite
+	Code string `json:"code"`
+	URL  string `json:"url"`
+}
+
+// CreateRoomInvite 處理建立邀請連結的請求
+// 這個 API 端點會是 POST /chatrooms/{id}/invites
+func (h *RoomInviteHandler) CreateRoomInvite(w http.ResponseWriter, r *http.Request) {
+	room, userID, ok := requireRoomAdmin(w, r)
+	if !ok {
+		return
+	}
+	if room.EffectiveKind() == models.RoomKindDM {
+		http.Error(w, errDirectMessageClosed.Error(), http.StatusConflict)
+		return
+	}
+	if room.IsArchived() {
+		http.Error(w, errRoomArchived.Error(), http.StatusConflict)
+		return
+	}
+
+	var req CreateRoomInviteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.ExpiresInSeconds < 0 || req.ExpiresInSeconds > maxRoomInviteExpiresIn {
+		http.Error(w, "expiresInSeconds must be between 0 and 2592000", http.StatusBadRequest)
+		return
+	}
+	if req.MaxUses < 0 || req.MaxUses > maxRoomInviteUses {
+		http.Error(w, "maxUses must be between 0 and 1000", http.StatusBadRequest)
+		return
+	}
+	if req.Role == "" {
+		req.Role = models.RoomRoleMember
+	}
+	if req.Role != models.RoomRoleMember && req.Role != models.RoomRoleAdmin {
+		http.Error(w, "role must be member or admin", http.StatusBadRequest)
+		return
+	}
+
+	code, err := newRoomInviteCode()
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	invite := models.RoomInvite{
+		RoomID:    room.ID,
+		CodeHash:  hashRoomInviteCode(code),
+		Role:      req.Role,
+		MaxUses:   req.MaxUses,
+		CreatedBy: userID,
+		CreatedAt: now,
+	}
+	if req.ExpiresInSeconds > 0 {
+		invite.ExpiresAt = now.Add(time.Duration(req.ExpiresInSeconds) * time.Second)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	invite.ID, err = h.Store.CreateRoomInvite(ctx, invite)
+	if err != nil {
+		log.Printf("Error creating invite for room %s: %v", room.ID.Hex(), err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(RoomInviteURLResponse{RoomInvite: invite, Code: code, URL: h.inviteURL(code)})
+}
+
+// ListRoomInvites 處理列出聊天室仍可使用的邀請的請求 (不包含邀請碼)
+// 這個 API 端點會是 GET /chatrooms/{id}/invites
+func (h *RoomInviteHandler) ListRoomInvites(w http.ResponseWriter, r *http.Request) {
+	room, _, ok := requireRoomAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	invites, err := h.Store.ListActiveRoomInvites(ctx, room.ID, time.Now())
+	if err != nil {
+		log.Printf("Error listing invites for room %s: %v", room.ID.Hex(), err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(invites)
+}
+
+// RevokeRoomInvite 處理撤銷邀請的請求，撤銷後邀請碼立即失效
+// 這個 API 端點會是 DELETE /chatrooms/{id}/invites/{inviteId}
+func (h *RoomInviteHandler) RevokeRoomInvite(w http.ResponseWriter, r *http.Request) {
+	room, _, ok := requireRoomAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	inviteID, err := primitive.ObjectIDFromHex(mux.Vars(r)["inviteId"])
+	if err != nil {
+		http.Error(w, "Invalid invite ID format", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	invite, err := h.Store.FindRoomInviteByID(ctx, inviteID)
+	if err == mongo.ErrNoDocuments || (err == nil && invite.RoomID != room.ID) {
+		http.Error(w, "Invite not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error finding invite %s: %v", inviteID.Hex(), err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.Store.DeleteRoomInvite(ctx, invite.ID); err != nil {
+		log.Printf("Error revoking invite %s: %v", invite.ID.Hex(), err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
+// RedeemRoomInvite 處理兌換邀請的請求，將呼叫者加入邀請所屬的聊天室並回傳聊天室
+// 已是成員時直接回傳聊天室，不會用掉一次使用次數
+// 這個 API 端點會是 POST /invites/{code}/redeem
+func (h *RoomInviteHandler) RedeemRoomInvite(w http.ResponseWriter, r *http.Request) {
+	userID, err := utils.GetUserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	code := mux.Vars(r)["code"]
+	if !strings.HasPrefix(code, roomInviteCodePrefix) {
+		http.Error(w, "Invite not found", http.StatusNotFound)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	invite, err := h.Store.FindRoomInviteByCodeHash(ctx, hashRoomInviteCode(code))
+	if err == mongo.ErrNoDocuments {
+		http.Error(w, "Invite not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error finding invite: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if !invite.IsUsable(time.Now()) {
+		http.Error(w, "Invite has expired or reached its usage limit", http.StatusGone)
+		return
+	}
+
+	room, err := database.FindChatRoomByID(invite.RoomID)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if room == nil {
+		http.Error(w, "Invite not found", http.StatusNotFound)
+		return
+	}
+	if isRoomParticipant(room, userID) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(room)
+		return
+	}
+
+	// 邀請連結不能繞過封鎖
+	ban, err := database.FindActiveRoomSanction(room.ID, userID, models.RoomSanctionBan)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if ban != nil {
+		http.Error(w, "You are banned from this chat room", http.StatusForbidden)
+		return
+	}
+
+	// 機器人與一般邀請相同，必須先在聊天室的允許清單中
+	botIDs, err := filterBotIDs([]primitive.ObjectID{userID})
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if len(botIDs) > 0 && !room.AllowsBot(userID) {
+		http.Error(w, errBotNotAllowed.Error(), http.StatusForbidden)
+		return
+	}
+
+	// 以原子操作扣除使用次數，同時兌換的請求不會超過上限
+	if _, err := h.Store.ConsumeRoomInvite(ctx, invite.ID, time.Now()); err != nil {
+		if err == mongo.ErrNoDocuments {
+			http.Error(w, "Invite has expired or reached its usage limit", http.StatusGone)
+			return
+		}
+		log.Printf("Error consuming invite %s: %v", invite.ID.Hex(), err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	updatedRoom, err := addRoomMember(room.ID, userID, invite.Role == models.RoomRoleAdmin)
+	if err != nil || updatedRoom == nil {
+		// 沒有加入聊天室 (例如聊天室在兌換期間被刪除或封存)，歸還剛扣除的使用次數
+		if releaseErr := h.Store.ReleaseRoomInvite(ctx, invite.ID); releaseErr != nil {
+			log.Printf("Error releasing invite %s: %v", invite.ID.Hex(), releaseErr)
+		}
+	}
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if updatedRoom == nil {
+		http.Error(w, "Invite not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updatedRoom)
+}
+
+// addRoomMember 將使用者加入聊天室並通知成員，admin 為 true 時同時授予管理員身分
+// 沒有自訂名稱的聊天室會依加入後的成員重新產生名稱；聊天室已刪除、已封存或是私訊時回傳 nil
+func addRoomMember(roomID, userID primitive.ObjectID, admin bool) (*models.ChatRoom, error) {
+	updatedRoom, err := database.AddChatRoomMember(roomID, userID, admin)
+	if err != nil || updatedRoom == nil {
+		return updatedRoom, err
+	}
+
+	// 名稱以加入後實際的成員計算，同時加入的其他成員不會被舊的成員列表蓋掉
+	if updatedRoom.CustomName == "" {
+		name, err := generateRoomNameFor(updatedRoom.Participants)
+		if err != nil {
+			log.Printf("Error getting usernames for new room name: %v", err)
+		} else if renamed, err := database.SetGeneratedChatRoomName(roomID, updatedRoom.Participants, name); err == nil && renamed != nil {
+			updatedRoom = renamed
+		}
+	}
+
+	announceMemberJoined(updatedRoom, userID)
+	return updatedRoom, nil
+}
+
+// inviteURL 組出分享用的前端邀請連結
+func (h *RoomInviteHandler) inviteURL(code string) string {
+	return h.Cfg.FrontendBaseURL + "/invite/" + code
+}
+
+// newRoomInviteCode 產生明文邀請碼
+func newRoomInviteCode() (string, error) {
+	return utils.GenerateOpaqueToken(roomInviteCodePrefix)
+}
+
+// hashRoomInviteCode 計算邀請碼的雜湊值，資料庫只保存雜湊
+func hashRoomInviteCode(code string) string {
+	return utils.HashToken(code)
+}
@@ -0,0 +1,91 @@
+// backend/handlers/room_invite_handler_test.go
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-chat/backend/config"
+	"go-chat/backend/models"
+	"go-chat/backend/store/mocks"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/mock/gomock"
+)
+
+// TestRedeemRoomInvite 測試兌換邀請時不需要查詢聊天室的驗證分支
+func TestRedeemRoomInvite(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockRoomInviteStorer(ctrl)
+	handler := NewRoomInviteHandler(mockStore, &config.Config{FrontendBaseURL: "https://chat.example.com"})
+	userID := primitive.NewObjectID()
+
+	redeem := func(code string) *httptest.ResponseRecorder {
+		req := withSession(httptest.NewRequest("POST", "/invites/"+code+"/redeem", nil), userID, primitive.NewObjectID())
+		req = mux.SetURLVars(req, map[string]string{"code": code})
+		rr := httptest.NewRecorder()
+		handler.RedeemRoomInvite(rr, req)
+		return rr
+	}
+
+	t.Run("格式錯誤的邀請碼直接回 404，不查詢資料庫", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, redeem("not-an-invite").Code)
+	})
+
+	t.Run("不存在或已撤銷的邀請回 404", func(t *testing.T) {
+		code := roomInviteCodePrefix + "revoked"
+		mockStore.EXPECT().
+			FindRoomInviteByCodeHash(gomock.Any(), hashRoomInviteCode(code)).
+			Return(nil, mongo.ErrNoDocuments).
+			Times(1)
+
+		assert.Equal(t, http.StatusNotFound, redeem(code).Code)
+	})
+
+	t.Run("已過期的邀請回 410", func(t *testing.T) {
+		code := roomInviteCodePrefix + "expired"
+		mockStore.EXPECT().
+			FindRoomInviteByCodeHash(gomock.Any(), hashRoomInviteCode(code)).
+			Return(&models.RoomInvite{ID: primitive.NewObjectID(), ExpiresAt: time.Now().Add(-time.Minute)}, nil).
+			Times(1)
+
+		assert.Equal(t, http.StatusGone, redeem(code).Code)
+	})
+
+	t.Run("已達使用次數上限的邀請回 410", func(t *testing.T) {
+		code := roomInviteCodePrefix + "used-up"
+		mockStore.EXPECT().
+			FindRoomInviteByCodeHash(gomock.Any(), hashRoomInviteCode(code)).
+			Return(&models.RoomInvite{ID: primitive.NewObjectID(), MaxUses: 3, Uses: 3}, nil).
+			Times(1)
+
+		assert.Equal(t, http.StatusGone, redeem(code).Code)
+	})
+}
+
+func TestRoomInviteIsUsable(t *testing.T) {
+	now := time.Now()
+
+	assert.True(t, (&models.RoomInvite{}).IsUsable(now), "沒有期限與次數上限的邀請永遠可以使用")
+	assert.True(t, (&models.RoomInvite{MaxUses: 2, Uses: 1, ExpiresAt: now.Add(time.Hour)}).IsUsable(now))
+	assert.False(t, (&models.RoomInvite{MaxUses: 2, Uses: 2}).IsUsable(now))
+	assert.False(t, (&models.RoomInvite{ExpiresAt: now}).IsUsable(now), "到期的瞬間就不能再使用")
+}
+
+func TestRoomInviteCode(t *testing.T) {
+	code, err := newRoomInviteCode()
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(code, roomInviteCodePrefix))
+	assert.NotEqual(t, code, hashRoomInviteCode(code), "資料庫只應保存雜湊")
+
+	handler := NewRoomInviteHandler(nil, &config.Config{FrontendBaseURL: "https://chat.example.com"})
+	assert.Equal(t, "https://chat.example.com/invite/"+code, handler.inviteURL(code))
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go-chat/backend/database"
+	"go-chat/backend/models"
+	"go-chat/backend/utils"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxRoomMuteDurationSeconds 是個人靜音的最長期限 (365 天)
+const maxRoomMuteDurationSeconds = 365 * 24 * 60 * 60
+
+// UpdateRoomSettingsRequest 定義更新個人聊天室設定的請求體，省略的欄位維持不變
+type UpdateRoomSettingsRequest struct {
+	Muted               *bool   `json:"muted"`
+	MuteDurationSeconds int     `json:"muteDurationSeconds"` // muted 為 true 時的靜音時間，0 代表直到取消靜音
+	Favorite            *bool   `json:"favorite"`
+	SortOrder           *int    `json:"sortOrder"`
+	Hidden              *bool   `json:"hidden"`
+	Nickname            *string `json:"nickname"` // 空字串代表移除暱稱
+}
+
+// UpdateRoomSettings 處理更新使用者對聊天室個人設定的請求，設定只會影響呼叫者自己
+// 這個 API 端點會是 PUT /chatrooms/{id}/settings
+func UpdateRoomSettings(w http.ResponseWriter, r *http.Request) {
+	userID, err := utils.GetUserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	roomID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid room ID format", http.StatusBadRequest)
+		return
+	}
+
+	var req UpdateRoomSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	room, err := database.FindChatRoomByID(roomID)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if room == nil || !isRoomParticipant(room, userID) {
+		http.Error(w, "Chat room not found", http.StatusNotFound)
+		return
+	}
+
+	settings, fields, err := applyRoomSettings(room.SettingsFor(userID), req, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	updatedRoom, err := database.UpdateRoomMemberSettings(room.ID, userID, settings, fields)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if updatedRoom == nil {
+		// 查詢後使用者已離開聊天室
+		http.Error(w, "Chat room not found", http.StatusNotFound)
+		return
+	}
+	// 個人設定只出現在自己的聊天室列表中
+	database.InvalidateUserChatRoomsCache(userID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updatedRoom.SettingsFor(userID))
+}
+
+// applyRoomSettings 驗證請求並套用到現有的設定上，回傳新的設定與請求中有變更的欄位 (bson 名稱)
+func applyRoomSettings(settings models.RoomMemberSettings, req UpdateRoomSettingsRequest, now time.Time) (models.RoomMemberSettings, []string, error) {
+	if req.MuteDurationSeconds < 0 || req.MuteDurationSeconds > maxRoomMuteDurationSeconds {
+		return settings, nil, fmt.Errorf("muteDurationSeconds must be between 0 and %d", maxRoomMuteDurationSeconds)
+	}
+	var fields []string
+	if req.Muted != nil {
+		fields = append(fields, "muted", "mutedUntil")
+		settings.Muted = *req.Muted
+		settings.MutedUntil = time.Time{}
+		if settings.Muted && req.MuteDurationSeconds > 0 {
+			settings.MutedUntil = now.Add(time.Duration(req.MuteDurationSeconds) * time.Second)
+		}
+	}
+	if req.Favorite != nil {
+		fields = append(fields, "favorite")
+		settings.Favorite = *req.Favorite
+	}
+	if req.SortOrder != nil {
+		fields = append(fields, "sortOrder")
+		settings.SortOrder = *req.SortOrder
+	}
+	if req.Hidden != nil {
+		fields = append(fields, "hidden")
+		settings.Hidden = *req.Hidden
+	}
+	if req.Nickname != nil {
+		nickname := strings.TrimSpace(*req.Nickname)
+		if utf8.RuneCountInString(nickname) > models.MaxRoomNicknameLength {
+			return settings, nil, fmt.Errorf("nickname must be at most %d characters", models.MaxRoomNicknameLength)
+		}
+		fields = append(fields, "nickname")
+		settings.Nickname = nickname
+	}
+	return settings, fields, nil
+}
+
+// MarkChatRoomRead 處理將聊天室標記為已讀的請求，之後聊天室列表中這個聊天室的未讀數為 0
+// 已讀時間不在聊天室列表快取中，標記已讀只需要一次寫入
+// 這個 API 端點會是 POST /chatrooms/{id}/read
+func MarkChatRoomRead(w http.ResponseWriter, r *http.Request) {
+	userID, err := utils.GetUserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	roomID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid room ID format", http.StatusBadRequest)
+		return
+	}
+
+	found, err := database.MarkChatRoomRead(roomID, userID)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.Error(w, "Chat room not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-chat/backend/models"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestApplyRoomSettings(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	yes, no, order, nickname := true, false, 3, "  週報  "
+
+	settings, fields, err := applyRoomSettings(models.RoomMemberSettings{}, UpdateRoomSettingsRequest{Muted: &yes, MuteDurationSeconds: 3600, Favorite: &yes, SortOrder: &order, Nickname: &nickname}, now)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"muted", "mutedUntil", "favorite", "sortOrder", "nickname"}, fields)
+	assert.Equal(t, models.RoomMemberSettings{Muted: true, MutedUntil: now.Add(time.Hour), Favorite: true, SortOrder: 3, Nickname: "週報"}, settings)
+	assert.True(t, settings.IsMuted(now))
+	assert.False(t, settings.IsMuted(now.Add(2*time.Hour)), "靜音到期後恢復通知")
+
+	// 省略的欄位維持不變，也不會被寫回資料庫
+	settings, fields, err = applyRoomSettings(settings, UpdateRoomSettingsRequest{Hidden: &yes}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"hidden"}, fields)
+	assert.True(t, settings.Favorite)
+	assert.True(t, settings.Hidden)
+
+	settings, _, err = applyRoomSettings(settings, UpdateRoomSettingsRequest{Muted: &no}, now)
+	assert.NoError(t, err)
+	assert.False(t, settings.IsMuted(now))
+	assert.True(t, settings.MutedUntil.IsZero())
+
+	settings, _, err = applyRoomSettings(settings, UpdateRoomSettingsRequest{Muted: &yes}, now)
+	assert.NoError(t, err)
+	assert.True(t, settings.IsMuted(now.AddDate(10, 0, 0)), "沒有期限時直到取消靜音")
+
+	_, _, err = applyRoomSettings(settings, UpdateRoomSettingsRequest{Muted: &yes, MuteDurationSeconds: -1}, now)
+	assert.Error(t, err)
+	tooLong := strings.Repeat("名", models.MaxRoomNicknameLength+1)
+	_, _, err = applyRoomSettings(settings, UpdateRoomSettingsRequest{Nickname: &tooLong}, now)
+	assert.Error(t, err)
+}
+
+func TestMarkChatRoomRead_InvalidRoomID(t *testing.T) {
+	req := httptest.NewRequest("POST", "/chatrooms/not-an-id/read", nil)
+	req = mux.SetURLVars(withSession(req, primitive.NewObjectID(), primitive.NilObjectID), map[string]string{"id": "not-an-id"})
+	rr := httptest.NewRecorder()
+
+	MarkChatRoomRead(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestLastReadAtFor(t *testing.T) {
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	reader, joiner, founder := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	room := &models.ChatRoom{
+		CreatedAt:   createdAt,
+		MemberSince: map[string]time.Time{joiner.Hex(): createdAt.Add(time.Hour)},
+		LastReadAt:  map[string]time.Time{reader.Hex(): createdAt.Add(2 * time.Hour)},
+	}
+
+	assert.Equal(t, createdAt.Add(2*time.Hour), room.LastReadAtFor(reader))
+	assert.Equal(t, createdAt.Add(time.Hour), room.LastReadAtFor(joiner), "加入前的訊息不算未讀")
+	assert.Equal(t, createdAt, room.LastReadAtFor(founder))
+}
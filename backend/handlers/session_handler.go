@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"go-chat/backend/models"
+	"go-chat/backend/utils"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// maxUserAgentLength 是 session 紀錄保存的 User-Agent 最大長度
+const maxUserAgentLength = 256
+
+// startSession 為這次登入建立 session 紀錄，並簽發屬於它的 access token 與 refresh token
+func (h *AuthHandler) startSession(ctx context.Context, w http.ResponseWriter, r *http.Request, user *models.User) error {
+	userAgent := r.UserAgent()
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+	now := time.Now()
+	session := models.Session{
+		ID:         primitive.NewObjectID(),
+		UserID:     user.ID,
+		UserAgent:  userAgent,
+		IP:         utils.GetClientIP(r),
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+	if err := h.SessionStore.CreateSession(ctx, session); err != nil {
+		return err
+	}
+	// session ID 同時也是 refresh token family ID 與 access token 的 sid
+	return h.issueSession(ctx, w, user, session.ID)
+}
+
+// ListSessions 列出目前使用者所有仍有效的登入裝置
+// 這個 API 端點會是 GET /sessions
+func (h *AuthHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	userID, err := utils.GetUserIDFromContext(r.Context())
+	if err != nil {
+		sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	currentSessionID := utils.GetSessionIDFromContext(r.Context())
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	// 超過 refresh token 效期都沒有活動的 session 已經無法再換發，不列出
+	sessions, err := h.SessionStore.ListActiveSessions(ctx, userID, time.Now().Add(-h.Cfg.RefreshTokenTTL))
+	if err != nil {
+		log.Printf("Error listing sessions of user %s: %v", userID.Hex(), err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentSessionID
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sessions)
+}
+
+// RevokeSessionByID 讓使用者登出指定的裝置，該裝置的 WebSocket 連線也會被中斷
+// 這個 API 端點會是 DELETE /sessions/{id}
+func (h *AuthHandler) RevokeSessionByID(w http.ResponseWriter, r *http.Request) {
+	userID, err := utils.GetUserIDFromContext(r.Context())
+	if err != nil {
+		sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	sessionID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		sendJSONError(w, "Invalid session ID format", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	session, err := h.SessionStore.FindSessionByID(ctx, sessionID)
+	// 不屬於自己的 session 一律回 404，避免洩漏其他使用者的 session 是否存在
+	if err == mongo.ErrNoDocuments || (err == nil && (session.UserID != userID || !session.RevokedAt.IsZero())) {
+		sendJSONError(w, "Session not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error finding session %s: %v", sessionID.Hex(), err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.revokeSession(ctx, userID, sessionID, "此裝置已從其他裝置被登出，請重新登入。"); err != nil {
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	// 登出的是目前這個裝置時，順便清除 cookie
+	if sessionID == utils.GetSessionIDFromContext(r.Context()) {
+		clearAuthCookies(w)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Session revoked"})
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-chat/backend/config"
+	"go-chat/backend/models"
+	"go-chat/backend/store/mocks"
+	"go-chat/backend/utils"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/mock/gomock"
+)
+
+// withSession 模擬 JWTMiddleware，把使用者與 session ID 放入 context
+func withSession(req *http.Request, userID, sessionID primitive.ObjectID) *http.Request {
+	ctx := context.WithValue(req.Context(), utils.UserIDKey, userID)
+	ctx = context.WithValue(ctx, utils.SessionIDKey, sessionID)
+	return req.WithContext(ctx)
+}
+
+func TestListSessions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSessionStore := mocks.NewMockSessionStorer(ctrl)
+	cfg := &config.Config{RefreshTokenTTL: 24 * time.Hour}
+	authHandler := NewAuthHandler(mocks.NewMockUserStorer(ctrl), mocks.NewMockRefreshTokenStorer(ctrl), mockSessionStore, mocks.NewMockTokenDenylist(ctrl), cfg)
+
+	userID := primitive.NewObjectID()
+	current := models.Session{ID: primitive.NewObjectID(), UserID: userID, UserAgent: "browser"}
+	other := models.Session{ID: primitive.NewObjectID(), UserID: userID, UserAgent: "phone"}
+	mockSessionStore.EXPECT().
+		ListActiveSessions(gomock.Any(), userID, gomock.Any()).
+		Return([]models.Session{other, current}, nil).
+		Times(1)
+
+	req := withSession(httptest.NewRequest("GET", "/sessions", nil), userID, current.ID)
+	rr := httptest.NewRecorder()
+
+	authHandler.ListSessions(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	var sessions []models.Session
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &sessions))
+	require.Len(t, sessions, 2)
+	assert.False(t, sessions[0].Current)
+	assert.True(t, sessions[1].Current, "發出請求的 session 應該標記為 current")
+}
+
+func TestRevokeSessionByID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTokenStore := mocks.NewMockRefreshTokenStorer(ctrl)
+	mockSessionStore := mocks.NewMockSessionStorer(ctrl)
+	mockDenylist := mocks.NewMockTokenDenylist(ctrl)
+	authHandler := NewAuthHandler(mocks.NewMockUserStorer(ctrl), mockTokenStore, mockSessionStore, mockDenylist, &config.Config{})
+
+	userID := primitive.NewObjectID()
+	currentSessionID := primitive.NewObjectID()
+
+	newRevokeRequest := func(sessionID string) *http.Request {
+		req := httptest.NewRequest("DELETE", "/sessions/"+sessionID, nil)
+		req = mux.SetURLVars(req, map[string]string{"id": sessionID})
+		return withSession(req, userID, currentSessionID)
+	}
+
+	t.Run("無效的 session ID 回 400", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+
+		authHandler.RevokeSessionByID(rr, newRevokeRequest("not-an-id"))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("不存在的 session 回 404", func(t *testing.T) {
+		sessionID := primitive.NewObjectID()
+		mockSessionStore.EXPECT().FindSessionByID(gomock.Any(), sessionID).Return(nil, mongo.ErrNoDocuments).Times(1)
+		rr := httptest.NewRecorder()
+
+		authHandler.RevokeSessionByID(rr, newRevokeRequest(sessionID.Hex()))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("其他使用者的 session 回 404", func(t *testing.T) {
+		session := &models.Session{ID: primitive.NewObjectID(), UserID: primitive.NewObjectID()}
+		mockSessionStore.EXPECT().FindSessionByID(gomock.Any(), session.ID).Return(session, nil).Times(1)
+		rr := httptest.NewRecorder()
+
+		authHandler.RevokeSessionByID(rr, newRevokeRequest(session.ID.Hex()))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("登出其他裝置時撤銷該 session 但保留目前的 cookie", func(t *testing.T) {
+		session := &models.Session{ID: primitive.NewObjectID(), UserID: userID}
+		mockSessionStore.EXPECT().FindSessionByID(gomock.Any(), session.ID).Return(session, nil).Times(1)
+		mockTokenStore.EXPECT().RevokeRefreshTokenFamily(gomock.Any(), session.ID).Return(nil).Times(1)
+		mockDenylist.EXPECT().RevokeSession(gomock.Any(), session.ID).Return(nil).Times(1)
+		mockSessionStore.EXPECT().RevokeSession(gomock.Any(), session.ID).Return(nil).Times(1)
+		rr := httptest.NewRecorder()
+
+		authHandler.RevokeSessionByID(rr, newRevokeRequest(session.ID.Hex()))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Nil(t, findCookie(rr, accessTokenCookieName))
+	})
+
+	t.Run("登出目前的裝置時一併清除 cookie", func(t *testing.T) {
+		session := &models.Session{ID: currentSessionID, UserID: userID}
+		mockSessionStore.EXPECT().FindSessionByID(gomock.Any(), session.ID).Return(session, nil).Times(1)
+		mockTokenStore.EXPECT().RevokeRefreshTokenFamily(gomock.Any(), session.ID).Return(nil).Times(1)
+		mockDenylist.EXPECT().RevokeSession(gomock.Any(), session.ID).Return(nil).Times(1)
+		mockSessionStore.EXPECT().RevokeSession(gomock.Any(), session.ID).Return(nil).Times(1)
+		rr := httptest.NewRecorder()
+
+		authHandler.RevokeSessionByID(rr, newRevokeRequest(session.ID.Hex()))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		cleared := findCookie(rr, accessTokenCookieName)
+		require.NotNil(t, cleared)
+		assert.Equal(t, -1, cleared.MaxAge)
+	})
+}
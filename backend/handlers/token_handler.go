@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"go-chat/backend/models"
+	"go-chat/backend/utils"
+	"go-chat/backend/websocket"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	accessTokenCookieName  = "token"
+	refreshTokenCookieName = "refresh_token"
+	refreshTokenPrefix     = "rt_"
+)
+
+// issueSession 簽發短效的 access token 與屬於 familyID 的新 refresh token，並寫入 cookie
+func (h *AuthHandler) issueSession(ctx context.Context, w http.ResponseWriter, user *models.User, familyID primitive.ObjectID) error {
+	accessToken, err := utils.GenerateJWT(user.ID, user.Username, familyID, h.tokenKeys(), h.Cfg.AccessTokenTTL)
+	if err != nil {
+		return err
+	}
+
+	refreshToken, err := utils.GenerateOpaqueToken(refreshTokenPrefix)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	err = h.TokenStore.CreateRefreshToken(ctx, models.RefreshToken{
+		ID:        primitive.NewObjectID(),
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: utils.HashToken(refreshToken),
+		ExpiresAt: now.Add(h.Cfg.RefreshTokenTTL),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return err
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     accessTokenCookieName,
+		Value:    accessToken,
+		Path:     "/",
+		Expires:  now.Add(h.Cfg.AccessTokenTTL),
+		HttpOnly: true,                    // true表示JS無法讀取
+		Secure:   true,                    //只在 HTTPS 連線下傳送
+		SameSite: http.SameSiteStrictMode, // 當請求完全來自自己的網站時，瀏覽器才會帶上這個cookie
+	})
+	http.SetCookie(w, &http.Cookie{
+		Name:     refreshTokenCookieName,
+		Value:    refreshToken,
+		Path:     "/",
+		Expires:  now.Add(h.Cfg.RefreshTokenTTL),
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	})
+	return nil
+}
+
+// refreshTokenReuseGrace 是 refresh token 第一次使用後仍可再次換發的時間
+// 同一個瀏覽器的多個分頁幾乎同時換發時會帶著同一個 token，不應該被當成外洩
+const refreshTokenReuseGrace = 5 * time.Second
+
+// refreshTokenReusable 檢查 token 在 now 時是否還能換發：未撤銷，且尚未使用或剛在寬限期內使用過
+func refreshTokenReusable(token *models.RefreshToken, now time.Time) bool {
+	if !token.RevokedAt.IsZero() {
+		return false
+	}
+	return token.UsedAt.IsZero() || now.Sub(token.UsedAt) <= refreshTokenReuseGrace
+}
+
+// RefreshSession 以 refresh token 換發新的 access token 與 refresh token
+// 每個 refresh token 只能使用一次 (寬限期 refreshTokenReuseGrace 內的並行換發除外)；
+// 已撤銷或超過寬限期的 token 再次出現時，視為外洩並撤銷整個 family
+// 這個 API 端點會是 POST /auth/refresh
+func (h *AuthHandler) RefreshSession(w http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie(refreshTokenCookieName)
+	if err != nil || cookie.Value == "" {
+		sendJSONError(w, "Refresh token required", http.StatusUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	token, err := h.TokenStore.FindRefreshTokenByHash(ctx, utils.HashToken(cookie.Value))
+	if err == mongo.ErrNoDocuments {
+		clearAuthCookies(w)
+		sendJSONError(w, "Invalid refresh token", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		log.Printf("Error finding refresh token: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if !refreshTokenReusable(token, time.Now()) {
+		h.revokeReusedFamily(ctx, w, token)
+		return
+	}
+	if time.Now().After(token.ExpiresAt) {
+		clearAuthCookies(w)
+		sendJSONError(w, "Refresh token expired", http.StatusUnauthorized)
+		return
+	}
+
+	if token.UsedAt.IsZero() {
+		// 並行的兩個請求拿著同一個 token 時只有一個能標記成功，另一個重新讀取後依寬限期判斷
+		marked, err := h.TokenStore.MarkRefreshTokenUsed(ctx, token.ID)
+		if err != nil {
+			log.Printf("Error marking refresh token %s used: %v", token.ID.Hex(), err)
+			sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if !marked {
+			token, err = h.TokenStore.FindRefreshTokenByHash(ctx, token.TokenHash)
+			if err != nil {
+				log.Printf("Error reloading refresh token: %v", err)
+				sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			if !refreshTokenReusable(token, time.Now()) {
+				h.revokeReusedFamily(ctx, w, token)
+				return
+			}
+		}
+	}
+
+	user, err := h.UserStore.FindUserByID(ctx, token.UserID)
+	if err == mongo.ErrNoDocuments {
+		clearAuthCookies(w)
+		sendJSONError(w, "User not found", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		log.Printf("Error finding user %s for refresh: %v", token.UserID.Hex(), err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.issueSession(ctx, w, user, token.FamilyID); err != nil {
+		log.Printf("Error issuing session for user %s: %v", user.ID.Hex(), err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if err := h.SessionStore.TouchSession(ctx, token.FamilyID); err != nil {
+		log.Printf("Error updating last seen time of session %s: %v", token.FamilyID.Hex(), err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.LoginResponse{
+		Message:  "Token refreshed",
+		ID:       user.ID.Hex(),
+		Username: user.Username,
+	})
+}
+
+// revokeReusedFamily 處理 refresh token 被重複使用的情況：撤銷整個 family 並要求重新登入
+func (h *AuthHandler) revokeReusedFamily(ctx context.Context, w http.ResponseWriter, token *models.RefreshToken) {
+	log.Printf("Refresh token reuse detected for user %s, revoking family %s", token.UserID.Hex(), token.FamilyID.Hex())
+	if err := h.revokeSession(ctx, token.UserID, token.FamilyID, "您的登入狀態已失效，請重新登入。"); err != nil {
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	clearAuthCookies(w)
+	sendJSONError(w, "Refresh token reuse detected", http.StatusUnauthorized)
+}
+
+// revokeSession 撤銷一次登入：refresh token family、已簽發的 access token 與 session 紀錄，並中斷該 session 的 WebSocket 連線
+func (h *AuthHandler) revokeSession(ctx context.Context, userID, sessionID primitive.ObjectID, reason string) error {
+	if err := h.TokenStore.RevokeRefreshTokenFamily(ctx, sessionID); err != nil {
+		log.Printf("Error revoking refresh token family %s: %v", sessionID.Hex(), err)
+		return err
+	}
+	if err := h.Denylist.RevokeSession(ctx, sessionID); err != nil {
+		log.Printf("Error revoking access tokens of session %s: %v", sessionID.Hex(), err)
+		return err
+	}
+	if err := h.SessionStore.RevokeSession(ctx, sessionID); err != nil {
+		log.Printf("Error marking session %s revoked: %v", sessionID.Hex(), err)
+		return err
+	}
+	websocket.DisconnectUser(userID, sessionID, reason)
+	return nil
+}
+
+// revokeAllSessions 撤銷使用者所有的登入，並中斷該使用者在所有裝置上的 WebSocket 連線
+func (h *AuthHandler) revokeAllSessions(ctx context.Context, userID primitive.ObjectID, reason string) error {
+	if err := h.TokenStore.RevokeUserRefreshTokens(ctx, userID); err != nil {
+		log.Printf("Error revoking refresh tokens of user %s: %v", userID.Hex(), err)
+		return err
+	}
+	if err := h.Denylist.RevokeUser(ctx, userID); err != nil {
+		log.Printf("Error revoking access tokens of user %s: %v", userID.Hex(), err)
+		return err
+	}
+	if err := h.SessionStore.RevokeUserSessions(ctx, userID); err != nil {
+		log.Printf("Error marking sessions of user %s revoked: %v", userID.Hex(), err)
+		return err
+	}
+	websocket.DisconnectUser(userID, primitive.NilObjectID, reason)
+	return nil
+}
+
+// RevokeAllUserSessions 讓系統管理員撤銷使用者所有的登入，所有裝置都必須重新登入
+// 這個 API 端點會是 POST /admin/users/{id}/revoke-sessions
+func (h *AuthHandler) RevokeAllUserSessions(w http.ResponseWriter, r *http.Request) {
+	userID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		sendJSONError(w, "Invalid user ID format", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if _, err := h.UserStore.FindUserByID(ctx, userID); err == mongo.ErrNoDocuments {
+		sendJSONError(w, "User not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.Printf("Error finding user %s: %v", userID.Hex(), err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.revokeAllSessions(ctx, userID, "您的所有登入已被管理員撤銷，請重新登入。"); err != nil {
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	adminID, _ := utils.GetUserIDFromContext(r.Context())
+	log.Printf("All sessions of user %s revoked by admin %s", userID.Hex(), adminID.Hex())
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "All sessions revoked"})
+}
+
+// clearAuthCookies 命令瀏覽器立即刪除所有登入相關的 cookie
+func clearAuthCookies(w http.ResponseWriter) {
+	for _, name := range []string{accessTokenCookieName, refreshTokenCookieName, "user_info"} {
+		http.SetCookie(w, &http.Cookie{
+			Name:   name,
+			Value:  "",
+			Path:   "/",
+			MaxAge: -1, // 【核心】立即過期
+		})
+	}
+}
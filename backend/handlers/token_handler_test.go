@@ -0,0 +1,282 @@
+// backend/handlers/token_handler_test.go
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-chat/backend/config"
+	"go-chat/backend/models"
+	"go-chat/backend/store/mocks"
+	"go-chat/backend/utils"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/mock/gomock"
+)
+
+// findCookie 從回應中找出指定名稱的 Set-Cookie
+func findCookie(rr *httptest.ResponseRecorder, name string) *http.Cookie {
+	for _, cookie := range rr.Result().Cookies() {
+		if cookie.Name == name {
+			return cookie
+		}
+	}
+	return nil
+}
+
+// newRefreshRequest 建立帶有 refresh token cookie 的換發請求
+func newRefreshRequest(refreshToken string) *http.Request {
+	req := httptest.NewRequest("POST", "/auth/refresh", nil)
+	if refreshToken != "" {
+		req.AddCookie(&http.Cookie{Name: refreshTokenCookieName, Value: refreshToken})
+	}
+	return req
+}
+
+func TestRefreshSession(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserStore := mocks.NewMockUserStorer(ctrl)
+	mockTokenStore := mocks.NewMockRefreshTokenStorer(ctrl)
+	mockSessionStore := mocks.NewMockSessionStorer(ctrl)
+	mockDenylist := mocks.NewMockTokenDenylist(ctrl)
+	cfg := &config.Config{
+		JWTSecret:       "test-secret-for-refresh",
+		AccessTokenTTL:  15 * time.Minute,
+		RefreshTokenTTL: 24 * time.Hour,
+	}
+	authHandler := NewAuthHandler(mockUserStore, mockTokenStore, mockSessionStore, mockDenylist, cfg)
+
+	user := &models.User{ID: primitive.NewObjectID(), Username: "testuser"}
+
+	t.Run("沒有 refresh token cookie 回 401", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+
+		authHandler.RefreshSession(rr, newRefreshRequest(""))
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("不存在的 refresh token 回 401 並清除 cookie", func(t *testing.T) {
+		mockTokenStore.EXPECT().
+			FindRefreshTokenByHash(gomock.Any(), utils.HashToken("rt_unknown")).
+			Return(nil, mongo.ErrNoDocuments).
+			Times(1)
+		rr := httptest.NewRecorder()
+
+		authHandler.RefreshSession(rr, newRefreshRequest("rt_unknown"))
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		cleared := findCookie(rr, refreshTokenCookieName)
+		require.NotNil(t, cleared)
+		assert.Equal(t, -1, cleared.MaxAge)
+	})
+
+	t.Run("換發成功時輪替 refresh token 並沿用同一個 family", func(t *testing.T) {
+		stored := &models.RefreshToken{
+			ID:        primitive.NewObjectID(),
+			UserID:    user.ID,
+			FamilyID:  primitive.NewObjectID(),
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+		mockTokenStore.EXPECT().FindRefreshTokenByHash(gomock.Any(), utils.HashToken("rt_valid")).Return(stored, nil).Times(1)
+		mockTokenStore.EXPECT().MarkRefreshTokenUsed(gomock.Any(), stored.ID).Return(true, nil).Times(1)
+		mockUserStore.EXPECT().FindUserByID(gomock.Any(), user.ID).Return(user, nil).Times(1)
+
+		var rotated models.RefreshToken
+		mockTokenStore.EXPECT().
+			CreateRefreshToken(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ interface{}, token models.RefreshToken) error {
+				rotated = token
+				return nil
+			}).
+			Times(1)
+		mockSessionStore.EXPECT().TouchSession(gomock.Any(), stored.FamilyID).Return(nil).Times(1)
+		rr := httptest.NewRecorder()
+
+		authHandler.RefreshSession(rr, newRefreshRequest("rt_valid"))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, stored.FamilyID, rotated.FamilyID, "新的 refresh token 應該屬於同一個 family")
+
+		newRefresh := findCookie(rr, refreshTokenCookieName)
+		require.NotNil(t, newRefresh)
+		assert.NotEqual(t, "rt_valid", newRefresh.Value, "refresh token 應該被輪替")
+		assert.Equal(t, utils.HashToken(newRefresh.Value), rotated.TokenHash)
+
+		access := findCookie(rr, accessTokenCookieName)
+		require.NotNil(t, access)
+		userID, err := utils.GetUserIDFromToken(access.Value, utils.NewHMACKeys(cfg.JWTSecret))
+		assert.NoError(t, err)
+		assert.Equal(t, user.ID, userID)
+	})
+
+	t.Run("超過寬限期後重複使用已換發過的 token 會撤銷整個 family", func(t *testing.T) {
+		stored := &models.RefreshToken{
+			ID:        primitive.NewObjectID(),
+			UserID:    user.ID,
+			FamilyID:  primitive.NewObjectID(),
+			ExpiresAt: time.Now().Add(time.Hour),
+			UsedAt:    time.Now().Add(-time.Minute),
+		}
+		mockTokenStore.EXPECT().FindRefreshTokenByHash(gomock.Any(), utils.HashToken("rt_reused")).Return(stored, nil).Times(1)
+		mockTokenStore.EXPECT().RevokeRefreshTokenFamily(gomock.Any(), stored.FamilyID).Return(nil).Times(1)
+		mockDenylist.EXPECT().RevokeSession(gomock.Any(), stored.FamilyID).Return(nil).Times(1)
+		mockSessionStore.EXPECT().RevokeSession(gomock.Any(), stored.FamilyID).Return(nil).Times(1)
+		rr := httptest.NewRecorder()
+
+		authHandler.RefreshSession(rr, newRefreshRequest("rt_reused"))
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		assert.Equal(t, -1, findCookie(rr, accessTokenCookieName).MaxAge)
+	})
+
+	// expectRotation 預期換發成功並建立同一個 family 的新 token
+	expectRotation := func(stored *models.RefreshToken) {
+		mockUserStore.EXPECT().FindUserByID(gomock.Any(), user.ID).Return(user, nil).Times(1)
+		mockTokenStore.EXPECT().
+			CreateRefreshToken(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ interface{}, token models.RefreshToken) error {
+				assert.Equal(t, stored.FamilyID, token.FamilyID)
+				return nil
+			}).
+			Times(1)
+		mockSessionStore.EXPECT().TouchSession(gomock.Any(), stored.FamilyID).Return(nil).Times(1)
+	}
+
+	t.Run("其他分頁剛換發過的 token 在寬限期內仍可換發，不會撤銷 family", func(t *testing.T) {
+		stored := &models.RefreshToken{
+			ID:        primitive.NewObjectID(),
+			UserID:    user.ID,
+			FamilyID:  primitive.NewObjectID(),
+			ExpiresAt: time.Now().Add(time.Hour),
+			UsedAt:    time.Now().Add(-time.Second),
+		}
+		mockTokenStore.EXPECT().FindRefreshTokenByHash(gomock.Any(), utils.HashToken("rt_other_tab")).Return(stored, nil).Times(1)
+		expectRotation(stored)
+		rr := httptest.NewRecorder()
+
+		authHandler.RefreshSession(rr, newRefreshRequest("rt_other_tab"))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.NotNil(t, findCookie(rr, refreshTokenCookieName))
+	})
+
+	t.Run("並行換發時搶輸的請求重新讀取後在寬限期內仍可換發", func(t *testing.T) {
+		stored := &models.RefreshToken{
+			ID:        primitive.NewObjectID(),
+			UserID:    user.ID,
+			FamilyID:  primitive.NewObjectID(),
+			TokenHash: utils.HashToken("rt_race"),
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+		reloaded := *stored
+		reloaded.UsedAt = time.Now()
+		gomock.InOrder(
+			mockTokenStore.EXPECT().FindRefreshTokenByHash(gomock.Any(), stored.TokenHash).Return(stored, nil),
+			mockTokenStore.EXPECT().MarkRefreshTokenUsed(gomock.Any(), stored.ID).Return(false, nil),
+			mockTokenStore.EXPECT().FindRefreshTokenByHash(gomock.Any(), stored.TokenHash).Return(&reloaded, nil),
+		)
+		expectRotation(stored)
+		rr := httptest.NewRecorder()
+
+		authHandler.RefreshSession(rr, newRefreshRequest("rt_race"))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("並行換發時 family 已被撤銷視為重複使用", func(t *testing.T) {
+		stored := &models.RefreshToken{
+			ID:        primitive.NewObjectID(),
+			UserID:    user.ID,
+			FamilyID:  primitive.NewObjectID(),
+			TokenHash: utils.HashToken("rt_race_revoked"),
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+		reloaded := *stored
+		reloaded.UsedAt = time.Now()
+		reloaded.RevokedAt = time.Now()
+		gomock.InOrder(
+			mockTokenStore.EXPECT().FindRefreshTokenByHash(gomock.Any(), stored.TokenHash).Return(stored, nil),
+			mockTokenStore.EXPECT().MarkRefreshTokenUsed(gomock.Any(), stored.ID).Return(false, nil),
+			mockTokenStore.EXPECT().FindRefreshTokenByHash(gomock.Any(), stored.TokenHash).Return(&reloaded, nil),
+		)
+		mockTokenStore.EXPECT().RevokeRefreshTokenFamily(gomock.Any(), stored.FamilyID).Return(nil).Times(1)
+		mockDenylist.EXPECT().RevokeSession(gomock.Any(), stored.FamilyID).Return(nil).Times(1)
+		mockSessionStore.EXPECT().RevokeSession(gomock.Any(), stored.FamilyID).Return(nil).Times(1)
+		rr := httptest.NewRecorder()
+
+		authHandler.RefreshSession(rr, newRefreshRequest("rt_race_revoked"))
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("過期的 refresh token 回 401", func(t *testing.T) {
+		stored := &models.RefreshToken{
+			ID:        primitive.NewObjectID(),
+			UserID:    user.ID,
+			FamilyID:  primitive.NewObjectID(),
+			ExpiresAt: time.Now().Add(-time.Minute),
+		}
+		mockTokenStore.EXPECT().FindRefreshTokenByHash(gomock.Any(), utils.HashToken("rt_expired")).Return(stored, nil).Times(1)
+		rr := httptest.NewRecorder()
+
+		authHandler.RefreshSession(rr, newRefreshRequest("rt_expired"))
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+}
+
+func TestRevokeAllUserSessions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserStore := mocks.NewMockUserStorer(ctrl)
+	mockTokenStore := mocks.NewMockRefreshTokenStorer(ctrl)
+	mockSessionStore := mocks.NewMockSessionStorer(ctrl)
+	mockDenylist := mocks.NewMockTokenDenylist(ctrl)
+	authHandler := NewAuthHandler(mockUserStore, mockTokenStore, mockSessionStore, mockDenylist, &config.Config{})
+
+	newRevokeRequest := func(userID string) *http.Request {
+		req := httptest.NewRequest("POST", "/admin/users/"+userID+"/revoke-sessions", nil)
+		return mux.SetURLVars(req, map[string]string{"id": userID})
+	}
+
+	t.Run("無效的使用者 ID 回 400", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+
+		authHandler.RevokeAllUserSessions(rr, newRevokeRequest("not-an-id"))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("使用者不存在回 404", func(t *testing.T) {
+		userID := primitive.NewObjectID()
+		mockUserStore.EXPECT().FindUserByID(gomock.Any(), userID).Return(nil, mongo.ErrNoDocuments).Times(1)
+		rr := httptest.NewRecorder()
+
+		authHandler.RevokeAllUserSessions(rr, newRevokeRequest(userID.Hex()))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("撤銷所有 refresh token 與已簽發的 access token", func(t *testing.T) {
+		user := &models.User{ID: primitive.NewObjectID(), Username: "target"}
+		mockUserStore.EXPECT().FindUserByID(gomock.Any(), user.ID).Return(user, nil).Times(1)
+		mockTokenStore.EXPECT().RevokeUserRefreshTokens(gomock.Any(), user.ID).Return(nil).Times(1)
+		mockDenylist.EXPECT().RevokeUser(gomock.Any(), user.ID).Return(nil).Times(1)
+		mockSessionStore.EXPECT().RevokeUserSessions(gomock.Any(), user.ID).Return(nil).Times(1)
+		rr := httptest.NewRecorder()
+
+		authHandler.RevokeAllUserSessions(rr, newRevokeRequest(user.ID.Hex()))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+}
@@ -0,0 +1,427 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-chat/backend/database"
+	"go-chat/backend/models"
+	"go-chat/backend/store"
+	"go-chat/backend/totp"
+	"go-chat/backend/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// twoFactorIssuer 是驗證器 App 中顯示的服務名稱
+const twoFactorIssuer = "GoChat"
+
+// twoFactorChallengeTTL 是密碼驗證通過後完成第二步驗證的期限
+const twoFactorChallengeTTL = 5 * time.Minute
+
+// recoveryCodeCount 是每次產生的復原碼數量
+const recoveryCodeCount = 10
+
+// totpSkew 允許前後各一個時間步的時鐘誤差
+const totpSkew = 1
+
+// 同一個使用者在時間窗內最多可以嘗試的驗證碼次數
+const (
+	twoFactorAttemptLimit  = 5
+	twoFactorAttemptWindow = 5 * time.Minute
+)
+
+// allowTwoFactorAttempt 檢查使用者是否還能嘗試驗證碼，測試時可替換
+var allowTwoFactorAttempt = func(userID primitive.ObjectID) (bool, time.Duration, error) {
+	return database.AllowSlidingWindow("rate-limit:2fa:"+userID.Hex(), twoFactorAttemptLimit, twoFactorAttemptWindow)
+}
+
+// TwoFactorHandler 處理 TOTP 兩步驟驗證的設定與登入第二步
+type TwoFactorHandler struct {
+	Auth           *AuthHandler
+	TwoFactorStore store.TwoFactorStorer
+}
+
+// NewTwoFactorHandler 是一個工廠函式，用於建立新的 TwoFactorHandler
+func NewTwoFactorHandler(auth *AuthHandler, twoFactorStore store.TwoFactorStorer) *TwoFactorHandler {
+	return &TwoFactorHandler{Auth: auth, TwoFactorStore: twoFactorStore}
+}
+
+// isEnabled 回傳使用者是否已啟用兩步驟驗證
+func (h *TwoFactorHandler) isEnabled(ctx context.Context, userID primitive.ObjectID) (bool, error) {
+	twoFactor, err := h.TwoFactorStore.FindTwoFactor(ctx, userID)
+	if err == mongo.ErrNoDocuments {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return twoFactor.Enabled, nil
+}
+
+// twoFactorChallenge 在使用者啟用兩步驟驗證時簽發 challenge token，未啟用時回傳空字串
+// 密碼登入與外部登入都必須經過這一步，不能直接開始 session
+func (h *AuthHandler) twoFactorChallenge(ctx context.Context, user *models.User) (string, error) {
+	if h.TwoFactor == nil {
+		return "", nil
+	}
+	enabled, err := h.TwoFactor.isEnabled(ctx, user.ID)
+	if err != nil || !enabled {
+		return "", err
+	}
+	return utils.GenerateChallengeToken(user.ID, h.tokenKeys(), twoFactorChallengeTTL)
+}
+
+// EnrollTwoFactor 產生新的 TOTP 密鑰與 otpauth URI，需要再以驗證碼確認後才會啟用
+// 這個 API 端點會是 POST /2fa/enroll
+func (h *TwoFactorHandler) EnrollTwoFactor(w http.ResponseWriter, r *http.Request) {
+	userID, err := utils.GetUserIDFromContext(r.Context())
+	if err != nil {
+		sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.Auth.UserStore.FindUserByID(ctx, userID)
+	if err != nil {
+		log.Printf("Error finding user %s for 2FA enrollment: %v", userID.Hex(), err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		log.Printf("Error generating TOTP secret: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	encrypted, err := utils.EncryptString(h.Auth.Cfg.TwoFactorEncryptionKey, secret)
+	if err != nil {
+		log.Printf("Error encrypting TOTP secret: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	saved, err := h.TwoFactorStore.SavePendingTwoFactor(ctx, userID, encrypted)
+	if err != nil {
+		log.Printf("Error saving pending 2FA for user %s: %v", userID.Hex(), err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if !saved {
+		sendJSONError(w, "Two-factor authentication is already enabled", http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.TwoFactorEnrollResponse{
+		Secret:     secret,
+		OTPAuthURI: totp.URI(twoFactorIssuer, user.Email, secret),
+	})
+}
+
+// ConfirmTwoFactor 以驗證器 App 產生的驗證碼確認密鑰並啟用兩步驟驗證，回傳只顯示一次的復原碼
+// 這個 API 端點會是 POST /2fa/confirm
+func (h *TwoFactorHandler) ConfirmTwoFactor(w http.ResponseWriter, r *http.Request) {
+	userID, err := utils.GetUserIDFromContext(r.Context())
+	if err != nil {
+		sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var req models.TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	if req.Code == "" {
+		sendJSONError(w, "Code is required", http.StatusBadRequest)
+		return
+	}
+	if !h.checkAttemptLimit(w, userID) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	twoFactor, err := h.TwoFactorStore.FindTwoFactor(ctx, userID)
+	if err == mongo.ErrNoDocuments {
+		sendJSONError(w, "Two-factor enrollment not started", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("Error finding 2FA for user %s: %v", userID.Hex(), err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if twoFactor.Enabled {
+		sendJSONError(w, "Two-factor authentication is already enabled", http.StatusConflict)
+		return
+	}
+
+	secret, err := utils.DecryptString(h.Auth.Cfg.TwoFactorEncryptionKey, twoFactor.EncryptedSecret)
+	if err != nil {
+		log.Printf("Error decrypting TOTP secret for user %s: %v", userID.Hex(), err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	step, ok := totp.Validate(secret, req.Code, time.Now(), totpSkew)
+	if !ok {
+		sendJSONError(w, "Invalid two-factor code", http.StatusUnauthorized)
+		return
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		log.Printf("Error generating recovery codes: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	// 記錄確認時使用的時間步，同一個驗證碼不能再拿來登入
+	if err := h.TwoFactorStore.EnableTwoFactor(ctx, userID, hashes, step); err != nil {
+		if err == mongo.ErrNoDocuments {
+			sendJSONError(w, "Two-factor authentication is already enabled", http.StatusConflict)
+			return
+		}
+		log.Printf("Error enabling 2FA for user %s: %v", userID.Hex(), err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("Two-factor authentication enabled for user %s", userID.Hex())
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableTwoFactor 以驗證碼或復原碼停用兩步驟驗證
+// 這個 API 端點會是 POST /2fa/disable
+func (h *TwoFactorHandler) DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	userID, err := utils.GetUserIDFromContext(r.Context())
+	if err != nil {
+		sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var req models.TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if !h.verifyEnabledFactor(ctx, w, userID, req, true) {
+		return
+	}
+	if err := h.TwoFactorStore.DeleteTwoFactor(ctx, userID); err != nil {
+		log.Printf("Error disabling 2FA for user %s: %v", userID.Hex(), err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("Two-factor authentication disabled for user %s", userID.Hex())
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes 以驗證碼產生新的復原碼，舊的復原碼會全部失效
+// 這個 API 端點會是 POST /2fa/recovery-codes
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	userID, err := utils.GetUserIDFromContext(r.Context())
+	if err != nil {
+		sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var req models.TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	// 只接受驗證碼，避免拿一個復原碼換到一整組新的復原碼
+	if !h.verifyEnabledFactor(ctx, w, userID, req, false) {
+		return
+	}
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		log.Printf("Error generating recovery codes: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if err := h.TwoFactorStore.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		log.Printf("Error replacing recovery codes for user %s: %v", userID.Hex(), err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// VerifyTwoFactorLogin 以 challenge token 加上驗證碼或復原碼完成登入，成功後才會簽發 cookie
+// 這個 API 端點會是 POST /login/2fa
+func (h *TwoFactorHandler) VerifyTwoFactorLogin(w http.ResponseWriter, r *http.Request) {
+	var req models.TwoFactorLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	if req.ChallengeToken == "" {
+		sendJSONError(w, "Challenge token is required", http.StatusBadRequest)
+		return
+	}
+	challenge, err := utils.ParseChallengeToken(req.ChallengeToken, h.Auth.tokenKeys())
+	if err != nil || challenge.TokenID == "" {
+		sendJSONError(w, "Invalid or expired challenge", http.StatusUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if !h.verifyEnabledFactor(ctx, w, challenge.UserID, req.TwoFactorCodeRequest, true) {
+		return
+	}
+	// 驗證碼正確後才消耗 challenge，輸入錯誤時還能在期限內重試
+	consumed, err := h.Auth.Denylist.ConsumeToken(ctx, challenge.TokenID, challenge.ExpiresAt)
+	if err != nil {
+		log.Printf("Error consuming 2FA challenge for user %s: %v", challenge.UserID.Hex(), err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if !consumed {
+		sendJSONError(w, "Invalid or expired challenge", http.StatusUnauthorized)
+		return
+	}
+
+	user, err := h.Auth.UserStore.FindUserByID(ctx, challenge.UserID)
+	if err == mongo.ErrNoDocuments {
+		sendJSONError(w, "Invalid or expired challenge", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		log.Printf("Error finding user %s for 2FA login: %v", challenge.UserID.Hex(), err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.Auth.startSession(ctx, w, r, user); err != nil {
+		log.Printf("Error issuing session for user %s: %v", user.ID.Hex(), err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("User logged in successfully with 2FA: %s", user.Email)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.LoginResponse{
+		Message:  "Login successful",
+		ID:       user.ID.Hex(),
+		Username: user.Username,
+	})
+}
+
+// checkAttemptLimit 檢查驗證碼嘗試次數，超過時回應 429 並回傳 false
+func (h *TwoFactorHandler) checkAttemptLimit(w http.ResponseWriter, userID primitive.ObjectID) bool {
+	allowed, retryAfter, err := allowTwoFactorAttempt(userID)
+	if err != nil {
+		log.Printf("Error checking rate limit for 2FA attempt: %v", err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return false
+	}
+	if !allowed {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		sendJSONError(w, "Too many two-factor attempts", http.StatusTooManyRequests)
+		return false
+	}
+	return true
+}
+
+// verifyEnabledFactor 驗證已啟用的兩步驟驗證的驗證碼，allowRecovery 為 true 時也接受復原碼
+// 驗證失敗時會寫入錯誤回應並回傳 false
+func (h *TwoFactorHandler) verifyEnabledFactor(ctx context.Context, w http.ResponseWriter, userID primitive.ObjectID, req models.TwoFactorCodeRequest, allowRecovery bool) bool {
+	useRecovery := allowRecovery && req.RecoveryCode != ""
+	if !useRecovery && req.Code == "" {
+		sendJSONError(w, "Code is required", http.StatusBadRequest)
+		return false
+	}
+	if !h.checkAttemptLimit(w, userID) {
+		return false
+	}
+
+	twoFactor, err := h.TwoFactorStore.FindTwoFactor(ctx, userID)
+	if err != nil && err != mongo.ErrNoDocuments {
+		log.Printf("Error finding 2FA for user %s: %v", userID.Hex(), err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return false
+	}
+	if err == mongo.ErrNoDocuments || !twoFactor.Enabled {
+		sendJSONError(w, "Two-factor authentication is not enabled", http.StatusBadRequest)
+		return false
+	}
+
+	var ok bool
+	if useRecovery {
+		ok, err = h.TwoFactorStore.UseRecoveryCode(ctx, userID, hashRecoveryCode(req.RecoveryCode))
+	} else {
+		ok, err = h.useTOTPCode(ctx, twoFactor, req.Code)
+	}
+	if err != nil {
+		log.Printf("Error verifying 2FA code for user %s: %v", userID.Hex(), err)
+		sendJSONError(w, "Internal server error", http.StatusInternalServerError)
+		return false
+	}
+	if !ok {
+		sendJSONError(w, "Invalid two-factor code", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+// useTOTPCode 驗證 TOTP 驗證碼並記錄它的時間步，同一個驗證碼只能使用一次
+func (h *TwoFactorHandler) useTOTPCode(ctx context.Context, twoFactor *models.TwoFactor, code string) (bool, error) {
+	secret, err := utils.DecryptString(h.Auth.Cfg.TwoFactorEncryptionKey, twoFactor.EncryptedSecret)
+	if err != nil {
+		return false, err
+	}
+	step, ok := totp.Validate(secret, code, time.Now(), totpSkew)
+	if !ok || step <= twoFactor.LastUsedStep {
+		return false, nil
+	}
+	return h.TwoFactorStore.UseTOTPStep(ctx, twoFactor.UserID, step)
+}
+
+// generateRecoveryCodes 產生一組復原碼與對應的雜湊，格式為 xxxxx-xxxxx
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		buf := make([]byte, 5)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+		raw := hex.EncodeToString(buf)
+		codes[i] = raw[:5] + "-" + raw[5:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode 忽略大小寫、空白與連字號後計算復原碼的雜湊
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(code)))
+	return utils.HashToken(normalized)
+}
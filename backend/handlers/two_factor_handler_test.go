@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-chat/backend/config"
+	"go-chat/backend/models"
+	"go-chat/backend/store/mocks"
+	"go-chat/backend/totp"
+	"go-chat/backend/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// newTwoFactorTestConfig 建立帶有 JWT secret 與 TOTP 加密金鑰的測試設定
+func newTwoFactorTestConfig() *config.Config {
+	return &config.Config{
+		JWTSecret:              "test-secret",
+		AccessTokenTTL:         15 * time.Minute,
+		RefreshTokenTTL:        time.Hour,
+		TwoFactorEncryptionKey: bytes.Repeat([]byte{7}, 32),
+	}
+}
+
+// stubTwoFactorAttempts 以不限次數的函式取代 Redis 限流，回傳還原函式
+func stubTwoFactorAttempts() func() {
+	original := allowTwoFactorAttempt
+	allowTwoFactorAttempt = func(primitive.ObjectID) (bool, time.Duration, error) { return true, 0, nil }
+	return func() { allowTwoFactorAttempt = original }
+}
+
+func TestEnrollAndConfirmTwoFactor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	defer stubTwoFactorAttempts()()
+
+	mockUserStore := mocks.NewMockUserStorer(ctrl)
+	mockTwoFactorStore := mocks.NewMockTwoFactorStorer(ctrl)
+	cfg := newTwoFactorTestConfig()
+	authHandler := NewAuthHandler(mockUserStore, mocks.NewMockRefreshTokenStorer(ctrl), mocks.NewMockSessionStorer(ctrl), mocks.NewMockTokenDenylist(ctrl), cfg)
+	handler := NewTwoFactorHandler(authHandler, mockTwoFactorStore)
+
+	userID := primitive.NewObjectID()
+	mockUserStore.EXPECT().FindUserByID(gomock.Any(), userID).Return(&models.User{ID: userID, Email: "user@example.com"}, nil).Times(1)
+
+	var encrypted string
+	mockTwoFactorStore.EXPECT().
+		SavePendingTwoFactor(gomock.Any(), userID, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ primitive.ObjectID, secret string) (bool, error) {
+			encrypted = secret
+			return true, nil
+		}).
+		Times(1)
+
+	rr := httptest.NewRecorder()
+	handler.EnrollTwoFactor(rr, withSession(httptest.NewRequest("POST", "/2fa/enroll", nil), userID, primitive.NewObjectID()))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	var enroll models.TwoFactorEnrollResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &enroll))
+	assert.Contains(t, enroll.OTPAuthURI, "otpauth://totp/GoChat:user@example.com")
+	assert.NotContains(t, encrypted, enroll.Secret, "資料庫中不應該保存明文密鑰")
+
+	t.Run("錯誤的驗證碼不會啟用", func(t *testing.T) {
+		mockTwoFactorStore.EXPECT().FindTwoFactor(gomock.Any(), userID).Return(&models.TwoFactor{UserID: userID, EncryptedSecret: encrypted}, nil).Times(1)
+
+		rr := httptest.NewRecorder()
+		req := withSession(newJSONRequest("POST", "/2fa/confirm", map[string]string{"code": "000000"}), userID, primitive.NewObjectID())
+		handler.ConfirmTwoFactor(rr, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("正確的驗證碼啟用並回傳復原碼", func(t *testing.T) {
+		code, err := totp.CodeAt(enroll.Secret, totp.Step(time.Now()))
+		require.NoError(t, err)
+		mockTwoFactorStore.EXPECT().FindTwoFactor(gomock.Any(), userID).Return(&models.TwoFactor{UserID: userID, EncryptedSecret: encrypted}, nil).Times(1)
+		var storedHashes []string
+		mockTwoFactorStore.EXPECT().
+			EnableTwoFactor(gomock.Any(), userID, gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ primitive.ObjectID, hashes []string, _ int64) error {
+				storedHashes = hashes
+				return nil
+			}).
+			Times(1)
+
+		rr := httptest.NewRecorder()
+		req := withSession(newJSONRequest("POST", "/2fa/confirm", map[string]string{"code": code}), userID, primitive.NewObjectID())
+		handler.ConfirmTwoFactor(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var resp models.RecoveryCodesResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Len(t, resp.RecoveryCodes, recoveryCodeCount)
+		assert.Equal(t, hashRecoveryCode(resp.RecoveryCodes[0]), storedHashes[0], "資料庫只保存復原碼的雜湊")
+		assert.NotContains(t, storedHashes, resp.RecoveryCodes[0])
+	})
+}
+
+func TestLoginUser_TwoFactorChallenge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserStore := mocks.NewMockUserStorer(ctrl)
+	mockTwoFactorStore := mocks.NewMockTwoFactorStorer(ctrl)
+	cfg := newTwoFactorTestConfig()
+	authHandler := NewAuthHandler(mockUserStore, mocks.NewMockRefreshTokenStorer(ctrl), mocks.NewMockSessionStorer(ctrl), mocks.NewMockTokenDenylist(ctrl), cfg)
+	authHandler.TwoFactor = NewTwoFactorHandler(authHandler, mockTwoFactorStore)
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("correct-password"), bcrypt.DefaultCost)
+	user := &models.User{ID: primitive.NewObjectID(), Email: "2fa@example.com", Password: string(hashedPassword)}
+	mockUserStore.EXPECT().FindUserByEmail(gomock.Any(), user.Email).Return(user, nil).Times(1)
+	mockTwoFactorStore.EXPECT().FindTwoFactor(gomock.Any(), user.ID).Return(&models.TwoFactor{UserID: user.ID, Enabled: true}, nil).Times(1)
+
+	rr := httptest.NewRecorder()
+	authHandler.LoginUser(rr, newJSONRequest("POST", "/login", map[string]string{"email": user.Email, "password": "correct-password"}))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	var resp models.LoginResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.True(t, resp.TwoFactorRequired)
+	assert.Nil(t, findCookie(rr, accessTokenCookieName), "第二步完成前不應該簽發 token")
+
+	claims, err := utils.ParseChallengeToken(resp.ChallengeToken, utils.NewHMACKeys(cfg.JWTSecret))
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, claims.UserID)
+	_, err = utils.ParseAccessToken(resp.ChallengeToken, utils.NewHMACKeys(cfg.JWTSecret))
+	assert.Error(t, err, "challenge token 不能當作 access token 使用")
+}
+
+func TestVerifyTwoFactorLogin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserStore := mocks.NewMockUserStorer(ctrl)
+	mockTokenStore := mocks.NewMockRefreshTokenStorer(ctrl)
+	mockSessionStore := mocks.NewMockSessionStorer(ctrl)
+	mockDenylist := mocks.NewMockTokenDenylist(ctrl)
+	mockTwoFactorStore := mocks.NewMockTwoFactorStorer(ctrl)
+	cfg := newTwoFactorTestConfig()
+	authHandler := NewAuthHandler(mockUserStore, mockTokenStore, mockSessionStore, mockDenylist, cfg)
+	handler := NewTwoFactorHandler(authHandler, mockTwoFactorStore)
+
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+	encrypted, err := utils.EncryptString(cfg.TwoFactorEncryptionKey, secret)
+	require.NoError(t, err)
+	user := &models.User{ID: primitive.NewObjectID(), Email: "2fa@example.com", Username: "twofa"}
+	enabled := &models.TwoFactor{UserID: user.ID, EncryptedSecret: encrypted, Enabled: true}
+
+	challenge, err := utils.GenerateChallengeToken(user.ID, utils.NewHMACKeys(cfg.JWTSecret), time.Minute)
+	require.NoError(t, err)
+
+	verify := func(payload models.TwoFactorLoginRequest) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		handler.VerifyTwoFactorLogin(rr, newJSONRequest("POST", "/login/2fa", payload))
+		return rr
+	}
+
+	t.Run("超過嘗試次數時回 429", func(t *testing.T) {
+		original := allowTwoFactorAttempt
+		defer func() { allowTwoFactorAttempt = original }()
+		allowTwoFactorAttempt = func(primitive.ObjectID) (bool, time.Duration, error) { return false, 90 * time.Second, nil }
+
+		rr := verify(models.TwoFactorLoginRequest{ChallengeToken: challenge, TwoFactorCodeRequest: models.TwoFactorCodeRequest{Code: "123456"}})
+
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		assert.Equal(t, "90", rr.Header().Get("Retry-After"))
+	})
+
+	defer stubTwoFactorAttempts()()
+
+	t.Run("access token 不能當作 challenge", func(t *testing.T) {
+		accessToken, err := utils.GenerateJWT(user.ID, user.Username, primitive.NewObjectID(), utils.NewHMACKeys(cfg.JWTSecret), time.Minute)
+		require.NoError(t, err)
+
+		rr := verify(models.TwoFactorLoginRequest{ChallengeToken: accessToken, TwoFactorCodeRequest: models.TwoFactorCodeRequest{Code: "123456"}})
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("已使用過的時間步會被拒絕", func(t *testing.T) {
+		step := totp.Step(time.Now())
+		code, err := totp.CodeAt(secret, step)
+		require.NoError(t, err)
+		mockTwoFactorStore.EXPECT().FindTwoFactor(gomock.Any(), user.ID).Return(enabled, nil).Times(1)
+		mockTwoFactorStore.EXPECT().UseTOTPStep(gomock.Any(), user.ID, step).Return(false, nil).Times(1)
+
+		rr := verify(models.TwoFactorLoginRequest{ChallengeToken: challenge, TwoFactorCodeRequest: models.TwoFactorCodeRequest{Code: code}})
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		assert.Nil(t, findCookie(rr, accessTokenCookieName))
+	})
+
+	t.Run("正確的驗證碼完成登入", func(t *testing.T) {
+		step := totp.Step(time.Now())
+		code, err := totp.CodeAt(secret, step)
+		require.NoError(t, err)
+		mockTwoFactorStore.EXPECT().FindTwoFactor(gomock.Any(), user.ID).Return(enabled, nil).Times(1)
+		mockTwoFactorStore.EXPECT().UseTOTPStep(gomock.Any(), user.ID, step).Return(true, nil).Times(1)
+		mockDenylist.EXPECT().ConsumeToken(gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil).Times(1)
+		mockUserStore.EXPECT().FindUserByID(gomock.Any(), user.ID).Return(user, nil).Times(1)
+		mockSessionStore.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+		mockTokenStore.EXPECT().CreateRefreshToken(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+
+		rr := verify(models.TwoFactorLoginRequest{ChallengeToken: challenge, TwoFactorCodeRequest: models.TwoFactorCodeRequest{Code: code}})
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.NotNil(t, findCookie(rr, accessTokenCookieName))
+		assert.NotNil(t, findCookie(rr, refreshTokenCookieName))
+	})
+
+	t.Run("復原碼只能使用一次，challenge 也只能使用一次", func(t *testing.T) {
+		mockTwoFactorStore.EXPECT().FindTwoFactor(gomock.Any(), user.ID).Return(enabled, nil).Times(1)
+		mockTwoFactorStore.EXPECT().UseRecoveryCode(gomock.Any(), user.ID, hashRecoveryCode("abcde-12345")).Return(true, nil).Times(1)
+		mockDenylist.EXPECT().ConsumeToken(gomock.Any(), gomock.Any(), gomock.Any()).Return(false, nil).Times(1)
+
+		rr := verify(models.TwoFactorLoginRequest{ChallengeToken: challenge, TwoFactorCodeRequest: models.TwoFactorCodeRequest{RecoveryCode: "ABCDE 12345"}})
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code, "已使用過的 challenge 不能再換到 session")
+	})
+
+	t.Run("未啟用兩步驟驗證時回 400", func(t *testing.T) {
+		mockTwoFactorStore.EXPECT().FindTwoFactor(gomock.Any(), user.ID).Return(nil, mongo.ErrNoDocuments).Times(1)
+
+		rr := verify(models.TwoFactorLoginRequest{ChallengeToken: challenge, TwoFactorCodeRequest: models.TwoFactorCodeRequest{Code: "123456"}})
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go-chat/backend/database"
+	"go-chat/backend/models"
+	"go-chat/backend/store"
+	"go-chat/backend/utils"
+	"go-chat/backend/webhooks"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// WebhookHandler 包含處理外送 Webhook 管理請求的所有依賴
+type WebhookHandler struct {
+	Store store.WebhookStorer
+}
+
+// NewWebhookHandler 是一個工廠函式，用於建立新的 WebhookHandler
+func NewWebhookHandler(webhookStore store.WebhookStorer) *WebhookHandler {
+	return &WebhookHandler{Store: webhookStore}
+}
+
+// CreateWebhookRequest 定義註冊外送 Webhook 的請求體
+type CreateWebhookRequest struct {
+	URL    string                `json:"url"`
+	Events []models.WebhookEvent `json:"events"` // 留空代表訂閱所有事件
+}
+
+// CreateWebhookResponse 包含簽章用的 secret，只會在建立時回傳一次
+type CreateWebhookResponse struct {
+	models.Webhook
+	Secret string `json:"secret"`
+}
+
+var allWebhookEvents = []models.WebhookEvent{
+	models.WebhookEventMessageCreated,
+	models.WebhookEventMembershipUpdated,
+	models.WebhookEventRoomDeleted,
+}
+
+// requireRoomAdmin 從路徑取得聊天室並確認呼叫者是管理員，失敗時已寫入錯誤回應
+func requireRoomAdmin(w http.ResponseWriter, r *http.Request) (*models.ChatRoom, primitive.ObjectID, bool) {
+	userID, err := utils.GetUserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return nil, primitive.NilObjectID, false
+	}
+
+	roomID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid room ID format", http.StatusBadRequest)
+		return nil, primitive.NilObjectID, false
+	}
+
+	room, err := database.FindChatRoomByID(roomID)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return nil, primitive.NilObjectID, false
+	}
+	if room == nil {
+		http.Error(w, "Chat room not found", http.StatusNotFound)
+		return nil, primitive.NilObjectID, false
+	}
+	if !room.IsAdmin(userID) {
+		http.Error(w, "Only room admins can perform this action", http.StatusForbidden)
+		return nil, primitive.NilObjectID, false
+	}
+	return room, userID, true
+}
+
+// CreateWebhook 處理註冊外送 Webhook 的請求
+// 這個 API 端點會是 POST /chatrooms/{id}/webhooks
+func (h *WebhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	room, userID, ok := requireRoomAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	var req CreateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// 解析主機並拒絕內部網路位址，投遞時的 HTTP client 會在連線前再檢查一次
+	resolveCtx, cancelResolve := context.WithTimeout(r.Context(), 5*time.Second)
+	parsedURL, err := webhooks.ValidateURL(resolveCtx, req.URL)
+	cancelResolve()
+	if errors.Is(err, webhooks.ErrDisallowedAddress) {
+		http.Error(w, "Webhook URL must not point to a private or internal address", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Webhook URL must be a valid https URL with a resolvable host", http.StatusBadRequest)
+		return
+	}
+
+	events := req.Events
+	if len(events) == 0 {
+		events = allWebhookEvents
+	}
+	for _, event := range events {
+		if !isKnownWebhookEvent(event) {
+			http.Error(w, "Unknown webhook event: "+string(event), http.StatusBadRequest)
+			return
+		}
+	}
+
+	secretBytes := make([]byte, 32)
+	if _, err := rand.Read(secretBytes); err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	webhook := models.Webhook{
+		RoomID:    room.ID,
+		URL:       parsedURL.String(),
+		Secret:    "whsec_" + hex.EncodeToString(secretBytes),
+		Events:    events,
+		CreatedBy: userID,
+		CreatedAt: time.Now(),
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	webhook.ID, err = h.Store.CreateWebhook(ctx, webhook)
+	if err != nil {
+		log.Printf("Error creating webhook for room %s: %v", room.ID.Hex(), err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(CreateWebhookResponse{Webhook: webhook, Secret: webhook.Secret})
+}
+
+// ListWebhooks 處理列出聊天室外送 Webhook 的請求 (不包含 secret)
+// 這個 API 端點會是 GET /chatrooms/{id}/webhooks
+func (h *WebhookHandler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	room, _, ok := requireRoomAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	hooks, err := h.Store.ListWebhooksByRoom(ctx, room.ID)
+	if err != nil {
+		log.Printf("Error listing webhooks for room %s: %v", room.ID.Hex(), err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(hooks)
+}
+
+// DeleteWebhook 處理刪除外送 Webhook 的請求
+// 這個 API 端點會是 DELETE /chatrooms/{id}/webhooks/{hookId}
+func (h *WebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := h.findRoomWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	if err := h.Store.DeleteWebhook(ctx, webhook.ID); err != nil {
+		log.Printf("Error deleting webhook %s: %v", webhook.ID.Hex(), err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
+// ListWebhookDeliveries 處理查詢最近投遞紀錄的請求
+// 可用 ?status=dead_letter 只查看放棄投遞的紀錄，?limit= 預設 50、最多 200
+// 這個 API 端點會是 GET /chatrooms/{id}/webhooks/{hookId}/deliveries
+func (h *WebhookHandler) ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := h.findRoomWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	limit := int64(50)
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.ParseInt(limitStr, 10, 64)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = min(parsed, 200)
+	}
+	status := models.WebhookDeliveryStatus(r.URL.Query().Get("status"))
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	deliveries, err := h.Store.ListDeliveries(ctx, webhook.ID, status, limit)
+	if err != nil {
+		log.Printf("Error listing deliveries for webhook %s: %v", webhook.ID.Hex(), err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deliveries)
+}
+
+// findRoomWebhook 確認呼叫者是管理員，並取得屬於該聊天室的 Webhook
+func (h *WebhookHandler) findRoomWebhook(w http.ResponseWriter, r *http.Request) (*models.Webhook, bool) {
+	room, _, ok := requireRoomAdmin(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	hookID, err := primitive.ObjectIDFromHex(mux.Vars(r)["hookId"])
+	if err != nil {
+		http.Error(w, "Invalid webhook ID format", http.StatusBadRequest)
+		return nil, false
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	webhook, err := h.Store.FindWebhookByID(ctx, hookID)
+	if err == mongo.ErrNoDocuments || (err == nil && webhook.RoomID != room.ID) {
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("Error finding webhook %s: %v", hookID.Hex(), err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return nil, false
+	}
+	return webhook, true
+}
+
+func isKnownWebhookEvent(event models.WebhookEvent) bool {
+	for _, known := range allWebhookEvents {
+		if event == known {
+			return true
+		}
+	}
+	return false
+}
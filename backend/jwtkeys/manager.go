@@ -0,0 +1,339 @@
+// Package jwtkeys 管理簽發 access token 的 RS256/EdDSA 金鑰：
+// 金鑰加密保存在資料庫中供所有後端實例共用，定期輪替，輪替後的舊金鑰在寬限期內仍可用於驗證
+package jwtkeys
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"go-chat/backend/config"
+	"go-chat/backend/models"
+	"go-chat/backend/store"
+	"go-chat/backend/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// reloadInterval 是背景重新載入金鑰並檢查是否需要輪替的間隔，其他實例輪替的金鑰最慢在這段時間後生效
+const reloadInterval = time.Minute
+
+// unknownKeyReloadInterval 是遇到未知 kid 時重新載入的最短間隔，避免偽造的 kid 讓我們不斷查詢資料庫
+const unknownKeyReloadInterval = 10 * time.Second
+
+// rsaKeyBits 是新產生的 RSA 金鑰長度
+const rsaKeyBits = 2048
+
+// Options 是金鑰輪替的設定
+type Options struct {
+	Algorithm        string        // config.JWTAlgorithmRS256 或 config.JWTAlgorithmEdDSA
+	EncryptionKey    []byte        // 加密保存私鑰的 AES-256 金鑰
+	RotationInterval time.Duration // 金鑰使用多久後輪替
+	GracePeriod      time.Duration // 輪替後舊金鑰仍可用於驗證的時間
+}
+
+// signingKey 是已解密、可直接用於簽章與驗證的金鑰
+type signingKey struct {
+	id        string
+	method    jwt.SigningMethod
+	private   crypto.Signer
+	public    crypto.PublicKey
+	createdAt time.Time
+	expiresAt time.Time // 零值表示仍在使用中
+}
+
+// Manager 實作 utils.TokenKeys，以最新的使用中金鑰簽發 token，並接受所有還在寬限期內的金鑰
+type Manager struct {
+	store store.JWTKeyStorer
+	opts  Options
+
+	mu       sync.RWMutex
+	active   *signingKey
+	keys     map[string]*signingKey
+	loadedAt time.Time
+
+	// reloadMu 確保同一時間只有一個重新載入或輪替在進行
+	reloadMu sync.Mutex
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewManager 是一個工廠函式，用於建立新的 Manager，使用前需要先呼叫 Load
+func NewManager(keyStore store.JWTKeyStorer, opts Options) *Manager {
+	return &Manager{store: keyStore, opts: opts, keys: map[string]*signingKey{}}
+}
+
+// Load 載入資料庫中的金鑰，沒有可用的金鑰或使用中的金鑰已到期時立即輪替
+func (m *Manager) Load(ctx context.Context) error {
+	m.reloadMu.Lock()
+	defer m.reloadMu.Unlock()
+
+	if err := m.reload(ctx); err != nil {
+		return err
+	}
+	if m.rotationDue() {
+		return m.rotate(ctx)
+	}
+	return nil
+}
+
+// Start 在背景定期重新載入金鑰，並在使用中的金鑰到期時輪替
+func (m *Manager) Start() {
+	m.stop = make(chan struct{})
+	m.done = make(chan struct{})
+	go func() {
+		defer close(m.done)
+		ticker := time.NewTicker(reloadInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-m.stop:
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				if err := m.Load(ctx); err != nil {
+					log.Printf("Error refreshing JWT signing keys: %v", err)
+				}
+				cancel()
+			}
+		}
+	}()
+}
+
+// Stop 停止背景的重新載入
+func (m *Manager) Stop() {
+	if m.stop == nil {
+		return
+	}
+	close(m.stop)
+	<-m.done
+}
+
+// Rotate 立即產生新的簽章金鑰，目前使用中的金鑰改為只用於驗證，寬限期後失效
+func (m *Manager) Rotate(ctx context.Context) error {
+	m.reloadMu.Lock()
+	defer m.reloadMu.Unlock()
+	return m.rotate(ctx)
+}
+
+// SigningKey 回傳目前用來簽發新 token 的金鑰
+func (m *Manager) SigningKey() (*utils.SigningKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.active == nil {
+		return nil, errors.New("no active JWT signing key")
+	}
+	return &utils.SigningKey{ID: m.active.id, Method: m.active.method, Key: m.active.private}, nil
+}
+
+// VerificationKey 依 kid 回傳驗證用的公鑰，token 的 alg 必須與金鑰相同
+// 遇到未知的 kid 時重新載入一次，以接受其他實例剛輪替的金鑰
+func (m *Manager) VerificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key := m.lookup(kid)
+	if key == nil && m.reloadForUnknownKey() {
+		key = m.lookup(kid)
+	}
+	if key == nil {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, errors.New("unexpected signing method")
+	}
+	if !key.expiresAt.IsZero() && time.Now().After(key.expiresAt) {
+		return nil, fmt.Errorf("key %q has expired", kid)
+	}
+	return key.public, nil
+}
+
+// JWKS 回傳所有仍可用於驗證的公鑰，使用中的金鑰排在最前面
+func (m *Manager) JWKS() JSONWebKeySet {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	set := JSONWebKeySet{Keys: []JSONWebKey{}}
+	if m.active != nil {
+		set.Keys = append(set.Keys, toJSONWebKey(m.active))
+	}
+	now := time.Now()
+	for _, key := range m.sortedKeys() {
+		if key == m.active || (!key.expiresAt.IsZero() && now.After(key.expiresAt)) {
+			continue
+		}
+		set.Keys = append(set.Keys, toJSONWebKey(key))
+	}
+	return set
+}
+
+func (m *Manager) lookup(kid string) *signingKey {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.keys[kid]
+}
+
+// reloadForUnknownKey 在距離上次載入超過 unknownKeyReloadInterval 時重新載入，回傳是否有重新載入
+func (m *Manager) reloadForUnknownKey() bool {
+	m.mu.RLock()
+	recent := time.Since(m.loadedAt) < unknownKeyReloadInterval
+	m.mu.RUnlock()
+	if recent {
+		return false
+	}
+
+	m.reloadMu.Lock()
+	defer m.reloadMu.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := m.reload(ctx); err != nil {
+		log.Printf("Error reloading JWT signing keys: %v", err)
+		return false
+	}
+	return true
+}
+
+// reload 從資料庫重新載入金鑰，已解密過的金鑰直接沿用；呼叫前必須持有 reloadMu
+func (m *Manager) reload(ctx context.Context) error {
+	stored, err := m.store.ListJWTKeys(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+
+	m.mu.RLock()
+	previous := m.keys
+	m.mu.RUnlock()
+
+	keys := make(map[string]*signingKey, len(stored))
+	var active *signingKey
+	for _, record := range stored {
+		key := previous[record.ID]
+		if key == nil {
+			if key, err = m.decryptKey(record); err != nil {
+				log.Printf("Skipping JWT signing key %s: %v", record.ID, err)
+				continue
+			}
+		}
+		// 金鑰可能在上次載入後被其他實例輪替，複製一份再更新到期時間
+		updated := *key
+		updated.expiresAt = record.ExpiresAt
+		keys[record.ID] = &updated
+		// 最新建立且尚未輪替的金鑰用於簽發；並行輪替時較舊的那把會被較新的輪替掉
+		if record.RetiredAt.IsZero() && (active == nil || updated.createdAt.After(active.createdAt)) {
+			active = &updated
+		}
+	}
+
+	m.mu.Lock()
+	m.keys = keys
+	m.active = active
+	m.loadedAt = time.Now()
+	m.mu.Unlock()
+	return nil
+}
+
+// rotationDue 回傳是否需要產生新的簽章金鑰；切換演算法時也會立即輪替
+func (m *Manager) rotationDue() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.active == nil ||
+		m.active.method.Alg() != m.opts.Algorithm ||
+		time.Since(m.active.createdAt) >= m.opts.RotationInterval
+}
+
+// rotate 產生並保存新的金鑰，再將較早建立的使用中金鑰改為只用於驗證；呼叫前必須持有 reloadMu
+func (m *Manager) rotate(ctx context.Context) error {
+	record, err := m.generateKey()
+	if err != nil {
+		return err
+	}
+	// 新金鑰必須比目前使用中的金鑰晚建立，否則同一毫秒內的輪替無法分出先後
+	m.mu.RLock()
+	if m.active != nil && !record.CreatedAt.After(m.active.createdAt) {
+		record.CreatedAt = m.active.createdAt.Add(time.Millisecond)
+	}
+	m.mu.RUnlock()
+	if err := m.store.CreateJWTKey(ctx, *record); err != nil {
+		return err
+	}
+	if err := m.store.RetireJWTKeysBefore(ctx, record.CreatedAt, record.CreatedAt, record.CreatedAt.Add(m.opts.GracePeriod)); err != nil {
+		return err
+	}
+	log.Printf("Rotated JWT signing key, new key id %s (%s)", record.ID, record.Algorithm)
+	return m.reload(ctx)
+}
+
+// generateKey 依設定的演算法產生新的金鑰，私鑰以 PKCS#8 PEM 加密保存
+func (m *Manager) generateKey() (*models.JWTKey, error) {
+	var private crypto.Signer
+	var err error
+	switch m.opts.Algorithm {
+	case config.JWTAlgorithmRS256:
+		private, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	case config.JWTAlgorithmEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported JWT algorithm %q", m.opts.Algorithm)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := utils.EncryptString(m.opts.EncryptionKey, string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})))
+	if err != nil {
+		return nil, err
+	}
+	idBytes := make([]byte, 12)
+	if _, err := rand.Read(idBytes); err != nil {
+		return nil, err
+	}
+	return &models.JWTKey{
+		ID:                  hex.EncodeToString(idBytes),
+		Algorithm:           m.opts.Algorithm,
+		EncryptedPrivateKey: encrypted,
+		// MongoDB 的時間只保存到毫秒，先截斷才能與資料庫中的值比較先後
+		CreatedAt: time.Now().Truncate(time.Millisecond),
+	}, nil
+}
+
+// decryptKey 解密資料庫中的私鑰
+func (m *Manager) decryptKey(record models.JWTKey) (*signingKey, error) {
+	decrypted, err := utils.DecryptString(m.opts.EncryptionKey, record.EncryptedPrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode([]byte(decrypted))
+	if block == nil {
+		return nil, errors.New("invalid private key PEM")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &signingKey{id: record.ID, createdAt: record.CreatedAt, expiresAt: record.ExpiresAt}
+	switch private := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.method, key.private, key.public = jwt.SigningMethodRS256, private, &private.PublicKey
+	case ed25519.PrivateKey:
+		key.method, key.private, key.public = jwt.SigningMethodEdDSA, private, private.Public()
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", parsed)
+	}
+	if key.method.Alg() != record.Algorithm {
+		return nil, fmt.Errorf("key type does not match algorithm %s", record.Algorithm)
+	}
+	return key, nil
+}
@@ -0,0 +1,206 @@
+package jwtkeys
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"go-chat/backend/config"
+	"go-chat/backend/models"
+	"go-chat/backend/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryKeyStore 是測試用的 JWTKeyStorer，多個 Manager 共用時相當於多個後端實例共用資料庫
+type memoryKeyStore struct {
+	mu   sync.Mutex
+	keys []models.JWTKey
+}
+
+func (s *memoryKeyStore) ListJWTKeys(_ context.Context, now time.Time) ([]models.JWTKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var keys []models.JWTKey
+	for _, key := range s.keys {
+		if key.ExpiresAt.IsZero() || key.ExpiresAt.After(now) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (s *memoryKeyStore) CreateJWTKey(_ context.Context, key models.JWTKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = append(s.keys, key)
+	return nil
+}
+
+func (s *memoryKeyStore) RetireJWTKeysBefore(_ context.Context, createdBefore, retiredAt, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.keys {
+		if s.keys[i].CreatedAt.Before(createdBefore) && s.keys[i].RetiredAt.IsZero() {
+			s.keys[i].RetiredAt, s.keys[i].ExpiresAt = retiredAt, expiresAt
+		}
+	}
+	return nil
+}
+
+// expireRetiredKeys 模擬寬限期已經結束
+func (s *memoryKeyStore) expireRetiredKeys() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.keys {
+		if !s.keys[i].RetiredAt.IsZero() {
+			s.keys[i].ExpiresAt = time.Now().Add(-time.Second)
+		}
+	}
+}
+
+// forgetLastLoad 模擬距離上次載入已經超過 unknownKeyReloadInterval
+func forgetLastLoad(m *Manager) {
+	m.mu.Lock()
+	m.loadedAt = time.Time{}
+	m.mu.Unlock()
+}
+
+func newTestManager(t *testing.T, keyStore *memoryKeyStore, algorithm string) *Manager {
+	t.Helper()
+	manager := NewManager(keyStore, Options{
+		Algorithm:        algorithm,
+		EncryptionKey:    []byte("0123456789abcdef0123456789abcdef"),
+		RotationInterval: 24 * time.Hour,
+		GracePeriod:      time.Hour,
+	})
+	require.NoError(t, manager.Load(context.Background()))
+	return manager
+}
+
+func TestManagerSignsAndVerifies(t *testing.T) {
+	for _, algorithm := range []string{config.JWTAlgorithmRS256, config.JWTAlgorithmEdDSA} {
+		t.Run(algorithm, func(t *testing.T) {
+			manager := newTestManager(t, &memoryKeyStore{}, algorithm)
+			userID := primitive.NewObjectID()
+
+			token, err := utils.GenerateJWT(userID, "user", primitive.NewObjectID(), manager, time.Minute)
+			require.NoError(t, err)
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+			require.NoError(t, err)
+			assert.Equal(t, algorithm, parsed.Method.Alg())
+			assert.NotEmpty(t, parsed.Header["kid"])
+
+			claims, err := utils.ParseAccessToken(token, manager)
+			require.NoError(t, err)
+			assert.Equal(t, userID, claims.UserID)
+		})
+	}
+}
+
+func TestManagerRejectsOtherKeys(t *testing.T) {
+	manager := newTestManager(t, &memoryKeyStore{}, config.JWTAlgorithmRS256)
+	userID := primitive.NewObjectID()
+
+	hmacToken, err := utils.GenerateJWT(userID, "user", primitive.NilObjectID, utils.NewHMACKeys("secret"), time.Minute)
+	require.NoError(t, err)
+	_, err = utils.ParseAccessToken(hmacToken, manager)
+	assert.Error(t, err, "沒有 kid 的 HS256 token 不應該被接受")
+
+	// 以公開的 kid 搭配 HS256 偽造 token (演算法混淆攻擊)
+	signingKey, err := manager.SigningKey()
+	require.NoError(t, err)
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"userId": userID.Hex(), "exp": time.Now().Add(time.Minute).Unix()})
+	forged.Header["kid"] = signingKey.ID
+	forgedString, err := forged.SignedString([]byte("guess"))
+	require.NoError(t, err)
+	_, err = utils.ParseAccessToken(forgedString, manager)
+	assert.Error(t, err, "kid 對應的演算法與 token 的 alg 不同時應該拒絕")
+
+	otherManager := newTestManager(t, &memoryKeyStore{}, config.JWTAlgorithmRS256)
+	otherToken, err := utils.GenerateJWT(userID, "user", primitive.NilObjectID, otherManager, time.Minute)
+	require.NoError(t, err)
+	_, err = utils.ParseAccessToken(otherToken, manager)
+	assert.Error(t, err, "其他金鑰組簽發的 token 不應該被接受")
+}
+
+func TestManagerRotation(t *testing.T) {
+	keyStore := &memoryKeyStore{}
+	manager := newTestManager(t, keyStore, config.JWTAlgorithmEdDSA)
+	// 另一個後端實例共用同一組金鑰
+	otherInstance := newTestManager(t, keyStore, config.JWTAlgorithmEdDSA)
+	userID := primitive.NewObjectID()
+
+	before, err := utils.GenerateJWT(userID, "user", primitive.NilObjectID, manager, time.Minute)
+	require.NoError(t, err)
+	oldKey, err := manager.SigningKey()
+	require.NoError(t, err)
+
+	require.NoError(t, manager.Rotate(context.Background()))
+	newKey, err := manager.SigningKey()
+	require.NoError(t, err)
+	assert.NotEqual(t, oldKey.ID, newKey.ID)
+
+	after, err := utils.GenerateJWT(userID, "user", primitive.NilObjectID, manager, time.Minute)
+	require.NoError(t, err)
+
+	_, err = utils.ParseAccessToken(before, manager)
+	assert.NoError(t, err, "寬限期內舊金鑰簽發的 token 仍然有效")
+	forgetLastLoad(otherInstance)
+	_, err = utils.ParseAccessToken(after, otherInstance)
+	assert.NoError(t, err, "其他實例遇到未知的 kid 時應該重新載入金鑰")
+
+	jwks := manager.JWKS()
+	require.Len(t, jwks.Keys, 2)
+	assert.Equal(t, newKey.ID, jwks.Keys[0].Kid, "使用中的金鑰排在最前面")
+	assert.Equal(t, oldKey.ID, jwks.Keys[1].Kid)
+
+	keyStore.expireRetiredKeys()
+	require.NoError(t, manager.Load(context.Background()))
+
+	_, err = utils.ParseAccessToken(before, manager)
+	assert.Error(t, err, "寬限期結束後舊金鑰簽發的 token 不再有效")
+	assert.Len(t, manager.JWKS().Keys, 1)
+}
+
+func TestJWKSPublicKeys(t *testing.T) {
+	t.Run("RS256", func(t *testing.T) {
+		manager := newTestManager(t, &memoryKeyStore{}, config.JWTAlgorithmRS256)
+		signingKey, err := manager.SigningKey()
+		require.NoError(t, err)
+		public := signingKey.Key.(*rsa.PrivateKey).PublicKey
+
+		jwk := manager.JWKS().Keys[0]
+		assert.Equal(t, "RSA", jwk.Kty)
+		assert.Equal(t, "RS256", jwk.Alg)
+		assert.Equal(t, "sig", jwk.Use)
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		require.NoError(t, err)
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		require.NoError(t, err)
+		assert.Equal(t, 0, public.N.Cmp(new(big.Int).SetBytes(n)))
+		assert.Equal(t, public.E, int(new(big.Int).SetBytes(e).Int64()))
+	})
+
+	t.Run("EdDSA", func(t *testing.T) {
+		manager := newTestManager(t, &memoryKeyStore{}, config.JWTAlgorithmEdDSA)
+		signingKey, err := manager.SigningKey()
+		require.NoError(t, err)
+
+		jwk := manager.JWKS().Keys[0]
+		assert.Equal(t, "OKP", jwk.Kty)
+		assert.Equal(t, "Ed25519", jwk.Crv)
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		require.NoError(t, err)
+		assert.Equal(t, []byte(signingKey.Key.(ed25519.PrivateKey).Public().(ed25519.PublicKey)), x)
+	})
+}
@@ -0,0 +1,108 @@
+// Package mailer 負責寄送系統通知信，例如密碼重設連結
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message 是一封要寄出的純文字郵件
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer 定義寄信的方式，正式環境使用 SMTP，測試時使用記憶體實作
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// SMTPMailer 透過 SMTP 伺服器寄信
+type SMTPMailer struct {
+	Addr string // host:port
+	From string
+	Auth smtp.Auth // 伺服器不需要驗證時為 nil
+}
+
+// NewSMTPMailer 是一個工廠函式，用於建立新的 SMTPMailer；username 為空時不做 SMTP 驗證
+func NewSMTPMailer(host, port, username, password, from string) *SMTPMailer {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &SMTPMailer{Addr: net.JoinHostPort(host, port), From: from, Auth: auth}
+}
+
+// Send 寄出郵件；net/smtp 不支援 context，因此在另一個 goroutine 中寄送並在 ctx 結束時放棄等待
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(m.Addr, m.Auth, envelopeAddress(m.From), []string{msg.To}, m.buildMessage(msg))
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// envelopeAddress 從 "名稱 <地址>" 格式取出 SMTP 信封使用的純地址
+func envelopeAddress(from string) string {
+	if addr, err := mail.ParseAddress(from); err == nil {
+		return addr.Address
+	}
+	return from
+}
+
+// buildMessage 組成包含標頭的 RFC 5322 郵件內容
+func (m *SMTPMailer) buildMessage(msg Message) []byte {
+	var b strings.Builder
+	// 標頭中不允許換行，避免被注入額外的標頭
+	header := func(key, value string) {
+		value = strings.NewReplacer("\r", "", "\n", "").Replace(value)
+		fmt.Fprintf(&b, "%s: %s\r\n", key, value)
+	}
+	header("From", m.From)
+	header("To", msg.To)
+	header("Subject", msg.Subject)
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=UTF-8")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// MemoryMailer 把郵件保存在記憶體中而不寄出，用於測試與沒有設定 SMTP 的開發環境
+type MemoryMailer struct {
+	mu   sync.Mutex
+	sent []Message
+}
+
+// NewMemoryMailer 是一個工廠函式，用於建立新的 MemoryMailer
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+// Send 記錄郵件
+func (m *MemoryMailer) Send(ctx context.Context, msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+// Sent 回傳目前為止記錄的所有郵件
+func (m *MemoryMailer) Sent() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Message(nil), m.sent...)
+}
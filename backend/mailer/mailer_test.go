@@ -0,0 +1,35 @@
+package mailer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildMessage(t *testing.T) {
+	m := NewSMTPMailer("smtp.example.com", "587", "", "", "GoChat <no-reply@example.com>")
+
+	raw := string(m.buildMessage(Message{
+		To:      "user@example.com",
+		Subject: "重設密碼\r\nBcc: attacker@example.com",
+		Body:    "第一行\n第二行",
+	}))
+
+	assert.Contains(t, raw, "From: GoChat <no-reply@example.com>\r\n")
+	assert.Contains(t, raw, "To: user@example.com\r\n")
+	assert.False(t, strings.Contains(raw, "\r\nBcc:"), "主旨中的換行不應該產生新的標頭")
+	assert.True(t, strings.HasSuffix(raw, "\r\n\r\n第一行\r\n第二行"))
+	assert.Equal(t, "no-reply@example.com", envelopeAddress(m.From))
+}
+
+func TestMemoryMailer(t *testing.T) {
+	m := NewMemoryMailer()
+
+	assert.NoError(t, m.Send(context.Background(), Message{To: "a@example.com", Subject: "hi"}))
+
+	sent := m.Sent()
+	assert.Len(t, sent, 1)
+	assert.Equal(t, "a@example.com", sent[0].To)
+}
@@ -13,57 +13,204 @@ import (
 	"go-chat/backend/config"
 	"go-chat/backend/database"
 	"go-chat/backend/handlers"
+	"go-chat/backend/jwtkeys"
 	"go-chat/backend/loadtestcontrol"
+	"go-chat/backend/mailer"
 	"go-chat/backend/middleware"
+	"go-chat/backend/oidc"
+	"go-chat/backend/store"
+	"go-chat/backend/utils"
+	"go-chat/backend/webhooks"
 	"go-chat/backend/websocket" // 引入 websocket 套件
 
 	"github.com/gorilla/mux"
 	"github.com/rs/cors"
-	"go-chat/backend/store"
 )
 
 func main() {
 	cfg := config.LoadConfig()
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
 
+	database.MessageRetention = cfg.MessageRetention
+	database.SessionRetention = cfg.RefreshTokenTTL
+	utils.SetTrustedProxies(cfg.TrustedProxies)
 	database.ConnectMongoDB(cfg.MongoDBURI, cfg.DBName)
 	database.ConnectRedis(cfg.RedisAddr)
 	defer database.DisconnectMongoDB()
 
-	// 初始化 Google OAuth 設定
-	handlers.InitializeOAuthGoogle()
+	// 註冊需要聊天室成員管理邏輯的斜線指令 (/invite、/leave)
+	handlers.RegisterChatCommands()
 
 	// 啟動 WebSocket Hub
 	go websocket.GlobalHub.Run()
 
 	router := mux.NewRouter()
 
+	// access token 的簽章金鑰：HS256 使用 JWT_SECRET，RS256/EdDSA 使用資料庫中定期輪替的金鑰
+	var jwtKeys utils.TokenKeys = utils.NewHMACKeys(cfg.JWTSecret)
+	var keyManager *jwtkeys.Manager
+	if cfg.JWTAlgorithm != config.JWTAlgorithmHS256 {
+		keyManager = jwtkeys.NewManager(store.NewMongoJWTKeyStore(), jwtkeys.Options{
+			Algorithm:        cfg.JWTAlgorithm,
+			EncryptionKey:    cfg.JWTKeyEncryptionKey,
+			RotationInterval: cfg.JWTKeyRotationInterval,
+			GracePeriod:      cfg.JWTKeyGracePeriod,
+		})
+		if err := keyManager.Load(context.Background()); err != nil {
+			log.Fatalf("Could not load JWT signing keys: %v", err)
+		}
+		keyManager.Start()
+		defer keyManager.Stop()
+		jwtKeys = keyManager
+	}
+	websocket.SetTokenKeys(jwtKeys)
+	jwksHandler := handlers.NewJWKSHandler(keyManager)
+
 	// 建立 UserStorer 的實例
 	userStore := store.NewMongoUserStore()
 	// 建立 AuthHandler 的實例，並注入依賴
-	authHandler := handlers.NewAuthHandler(userStore, cfg)
+	authHandler := handlers.NewAuthHandler(userStore, store.NewMongoRefreshTokenStore(), store.NewMongoSessionStore(), store.NewRedisTokenDenylist(cfg.AccessTokenTTL), cfg)
+	authHandler.Keys = jwtKeys
+
+	// 沒有設定 SMTP 時，郵件只保存在記憶體中 (僅適用於開發環境)
+	var mailSender mailer.Mailer
+	if cfg.SMTPHost != "" {
+		mailSender = mailer.NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom)
+	} else {
+		log.Println("SMTP_HOST is not set, emails will not be delivered.")
+		mailSender = mailer.NewMemoryMailer()
+	}
+	passwordResetHandler := handlers.NewPasswordResetHandler(authHandler, store.NewMongoPasswordResetStore(), mailSender)
+	emailVerificationHandler := handlers.NewEmailVerificationHandler(authHandler, store.NewMongoEmailVerificationStore(), mailSender)
+	authHandler.Verification = emailVerificationHandler
+	twoFactorHandler := handlers.NewTwoFactorHandler(authHandler, store.NewMongoTwoFactorStore())
+	authHandler.TwoFactor = twoFactorHandler
+	authHandler.Throttle = store.NewRedisLoginThrottler(store.LoginThrottleLimits{
+		IPLimit:      cfg.LoginIPLimit,
+		AccountLimit: cfg.LoginAccountLimit,
+		Window:       cfg.LoginAttemptWindow,
+		MaxFailures:  cfg.LoginMaxFailures,
+		LockoutBase:  cfg.LoginLockoutBase,
+		LockoutMax:   cfg.LoginLockoutMax,
+	})
+	authHandler.AuditLogs = store.NewMongoAuditLogStore()
+	// 以 OpenID Connect 提供者登入 (Google 與 OIDC_PROVIDERS 中設定的提供者)
+	oidcHandler := handlers.NewOIDCHandler(authHandler, oidc.NewRegistry(cfg.OIDCProviders), store.NewMongoIdentityStore())
+
+	// 啟動外送 Webhook 的投遞 worker
+	webhookStore := store.NewMongoWebhookStore()
+	webhookDispatcher := webhooks.NewDispatcher(webhookStore, webhooks.DefaultOptions())
+	webhookDispatcher.Start()
+	defer webhookDispatcher.Stop()
+	webhooks.SetDefault(webhookDispatcher)
+	webhookHandler := handlers.NewWebhookHandler(webhookStore)
+	incomingWebhookHandler := handlers.NewIncomingWebhookHandler(store.NewMongoIncomingWebhookStore(), cfg)
+	roomInviteHandler := handlers.NewRoomInviteHandler(store.NewMongoRoomInviteStore(), cfg)
 
 	// 健康檢查路由 (通常不需要 JWT)
 	router.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprintf(w, "Backend is running!")
 	}).Methods("GET")
+	// 其他服務用來驗證 access token 的公鑰
+	router.HandleFunc("/.well-known/jwks.json", jwksHandler.ServeJWKS).Methods("GET")
 
 	// 不需要 JWT 的路由
 	router.HandleFunc("/register", authHandler.RegisterUser).Methods("POST")
 	router.HandleFunc("/login", authHandler.LoginUser).Methods("POST")
+	router.HandleFunc("/login/2fa", twoFactorHandler.VerifyTwoFactorLogin).Methods("POST")
 	router.HandleFunc("/logout", authHandler.LogoutUser).Methods("POST")
-	router.HandleFunc("/auth/google/login", handlers.HandleGoogleLogin).Methods("GET")
-	router.HandleFunc("/auth/google/callback", handlers.HandleGoogleCallback).Methods("GET")
+	router.HandleFunc("/auth/refresh", authHandler.RefreshSession).Methods("POST")
+	router.HandleFunc("/password-reset/request", passwordResetHandler.RequestPasswordReset).Methods("POST")
+	router.HandleFunc("/password-reset/confirm", passwordResetHandler.ConfirmPasswordReset).Methods("POST")
+	router.HandleFunc("/email-verification/confirm", emailVerificationHandler.ConfirmEmailVerification).Methods("POST")
+	router.HandleFunc("/email-verification/resend", emailVerificationHandler.ResendEmailVerification).Methods("POST")
+	router.HandleFunc("/auth/providers", oidcHandler.ListProviders).Methods("GET")
+	router.HandleFunc("/auth/{provider}/login", oidcHandler.HandleOIDCLogin).Methods("GET")
+	router.HandleFunc("/auth/{provider}/callback", oidcHandler.HandleOIDCCallback).Methods("GET")
+	router.HandleFunc("/auth/link/confirm", oidcHandler.ConfirmIdentityLink).Methods("POST")
+	// Incoming webhook 以 URL 中的金鑰驗證，不需要 JWT
+	router.HandleFunc("/hooks/incoming/{token}", incomingWebhookHandler.ReceiveIncomingWebhook).Methods("POST")
 
 	// --- 需要 JWT 驗證的路由 ---
 	// 獲取所有使用者 API 路由 (需要登入才能看)
-	router.Handle("/all-users", middleware.JWTMiddleware(http.HandlerFunc(handlers.GetAllUsers), cfg.JWTSecret)).Methods("GET")
+	router.Handle("/all-users", middleware.JWTMiddleware(http.HandlerFunc(handlers.GetAllUsers), jwtKeys)).Methods("GET")
+
+	// 登入裝置管理 (只能查看與登出自己的 session)
+	router.Handle("/sessions", middleware.JWTMiddleware(http.HandlerFunc(authHandler.ListSessions), jwtKeys)).Methods("GET")
+	router.Handle("/sessions/{id}", middleware.JWTMiddleware(http.HandlerFunc(authHandler.RevokeSessionByID), jwtKeys)).Methods("DELETE")
+
+	// 外部登入身分的連結與解除 (只能管理自己的身分)
+	router.Handle("/auth/{provider}/link", middleware.JWTMiddleware(http.HandlerFunc(oidcHandler.HandleOIDCLink), jwtKeys)).Methods("GET")
+	router.Handle("/identities", middleware.JWTMiddleware(http.HandlerFunc(oidcHandler.ListIdentities), jwtKeys)).Methods("GET")
+	router.Handle("/identities/{id}", middleware.JWTMiddleware(http.HandlerFunc(oidcHandler.UnlinkIdentity), jwtKeys)).Methods("DELETE")
+
+	// TOTP 兩步驟驗證的設定
+	router.Handle("/2fa/enroll", middleware.JWTMiddleware(http.HandlerFunc(twoFactorHandler.EnrollTwoFactor), jwtKeys)).Methods("POST")
+	router.Handle("/2fa/confirm", middleware.JWTMiddleware(http.HandlerFunc(twoFactorHandler.ConfirmTwoFactor), jwtKeys)).Methods("POST")
+	router.Handle("/2fa/disable", middleware.JWTMiddleware(http.HandlerFunc(twoFactorHandler.DisableTwoFactor), jwtKeys)).Methods("POST")
+	router.Handle("/2fa/recovery-codes", middleware.JWTMiddleware(http.HandlerFunc(twoFactorHandler.RegenerateRecoveryCodes), jwtKeys)).Methods("POST")
 
 	// 聊天室相關路由 (需要登入才能操作)
-	router.Handle("/create-chatrooms", middleware.JWTMiddleware(http.HandlerFunc(handlers.CreateChatRoom), cfg.JWTSecret)).Methods("POST")
-	router.Handle("/user-chatrooms", middleware.JWTMiddleware(http.HandlerFunc(handlers.GetUserChatRooms), cfg.JWTSecret)).Methods("GET")
-	router.Handle("/chatrooms/{id}/update", middleware.JWTMiddleware(http.HandlerFunc(handlers.UpdateChatRoom), cfg.JWTSecret)).Methods("PUT")
-	router.Handle("/chatrooms/{id}/leave", middleware.JWTMiddleware(http.HandlerFunc(handlers.LeaveChatRoom), cfg.JWTSecret)).Methods("POST")
-	router.Handle("/chatrooms/{id}/participants", middleware.JWTMiddleware(http.HandlerFunc(handlers.AddParticipants), cfg.JWTSecret)).Methods("PUT")
+	router.Handle("/create-chatrooms", middleware.JWTMiddleware(http.HandlerFunc(handlers.CreateChatRoom), jwtKeys)).Methods("POST")
+	router.Handle("/user-chatrooms", middleware.JWTMiddleware(http.HandlerFunc(handlers.GetUserChatRooms), jwtKeys)).Methods("GET")
+	router.Handle("/chatrooms/{id}/update", middleware.JWTMiddleware(http.HandlerFunc(handlers.UpdateChatRoom), jwtKeys)).Methods("PUT")
+	router.Handle("/chatrooms/{id}/leave", middleware.JWTMiddleware(http.HandlerFunc(handlers.LeaveChatRoom), jwtKeys)).Methods("POST")
+	router.Handle("/chatrooms/{id}/participants", middleware.JWTMiddleware(http.HandlerFunc(handlers.AddParticipants), jwtKeys)).Methods("PUT")
+	router.Handle("/chatrooms/{id}/messages", middleware.JWTMiddleware(http.HandlerFunc(handlers.SendChatMessage), jwtKeys)).Methods("POST")
+	router.Handle("/chatrooms/{id}/bots", middleware.JWTMiddleware(http.HandlerFunc(handlers.SetRoomBotAccess), jwtKeys)).Methods("PUT")
+	router.Handle("/chatrooms/{id}/settings", middleware.JWTMiddleware(http.HandlerFunc(handlers.UpdateRoomSettings), jwtKeys)).Methods("PUT")
+	router.Handle("/chatrooms/{id}/read", middleware.JWTMiddleware(http.HandlerFunc(handlers.MarkChatRoomRead), jwtKeys)).Methods("POST")
+	// 公開頻道目錄，任何使用者都可以不經邀請加入
+	router.Handle("/channels", middleware.JWTMiddleware(http.HandlerFunc(handlers.ListPublicChannels), jwtKeys)).Methods("GET")
+	router.Handle("/chatrooms/{id}/join", middleware.JWTMiddleware(http.HandlerFunc(handlers.JoinChannel), jwtKeys)).Methods("POST")
+
+	// 外送 Webhook 管理 (僅聊天室管理員)
+	router.Handle("/chatrooms/{id}/webhooks", middleware.JWTMiddleware(http.HandlerFunc(webhookHandler.CreateWebhook), jwtKeys)).Methods("POST")
+	router.Handle("/chatrooms/{id}/webhooks", middleware.JWTMiddleware(http.HandlerFunc(webhookHandler.ListWebhooks), jwtKeys)).Methods("GET")
+	router.Handle("/chatrooms/{id}/webhooks/{hookId}", middleware.JWTMiddleware(http.HandlerFunc(webhookHandler.DeleteWebhook), jwtKeys)).Methods("DELETE")
+	router.Handle("/chatrooms/{id}/webhooks/{hookId}/deliveries", middleware.JWTMiddleware(http.HandlerFunc(webhookHandler.ListWebhookDeliveries), jwtKeys)).Methods("GET")
+
+	// Incoming webhook 管理 (僅聊天室管理員)
+	router.Handle("/chatrooms/{id}/incoming-webhooks", middleware.JWTMiddleware(http.HandlerFunc(incomingWebhookHandler.CreateIncomingWebhook), jwtKeys)).Methods("POST")
+	router.Handle("/chatrooms/{id}/incoming-webhooks", middleware.JWTMiddleware(http.HandlerFunc(incomingWebhookHandler.ListIncomingWebhooks), jwtKeys)).Methods("GET")
+	router.Handle("/chatrooms/{id}/incoming-webhooks/{hookId}/rotate", middleware.JWTMiddleware(http.HandlerFunc(incomingWebhookHandler.RotateIncomingWebhook), jwtKeys)).Methods("POST")
+	router.Handle("/chatrooms/{id}/incoming-webhooks/{hookId}", middleware.JWTMiddleware(http.HandlerFunc(incomingWebhookHandler.DeleteIncomingWebhook), jwtKeys)).Methods("DELETE")
+
+	// 成員管理：移出、封鎖與禁言 (僅聊天室管理員)
+	router.Handle("/chatrooms/{id}/members/{userId}/kick", middleware.JWTMiddleware(http.HandlerFunc(handlers.KickMember), jwtKeys)).Methods("POST")
+	router.Handle("/chatrooms/{id}/bans", middleware.JWTMiddleware(http.HandlerFunc(handlers.ListBans), jwtKeys)).Methods("GET")
+	router.Handle("/chatrooms/{id}/bans/{userId}", middleware.JWTMiddleware(http.HandlerFunc(handlers.BanMember), jwtKeys)).Methods("PUT")
+	router.Handle("/chatrooms/{id}/bans/{userId}", middleware.JWTMiddleware(http.HandlerFunc(handlers.UnbanMember), jwtKeys)).Methods("DELETE")
+	router.Handle("/chatrooms/{id}/mutes", middleware.JWTMiddleware(http.HandlerFunc(handlers.ListMutes), jwtKeys)).Methods("GET")
+	router.Handle("/chatrooms/{id}/mutes/{userId}", middleware.JWTMiddleware(http.HandlerFunc(handlers.MuteMember), jwtKeys)).Methods("PUT")
+	router.Handle("/chatrooms/{id}/mutes/{userId}", middleware.JWTMiddleware(http.HandlerFunc(handlers.UnmuteMember), jwtKeys)).Methods("DELETE")
+
+	// 擁有權轉移、封存與刪除 (僅聊天室擁有者)
+	router.Handle("/chatrooms/{id}/owner", middleware.JWTMiddleware(http.HandlerFunc(handlers.TransferOwnership), jwtKeys)).Methods("PUT")
+	router.Handle("/chatrooms/{id}/archive", middleware.JWTMiddleware(http.HandlerFunc(handlers.ArchiveChatRoom), jwtKeys)).Methods("POST")
+	router.Handle("/chatrooms/{id}/unarchive", middleware.JWTMiddleware(http.HandlerFunc(handlers.UnarchiveChatRoom), jwtKeys)).Methods("POST")
+	router.Handle("/chatrooms/{id}", middleware.JWTMiddleware(http.HandlerFunc(handlers.DeleteChatRoom), jwtKeys)).Methods("DELETE")
+
+	// 邀請連結管理 (僅聊天室管理員)，任何登入的使用者都可以兌換
+	router.Handle("/chatrooms/{id}/invites", middleware.JWTMiddleware(http.HandlerFunc(roomInviteHandler.CreateRoomInvite), jwtKeys)).Methods("POST")
+	router.Handle("/chatrooms/{id}/invites", middleware.JWTMiddleware(http.HandlerFunc(roomInviteHandler.ListRoomInvites), jwtKeys)).Methods("GET")
+	router.Handle("/chatrooms/{id}/invites/{inviteId}", middleware.JWTMiddleware(http.HandlerFunc(roomInviteHandler.RevokeRoomInvite), jwtKeys)).Methods("DELETE")
+	router.Handle("/invites/{code}/redeem", middleware.JWTMiddleware(http.HandlerFunc(roomInviteHandler.RedeemRoomInvite), jwtKeys)).Methods("POST")
+
+	// 機器人帳號管理 (擁有者以 JWT 驗證) 與機器人 REST 發訊
+	// 機器人的 API 金鑰只能用於 /bots/messages (BotMiddleware) 與 /ws，JWTMiddleware 會拒絕機器人金鑰
+	router.Handle("/bots", middleware.JWTMiddleware(http.HandlerFunc(handlers.CreateBot), jwtKeys)).Methods("POST")
+	router.Handle("/bots", middleware.JWTMiddleware(http.HandlerFunc(handlers.GetMyBots), jwtKeys)).Methods("GET")
+	router.Handle("/bots/messages", middleware.BotMiddleware(http.HandlerFunc(handlers.PostBotMessage))).Methods("POST")
+	router.Handle("/bots/{id}/token", middleware.JWTMiddleware(http.HandlerFunc(handlers.RotateBotToken), jwtKeys)).Methods("POST")
+
+	// 系統管理員路由 (需要 JWT 且使用者為系統管理員)
+	router.Handle("/admin/users/{id}/revoke-sessions", middleware.JWTMiddleware(middleware.RequireAdmin(http.HandlerFunc(authHandler.RevokeAllUserSessions)), jwtKeys)).Methods("POST")
+	router.Handle("/admin/users/{id}/unlock", middleware.JWTMiddleware(middleware.RequireAdmin(http.HandlerFunc(authHandler.UnlockUserLogin)), jwtKeys)).Methods("POST")
+	router.Handle("/admin/audit-logs", middleware.JWTMiddleware(middleware.RequireAdmin(http.HandlerFunc(authHandler.ListAuditLogs)), jwtKeys)).Methods("GET")
+	router.Handle("/admin/jwt-keys/rotate", middleware.JWTMiddleware(middleware.RequireAdmin(http.HandlerFunc(jwksHandler.RotateJWTKey)), jwtKeys)).Methods("POST")
 
 	// WebSocket 路由 (WebSocket 連線通常通過 URL 參數或 Cookies 進行認證，而不是 Authorization Header)
 	// 如果你的 WebSocket 連接在 URL 中傳遞了 token，可能需要在 HandleConnections 內部進行驗證
@@ -72,7 +219,7 @@ func main() {
 	// 這裡需要注意：websocket.HandleChatHistory 如果是 REST API 獲取歷史記錄，應該加上 JWT
 	// 如果這個 /chat-history 是 WebSocket 協定的一部分，那麼應該在 HandleConnections 內部處理
 	// 假設它是一個獨立的 REST API
-	router.Handle("/chat-history", middleware.JWTMiddleware(http.HandlerFunc(websocket.HandleChatHistory), cfg.JWTSecret)).Methods("GET")
+	router.Handle("/chat-history", middleware.JWTMiddleware(http.HandlerFunc(websocket.HandleChatHistory), jwtKeys)).Methods("GET")
 
 	if cfg.LoadtestMode {
 		router.HandleFunc("/loadtest/barrier/status", loadtestcontrol.HandleBarrierStatus).Methods("GET")
@@ -85,7 +232,7 @@ func main() {
 	// 實際生產環境中，你應該將 AllowedOrigins 限制為你的前端網域
 	c := cors.New(cors.Options{
 		AllowedOrigins:   []string{"http://localhost:5173"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "OPTIONS"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Content-Type", "Authorization"},
 		AllowCredentials: true,
 	})
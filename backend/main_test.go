@@ -9,8 +9,11 @@ import (
 	"testing"
 
 	"go-chat/backend/database"
+	"go-chat/backend/websocket"
 
+	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/modules/mongodb"
+	"github.com/testcontainers/testcontainers-go/wait"
 )
 
 // TestMain 是整個測試套件的進入點
@@ -37,6 +40,27 @@ func TestMain(m *testing.M) {
 	database.ConnectMongoDB(uri, "test-db")
 	fmt.Println("Successfully connected to test MongoDB container!")
 
+	// 4. 啟動 Redis 容器，限流與聊天室列表快取都需要 Redis
+	redisContainer, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        "redis:7-alpine",
+			ExposedPorts: []string{"6379/tcp"},
+			WaitingFor:   wait.ForLog("Ready to accept connections"),
+		},
+		Started: true,
+	})
+	if err != nil {
+		log.Fatalf("failed to start redis container: %s", err)
+	}
+	redisAddr, err := redisContainer.Endpoint(ctx, "")
+	if err != nil {
+		log.Fatalf("failed to get redis endpoint: %s", err)
+	}
+	database.ConnectRedis(redisAddr)
+
+	// 聊天室相關的 handler 會透過 WebSocket Hub 廣播系統訊息
+	go websocket.GlobalHub.Run()
+
 	// --- 執行所有測試 ---
 	// m.Run() 會執行這個套件中所有其他的 Test... 函式
 	exitCode := m.Run()
@@ -46,6 +70,9 @@ func TestMain(m *testing.M) {
 	if err := mongodbContainer.Terminate(ctx); err != nil {
 		log.Fatalf("failed to terminate container: %s", err)
 	}
+	if err := redisContainer.Terminate(ctx); err != nil {
+		log.Fatalf("failed to terminate redis container: %s", err)
+	}
 
 	// 6. 退出測試
 	os.Exit(exitCode)
@@ -0,0 +1,32 @@
+// backend/middleware/admin_middleware.go
+package middleware
+
+import (
+	"go-chat/backend/database"
+	"go-chat/backend/utils"
+	"log"
+	"net/http"
+)
+
+// RequireAdmin 只允許系統管理員通過，必須包在 JWTMiddleware 裡面使用
+func RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, err := utils.GetUserIDFromContext(r.Context())
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		user, err := database.GetUserByID(userID)
+		if err != nil {
+			log.Printf("Error loading user %s for admin check: %v", userID.Hex(), err)
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		if !user.IsAdmin {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -3,17 +3,50 @@ package middleware
 
 import (
 	"context"
+	"go-chat/backend/database"
+	"go-chat/backend/utils"
 	"log"
 	"net/http"
-	"go-chat/backend/utils"
+	"strings"
 )
 
-// JWTMiddleware 驗證 JWT Token 並將使用者 ID 放入 context
-func JWTMiddleware(next http.Handler, jwtSecret string) http.Handler {
+// isAccessTokenRevoked 檢查 access token 是否在撤銷清單中，測試時可替換
+var isAccessTokenRevoked = func(claims *utils.AccessClaims) (bool, error) {
+	return database.IsAccessTokenRevoked(claims.TokenID, claims.SessionID, claims.UserID, claims.IssuedAt)
+}
+
+// findUserByAPIToken 依 API 金鑰查找機器人帳號，測試時可替換
+var findUserByAPIToken = database.FindUserByAPIToken
+
+// BotMiddleware 只接受機器人帳號的 Authorization: Bearer <API 金鑰>，並將機器人 ID 放入 context
+// 只掛在機器人專用的路由上，其餘路由使用 JWTMiddleware，API 金鑰在那裡一律無效
+func BotMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// cfg := config.LoadConfig()
-		// jwtSecret := cfg.JWTSecret
+		apiToken := utils.GetBearerToken(r)
+		if !strings.HasPrefix(apiToken, database.APITokenPrefix) {
+			http.Error(w, "Bot API token required", http.StatusUnauthorized)
+			return
+		}
+
+		bot, err := findUserByAPIToken(apiToken)
+		if err != nil {
+			log.Printf("Error verifying API token: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if bot == nil || !bot.IsBot {
+			http.Error(w, "Invalid API token", http.StatusUnauthorized)
+			return
+		}
+		ctx := context.WithValue(r.Context(), utils.UserIDKey, bot.ID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
 
+// JWTMiddleware 驗證 JWT Token 並將使用者 ID 放入 context
+// 只接受使用者登入後的 token cookie，機器人的 API 金鑰不能用來存取一般使用者的 API
+func JWTMiddleware(next http.Handler, keys utils.TokenKeys) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// --- 核心修改：從 Cookie 讀取 Token ---
 		cookie, err := r.Cookie("token")
 		if err != nil {
@@ -31,7 +64,7 @@ func JWTMiddleware(next http.Handler, jwtSecret string) http.Handler {
 		// --- 修改結束 ---
 
 		// 驗證 token 的邏輯保持不變
-		userID, err := utils.GetUserIDFromToken(tokenString, jwtSecret)
+		claims, err := utils.ParseAccessToken(tokenString, keys)
 		if err != nil {
 			log.Printf("Invalid JWT token from cookie: %v", err)
 			// 當 token 無效或過期時，可以順便命令瀏覽器刪除這個無用的 cookie
@@ -45,8 +78,23 @@ func JWTMiddleware(next http.Handler, jwtSecret string) http.Handler {
 			return
 		}
 
+		// 已登出或被管理員撤銷的 token 即使還沒過期也不能再使用
+		revoked, err := isAccessTokenRevoked(claims)
+		if err != nil {
+			log.Printf("Error checking token denylist: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if revoked {
+			http.Error(w, "Token has been revoked", http.StatusUnauthorized)
+			return
+		}
+
 		// 將使用者 ID 存儲到請求的 context 中
-		ctx := context.WithValue(r.Context(), utils.UserIDKey, userID)
+		ctx := context.WithValue(r.Context(), utils.UserIDKey, claims.UserID)
+		if !claims.SessionID.IsZero() {
+			ctx = context.WithValue(ctx, utils.SessionIDKey, claims.SessionID)
+		}
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
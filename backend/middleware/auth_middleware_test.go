@@ -5,6 +5,8 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"go-chat/backend/database"
+	"go-chat/backend/models"
 	"go-chat/backend/utils"
 	"time"
 
@@ -16,6 +18,15 @@ import (
 
 func TestJWTMiddleware(t *testing.T) {
 	jwtSecret := "test-secret-for-middleware"
+	keys := utils.NewHMACKeys(jwtSecret)
+
+	// 撤銷清單存在 Redis，測試時以記憶體中的 jti 集合代替
+	revokedTokenIDs := map[string]bool{}
+	originalIsRevoked := isAccessTokenRevoked
+	isAccessTokenRevoked = func(claims *utils.AccessClaims) (bool, error) {
+		return revokedTokenIDs[claims.TokenID], nil
+	}
+	defer func() { isAccessTokenRevoked = originalIsRevoked }()
 
 	t.Run("成功情境 - 有效的 Token", func(t *testing.T) {
 		var handlerCalled bool
@@ -29,10 +40,9 @@ func TestJWTMiddleware(t *testing.T) {
 			w.WriteHeader(http.StatusOK) // 表示成功處理
 		})
 
-		middleware := JWTMiddleware(nextHandler, jwtSecret)
+		middleware := JWTMiddleware(nextHandler, keys)
 
-		
-		token, err := utils.GenerateJWT(expectedUserID, "testuser", jwtSecret)
+		token, err := utils.GenerateJWT(expectedUserID, "testuser", primitive.NilObjectID, keys, time.Hour)
 		assert.NoError(t, err)
 
 		req := httptest.NewRequest("GET", "/protected-route", nil)
@@ -57,7 +67,7 @@ func TestJWTMiddleware(t *testing.T) {
 			w.WriteHeader(http.StatusOK)
 		})
 
-		middleware := JWTMiddleware(nextHandler, jwtSecret)
+		middleware := JWTMiddleware(nextHandler, keys)
 
 		req := httptest.NewRequest("GET", "/protected-route", nil)
 		rr := httptest.NewRecorder()
@@ -76,7 +86,7 @@ func TestJWTMiddleware(t *testing.T) {
 			w.WriteHeader(http.StatusOK)
 		})
 
-		middleware := JWTMiddleware(nextHandler, jwtSecret)
+		middleware := JWTMiddleware(nextHandler, keys)
 
 		req := httptest.NewRequest("GET", "/protected-route", nil)
 		req.AddCookie(&http.Cookie{
@@ -105,11 +115,11 @@ func TestJWTMiddleware(t *testing.T) {
 			w.WriteHeader(http.StatusOK)
 		})
 
-		middleware := JWTMiddleware(nextHandler, jwtSecret)
+		middleware := JWTMiddleware(nextHandler, keys)
 
 		userID := primitive.NewObjectID()
 		wrongSecret := "wrong-secret"
-		token, err := utils.GenerateJWT(userID, "testuser", wrongSecret)
+		token, err := utils.GenerateJWT(userID, "testuser", primitive.NilObjectID, utils.NewHMACKeys(wrongSecret), time.Hour)
 		assert.NoError(t, err)
 
 		req := httptest.NewRequest("GET", "/protected-route", nil)
@@ -138,7 +148,7 @@ func TestJWTMiddleware(t *testing.T) {
 			w.WriteHeader(http.StatusOK)
 		})
 
-		middleware := JWTMiddleware(nextHandler, jwtSecret)
+		middleware := JWTMiddleware(nextHandler, keys)
 
 		userID := primitive.NewObjectID()
 
@@ -170,4 +180,89 @@ func TestJWTMiddleware(t *testing.T) {
 		assert.Contains(t, setCookieHeader, "Max-Age=0", "應該讓 token cookie 立即失效")
 	})
 
+	t.Run("失敗情境 - 已撤銷的 Token", func(t *testing.T) {
+		var handlerCalled bool
+
+		nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handlerCalled = true
+			w.WriteHeader(http.StatusOK)
+		})
+
+		middleware := JWTMiddleware(nextHandler, keys)
+
+		token, err := utils.GenerateJWT(primitive.NewObjectID(), "testuser", primitive.NewObjectID(), keys, time.Hour)
+		assert.NoError(t, err)
+		claims, err := utils.ParseAccessToken(token, keys)
+		assert.NoError(t, err)
+		revokedTokenIDs[claims.TokenID] = true
+
+		req := httptest.NewRequest("GET", "/protected-route", nil)
+		req.AddCookie(&http.Cookie{
+			Name:  "token",
+			Value: token,
+		})
+		rr := httptest.NewRecorder()
+
+		middleware.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code, "已撤銷的 token 應該回傳 401")
+		assert.False(t, handlerCalled, "已撤銷的 token 不應該呼叫 next handler")
+	})
+
+	t.Run("失敗情境 - 機器人 API 金鑰不能存取一般路由", func(t *testing.T) {
+		var handlerCalled bool
+		middleware := JWTMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handlerCalled = true
+		}), keys)
+
+		req := httptest.NewRequest("GET", "/protected-route", nil)
+		req.Header.Set("Authorization", "Bearer "+database.APITokenPrefix+"abc123")
+		rr := httptest.NewRecorder()
+
+		middleware.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		assert.False(t, handlerCalled)
+	})
+}
+
+func TestBotMiddleware(t *testing.T) {
+	bot := &models.User{ID: primitive.NewObjectID(), Username: "ci-bot", IsBot: true}
+	human := &models.User{ID: primitive.NewObjectID(), Username: "alice"}
+	tokens := map[string]*models.User{
+		database.APITokenPrefix + "bot":   bot,
+		database.APITokenPrefix + "human": human,
+	}
+	originalFind := findUserByAPIToken
+	findUserByAPIToken = func(apiToken string) (*models.User, error) {
+		return tokens[apiToken], nil
+	}
+	defer func() { findUserByAPIToken = originalFind }()
+
+	serve := func(authorization string) (int, primitive.ObjectID) {
+		var gotID primitive.ObjectID
+		middleware := BotMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotID, _ = utils.GetUserIDFromContext(r.Context())
+		}))
+		req := httptest.NewRequest("POST", "/bots/messages", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rr := httptest.NewRecorder()
+		middleware.ServeHTTP(rr, req)
+		return rr.Code, gotID
+	}
+
+	code, gotID := serve("Bearer " + database.APITokenPrefix + "bot")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, bot.ID, gotID)
+
+	code, _ = serve("Bearer " + database.APITokenPrefix + "human")
+	assert.Equal(t, http.StatusUnauthorized, code, "非機器人帳號的金鑰不能使用")
+
+	code, _ = serve("Bearer " + database.APITokenPrefix + "unknown")
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	code, _ = serve("")
+	assert.Equal(t, http.StatusUnauthorized, code, "機器人路由不接受沒有 API 金鑰的請求")
 }
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AuditAction 是稽核紀錄的事件類型
+type AuditAction string
+
+const (
+	AuditActionAccountLocked   AuditAction = "account_locked"   // 連續登入失敗導致帳號暫時鎖定
+	AuditActionAccountUnlocked AuditAction = "account_unlocked" // 系統管理員解除帳號鎖定
+)
+
+// AuditLog 記錄與帳號安全相關的事件
+type AuditLog struct {
+	ID             primitive.ObjectID `bson:"_id" json:"id"`
+	Action         AuditAction        `bson:"action" json:"action"`
+	Email          string             `bson:"email" json:"email"`                         // 事件針對的帳號，帳號不存在時仍會記錄嘗試登入的 Email
+	UserID         primitive.ObjectID `bson:"userId,omitempty" json:"userId,omitempty"`   // 帳號存在時的使用者 ID
+	ActorID        primitive.ObjectID `bson:"actorId,omitempty" json:"actorId,omitempty"` // 執行操作的系統管理員
+	IP             string             `bson:"ip,omitempty" json:"ip,omitempty"`
+	LockoutSeconds int64              `bson:"lockoutSeconds,omitempty" json:"lockoutSeconds,omitempty"` // 鎖定的秒數
+	CreatedAt      time.Time          `bson:"createdAt" json:"createdAt"`
+}
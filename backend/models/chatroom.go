@@ -6,12 +6,160 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// 聊天室名稱、主題與說明的長度上限 (以字元計)
+const (
+	MaxRoomNameLength        = 100
+	MaxRoomTopicLength       = 200
+	MaxRoomDescriptionLength = 1000
+)
+
+// RoomKind 是聊天室的類型，決定建立與邀請成員的規則
+type RoomKind string
+
+const (
+	RoomKindDM      RoomKind = "dm"      // 兩人私訊：每對使用者只有一個，不能加入其他成員
+	RoomKindGroup   RoomKind = "group"   // 群組：每次建立都是新的聊天室，成員都可以邀請其他人
+	RoomKindChannel RoomKind = "channel" // 頻道：建立時需要名稱，只有管理員可以邀請成員
+)
+
 // ChatRoom 代表一個聊天室的元資料
 type ChatRoom struct {
-	ID          primitive.ObjectID   `bson:"_id,omitempty" json:"id,omitempty"`
-	Name        string               `bson:"name" json:"name"`
-	CreatorID   primitive.ObjectID   `bson:"creatorId" json:"creatorId"`
-	Participants []primitive.ObjectID `bson:"participants" json:"participants"` // 參與者的使用者 ID 列表
-	CreatedAt   time.Time            `bson:"createdAt" json:"createdAt"`
-	UpdatedAt   time.Time            `bson:"updatedAt" json:"updatedAt"`
+	ID           primitive.ObjectID   `bson:"_id,omitempty" json:"id,omitempty"`
+	Name         string               `bson:"name" json:"name"`                                 // 顯示用的名稱：有自訂名稱時為自訂名稱，否則依成員自動產生
+	CustomName   string               `bson:"customName,omitempty" json:"customName,omitempty"` // 管理員自訂的名稱，空字串表示使用自動產生的名稱
+	Kind         RoomKind             `bson:"kind,omitempty" json:"kind"`                       // 改版前建立的聊天室沒有 kind，視為群組 (兩人的舊聊天室在啟動時升級為私訊)
+	DMKey        string               `bson:"dmKey,omitempty" json:"-"`                         // 私訊兩位成員的 ID 組合，以唯一索引確保每對使用者只有一個私訊
+	Public       bool                 `bson:"public,omitempty" json:"public,omitempty"`         // 公開頻道：任何使用者都能在頻道目錄中找到並直接加入
+	CreatorID    primitive.ObjectID   `bson:"creatorId" json:"creatorId"`
+	OwnerID      primitive.ObjectID   `bson:"ownerId,omitempty" json:"ownerId"`                   // 擁有者，改版前建立的聊天室沒有 ownerId，以建立者為擁有者
+	Participants []primitive.ObjectID `bson:"participants" json:"participants"`                   // 參與者的使用者 ID 列表
+	MemberSince  map[string]time.Time `bson:"memberSince,omitempty" json:"-"`                     // 各成員 (以 ID 的 hex 為鍵) 加入聊天室的時間，用於決定擁有者離開後由誰接手
+	Topic        string               `bson:"topic,omitempty" json:"topic,omitempty"`             // 聊天室主題，可透過 /topic 指令設定
+	Description  string               `bson:"description,omitempty" json:"description,omitempty"` // 聊天室說明
+	Admins       []primitive.ObjectID `bson:"admins,omitempty" json:"admins,omitempty"`           // 額外的管理員，擁有者永遠是管理員
+	AllowedBots  []primitive.ObjectID `bson:"allowedBots,omitempty" json:"allowedBots,omitempty"` // 管理員允許加入的機器人帳號
+	ArchivedAt   time.Time            `bson:"archivedAt,omitempty" json:"archivedAt,omitempty"`   // 封存後聊天室唯讀：保留歷史訊息，但不能再發送訊息或加入成員
+	CreatedAt    time.Time            `bson:"createdAt" json:"createdAt"`
+	UpdatedAt    time.Time            `bson:"updatedAt" json:"updatedAt"`
+
+	// MemberSettings 是各成員 (以 ID 的 hex 為鍵) 的個人設定，只有本人看得到
+	MemberSettings map[string]RoomMemberSettings `bson:"memberSettings,omitempty" json:"-"`
+	// Settings 是目前使用者的個人設定，只在使用者自己的聊天室列表中填入
+	Settings *RoomMemberSettings `bson:"-" json:"settings,omitempty"`
+
+	// LastMessage 是最後一則顯示在聊天室中的訊息，在寫入訊息時一併更新
+	// 聊天室列表快取不包含這個欄位，每次讀取列表時才從資料庫取得
+	LastMessage *MessagePreview `bson:"lastMessage,omitempty" json:"lastMessage,omitempty"`
+	// LastReadAt 是各成員 (以 ID 的 hex 為鍵) 最後一次讀取聊天室的時間，之後其他人的訊息算未讀
+	LastReadAt map[string]time.Time `bson:"lastReadAt,omitempty" json:"-"`
+	// UnreadCount 是目前使用者的未讀訊息數，只在使用者自己的聊天室列表中填入
+	UnreadCount int64 `bson:"-" json:"unreadCount"`
+}
+
+// ChannelDirectoryEntry 是頻道目錄中的一個公開頻道，由資料庫查詢時直接投影產生
+type ChannelDirectoryEntry struct {
+	ID          primitive.ObjectID `bson:"_id" json:"id"`
+	Name        string             `bson:"name" json:"name"`
+	Topic       string             `bson:"topic,omitempty" json:"topic,omitempty"`
+	Description string             `bson:"description,omitempty" json:"description,omitempty"`
+	MemberCount int                `bson:"memberCount" json:"memberCount"`
+	Joined      bool               `bson:"joined" json:"joined"` // 發出請求的使用者是否已經是成員
+}
+
+// ChannelDirectoryPage 是頻道目錄的一頁結果
+type ChannelDirectoryPage struct {
+	Channels []ChannelDirectoryEntry `json:"channels"`
+	Total    int64                   `json:"total"`
+	Page     int64                   `json:"page"`
+	Limit    int64                   `json:"limit"`
+}
+
+// EffectiveKind 回傳聊天室的類型，沒有 kind 的舊聊天室視為群組
+func (r *ChatRoom) EffectiveKind() RoomKind {
+	if r.Kind == "" {
+		return RoomKindGroup
+	}
+	return r.Kind
+}
+
+// Owner 回傳聊天室的擁有者，沒有 ownerId 的舊聊天室以建立者為擁有者
+func (r *ChatRoom) Owner() primitive.ObjectID {
+	if r.OwnerID.IsZero() {
+		return r.CreatorID
+	}
+	return r.OwnerID
+}
+
+// IsArchived 檢查聊天室是否已封存
+func (r *ChatRoom) IsArchived() bool {
+	return !r.ArchivedAt.IsZero()
+}
+
+// JoinedAt 回傳成員加入聊天室的時間，沒有紀錄的成員 (建立時的成員或改版前加入的成員) 視為建立時加入
+func (r *ChatRoom) JoinedAt(userID primitive.ObjectID) time.Time {
+	if joinedAt, ok := r.MemberSince[userID.Hex()]; ok {
+		return joinedAt
+	}
+	return r.CreatedAt
+}
+
+// SettingsFor 回傳成員對聊天室的個人設定，沒有設定時回傳零值
+func (r *ChatRoom) SettingsFor(userID primitive.ObjectID) RoomMemberSettings {
+	return r.MemberSettings[userID.Hex()]
+}
+
+// LastReadAtFor 回傳成員最後一次讀取聊天室的時間，從未標記已讀的成員以加入時間為準
+func (r *ChatRoom) LastReadAtFor(userID primitive.ObjectID) time.Time {
+	if readAt, ok := r.LastReadAt[userID.Hex()]; ok {
+		return readAt
+	}
+	return r.JoinedAt(userID)
+}
+
+// LastActivityAt 回傳聊天室最後的活動時間：最後一則訊息或聊天室資訊更新的時間，取較晚者
+func (r *ChatRoom) LastActivityAt() time.Time {
+	if r.LastMessage != nil && r.LastMessage.Timestamp.After(r.UpdatedAt) {
+		return r.LastMessage.Timestamp
+	}
+	return r.UpdatedAt
+}
+
+// IsAdmin 檢查使用者是否為聊天室管理員 (擁有者或被指定的管理員)
+// 私訊的兩位成員都是管理員
+func (r *ChatRoom) IsAdmin(userID primitive.ObjectID) bool {
+	if r.Owner() == userID {
+		return true
+	}
+	if r.Kind == RoomKindDM {
+		for _, participantID := range r.Participants {
+			if participantID == userID {
+				return true
+			}
+		}
+		return false
+	}
+	for _, adminID := range r.Admins {
+		if adminID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsBot 檢查機器人帳號是否被允許加入此聊天室
+func (r *ChatRoom) AllowsBot(botID primitive.ObjectID) bool {
+	for _, id := range r.AllowedBots {
+		if id == botID {
+			return true
+		}
+	}
+	return false
+}
+
+// DirectMessageKey 回傳兩位使用者的私訊識別字串，與兩人的順序無關
+func DirectMessageKey(a, b primitive.ObjectID) string {
+	if b.Hex() < a.Hex() {
+		a, b = b, a
+	}
+	return a.Hex() + ":" + b.Hex()
 }
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EmailVerification 是寄到使用者信箱的一次性驗證 token，只保存雜湊
+// Email 記錄寄出時的地址，使用者之後更換 Email 時舊的 token 不會驗證到新地址
+type EmailVerification struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID    primitive.ObjectID `bson:"userId" json:"userId"`
+	Email     string             `bson:"email" json:"email"`
+	TokenHash string             `bson:"tokenHash" json:"-"`
+	ExpiresAt time.Time          `bson:"expiresAt" json:"expiresAt"`
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+	UsedAt    time.Time          `bson:"usedAt,omitempty" json:"usedAt,omitempty"`
+}
+
+// EmailVerificationConfirmRequest 是以 token 驗證 Email 的請求內容
+type EmailVerificationConfirmRequest struct {
+	Token string `json:"token"`
+}
+
+// EmailVerificationResendRequest 是重新寄送驗證信的請求內容
+type EmailVerificationResendRequest struct {
+	Email string `json:"email"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Identity 是連結到使用者的外部登入身分，同一個使用者可以有多個提供者的身分
+// (provider, subject) 在所有使用者之間是唯一的
+type Identity struct {
+	ID          primitive.ObjectID `bson:"_id" json:"id"`
+	UserID      primitive.ObjectID `bson:"userId" json:"-"`
+	Provider    string             `bson:"provider" json:"provider"`
+	Subject     string             `bson:"subject" json:"-"` // ID token 的 sub
+	Email       string             `bson:"email,omitempty" json:"email,omitempty"`
+	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
+	LastLoginAt time.Time          `bson:"lastLoginAt" json:"lastLoginAt"`
+}
+
+// OIDCProviderInfo 是登入頁面顯示的提供者資訊
+type OIDCProviderInfo struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"displayName"`
+}
+
+// IdentityLinkRequest 是以原帳號密碼確認連結外部身分的請求內容
+// 原帳號啟用兩步驟驗證時還需要帶上驗證碼或復原碼
+type IdentityLinkRequest struct {
+	LinkToken string `json:"linkToken"`
+	Password  string `json:"password"`
+	TwoFactorCodeRequest
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DefaultIncomingWebhookRateLimit 是 incoming webhook 每分鐘可以發送的預設訊息數
+const DefaultIncomingWebhookRateLimit = 30
+
+// IncomingWebhook 讓外部系統透過一個帶有金鑰的 URL 將訊息發送到聊天室
+type IncomingWebhook struct {
+	ID                 primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	RoomID             primitive.ObjectID `bson:"roomId" json:"roomId"`
+	Name               string             `bson:"name" json:"name"` // 訊息顯示的發送者名稱
+	TokenHash          string             `bson:"tokenHash" json:"-"`
+	RateLimitPerMinute int                `bson:"rateLimitPerMinute" json:"rateLimitPerMinute"`
+	CreatedBy          primitive.ObjectID `bson:"createdBy" json:"createdBy"`
+	CreatedAt          time.Time          `bson:"createdAt" json:"createdAt"`
+	RotatedAt          time.Time          `bson:"rotatedAt,omitempty" json:"rotatedAt,omitempty"`
+}
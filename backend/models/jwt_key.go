@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// JWTKey 是簽發 access token 的非對稱金鑰，ID 即 token 標頭的 kid
+// 私鑰以 AES-GCM 加密保存；輪替後 RetiredAt 不為零，只用於驗證，到 ExpiresAt 後就不再接受
+type JWTKey struct {
+	ID                  string    `bson:"_id" json:"kid"`
+	Algorithm           string    `bson:"algorithm" json:"alg"`
+	EncryptedPrivateKey string    `bson:"encryptedPrivateKey" json:"-"`
+	CreatedAt           time.Time `bson:"createdAt" json:"createdAt"`
+	RetiredAt           time.Time `bson:"retiredAt,omitempty" json:"retiredAt,omitempty"`
+	ExpiresAt           time.Time `bson:"expiresAt,omitempty" json:"expiresAt,omitempty"`
+}
@@ -15,6 +15,9 @@ const (
 	MessageTypeUpdate        MessageType = "room_state_update" // 更新消息(需隱藏)
 	MessageTypeForceLogout   MessageType = "force_logout"
 	MessageTypeLoadtestStart MessageType = "loadtest_start"
+	MessageTypeCommandReply  MessageType = "command_response" // 斜線指令的私人回覆(僅發送者可見，不儲存)
+	MessageTypeRoomRemoved   MessageType = "room_removed"     // 通知被移出或封鎖的使用者(僅當事人可見，不儲存)
+	MessageTypeError         MessageType = "error"            // 訊息被拒絕的原因(僅發送者可見，不儲存)
 )
 
 // Message 代表一個聊天訊息
@@ -23,9 +26,57 @@ type Message struct {
 	Type           MessageType        `bson:"type" json:"type"` // 消息類型
 	SenderID       primitive.ObjectID `bson:"senderId" json:"senderId"`
 	SenderUsername string             `bson:"senderUsername" json:"senderUsername"`
-	RoomID         string             `bson:"roomId" json:"roomId"`     // 聊天室ID
-	RoomName       string             `bson:"roomName" json:"roomName"` // 聊天室名稱
+	IsBot          bool               `bson:"isBot,omitempty" json:"isBot,omitempty"` // 是否由機器人帳號發送
+	RoomID         string             `bson:"roomId" json:"roomId"`                   // 聊天室ID
+	RoomName       string             `bson:"roomName" json:"roomName"`               // 聊天室名稱
 	Content        string             `bson:"content" json:"content"`
 	Timestamp      time.Time          `bson:"timestamp" json:"timestamp"`
 	IsRead         bool               `bson:"isRead" json:"isRead"` // 新增已讀狀態
+	// Silent 只在推送給將聊天室設為靜音的成員時為 true，前端收到時不顯示通知
+	Silent bool `bson:"-" json:"silent,omitempty"`
+	// ExpiresAt 是訊息被自動清除的時間，封存聊天室的訊息沒有這個欄位，會一直保留
+	ExpiresAt time.Time `bson:"expiresAt,omitempty" json:"-"`
+}
+
+// MaxMessagePreviewLength 是聊天室列表中最後一則訊息預覽的長度上限 (以字元計)
+const MaxMessagePreviewLength = 100
+
+// ShownInRoomList 回傳這種訊息是否會成為聊天室列表中的最後一則訊息並計入未讀數
+// 狀態更新只給前端同步用，不顯示在聊天室中
+func (t MessageType) ShownInRoomList() bool {
+	return t == MessageTypeNormal || t == MessageTypeSystem
+}
+
+// MessagePreview 是聊天室列表中顯示的最後一則訊息，內容超過上限時會被截斷
+type MessagePreview struct {
+	ID             primitive.ObjectID `bson:"id" json:"id"`
+	Type           MessageType        `bson:"type" json:"type"`
+	SenderID       primitive.ObjectID `bson:"senderId" json:"senderId"`
+	SenderUsername string             `bson:"senderUsername" json:"senderUsername"`
+	Content        string             `bson:"content" json:"content"`
+	Timestamp      time.Time          `bson:"timestamp" json:"timestamp"`
+	// ExpiresAt 與訊息本身的 ExpiresAt 相同，訊息被清除後不再顯示預覽
+	ExpiresAt time.Time `bson:"expiresAt,omitempty" json:"-"`
+}
+
+// Expired 回傳預覽的訊息是否已超過保留期限
+func (p *MessagePreview) Expired(now time.Time) bool {
+	return !p.ExpiresAt.IsZero() && !p.ExpiresAt.After(now)
+}
+
+// NewMessagePreview 依訊息建立聊天室列表的預覽
+func NewMessagePreview(message Message) MessagePreview {
+	content := message.Content
+	if runes := []rune(content); len(runes) > MaxMessagePreviewLength {
+		content = string(runes[:MaxMessagePreviewLength]) + "…"
+	}
+	return MessagePreview{
+		ID:             message.ID,
+		Type:           message.Type,
+		SenderID:       message.SenderID,
+		SenderUsername: message.SenderUsername,
+		Content:        content,
+		Timestamp:      message.Timestamp,
+		ExpiresAt:      message.ExpiresAt,
+	}
 }
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestNewMessagePreview(t *testing.T) {
+	message := Message{
+		ID:             primitive.NewObjectID(),
+		Type:           MessageTypeNormal,
+		SenderID:       primitive.NewObjectID(),
+		SenderUsername: "alice",
+		Content:        "hello",
+		Timestamp:      time.Now(),
+	}
+
+	preview := NewMessagePreview(message)
+	assert.Equal(t, message.ID, preview.ID)
+	assert.Equal(t, "alice", preview.SenderUsername)
+	assert.Equal(t, "hello", preview.Content)
+	assert.Equal(t, message.Timestamp, preview.Timestamp)
+
+	message.Content = strings.Repeat("字", MaxMessagePreviewLength+10)
+	preview = NewMessagePreview(message)
+	assert.Equal(t, strings.Repeat("字", MaxMessagePreviewLength)+"…", preview.Content, "以字數截斷，不會切開多位元組字元")
+}
+
+func TestShownInRoomList(t *testing.T) {
+	assert.True(t, MessageTypeNormal.ShownInRoomList())
+	assert.True(t, MessageTypeSystem.ShownInRoomList())
+	assert.False(t, MessageTypeUpdate.ShownInRoomList(), "狀態更新不是最後一則訊息")
+}
+
+func TestMessagePreviewExpired(t *testing.T) {
+	now := time.Now()
+
+	assert.False(t, (&MessagePreview{}).Expired(now), "封存聊天室的訊息沒有期限")
+	assert.False(t, (&MessagePreview{ExpiresAt: now.Add(time.Minute)}).Expired(now))
+	assert.True(t, (&MessagePreview{ExpiresAt: now}).Expired(now), "訊息被清除後不再顯示預覽")
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RefreshToken 是一次性使用的 refresh token，每次換發都會產生同一個 family 的新 token
+// 已使用過的 token 再次出現代表可能被竊取，整個 family 都會被撤銷
+type RefreshToken struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID    primitive.ObjectID `bson:"userId" json:"userId"`
+	FamilyID  primitive.ObjectID `bson:"familyId" json:"familyId"` // 同一次登入換發出來的所有 token 共用
+	TokenHash string             `bson:"tokenHash" json:"-"`
+	ExpiresAt time.Time          `bson:"expiresAt" json:"expiresAt"`
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+	UsedAt    time.Time          `bson:"usedAt,omitempty" json:"usedAt,omitempty"`
+	RevokedAt time.Time          `bson:"revokedAt,omitempty" json:"revokedAt,omitempty"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RoomRole 是透過邀請連結加入聊天室時授予的身分
+type RoomRole string
+
+const (
+	RoomRoleMember RoomRole = "member" // 一般成員
+	RoomRoleAdmin  RoomRole = "admin"  // 加入後同時成為聊天室管理員
+)
+
+// RoomInvite 是聊天室管理員建立的邀請連結，任何持有邀請碼的使用者都能用它加入聊天室
+// 資料庫只保存邀請碼的雜湊；MaxUses 為 0 表示不限次數，ExpiresAt 為零表示永不過期
+type RoomInvite struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	RoomID    primitive.ObjectID `bson:"roomId" json:"roomId"`
+	CodeHash  string             `bson:"codeHash" json:"-"`
+	Role      RoomRole           `bson:"role" json:"role"`
+	MaxUses   int                `bson:"maxUses" json:"maxUses"`
+	Uses      int                `bson:"uses" json:"uses"`
+	CreatedBy primitive.ObjectID `bson:"createdBy" json:"createdBy"`
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+	ExpiresAt time.Time          `bson:"expiresAt,omitempty" json:"expiresAt,omitempty"`
+}
+
+// IsUsable 檢查邀請在 now 時是否仍可使用 (未過期且未達使用次數上限)
+func (i *RoomInvite) IsUsable(now time.Time) bool {
+	if !i.ExpiresAt.IsZero() && !now.Before(i.ExpiresAt) {
+		return false
+	}
+	return i.MaxUses == 0 || i.Uses < i.MaxUses
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MaxSanctionReasonLength 是封鎖與禁言原因的長度上限 (以字元計)
+const MaxSanctionReasonLength = 200
+
+// RoomSanctionKind 是聊天室管理員對成員的處分類型
+type RoomSanctionKind string
+
+const (
+	RoomSanctionBan  RoomSanctionKind = "ban"  // 封鎖：移出聊天室，且不能再被邀請或自行加入
+	RoomSanctionMute RoomSanctionKind = "mute" // 禁言：仍是成員，但不能在聊天室發送訊息
+)
+
+// RoomSanction 是聊天室對某位使用者的封鎖或禁言紀錄，同一聊天室、使用者與類型只會有一筆
+// ExpiresAt 為零表示永久有效，到期後由 TTL 索引自動刪除
+type RoomSanction struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	RoomID    primitive.ObjectID `bson:"roomId" json:"roomId"`
+	UserID    primitive.ObjectID `bson:"userId" json:"userId"`
+	Kind      RoomSanctionKind   `bson:"kind" json:"kind"`
+	Reason    string             `bson:"reason,omitempty" json:"reason,omitempty"`
+	CreatedBy primitive.ObjectID `bson:"createdBy" json:"createdBy"`
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+	ExpiresAt time.Time          `bson:"expiresAt,omitempty" json:"expiresAt,omitempty"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// MaxRoomNicknameLength 是使用者替聊天室取的暱稱長度上限 (以字元計)
+const MaxRoomNicknameLength = 100
+
+// RoomMemberSettings 是使用者對單一聊天室的個人設定，不會影響其他成員
+type RoomMemberSettings struct {
+	Muted      bool      `bson:"muted,omitempty" json:"muted,omitempty"`          // 靜音的聊天室不會產生通知
+	MutedUntil time.Time `bson:"mutedUntil,omitempty" json:"mutedUntil,omitzero"` // 靜音結束時間，零值代表直到取消靜音
+	Favorite   bool      `bson:"favorite,omitempty" json:"favorite,omitempty"`    // 我的最愛會置頂顯示
+	SortOrder  int       `bson:"sortOrder,omitempty" json:"sortOrder,omitempty"`  // 我的最愛之間的順序，數字小的在前
+	Hidden     bool      `bson:"hidden,omitempty" json:"hidden,omitempty"`        // 隱藏的聊天室不會出現在預設的聊天室列表中
+	Nickname   string    `bson:"nickname,omitempty" json:"nickname,omitempty"`    // 只有自己看得到的聊天室名稱
+}
+
+// IsMuted 檢查聊天室在 now 時是否處於靜音狀態
+func (s RoomMemberSettings) IsMuted(now time.Time) bool {
+	return s.Muted && (s.MutedUntil.IsZero() || now.Before(s.MutedUntil))
+}
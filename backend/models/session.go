@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Session 記錄使用者的一次登入，ID 與這次登入的 refresh token family 相同
+// 使用者可以在裝置列表中看到它，並從其他裝置將它登出
+type Session struct {
+	ID         primitive.ObjectID `bson:"_id" json:"id"`
+	UserID     primitive.ObjectID `bson:"userId" json:"-"`
+	UserAgent  string             `bson:"userAgent" json:"userAgent"`
+	IP         string             `bson:"ip" json:"ip"`
+	CreatedAt  time.Time          `bson:"createdAt" json:"createdAt"`
+	LastSeenAt time.Time          `bson:"lastSeenAt" json:"lastSeenAt"`
+	RevokedAt  time.Time          `bson:"revokedAt,omitempty" json:"-"`
+	Current    bool               `bson:"-" json:"current"` // 是否為發出這個請求的 session，只在回應中使用
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TwoFactor 是使用者的 TOTP 兩步驟驗證設定，以使用者 ID 作為文件 ID
+// 密鑰以 AES-GCM 加密保存，復原碼只保存雜湊
+type TwoFactor struct {
+	UserID             primitive.ObjectID `bson:"_id" json:"-"`
+	EncryptedSecret    string             `bson:"encryptedSecret" json:"-"`
+	Enabled            bool               `bson:"enabled" json:"enabled"`
+	RecoveryCodeHashes []string           `bson:"recoveryCodeHashes,omitempty" json:"-"`
+	LastUsedStep       int64              `bson:"lastUsedStep" json:"-"` // 最後一次成功使用的 TOTP 時間步，用來拒絕重放
+	CreatedAt          time.Time          `bson:"createdAt" json:"createdAt"`
+	EnabledAt          time.Time          `bson:"enabledAt,omitempty" json:"enabledAt,omitempty"`
+}
+
+// TwoFactorEnrollResponse 是開始設定兩步驟驗證時的回應，密鑰只會出現這一次
+type TwoFactorEnrollResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauthUri"`
+}
+
+// TwoFactorCodeRequest 是帶有驗證碼或復原碼的請求內容
+type TwoFactorCodeRequest struct {
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recoveryCode,omitempty"`
+}
+
+// TwoFactorLoginRequest 是登入第二步的請求內容
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challengeToken"`
+	TwoFactorCodeRequest
+}
+
+// RecoveryCodesResponse 回傳新產生的復原碼，明文只會出現這一次
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
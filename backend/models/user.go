@@ -18,6 +18,9 @@ type LoginResponse struct {
 	ID       string `json:"id"`
 	Username string `json:"username"`
 	// Token    string `json:"token"`
+	// 啟用兩步驟驗證時，密碼正確只會拿到 challenge token，需要再以驗證碼呼叫 /login/2fa
+	TwoFactorRequired bool   `json:"twoFactorRequired,omitempty"`
+	ChallengeToken    string `json:"challengeToken,omitempty"`
 }
 
 // RegisterResponse 結構體用於註冊成功後的響應
@@ -38,6 +41,14 @@ type User struct {
 	Username string             `bson:"username" json:"username"`          // 使用者名稱
 	Password string             `bson:"password,omitempty" json:"-"`       // 儲存哈希後的密碼，設為可選
 	GoogleID string             `bson:"googleId,omitempty" json:"-"`       // 儲存哈希後的密碼，JSON 輸出時忽略
+	IsBot    bool               `bson:"isBot,omitempty" json:"isBot,omitempty"`
+	// BotOwnerID 為建立此機器人帳號的使用者，只有機器人帳號才有值
+	BotOwnerID primitive.ObjectID `bson:"botOwnerId,omitempty" json:"botOwnerId,omitempty"`
+	// IsAdmin 代表系統管理員，可使用 /admin 底下的端點；目前只能直接在資料庫中設定
+	IsAdmin bool `bson:"isAdmin,omitempty" json:"-"`
+	// Verified 代表 Email 已通過驗證；Google 帳號以 Google 的驗證結果為準
+	// 驗證功能上線前註冊的使用者沒有這個欄位，後端啟動時會補為 true
+	Verified bool `bson:"verified" json:"verified"`
 }
 
 // PublicUser 結構體用於返回給前端，不包含敏感資訊
@@ -45,5 +56,6 @@ type PublicUser struct {
 	ID       string `json:"id"`
 	Username string `json:"username"`
 	Email    string `json:"email"`
+	IsBot    bool   `json:"isBot,omitempty"`
 	// 可以添加其他非敏感字段
 }
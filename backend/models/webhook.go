@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// WebhookEvent 定義會觸發外送 Webhook 的聊天室事件
+type WebhookEvent string
+
+const (
+	WebhookEventMessageCreated    WebhookEvent = "message.created"    // 新的一般訊息
+	WebhookEventMembershipUpdated WebhookEvent = "membership.updated" // 成員或聊天室資訊變動 (room_state_update)
+	WebhookEventRoomDeleted       WebhookEvent = "room.deleted"       // 聊天室被刪除
+)
+
+// WebhookDeliveryStatus 定義外送 Webhook 的投遞狀態
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending    WebhookDeliveryStatus = "pending"     // 等待投遞或重試，到了 nextAttemptAt 由 worker 取出
+	WebhookDeliverySucceeded  WebhookDeliveryStatus = "succeeded"   // 對方回應 2xx
+	WebhookDeliveryDeadLetter WebhookDeliveryStatus = "dead_letter" // 超過重試次數，放棄投遞
+)
+
+// Webhook 是聊天室管理員註冊的外送 Webhook
+type Webhook struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	RoomID    primitive.ObjectID `bson:"roomId" json:"roomId"`
+	URL       string             `bson:"url" json:"url"`
+	Secret    string             `bson:"secret" json:"-"` // 用於 HMAC 簽章，只在建立時回傳一次
+	Events    []WebhookEvent     `bson:"events" json:"events"`
+	CreatedBy primitive.ObjectID `bson:"createdBy" json:"createdBy"`
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+}
+
+// WebhookDelivery 記錄一次事件投遞與其最後一次嘗試的結果
+// 建立時會複製 Webhook 的網址與 secret，Webhook 被刪除後已排入的投遞仍可送出
+type WebhookDelivery struct {
+	ID            primitive.ObjectID    `bson:"_id,omitempty" json:"id,omitempty"`
+	WebhookID     primitive.ObjectID    `bson:"webhookId" json:"webhookId"`
+	URL           string                `bson:"url" json:"url"`
+	Secret        string                `bson:"secret" json:"-"`
+	RoomID        primitive.ObjectID    `bson:"roomId" json:"roomId"`
+	Event         WebhookEvent          `bson:"event" json:"event"`
+	Payload       string                `bson:"payload" json:"payload"`
+	Status        WebhookDeliveryStatus `bson:"status" json:"status"`
+	Attempts      int                   `bson:"attempts" json:"attempts"`
+	StatusCode    int                   `bson:"statusCode,omitempty" json:"statusCode,omitempty"`
+	Error         string                `bson:"error,omitempty" json:"error,omitempty"`
+	NextAttemptAt time.Time             `bson:"nextAttemptAt,omitempty" json:"nextAttemptAt,omitempty"`
+	CreatedAt     time.Time             `bson:"createdAt" json:"createdAt"`
+	UpdatedAt     time.Time             `bson:"updatedAt" json:"updatedAt"`
+}
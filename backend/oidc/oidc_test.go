@@ -0,0 +1,138 @@
+package oidc
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"go-chat/backend/config"
+	"go-chat/backend/oidc/oidctest"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func newTestProvider(server *oidctest.Server) *Provider {
+	return NewProvider(config.OIDCProviderConfig{
+		ID:           "test",
+		Issuer:       server.Issuer(),
+		ClientID:     server.ClientID,
+		ClientSecret: server.ClientSecret,
+		RedirectURL:  "http://backend.test/auth/test/callback",
+		Scopes:       []string{"openid", "email"},
+	}, server.Client())
+}
+
+func TestAuthorizationCodeFlowWithPKCE(t *testing.T) {
+	server := oidctest.NewServer()
+	defer server.Close()
+	provider := newTestProvider(server)
+	ctx := context.Background()
+
+	verifier := oauth2.GenerateVerifier()
+	authURL, err := provider.AuthCodeURL(ctx, "state-123", verifier)
+	require.NoError(t, err)
+	parsed, err := url.Parse(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, server.Issuer()+"/authorize", parsed.Scheme+"://"+parsed.Host+parsed.Path)
+	assert.Equal(t, "S256", parsed.Query().Get("code_challenge_method"))
+	assert.NotContains(t, authURL, verifier, "網址中只能出現 code challenge")
+
+	identity := oidctest.Identity{Subject: "user-1", Email: "user@example.com", EmailVerified: true, Name: "User"}
+
+	t.Run("正確的 verifier 可以換到驗證過的 claims", func(t *testing.T) {
+		callback, err := server.Authorize(authURL, identity)
+		require.NoError(t, err)
+		code := mustQuery(t, callback, "code")
+		assert.Equal(t, "state-123", mustQuery(t, callback, "state"))
+
+		claims, err := provider.Exchange(ctx, code, verifier)
+
+		require.NoError(t, err)
+		assert.Equal(t, "user-1", claims.Subject)
+		assert.Equal(t, "user@example.com", claims.Email)
+		assert.True(t, claims.EmailVerified)
+	})
+
+	t.Run("錯誤的 verifier 會被拒絕", func(t *testing.T) {
+		callback, err := server.Authorize(authURL, identity)
+		require.NoError(t, err)
+
+		_, err = provider.Exchange(ctx, mustQuery(t, callback, "code"), oauth2.GenerateVerifier())
+
+		assert.Error(t, err)
+	})
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	server := oidctest.NewServer()
+	defer server.Close()
+	provider := newTestProvider(server)
+	ctx := context.Background()
+
+	t.Run("有效的 token", func(t *testing.T) {
+		claims, err := provider.VerifyIDToken(ctx, server.SignIDToken(jwt.MapClaims{"sub": "abc", "email_verified": "true"}))
+
+		require.NoError(t, err)
+		assert.Equal(t, "abc", claims.Subject)
+		assert.True(t, claims.EmailVerified, "字串形式的 email_verified 也要接受")
+	})
+
+	rejected := map[string]jwt.MapClaims{
+		"audience 不符":    {"sub": "abc", "aud": "other-client"},
+		"issuer 不符":      {"sub": "abc", "iss": "https://evil.example.com"},
+		"已過期":            {"sub": "abc", "exp": time.Now().Add(-time.Hour).Unix()},
+		"缺少 sub":         {},
+		"azp 是其他 client": {"sub": "abc", "aud": []string{server.ClientID, "other"}, "azp": "other"},
+	}
+	for name, claims := range rejected {
+		t.Run(name+"時拒絕", func(t *testing.T) {
+			_, err := provider.VerifyIDToken(ctx, server.SignIDToken(claims))
+			assert.Error(t, err)
+		})
+	}
+
+	t.Run("不接受 HS256 簽章", func(t *testing.T) {
+		forged, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"sub": "abc", "iss": server.Issuer(), "aud": server.ClientID, "exp": time.Now().Add(time.Hour).Unix(),
+		}).SignedString([]byte(server.ClientSecret))
+		require.NoError(t, err)
+
+		_, err = provider.VerifyIDToken(ctx, forged)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("提供者輪替金鑰後會重新抓取 JWKS", func(t *testing.T) {
+		_, err := provider.VerifyIDToken(ctx, server.SignIDToken(jwt.MapClaims{"sub": "abc"}))
+		require.NoError(t, err)
+		server.RotateKey()
+		provider.keys.fetchedAt = time.Now().Add(-2 * jwksMinRefresh)
+
+		_, err = provider.VerifyIDToken(ctx, server.SignIDToken(jwt.MapClaims{"sub": "abc"}))
+
+		assert.NoError(t, err)
+	})
+}
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry([]config.OIDCProviderConfig{{ID: "google"}, {ID: "okta"}})
+
+	provider, err := registry.Get("okta")
+	require.NoError(t, err)
+	assert.Equal(t, "okta", provider.ID)
+	_, err = registry.Get("unknown")
+	assert.ErrorIs(t, err, ErrUnknownProvider)
+	require.Len(t, registry.List(), 2)
+	assert.Equal(t, "google", registry.List()[0].ID)
+}
+
+func mustQuery(t *testing.T, rawURL, key string) string {
+	t.Helper()
+	parsed, err := url.Parse(rawURL)
+	require.NoError(t, err)
+	return parsed.Query().Get(key)
+}
@@ -0,0 +1,219 @@
+// Package oidc 實作 OpenID Connect 登入：discovery、以 JWKS 驗證 ID token，以及授權碼流程的 PKCE
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go-chat/backend/config"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/oauth2"
+)
+
+// discoveryTTL 是 discovery 文件快取的時間
+const discoveryTTL = time.Hour
+
+// clockSkew 是驗證 ID token 時間欄位時允許的時鐘誤差
+const clockSkew = time.Minute
+
+// signingMethods 是接受的 ID token 簽章演算法，不接受 none 與 HS*
+var signingMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// ErrUnknownProvider 表示沒有設定這個提供者
+var ErrUnknownProvider = errors.New("unknown oidc provider")
+
+// Claims 是從 ID token 取出、登入時需要的使用者資訊
+type Claims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Nonce         string
+}
+
+// discoveryDocument 是 /.well-known/openid-configuration 中用到的欄位
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// idTokenClaims 是 ID token 的 claims
+type idTokenClaims struct {
+	jwt.RegisteredClaims
+	Email           string   `json:"email"`
+	EmailVerified   flexBool `json:"email_verified"`
+	Name            string   `json:"name"`
+	Nonce           string   `json:"nonce"`
+	AuthorizedParty string   `json:"azp"`
+}
+
+// flexBool 同時接受 true 與 "true"，部分提供者會以字串表示 email_verified
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	switch strings.Trim(string(data), `"`) {
+	case "true":
+		*b = true
+	default:
+		*b = false
+	}
+	return nil
+}
+
+// Provider 是一個 OpenID Connect 提供者，discovery 文件與 JWKS 都在第一次使用時才取得並快取
+type Provider struct {
+	ID          string
+	DisplayName string
+
+	cfg    config.OIDCProviderConfig
+	client *http.Client
+
+	mu           sync.Mutex
+	doc          *discoveryDocument
+	docFetchedAt time.Time
+	keys         *keySet
+}
+
+// NewProvider 是一個工廠函式，client 為 nil 時使用 http.DefaultClient
+func NewProvider(cfg config.OIDCProviderConfig, client *http.Client) *Provider {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Provider{ID: cfg.ID, DisplayName: cfg.DisplayName, cfg: cfg, client: client}
+}
+
+// discover 取得 discovery 文件，並確認其中的 issuer 與設定相同
+func (p *Provider) discover(ctx context.Context) (*discoveryDocument, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.doc != nil && time.Since(p.docFetchedAt) < discoveryTTL {
+		return p.doc, nil
+	}
+
+	issuer := strings.TrimRight(p.cfg.Issuer, "/")
+	var doc discoveryDocument
+	if err := getJSON(ctx, p.client, issuer+"/.well-known/openid-configuration", &doc); err != nil {
+		return nil, fmt.Errorf("oidc discovery for %s: %w", p.ID, err)
+	}
+	if strings.TrimRight(doc.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("oidc discovery for %s: issuer mismatch %q", p.ID, doc.Issuer)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.JWKSURI == "" {
+		return nil, fmt.Errorf("oidc discovery for %s: incomplete document", p.ID)
+	}
+
+	if p.keys == nil || p.keys.url != doc.JWKSURI {
+		p.keys = newKeySet(doc.JWKSURI, p.client)
+	}
+	p.doc = &doc
+	p.docFetchedAt = time.Now()
+	return p.doc, nil
+}
+
+// oauthConfig 依 discovery 文件建立 oauth2 設定
+func (p *Provider) oauthConfig(doc *discoveryDocument) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     p.cfg.ClientID,
+		ClientSecret: p.cfg.ClientSecret,
+		RedirectURL:  p.cfg.RedirectURL,
+		Scopes:       p.cfg.Scopes,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  doc.AuthorizationEndpoint,
+			TokenURL: doc.TokenEndpoint,
+		},
+	}
+}
+
+// AuthCodeURL 回傳導向提供者登入頁面的網址，verifier 是這次登入的 PKCE code verifier
+func (p *Provider) AuthCodeURL(ctx context.Context, state, verifier string, opts ...oauth2.AuthCodeOption) (string, error) {
+	doc, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	opts = append(opts, oauth2.S256ChallengeOption(verifier))
+	return p.oauthConfig(doc).AuthCodeURL(state, opts...), nil
+}
+
+// Exchange 以授權碼與 PKCE verifier 換取 token，並驗證回傳的 ID token
+func (p *Provider) Exchange(ctx context.Context, code, verifier string) (*Claims, error) {
+	doc, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, p.client)
+	token, err := p.oauthConfig(doc).Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("oidc code exchange for %s: %w", p.ID, err)
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, fmt.Errorf("oidc code exchange for %s: no id_token in response", p.ID)
+	}
+	return p.VerifyIDToken(ctx, rawIDToken)
+}
+
+// VerifyIDToken 驗證 ID token 的簽章、issuer、audience 與效期並取出 claims
+func (p *Provider) VerifyIDToken(ctx context.Context, rawIDToken string) (*Claims, error) {
+	doc, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := jwt.NewParser(
+		jwt.WithValidMethods(signingMethods),
+		jwt.WithIssuer(doc.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(clockSkew),
+	)
+	var claims idTokenClaims
+	_, err = parser.ParseWithClaims(rawIDToken, &claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.keys.key(ctx, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid id token from %s: %w", p.ID, err)
+	}
+	// 有多個 audience 時，azp 必須是我們自己
+	if claims.AuthorizedParty != "" && claims.AuthorizedParty != p.cfg.ClientID {
+		return nil, fmt.Errorf("invalid id token from %s: unexpected azp", p.ID)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("invalid id token from %s: missing sub", p.ID)
+	}
+
+	return &Claims{
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified),
+		Name:          claims.Name,
+		Nonce:         claims.Nonce,
+	}, nil
+}
+
+// getJSON 以 GET 取得 JSON 並解碼到 v
+func getJSON(ctx context.Context, client *http.Client, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,50 @@
+package oidc
+
+import (
+	"net/http"
+	"time"
+
+	"go-chat/backend/config"
+)
+
+// Registry 保存所有設定好的 OIDC 提供者，依設定的順序列出
+type Registry struct {
+	providers map[string]*Provider
+	order     []string
+}
+
+// NewRegistry 依設定建立所有提供者，不會在啟動時連線，提供者暫時無法連線也不影響啟動
+func NewRegistry(cfgs []config.OIDCProviderConfig) *Registry {
+	client := &http.Client{Timeout: 10 * time.Second}
+	registry := &Registry{providers: make(map[string]*Provider, len(cfgs))}
+	for _, cfg := range cfgs {
+		registry.Add(NewProvider(cfg, client))
+	}
+	return registry
+}
+
+// Add 加入一個提供者，ID 重複時後加入的會取代先前的
+func (r *Registry) Add(provider *Provider) {
+	if _, exists := r.providers[provider.ID]; !exists {
+		r.order = append(r.order, provider.ID)
+	}
+	r.providers[provider.ID] = provider
+}
+
+// Get 依 ID 取得提供者
+func (r *Registry) Get(id string) (*Provider, error) {
+	provider, ok := r.providers[id]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	return provider, nil
+}
+
+// List 依設定的順序列出所有提供者
+func (r *Registry) List() []*Provider {
+	providers := make([]*Provider, 0, len(r.order))
+	for _, id := range r.order {
+		providers = append(providers, r.providers[id])
+	}
+	return providers
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-chat/backend/database"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAllowSlidingWindow_Concurrent 確認同時到達的請求不會超過限流上限
+func TestAllowSlidingWindow_Concurrent(t *testing.T) {
+	key := "rate-limit:integration-test"
+	require.NoError(t, database.ResetRateLimit(key))
+
+	const limit = 5
+	var allowedCount atomic.Int32
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			allowed, _, err := database.AllowSlidingWindow(key, limit, time.Minute)
+			assert.NoError(t, err)
+			if allowed {
+				allowedCount.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(limit), allowedCount.Load(), "同時到達的請求也只能通過 limit 次")
+
+	allowed, retryAfter, err := database.AllowSlidingWindow(key, limit, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Greater(t, retryAfter, time.Duration(0))
+	assert.LessOrEqual(t, retryAfter, time.Minute)
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-chat/backend/config"
+	"go-chat/backend/database"
+	"go-chat/backend/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// findUserRoom 從使用者的聊天室列表中找出指定的聊天室
+func findUserRoom(t *testing.T, userID, roomID primitive.ObjectID) models.ChatRoom {
+	t.Helper()
+	rooms, err := database.GetUserChatRooms(userID, &config.Config{})
+	require.NoError(t, err)
+	for _, room := range rooms {
+		if room.ID == roomID {
+			return room
+		}
+	}
+	t.Fatalf("room %s not found in the list of user %s", roomID.Hex(), userID.Hex())
+	return models.ChatRoom{}
+}
+
+// insertRoomMessage 以目前時間寫入一則訊息
+func insertRoomMessage(t *testing.T, room models.ChatRoom, messageType models.MessageType, senderID primitive.ObjectID, content string) {
+	t.Helper()
+	_, err := database.InsertMessage(models.Message{
+		Type:      messageType,
+		SenderID:  senderID,
+		RoomID:    room.ID.Hex(),
+		RoomName:  room.Name,
+		Content:   content,
+		Timestamp: time.Now(),
+	})
+	require.NoError(t, err)
+}
+
+// TestRoomListActivity_Integration 測試聊天室列表的最後訊息預覽與未讀數
+func TestRoomListActivity_Integration(t *testing.T) {
+	alice, bob := primitive.NewObjectID(), primitive.NewObjectID()
+	createdAt := time.Now().Add(-time.Minute)
+	room := models.ChatRoom{
+		ID:           primitive.NewObjectID(),
+		Name:         "activity",
+		Kind:         models.RoomKindGroup,
+		CreatorID:    alice,
+		Participants: []primitive.ObjectID{alice, bob},
+		CreatedAt:    createdAt,
+		UpdatedAt:    createdAt,
+	}
+	_, err := database.InsertChatRoom(room)
+	require.NoError(t, err)
+
+	t.Run("其他人的訊息算未讀，自己的訊息不算", func(t *testing.T) {
+		insertRoomMessage(t, room, models.MessageTypeNormal, alice, "hello")
+
+		bobRoom := findUserRoom(t, bob, room.ID)
+		require.NotNil(t, bobRoom.LastMessage)
+		assert.Equal(t, "hello", bobRoom.LastMessage.Content)
+		assert.Equal(t, int64(1), bobRoom.UnreadCount)
+		assert.Equal(t, int64(0), findUserRoom(t, alice, room.ID).UnreadCount)
+	})
+
+	t.Run("狀態更新不改變預覽也不算未讀", func(t *testing.T) {
+		insertRoomMessage(t, room, models.MessageTypeUpdate, alice, "")
+
+		bobRoom := findUserRoom(t, bob, room.ID)
+		assert.Equal(t, "hello", bobRoom.LastMessage.Content)
+		assert.Equal(t, int64(1), bobRoom.UnreadCount)
+	})
+
+	t.Run("標記已讀後未讀數歸零", func(t *testing.T) {
+		found, err := database.MarkChatRoomRead(room.ID, bob)
+		require.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, int64(0), findUserRoom(t, bob, room.ID).UnreadCount)
+
+		found, err = database.MarkChatRoomRead(room.ID, primitive.NewObjectID())
+		require.NoError(t, err)
+		assert.False(t, found, "非成員不能標記已讀")
+	})
+
+	t.Run("快取的列表也會反映新訊息", func(t *testing.T) {
+		// 上一個步驟已經讀取過列表，這次會命中快取
+		time.Sleep(5 * time.Millisecond)
+		insertRoomMessage(t, room, models.MessageTypeSystem, primitive.NilObjectID, "alice 已更新主題")
+
+		bobRoom := findUserRoom(t, bob, room.ID)
+		assert.Equal(t, "alice 已更新主題", bobRoom.LastMessage.Content)
+		assert.Equal(t, int64(1), bobRoom.UnreadCount)
+	})
+
+	t.Run("過期的訊息不顯示預覽也不算未讀", func(t *testing.T) {
+		expiredRoom := room
+		expiredRoom.ID = primitive.NewObjectID()
+		expiredRoom.CreatedAt = time.Now().Add(-2 * time.Hour)
+		_, err := database.InsertChatRoom(expiredRoom)
+		require.NoError(t, err)
+
+		_, err = database.InsertMessage(models.Message{
+			Type:      models.MessageTypeNormal,
+			SenderID:  alice,
+			RoomID:    expiredRoom.ID.Hex(),
+			Content:   "已經被清除的訊息",
+			Timestamp: time.Now().Add(-time.Hour),
+			ExpiresAt: time.Now().Add(-time.Minute),
+		})
+		require.NoError(t, err)
+
+		bobRoom := findUserRoom(t, bob, expiredRoom.ID)
+		assert.Nil(t, bobRoom.LastMessage)
+		assert.Equal(t, int64(0), bobRoom.UnreadCount)
+	})
+}
+
+// TestArchivedRoomMessages_Integration 測試封存後寫入的訊息與預覽都不會過期
+func TestArchivedRoomMessages_Integration(t *testing.T) {
+	alice := primitive.NewObjectID()
+	room := models.ChatRoom{
+		ID:           primitive.NewObjectID(),
+		Name:         "archived",
+		Kind:         models.RoomKindGroup,
+		CreatorID:    alice,
+		Participants: []primitive.ObjectID{alice},
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+	_, err := database.InsertChatRoom(room)
+	require.NoError(t, err)
+
+	_, err = database.SetChatRoomArchived(room.ID, true)
+	require.NoError(t, err)
+	insertRoomMessage(t, room, models.MessageTypeSystem, alice, "alice 已離開聊天室")
+
+	ctx := context.Background()
+	count, err := database.GetCollection("messages").CountDocuments(ctx, bson.M{"roomId": room.ID.Hex(), "expiresAt": bson.M{"$exists": true}})
+	require.NoError(t, err)
+	assert.Zero(t, count, "封存後寫入的訊息不應該有 expiresAt")
+
+	archived, err := database.FindChatRoomByID(room.ID)
+	require.NoError(t, err)
+	require.NotNil(t, archived.LastMessage)
+	assert.True(t, archived.LastMessage.ExpiresAt.IsZero(), "預覽跟著訊息一起保留")
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-chat/backend/config"
+	"go-chat/backend/database"
+	"go-chat/backend/handlers"
+	"go-chat/backend/models"
+	"go-chat/backend/store"
+	"go-chat/backend/utils"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TestRedeemRoomInvite_Integration 測試兌換邀請的成功流程與加入失敗時歸還使用次數
+func TestRedeemRoomInvite_Integration(t *testing.T) {
+	ctx := context.Background()
+	inviteStore := store.NewMongoRoomInviteStore()
+	handler := handlers.NewRoomInviteHandler(inviteStore, &config.Config{FrontendBaseURL: "https://chat.example.com"})
+
+	// createInvite 以擁有者身分建立只能使用一次的邀請，回傳邀請碼
+	createInvite := func(t *testing.T, room *models.ChatRoom, ownerID primitive.ObjectID) handlers.RoomInviteURLResponse {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		handler.CreateRoomInvite(rr, roomRequest("POST", "/chatrooms/"+room.ID.Hex()+"/invites", room.ID, ownerID, `{"maxUses":1}`))
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+		var created handlers.RoomInviteURLResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &created))
+		return created
+	}
+
+	redeem := func(code string, userID primitive.ObjectID) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/invites/"+code+"/redeem", nil)
+		req = mux.SetURLVars(req.WithContext(context.WithValue(req.Context(), utils.UserIDKey, userID)), map[string]string{"code": code})
+		rr := httptest.NewRecorder()
+		handler.RedeemRoomInvite(rr, req)
+		return rr
+	}
+
+	// insertGuest 建立一位還不在聊天室中的使用者
+	insertGuest := func(t *testing.T, username string) primitive.ObjectID {
+		t.Helper()
+		guestID := primitive.NewObjectID()
+		_, err := database.GetCollection("users").InsertOne(ctx, models.User{ID: guestID, Username: username, Email: username + "@example.com"})
+		require.NoError(t, err)
+		return guestID
+	}
+
+	t.Run("兌換後加入聊天室並依新的成員產生名稱", func(t *testing.T) {
+		room, userIDs := insertRoomMembers(t, "invite-owner")
+		// 改成沒有自訂名稱的聊天室，名稱應該跟著成員變動
+		_, err := database.GetCollection("chatrooms").UpdateOne(ctx, bson.M{"_id": room.ID}, bson.M{"$set": bson.M{"name": "invite-owner 的聊天室"}, "$unset": bson.M{"customName": ""}})
+		require.NoError(t, err)
+		guestID := insertGuest(t, "invite-guest")
+		created := createInvite(t, room, userIDs[0])
+
+		rr := redeem(created.Code, guestID)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		var joined models.ChatRoom
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &joined))
+		assert.Contains(t, joined.Participants, guestID)
+		assert.Contains(t, joined.Name, "invite-guest")
+		assert.Contains(t, joined.Name, "invite-owner")
+
+		invite, err := inviteStore.FindRoomInviteByID(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, invite.Uses)
+
+		// 次數用完後其他人不能再兌換
+		assert.Equal(t, http.StatusGone, redeem(created.Code, insertGuest(t, "invite-late")).Code)
+	})
+
+	t.Run("聊天室在兌換期間被封存時歸還使用次數", func(t *testing.T) {
+		room, userIDs := insertRoomMembers(t, "archive-owner")
+		created := createInvite(t, room, userIDs[0])
+		_, err := database.GetCollection("chatrooms").UpdateOne(ctx, bson.M{"_id": room.ID}, bson.M{"$set": bson.M{"archivedAt": time.Now()}})
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusNotFound, redeem(created.Code, insertGuest(t, "archive-guest")).Code)
+
+		invite, err := inviteStore.FindRoomInviteByID(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 0, invite.Uses, "沒有加入聊天室時不應該用掉次數")
+	})
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-chat/backend/database"
+	"go-chat/backend/handlers"
+	"go-chat/backend/models"
+	"go-chat/backend/store"
+	"go-chat/backend/utils"
+	"go-chat/backend/webhooks"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// roomRequest 建立以 userID 身分對聊天室發出的請求
+func roomRequest(method, path string, roomID, userID primitive.ObjectID, body string) *http.Request {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req = req.WithContext(context.WithValue(req.Context(), utils.UserIDKey, userID))
+	return mux.SetURLVars(req, map[string]string{"id": roomID.Hex()})
+}
+
+// insertRoomMembers 在資料庫中建立使用者與一個由第一位使用者擁有的群組
+func insertRoomMembers(t *testing.T, usernames ...string) (*models.ChatRoom, []primitive.ObjectID) {
+	t.Helper()
+	ctx := context.Background()
+	createdAt := time.Now().Add(-time.Hour)
+
+	var userIDs []primitive.ObjectID
+	memberSince := map[string]time.Time{}
+	for i, username := range usernames {
+		userID := primitive.NewObjectID()
+		_, err := database.GetCollection("users").InsertOne(ctx, models.User{ID: userID, Username: username, Email: username + "@example.com"})
+		require.NoError(t, err)
+		userIDs = append(userIDs, userID)
+		memberSince[userID.Hex()] = createdAt.Add(time.Duration(i) * time.Minute)
+	}
+
+	room := &models.ChatRoom{
+		ID:           primitive.NewObjectID(),
+		Name:         "專案討論",
+		CustomName:   "專案討論",
+		Kind:         models.RoomKindGroup,
+		CreatorID:    userIDs[0],
+		OwnerID:      userIDs[0],
+		Participants: userIDs,
+		MemberSince:  memberSince,
+		CreatedAt:    createdAt,
+		UpdatedAt:    createdAt,
+	}
+	_, err := database.GetCollection("chatrooms").InsertOne(ctx, room)
+	require.NoError(t, err)
+	return room, userIDs
+}
+
+// TestRoomOwnership_Integration 測試擁有權轉移、擁有者離開後的繼任，以及刪除聊天室時清除關聯資料
+func TestRoomOwnership_Integration(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("轉移擁有權後原擁有者離開，擁有權交給最資深的成員", func(t *testing.T) {
+		room, userIDs := insertRoomMembers(t, "owner-a", "member-b", "member-c")
+		owner, early, late := userIDs[0], userIDs[1], userIDs[2]
+
+		rr := httptest.NewRecorder()
+		handlers.TransferOwnership(rr, roomRequest("PUT", "/chatrooms/"+room.ID.Hex()+"/owner", room.ID, owner, `{"userId":"`+late.Hex()+`"}`))
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		transferred, err := database.FindChatRoomByID(room.ID)
+		require.NoError(t, err)
+		assert.Equal(t, late, transferred.Owner())
+		assert.True(t, transferred.IsAdmin(owner), "原擁有者保留管理員身分")
+
+		// 新擁有者離開後由管理員 (原擁有者) 優先接手，而不是更早加入的一般成員
+		rr = httptest.NewRecorder()
+		handlers.LeaveChatRoom(rr, roomRequest("POST", "/chatrooms/"+room.ID.Hex()+"/leave", room.ID, late, ""))
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		succeeded, err := database.FindChatRoomByID(room.ID)
+		require.NoError(t, err)
+		assert.Equal(t, owner, succeeded.Owner())
+		assert.ElementsMatch(t, []primitive.ObjectID{owner, early}, succeeded.Participants)
+
+		// 擁有者再離開時交給剩下的成員
+		rr = httptest.NewRecorder()
+		handlers.LeaveChatRoom(rr, roomRequest("POST", "/chatrooms/"+room.ID.Hex()+"/leave", room.ID, owner, ""))
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		succeeded, err = database.FindChatRoomByID(room.ID)
+		require.NoError(t, err)
+		assert.Equal(t, early, succeeded.Owner())
+	})
+
+	t.Run("只有擁有者可以轉移擁有權", func(t *testing.T) {
+		room, userIDs := insertRoomMembers(t, "owner-d", "member-e")
+
+		rr := httptest.NewRecorder()
+		handlers.TransferOwnership(rr, roomRequest("PUT", "/chatrooms/"+room.ID.Hex()+"/owner", room.ID, userIDs[1], `{"userId":"`+userIDs[1].Hex()+`"}`))
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("刪除聊天室時清除關聯資料並在 room.deleted 排入後移除外送 Webhook", func(t *testing.T) {
+		room, userIDs := insertRoomMembers(t, "owner-f", "member-g")
+		owner := userIDs[0]
+
+		webhookStore := store.NewMongoWebhookStore()
+		// 不啟動 worker，只確認投遞紀錄已經存入
+		webhooks.SetDefault(webhooks.NewDispatcher(webhookStore, webhooks.Options{}))
+		defer webhooks.SetDefault(nil)
+
+		hookID, err := webhookStore.CreateWebhook(ctx, models.Webhook{
+			RoomID:    room.ID,
+			URL:       "https://93.184.216.34/hooks",
+			Secret:    "whsec_test",
+			Events:    []models.WebhookEvent{models.WebhookEventRoomDeleted},
+			CreatedBy: owner,
+			CreatedAt: time.Now(),
+		})
+		require.NoError(t, err)
+
+		related := map[string]bson.M{
+			"messages":          {"roomId": room.ID.Hex(), "content": "hello", "timestamp": time.Now()},
+			"room_invites":      {"roomId": room.ID, "codeHash": primitive.NewObjectID().Hex()},
+			"room_sanctions":    {"roomId": room.ID, "userId": primitive.NewObjectID(), "kind": models.RoomSanctionBan},
+			"incoming_webhooks": {"roomId": room.ID, "name": "CI"},
+		}
+		for collection, doc := range related {
+			_, err := database.GetCollection(collection).InsertOne(ctx, doc)
+			require.NoError(t, err)
+		}
+
+		rr := httptest.NewRecorder()
+		handlers.DeleteChatRoom(rr, roomRequest("DELETE", "/chatrooms/"+room.ID.Hex(), room.ID, owner, ""))
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		deleted, err := database.FindChatRoomByID(room.ID)
+		require.NoError(t, err)
+		assert.Nil(t, deleted)
+
+		roomFilter := map[string]bson.M{
+			"messages":          {"roomId": room.ID.Hex()},
+			"room_invites":      {"roomId": room.ID},
+			"room_sanctions":    {"roomId": room.ID},
+			"incoming_webhooks": {"roomId": room.ID},
+			"webhooks":          {"roomId": room.ID},
+		}
+		for collection, filter := range roomFilter {
+			count, err := database.GetCollection(collection).CountDocuments(ctx, filter)
+			require.NoError(t, err)
+			assert.Zero(t, count, "%s 應該被清除", collection)
+		}
+
+		deliveries, err := webhookStore.ListDeliveries(ctx, hookID, models.WebhookDeliveryPending, 10)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1, "room.deleted 應該在 Webhook 刪除前排入投遞")
+		assert.Equal(t, models.WebhookEventRoomDeleted, deliveries[0].Event)
+		assert.Equal(t, "https://93.184.216.34/hooks", deliveries[0].URL)
+	})
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"go-chat/backend/database"
+	"go-chat/backend/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TestIsRoomMemberMuted_Integration 測試發送訊息時使用的禁言名單快取會跟著禁言變動更新
+func TestIsRoomMemberMuted_Integration(t *testing.T) {
+	roomID, muted, other, moderator := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+
+	isMuted := func(userID primitive.ObjectID) bool {
+		t.Helper()
+		result, err := database.IsRoomMemberMuted(roomID, userID)
+		require.NoError(t, err)
+		return result
+	}
+
+	// 先讀取一次，讓沒有任何禁言的名單被快取
+	assert.False(t, isMuted(muted))
+
+	_, err := database.SetRoomSanction(models.RoomSanction{RoomID: roomID, UserID: muted, Kind: models.RoomSanctionMute, CreatedBy: moderator, CreatedAt: time.Now()})
+	require.NoError(t, err)
+	assert.True(t, isMuted(muted), "禁言後快取要失效")
+	assert.False(t, isMuted(other))
+
+	deleted, err := database.DeleteRoomSanction(roomID, muted, models.RoomSanctionMute)
+	require.NoError(t, err)
+	assert.True(t, deleted)
+	assert.False(t, isMuted(muted), "解除禁言後快取要失效")
+
+	// 快取中的到期時間過了之後，不需要等快取失效就能發言
+	_, err = database.SetRoomSanction(models.RoomSanction{RoomID: roomID, UserID: other, Kind: models.RoomSanctionMute, CreatedBy: moderator, CreatedAt: time.Now(), ExpiresAt: time.Now().Add(200 * time.Millisecond)})
+	require.NoError(t, err)
+	assert.True(t, isMuted(other))
+	time.Sleep(300 * time.Millisecond)
+	assert.False(t, isMuted(other))
+}
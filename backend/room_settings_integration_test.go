@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-chat/backend/database"
+	"go-chat/backend/handlers"
+	"go-chat/backend/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRoomSettings_Integration 測試個人設定只寫入請求中的欄位，同時送出的更新不會互相覆蓋
+func TestRoomSettings_Integration(t *testing.T) {
+	room, userIDs := insertRoomMembers(t, "settings-owner", "settings-member")
+	userID := userIDs[1]
+
+	// 模擬另一個請求在這之前已經讀取了聊天室，手上的設定還沒有我的最愛
+	stale, err := database.FindChatRoomByID(room.ID)
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handlers.UpdateRoomSettings(rr, roomRequest("PUT", "/chatrooms/"+room.ID.Hex()+"/settings", room.ID, userID, `{"favorite":true,"sortOrder":2}`))
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var returned models.RoomMemberSettings
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &returned))
+	assert.True(t, returned.Favorite)
+
+	muted := stale.SettingsFor(userID)
+	muted.Muted = true
+	_, err = database.UpdateRoomMemberSettings(room.ID, userID, muted, []string{"muted", "mutedUntil"})
+	require.NoError(t, err)
+
+	updated, err := database.FindChatRoomByID(room.ID)
+	require.NoError(t, err)
+	settings := updated.SettingsFor(userID)
+	assert.True(t, settings.Muted)
+	assert.True(t, settings.Favorite, "較晚寫入的靜音設定不應該覆蓋我的最愛")
+	assert.Equal(t, 2, settings.SortOrder)
+
+	// 取消我的最愛時移除欄位，其他設定維持不變
+	rr = httptest.NewRecorder()
+	handlers.UpdateRoomSettings(rr, roomRequest("PUT", "/chatrooms/"+room.ID.Hex()+"/settings", room.ID, userID, `{"favorite":false}`))
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	updated, err = database.FindChatRoomByID(room.ID)
+	require.NoError(t, err)
+	settings = updated.SettingsFor(userID)
+	assert.False(t, settings.Favorite)
+	assert.True(t, settings.Muted)
+	assert.Empty(t, updated.SettingsFor(userIDs[0]), "其他成員的設定不受影響")
+}
@@ -0,0 +1,14 @@
+// backend/store/audit_log_store.go
+package store
+
+import (
+	"context"
+	"go-chat/backend/models"
+)
+
+// AuditLogStorer 定義稽核紀錄的資料操作
+type AuditLogStorer interface {
+	CreateAuditLog(ctx context.Context, entry models.AuditLog) error
+	// ListAuditLogs 列出最近的稽核紀錄，action 為空字串時列出所有類型
+	ListAuditLogs(ctx context.Context, action models.AuditAction, limit int64) ([]models.AuditLog, error)
+}
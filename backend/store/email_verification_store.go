@@ -0,0 +1,17 @@
+// backend/store/email_verification_store.go
+package store
+
+import (
+	"context"
+	"go-chat/backend/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EmailVerificationStorer 定義 Email 驗證 token 的資料操作
+type EmailVerificationStorer interface {
+	CreateEmailVerification(ctx context.Context, verification models.EmailVerification) error
+	FindEmailVerificationByHash(ctx context.Context, tokenHash string) (*models.EmailVerification, error)
+	// MarkEmailVerificationUsed 以原子操作標記 token 已使用，token 已被使用時回傳 false
+	MarkEmailVerificationUsed(ctx context.Context, verificationID primitive.ObjectID) (bool, error)
+}
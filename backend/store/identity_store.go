@@ -0,0 +1,20 @@
+// backend/store/identity_store.go
+package store
+
+import (
+	"context"
+	"go-chat/backend/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// IdentityStorer 定義外部登入身分的資料操作
+type IdentityStorer interface {
+	FindIdentity(ctx context.Context, provider, subject string) (*models.Identity, error)
+	CreateIdentity(ctx context.Context, identity models.Identity) error
+	// TouchIdentity 更新身分最後一次登入的時間與提供者回傳的 Email
+	TouchIdentity(ctx context.Context, identityID primitive.ObjectID, email string) error
+	ListUserIdentities(ctx context.Context, userID primitive.ObjectID) ([]models.Identity, error)
+	// DeleteIdentity 刪除屬於使用者的身分，身分不存在或屬於其他使用者時回傳 mongo.ErrNoDocuments
+	DeleteIdentity(ctx context.Context, userID, identityID primitive.ObjectID) error
+}
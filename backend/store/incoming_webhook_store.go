@@ -0,0 +1,19 @@
+// backend/store/incoming_webhook_store.go
+package store
+
+import (
+	"context"
+	"go-chat/backend/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// IncomingWebhookStorer 定義 incoming webhook 的資料操作
+type IncomingWebhookStorer interface {
+	CreateIncomingWebhook(ctx context.Context, webhook models.IncomingWebhook) (primitive.ObjectID, error)
+	FindIncomingWebhookByID(ctx context.Context, webhookID primitive.ObjectID) (*models.IncomingWebhook, error)
+	FindIncomingWebhookByTokenHash(ctx context.Context, tokenHash string) (*models.IncomingWebhook, error)
+	ListIncomingWebhooksByRoom(ctx context.Context, roomID primitive.ObjectID) ([]models.IncomingWebhook, error)
+	UpdateIncomingWebhookToken(ctx context.Context, webhookID primitive.ObjectID, tokenHash string) error
+	DeleteIncomingWebhook(ctx context.Context, webhookID primitive.ObjectID) error
+}
@@ -0,0 +1,17 @@
+// backend/store/jwt_key_store.go
+package store
+
+import (
+	"context"
+	"go-chat/backend/models"
+	"time"
+)
+
+// JWTKeyStorer 定義 JWT 簽章金鑰的資料操作，多個後端實例共用同一組金鑰
+type JWTKeyStorer interface {
+	// ListJWTKeys 列出在 now 仍可用於驗證的金鑰 (使用中，或輪替後還在寬限期內)
+	ListJWTKeys(ctx context.Context, now time.Time) ([]models.JWTKey, error)
+	CreateJWTKey(ctx context.Context, key models.JWTKey) error
+	// RetireJWTKeysBefore 將 createdBefore 之前建立且仍在使用中的金鑰標記為已輪替，到 expiresAt 後不再接受
+	RetireJWTKeysBefore(ctx context.Context, createdBefore, retiredAt, expiresAt time.Time) error
+}
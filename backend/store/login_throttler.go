@@ -0,0 +1,18 @@
+// backend/store/login_throttler.go
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// LoginThrottler 限制密碼登入的嘗試次數，並在連續失敗後暫時鎖定帳號
+// account 是正規化後的 Email，不論帳號是否存在都以相同方式計數，避免被用來探測帳號
+type LoginThrottler interface {
+	// CheckLogin 檢查這次嘗試是否超過 IP 或帳號的限流、帳號是否鎖定中，不允許時回傳需要等待的時間
+	CheckLogin(ctx context.Context, ip, account string) (bool, time.Duration, error)
+	// RecordFailure 記錄一次失敗，這次失敗觸發鎖定時回傳鎖定時間，否則回傳 0
+	RecordFailure(ctx context.Context, account string) (time.Duration, error)
+	RecordSuccess(ctx context.Context, account string) error
+	Unlock(ctx context.Context, account string) error
+}
@@ -0,0 +1,71 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: store/audit_log_store.go
+//
+// Generated by this command:
+//
+//	mockgen -source=store/audit_log_store.go -destination=store/mocks/mock_audit_log_store.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	models "go-chat/backend/models"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAuditLogStorer is a mock of AuditLogStorer interface.
+type MockAuditLogStorer struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditLogStorerMockRecorder
+	isgomock struct{}
+}
+
+// MockAuditLogStorerMockRecorder is the mock recorder for MockAuditLogStorer.
+type MockAuditLogStorerMockRecorder struct {
+	mock *MockAuditLogStorer
+}
+
+// NewMockAuditLogStorer creates a new mock instance.
+func NewMockAuditLogStorer(ctrl *gomock.Controller) *MockAuditLogStorer {
+	mock := &MockAuditLogStorer{ctrl: ctrl}
+	mock.recorder = &MockAuditLogStorerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditLogStorer) EXPECT() *MockAuditLogStorerMockRecorder {
+	return m.recorder
+}
+
+// CreateAuditLog mocks base method.
+func (m *MockAuditLogStorer) CreateAuditLog(ctx context.Context, entry models.AuditLog) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAuditLog", ctx, entry)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAuditLog indicates an expected call of CreateAuditLog.
+func (mr *MockAuditLogStorerMockRecorder) CreateAuditLog(ctx, entry any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAuditLog", reflect.TypeOf((*MockAuditLogStorer)(nil).CreateAuditLog), ctx, entry)
+}
+
+// ListAuditLogs mocks base method.
+func (m *MockAuditLogStorer) ListAuditLogs(ctx context.Context, action models.AuditAction, limit int64) ([]models.AuditLog, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAuditLogs", ctx, action, limit)
+	ret0, _ := ret[0].([]models.AuditLog)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAuditLogs indicates an expected call of ListAuditLogs.
+func (mr *MockAuditLogStorerMockRecorder) ListAuditLogs(ctx, action, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAuditLogs", reflect.TypeOf((*MockAuditLogStorer)(nil).ListAuditLogs), ctx, action, limit)
+}
@@ -0,0 +1,87 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: store/email_verification_store.go
+//
+// Generated by this command:
+//
+//	mockgen -source=store/email_verification_store.go -destination=store/mocks/mock_email_verification_store.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	models "go-chat/backend/models"
+	reflect "reflect"
+
+	primitive "go.mongodb.org/mongo-driver/bson/primitive"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockEmailVerificationStorer is a mock of EmailVerificationStorer interface.
+type MockEmailVerificationStorer struct {
+	ctrl     *gomock.Controller
+	recorder *MockEmailVerificationStorerMockRecorder
+	isgomock struct{}
+}
+
+// MockEmailVerificationStorerMockRecorder is the mock recorder for MockEmailVerificationStorer.
+type MockEmailVerificationStorerMockRecorder struct {
+	mock *MockEmailVerificationStorer
+}
+
+// NewMockEmailVerificationStorer creates a new mock instance.
+func NewMockEmailVerificationStorer(ctrl *gomock.Controller) *MockEmailVerificationStorer {
+	mock := &MockEmailVerificationStorer{ctrl: ctrl}
+	mock.recorder = &MockEmailVerificationStorerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEmailVerificationStorer) EXPECT() *MockEmailVerificationStorerMockRecorder {
+	return m.recorder
+}
+
+// CreateEmailVerification mocks base method.
+func (m *MockEmailVerificationStorer) CreateEmailVerification(ctx context.Context, verification models.EmailVerification) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateEmailVerification", ctx, verification)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateEmailVerification indicates an expected call of CreateEmailVerification.
+func (mr *MockEmailVerificationStorerMockRecorder) CreateEmailVerification(ctx, verification any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEmailVerification", reflect.TypeOf((*MockEmailVerificationStorer)(nil).CreateEmailVerification), ctx, verification)
+}
+
+// FindEmailVerificationByHash mocks base method.
+func (m *MockEmailVerificationStorer) FindEmailVerificationByHash(ctx context.Context, tokenHash string) (*models.EmailVerification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindEmailVerificationByHash", ctx, tokenHash)
+	ret0, _ := ret[0].(*models.EmailVerification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindEmailVerificationByHash indicates an expected call of FindEmailVerificationByHash.
+func (mr *MockEmailVerificationStorerMockRecorder) FindEmailVerificationByHash(ctx, tokenHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindEmailVerificationByHash", reflect.TypeOf((*MockEmailVerificationStorer)(nil).FindEmailVerificationByHash), ctx, tokenHash)
+}
+
+// MarkEmailVerificationUsed mocks base method.
+func (m *MockEmailVerificationStorer) MarkEmailVerificationUsed(ctx context.Context, verificationID primitive.ObjectID) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkEmailVerificationUsed", ctx, verificationID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkEmailVerificationUsed indicates an expected call of MarkEmailVerificationUsed.
+func (mr *MockEmailVerificationStorerMockRecorder) MarkEmailVerificationUsed(ctx, verificationID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkEmailVerificationUsed", reflect.TypeOf((*MockEmailVerificationStorer)(nil).MarkEmailVerificationUsed), ctx, verificationID)
+}
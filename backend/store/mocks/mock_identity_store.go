@@ -0,0 +1,115 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: store/identity_store.go
+//
+// Generated by this command:
+//
+//	mockgen -source=store/identity_store.go -destination=store/mocks/mock_identity_store.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	models "go-chat/backend/models"
+	reflect "reflect"
+
+	primitive "go.mongodb.org/mongo-driver/bson/primitive"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockIdentityStorer is a mock of IdentityStorer interface.
+type MockIdentityStorer struct {
+	ctrl     *gomock.Controller
+	recorder *MockIdentityStorerMockRecorder
+	isgomock struct{}
+}
+
+// MockIdentityStorerMockRecorder is the mock recorder for MockIdentityStorer.
+type MockIdentityStorerMockRecorder struct {
+	mock *MockIdentityStorer
+}
+
+// NewMockIdentityStorer creates a new mock instance.
+func NewMockIdentityStorer(ctrl *gomock.Controller) *MockIdentityStorer {
+	mock := &MockIdentityStorer{ctrl: ctrl}
+	mock.recorder = &MockIdentityStorerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIdentityStorer) EXPECT() *MockIdentityStorerMockRecorder {
+	return m.recorder
+}
+
+// CreateIdentity mocks base method.
+func (m *MockIdentityStorer) CreateIdentity(ctx context.Context, identity models.Identity) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateIdentity", ctx, identity)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateIdentity indicates an expected call of CreateIdentity.
+func (mr *MockIdentityStorerMockRecorder) CreateIdentity(ctx, identity any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateIdentity", reflect.TypeOf((*MockIdentityStorer)(nil).CreateIdentity), ctx, identity)
+}
+
+// DeleteIdentity mocks base method.
+func (m *MockIdentityStorer) DeleteIdentity(ctx context.Context, userID, identityID primitive.ObjectID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteIdentity", ctx, userID, identityID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteIdentity indicates an expected call of DeleteIdentity.
+func (mr *MockIdentityStorerMockRecorder) DeleteIdentity(ctx, userID, identityID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteIdentity", reflect.TypeOf((*MockIdentityStorer)(nil).DeleteIdentity), ctx, userID, identityID)
+}
+
+// FindIdentity mocks base method.
+func (m *MockIdentityStorer) FindIdentity(ctx context.Context, provider, subject string) (*models.Identity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindIdentity", ctx, provider, subject)
+	ret0, _ := ret[0].(*models.Identity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindIdentity indicates an expected call of FindIdentity.
+func (mr *MockIdentityStorerMockRecorder) FindIdentity(ctx, provider, subject any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindIdentity", reflect.TypeOf((*MockIdentityStorer)(nil).FindIdentity), ctx, provider, subject)
+}
+
+// ListUserIdentities mocks base method.
+func (m *MockIdentityStorer) ListUserIdentities(ctx context.Context, userID primitive.ObjectID) ([]models.Identity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUserIdentities", ctx, userID)
+	ret0, _ := ret[0].([]models.Identity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUserIdentities indicates an expected call of ListUserIdentities.
+func (mr *MockIdentityStorerMockRecorder) ListUserIdentities(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserIdentities", reflect.TypeOf((*MockIdentityStorer)(nil).ListUserIdentities), ctx, userID)
+}
+
+// TouchIdentity mocks base method.
+func (m *MockIdentityStorer) TouchIdentity(ctx context.Context, identityID primitive.ObjectID, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchIdentity", ctx, identityID, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TouchIdentity indicates an expected call of TouchIdentity.
+func (mr *MockIdentityStorerMockRecorder) TouchIdentity(ctx, identityID, email any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchIdentity", reflect.TypeOf((*MockIdentityStorer)(nil).TouchIdentity), ctx, identityID, email)
+}
@@ -0,0 +1,131 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: store/incoming_webhook_store.go
+//
+// Generated by this command:
+//
+//	mockgen -source=store/incoming_webhook_store.go -destination=store/mocks/mock_incoming_webhook_store.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	models "go-chat/backend/models"
+	reflect "reflect"
+
+	primitive "go.mongodb.org/mongo-driver/bson/primitive"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockIncomingWebhookStorer is a mock of IncomingWebhookStorer interface.
+type MockIncomingWebhookStorer struct {
+	ctrl     *gomock.Controller
+	recorder *MockIncomingWebhookStorerMockRecorder
+	isgomock struct{}
+}
+
+// MockIncomingWebhookStorerMockRecorder is the mock recorder for MockIncomingWebhookStorer.
+type MockIncomingWebhookStorerMockRecorder struct {
+	mock *MockIncomingWebhookStorer
+}
+
+// NewMockIncomingWebhookStorer creates a new mock instance.
+func NewMockIncomingWebhookStorer(ctrl *gomock.Controller) *MockIncomingWebhookStorer {
+	mock := &MockIncomingWebhookStorer{ctrl: ctrl}
+	mock.recorder = &MockIncomingWebhookStorerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIncomingWebhookStorer) EXPECT() *MockIncomingWebhookStorerMockRecorder {
+	return m.recorder
+}
+
+// CreateIncomingWebhook mocks base method.
+func (m *MockIncomingWebhookStorer) CreateIncomingWebhook(ctx context.Context, webhook models.IncomingWebhook) (primitive.ObjectID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateIncomingWebhook", ctx, webhook)
+	ret0, _ := ret[0].(primitive.ObjectID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateIncomingWebhook indicates an expected call of CreateIncomingWebhook.
+func (mr *MockIncomingWebhookStorerMockRecorder) CreateIncomingWebhook(ctx, webhook any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateIncomingWebhook", reflect.TypeOf((*MockIncomingWebhookStorer)(nil).CreateIncomingWebhook), ctx, webhook)
+}
+
+// DeleteIncomingWebhook mocks base method.
+func (m *MockIncomingWebhookStorer) DeleteIncomingWebhook(ctx context.Context, webhookID primitive.ObjectID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteIncomingWebhook", ctx, webhookID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteIncomingWebhook indicates an expected call of DeleteIncomingWebhook.
+func (mr *MockIncomingWebhookStorerMockRecorder) DeleteIncomingWebhook(ctx, webhookID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteIncomingWebhook", reflect.TypeOf((*MockIncomingWebhookStorer)(nil).DeleteIncomingWebhook), ctx, webhookID)
+}
+
+// FindIncomingWebhookByID mocks base method.
+func (m *MockIncomingWebhookStorer) FindIncomingWebhookByID(ctx context.Context, webhookID primitive.ObjectID) (*models.IncomingWebhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindIncomingWebhookByID", ctx, webhookID)
+	ret0, _ := ret[0].(*models.IncomingWebhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindIncomingWebhookByID indicates an expected call of FindIncomingWebhookByID.
+func (mr *MockIncomingWebhookStorerMockRecorder) FindIncomingWebhookByID(ctx, webhookID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindIncomingWebhookByID", reflect.TypeOf((*MockIncomingWebhookStorer)(nil).FindIncomingWebhookByID), ctx, webhookID)
+}
+
+// FindIncomingWebhookByTokenHash mocks base method.
+func (m *MockIncomingWebhookStorer) FindIncomingWebhookByTokenHash(ctx context.Context, tokenHash string) (*models.IncomingWebhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindIncomingWebhookByTokenHash", ctx, tokenHash)
+	ret0, _ := ret[0].(*models.IncomingWebhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindIncomingWebhookByTokenHash indicates an expected call of FindIncomingWebhookByTokenHash.
+func (mr *MockIncomingWebhookStorerMockRecorder) FindIncomingWebhookByTokenHash(ctx, tokenHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindIncomingWebhookByTokenHash", reflect.TypeOf((*MockIncomingWebhookStorer)(nil).FindIncomingWebhookByTokenHash), ctx, tokenHash)
+}
+
+// ListIncomingWebhooksByRoom mocks base method.
+func (m *MockIncomingWebhookStorer) ListIncomingWebhooksByRoom(ctx context.Context, roomID primitive.ObjectID) ([]models.IncomingWebhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListIncomingWebhooksByRoom", ctx, roomID)
+	ret0, _ := ret[0].([]models.IncomingWebhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListIncomingWebhooksByRoom indicates an expected call of ListIncomingWebhooksByRoom.
+func (mr *MockIncomingWebhookStorerMockRecorder) ListIncomingWebhooksByRoom(ctx, roomID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListIncomingWebhooksByRoom", reflect.TypeOf((*MockIncomingWebhookStorer)(nil).ListIncomingWebhooksByRoom), ctx, roomID)
+}
+
+// UpdateIncomingWebhookToken mocks base method.
+func (m *MockIncomingWebhookStorer) UpdateIncomingWebhookToken(ctx context.Context, webhookID primitive.ObjectID, tokenHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateIncomingWebhookToken", ctx, webhookID, tokenHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateIncomingWebhookToken indicates an expected call of UpdateIncomingWebhookToken.
+func (mr *MockIncomingWebhookStorerMockRecorder) UpdateIncomingWebhookToken(ctx, webhookID, tokenHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateIncomingWebhookToken", reflect.TypeOf((*MockIncomingWebhookStorer)(nil).UpdateIncomingWebhookToken), ctx, webhookID, tokenHash)
+}
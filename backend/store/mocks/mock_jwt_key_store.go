@@ -0,0 +1,86 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: store/jwt_key_store.go
+//
+// Generated by this command:
+//
+//	mockgen -source=store/jwt_key_store.go -destination=store/mocks/mock_jwt_key_store.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	models "go-chat/backend/models"
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockJWTKeyStorer is a mock of JWTKeyStorer interface.
+type MockJWTKeyStorer struct {
+	ctrl     *gomock.Controller
+	recorder *MockJWTKeyStorerMockRecorder
+	isgomock struct{}
+}
+
+// MockJWTKeyStorerMockRecorder is the mock recorder for MockJWTKeyStorer.
+type MockJWTKeyStorerMockRecorder struct {
+	mock *MockJWTKeyStorer
+}
+
+// NewMockJWTKeyStorer creates a new mock instance.
+func NewMockJWTKeyStorer(ctrl *gomock.Controller) *MockJWTKeyStorer {
+	mock := &MockJWTKeyStorer{ctrl: ctrl}
+	mock.recorder = &MockJWTKeyStorerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockJWTKeyStorer) EXPECT() *MockJWTKeyStorerMockRecorder {
+	return m.recorder
+}
+
+// CreateJWTKey mocks base method.
+func (m *MockJWTKeyStorer) CreateJWTKey(ctx context.Context, key models.JWTKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateJWTKey", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateJWTKey indicates an expected call of CreateJWTKey.
+func (mr *MockJWTKeyStorerMockRecorder) CreateJWTKey(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateJWTKey", reflect.TypeOf((*MockJWTKeyStorer)(nil).CreateJWTKey), ctx, key)
+}
+
+// ListJWTKeys mocks base method.
+func (m *MockJWTKeyStorer) ListJWTKeys(ctx context.Context, now time.Time) ([]models.JWTKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListJWTKeys", ctx, now)
+	ret0, _ := ret[0].([]models.JWTKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListJWTKeys indicates an expected call of ListJWTKeys.
+func (mr *MockJWTKeyStorerMockRecorder) ListJWTKeys(ctx, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListJWTKeys", reflect.TypeOf((*MockJWTKeyStorer)(nil).ListJWTKeys), ctx, now)
+}
+
+// RetireJWTKeysBefore mocks base method.
+func (m *MockJWTKeyStorer) RetireJWTKeysBefore(ctx context.Context, createdBefore, retiredAt, expiresAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetireJWTKeysBefore", ctx, createdBefore, retiredAt, expiresAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RetireJWTKeysBefore indicates an expected call of RetireJWTKeysBefore.
+func (mr *MockJWTKeyStorerMockRecorder) RetireJWTKeysBefore(ctx, createdBefore, retiredAt, expiresAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetireJWTKeysBefore", reflect.TypeOf((*MockJWTKeyStorer)(nil).RetireJWTKeysBefore), ctx, createdBefore, retiredAt, expiresAt)
+}
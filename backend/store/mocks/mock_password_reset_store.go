@@ -0,0 +1,101 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: store/password_reset_store.go
+//
+// Generated by this command:
+//
+//	mockgen -source=store/password_reset_store.go -destination=store/mocks/mock_password_reset_store.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	models "go-chat/backend/models"
+	reflect "reflect"
+
+	primitive "go.mongodb.org/mongo-driver/bson/primitive"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockPasswordResetStorer is a mock of PasswordResetStorer interface.
+type MockPasswordResetStorer struct {
+	ctrl     *gomock.Controller
+	recorder *MockPasswordResetStorerMockRecorder
+	isgomock struct{}
+}
+
+// MockPasswordResetStorerMockRecorder is the mock recorder for MockPasswordResetStorer.
+type MockPasswordResetStorerMockRecorder struct {
+	mock *MockPasswordResetStorer
+}
+
+// NewMockPasswordResetStorer creates a new mock instance.
+func NewMockPasswordResetStorer(ctrl *gomock.Controller) *MockPasswordResetStorer {
+	mock := &MockPasswordResetStorer{ctrl: ctrl}
+	mock.recorder = &MockPasswordResetStorerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPasswordResetStorer) EXPECT() *MockPasswordResetStorerMockRecorder {
+	return m.recorder
+}
+
+// CreatePasswordReset mocks base method.
+func (m *MockPasswordResetStorer) CreatePasswordReset(ctx context.Context, reset models.PasswordReset) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePasswordReset", ctx, reset)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreatePasswordReset indicates an expected call of CreatePasswordReset.
+func (mr *MockPasswordResetStorerMockRecorder) CreatePasswordReset(ctx, reset any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePasswordReset", reflect.TypeOf((*MockPasswordResetStorer)(nil).CreatePasswordReset), ctx, reset)
+}
+
+// FindPasswordResetByHash mocks base method.
+func (m *MockPasswordResetStorer) FindPasswordResetByHash(ctx context.Context, tokenHash string) (*models.PasswordReset, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindPasswordResetByHash", ctx, tokenHash)
+	ret0, _ := ret[0].(*models.PasswordReset)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindPasswordResetByHash indicates an expected call of FindPasswordResetByHash.
+func (mr *MockPasswordResetStorerMockRecorder) FindPasswordResetByHash(ctx, tokenHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindPasswordResetByHash", reflect.TypeOf((*MockPasswordResetStorer)(nil).FindPasswordResetByHash), ctx, tokenHash)
+}
+
+// InvalidateUserPasswordResets mocks base method.
+func (m *MockPasswordResetStorer) InvalidateUserPasswordResets(ctx context.Context, userID primitive.ObjectID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InvalidateUserPasswordResets", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InvalidateUserPasswordResets indicates an expected call of InvalidateUserPasswordResets.
+func (mr *MockPasswordResetStorerMockRecorder) InvalidateUserPasswordResets(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InvalidateUserPasswordResets", reflect.TypeOf((*MockPasswordResetStorer)(nil).InvalidateUserPasswordResets), ctx, userID)
+}
+
+// MarkPasswordResetUsed mocks base method.
+func (m *MockPasswordResetStorer) MarkPasswordResetUsed(ctx context.Context, resetID primitive.ObjectID) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkPasswordResetUsed", ctx, resetID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkPasswordResetUsed indicates an expected call of MarkPasswordResetUsed.
+func (mr *MockPasswordResetStorerMockRecorder) MarkPasswordResetUsed(ctx, resetID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkPasswordResetUsed", reflect.TypeOf((*MockPasswordResetStorer)(nil).MarkPasswordResetUsed), ctx, resetID)
+}
@@ -0,0 +1,147 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: store/room_invite_store.go
+//
+// Generated by this command:
+//
+//	mockgen -source=store/room_invite_store.go -destination=store/mocks/mock_room_invite_store.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	models "go-chat/backend/models"
+	reflect "reflect"
+	time "time"
+
+	primitive "go.mongodb.org/mongo-driver/bson/primitive"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockRoomInviteStorer is a mock of RoomInviteStorer interface.
+type MockRoomInviteStorer struct {
+	ctrl     *gomock.Controller
+	recorder *MockRoomInviteStorerMockRecorder
+	isgomock struct{}
+}
+
+// MockRoomInviteStorerMockRecorder is the mock recorder for MockRoomInviteStorer.
+type MockRoomInviteStorerMockRecorder struct {
+	mock *MockRoomInviteStorer
+}
+
+// NewMockRoomInviteStorer creates a new mock instance.
+func NewMockRoomInviteStorer(ctrl *gomock.Controller) *MockRoomInviteStorer {
+	mock := &MockRoomInviteStorer{ctrl: ctrl}
+	mock.recorder = &MockRoomInviteStorerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRoomInviteStorer) EXPECT() *MockRoomInviteStorerMockRecorder {
+	return m.recorder
+}
+
+// ConsumeRoomInvite mocks base method.
+func (m *MockRoomInviteStorer) ConsumeRoomInvite(ctx context.Context, inviteID primitive.ObjectID, now time.Time) (*models.RoomInvite, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeRoomInvite", ctx, inviteID, now)
+	ret0, _ := ret[0].(*models.RoomInvite)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeRoomInvite indicates an expected call of ConsumeRoomInvite.
+func (mr *MockRoomInviteStorerMockRecorder) ConsumeRoomInvite(ctx, inviteID, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeRoomInvite", reflect.TypeOf((*MockRoomInviteStorer)(nil).ConsumeRoomInvite), ctx, inviteID, now)
+}
+
+// CreateRoomInvite mocks base method.
+func (m *MockRoomInviteStorer) CreateRoomInvite(ctx context.Context, invite models.RoomInvite) (primitive.ObjectID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRoomInvite", ctx, invite)
+	ret0, _ := ret[0].(primitive.ObjectID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateRoomInvite indicates an expected call of CreateRoomInvite.
+func (mr *MockRoomInviteStorerMockRecorder) CreateRoomInvite(ctx, invite any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRoomInvite", reflect.TypeOf((*MockRoomInviteStorer)(nil).CreateRoomInvite), ctx, invite)
+}
+
+// DeleteRoomInvite mocks base method.
+func (m *MockRoomInviteStorer) DeleteRoomInvite(ctx context.Context, inviteID primitive.ObjectID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRoomInvite", ctx, inviteID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRoomInvite indicates an expected call of DeleteRoomInvite.
+func (mr *MockRoomInviteStorerMockRecorder) DeleteRoomInvite(ctx, inviteID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRoomInvite", reflect.TypeOf((*MockRoomInviteStorer)(nil).DeleteRoomInvite), ctx, inviteID)
+}
+
+// FindRoomInviteByCodeHash mocks base method.
+func (m *MockRoomInviteStorer) FindRoomInviteByCodeHash(ctx context.Context, codeHash string) (*models.RoomInvite, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindRoomInviteByCodeHash", ctx, codeHash)
+	ret0, _ := ret[0].(*models.RoomInvite)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindRoomInviteByCodeHash indicates an expected call of FindRoomInviteByCodeHash.
+func (mr *MockRoomInviteStorerMockRecorder) FindRoomInviteByCodeHash(ctx, codeHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindRoomInviteByCodeHash", reflect.TypeOf((*MockRoomInviteStorer)(nil).FindRoomInviteByCodeHash), ctx, codeHash)
+}
+
+// FindRoomInviteByID mocks base method.
+func (m *MockRoomInviteStorer) FindRoomInviteByID(ctx context.Context, inviteID primitive.ObjectID) (*models.RoomInvite, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindRoomInviteByID", ctx, inviteID)
+	ret0, _ := ret[0].(*models.RoomInvite)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindRoomInviteByID indicates an expected call of FindRoomInviteByID.
+func (mr *MockRoomInviteStorerMockRecorder) FindRoomInviteByID(ctx, inviteID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindRoomInviteByID", reflect.TypeOf((*MockRoomInviteStorer)(nil).FindRoomInviteByID), ctx, inviteID)
+}
+
+// ListActiveRoomInvites mocks base method.
+func (m *MockRoomInviteStorer) ListActiveRoomInvites(ctx context.Context, roomID primitive.ObjectID, now time.Time) ([]models.RoomInvite, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListActiveRoomInvites", ctx, roomID, now)
+	ret0, _ := ret[0].([]models.RoomInvite)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListActiveRoomInvites indicates an expected call of ListActiveRoomInvites.
+func (mr *MockRoomInviteStorerMockRecorder) ListActiveRoomInvites(ctx, roomID, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListActiveRoomInvites", reflect.TypeOf((*MockRoomInviteStorer)(nil).ListActiveRoomInvites), ctx, roomID, now)
+}
+
+// ReleaseRoomInvite mocks base method.
+func (m *MockRoomInviteStorer) ReleaseRoomInvite(ctx context.Context, inviteID primitive.ObjectID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseRoomInvite", ctx, inviteID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseRoomInvite indicates an expected call of ReleaseRoomInvite.
+func (mr *MockRoomInviteStorerMockRecorder) ReleaseRoomInvite(ctx, inviteID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseRoomInvite", reflect.TypeOf((*MockRoomInviteStorer)(nil).ReleaseRoomInvite), ctx, inviteID)
+}
@@ -0,0 +1,130 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: store/session_store.go
+//
+// Generated by this command:
+//
+//	mockgen -source=store/session_store.go -destination=store/mocks/mock_session_store.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	models "go-chat/backend/models"
+	reflect "reflect"
+	time "time"
+
+	primitive "go.mongodb.org/mongo-driver/bson/primitive"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockSessionStorer is a mock of SessionStorer interface.
+type MockSessionStorer struct {
+	ctrl     *gomock.Controller
+	recorder *MockSessionStorerMockRecorder
+	isgomock struct{}
+}
+
+// MockSessionStorerMockRecorder is the mock recorder for MockSessionStorer.
+type MockSessionStorerMockRecorder struct {
+	mock *MockSessionStorer
+}
+
+// NewMockSessionStorer creates a new mock instance.
+func NewMockSessionStorer(ctrl *gomock.Controller) *MockSessionStorer {
+	mock := &MockSessionStorer{ctrl: ctrl}
+	mock.recorder = &MockSessionStorerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSessionStorer) EXPECT() *MockSessionStorerMockRecorder {
+	return m.recorder
+}
+
+// CreateSession mocks base method.
+func (m *MockSessionStorer) CreateSession(ctx context.Context, session models.Session) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSession", ctx, session)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateSession indicates an expected call of CreateSession.
+func (mr *MockSessionStorerMockRecorder) CreateSession(ctx, session any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockSessionStorer)(nil).CreateSession), ctx, session)
+}
+
+// FindSessionByID mocks base method.
+func (m *MockSessionStorer) FindSessionByID(ctx context.Context, sessionID primitive.ObjectID) (*models.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindSessionByID", ctx, sessionID)
+	ret0, _ := ret[0].(*models.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindSessionByID indicates an expected call of FindSessionByID.
+func (mr *MockSessionStorerMockRecorder) FindSessionByID(ctx, sessionID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindSessionByID", reflect.TypeOf((*MockSessionStorer)(nil).FindSessionByID), ctx, sessionID)
+}
+
+// ListActiveSessions mocks base method.
+func (m *MockSessionStorer) ListActiveSessions(ctx context.Context, userID primitive.ObjectID, since time.Time) ([]models.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListActiveSessions", ctx, userID, since)
+	ret0, _ := ret[0].([]models.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListActiveSessions indicates an expected call of ListActiveSessions.
+func (mr *MockSessionStorerMockRecorder) ListActiveSessions(ctx, userID, since any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListActiveSessions", reflect.TypeOf((*MockSessionStorer)(nil).ListActiveSessions), ctx, userID, since)
+}
+
+// RevokeSession mocks base method.
+func (m *MockSessionStorer) RevokeSession(ctx context.Context, sessionID primitive.ObjectID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSession", ctx, sessionID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeSession indicates an expected call of RevokeSession.
+func (mr *MockSessionStorerMockRecorder) RevokeSession(ctx, sessionID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockSessionStorer)(nil).RevokeSession), ctx, sessionID)
+}
+
+// RevokeUserSessions mocks base method.
+func (m *MockSessionStorer) RevokeUserSessions(ctx context.Context, userID primitive.ObjectID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeUserSessions", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeUserSessions indicates an expected call of RevokeUserSessions.
+func (mr *MockSessionStorerMockRecorder) RevokeUserSessions(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeUserSessions", reflect.TypeOf((*MockSessionStorer)(nil).RevokeUserSessions), ctx, userID)
+}
+
+// TouchSession mocks base method.
+func (m *MockSessionStorer) TouchSession(ctx context.Context, sessionID primitive.ObjectID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchSession", ctx, sessionID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TouchSession indicates an expected call of TouchSession.
+func (mr *MockSessionStorerMockRecorder) TouchSession(ctx, sessionID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchSession", reflect.TypeOf((*MockSessionStorer)(nil).TouchSession), ctx, sessionID)
+}
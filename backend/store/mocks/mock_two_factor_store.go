@@ -0,0 +1,145 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: store/two_factor_store.go
+//
+// Generated by this command:
+//
+//	mockgen -source=store/two_factor_store.go -destination=store/mocks/mock_two_factor_store.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	models "go-chat/backend/models"
+	reflect "reflect"
+
+	primitive "go.mongodb.org/mongo-driver/bson/primitive"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockTwoFactorStorer is a mock of TwoFactorStorer interface.
+type MockTwoFactorStorer struct {
+	ctrl     *gomock.Controller
+	recorder *MockTwoFactorStorerMockRecorder
+	isgomock struct{}
+}
+
+// MockTwoFactorStorerMockRecorder is the mock recorder for MockTwoFactorStorer.
+type MockTwoFactorStorerMockRecorder struct {
+	mock *MockTwoFactorStorer
+}
+
+// NewMockTwoFactorStorer creates a new mock instance.
+func NewMockTwoFactorStorer(ctrl *gomock.Controller) *MockTwoFactorStorer {
+	mock := &MockTwoFactorStorer{ctrl: ctrl}
+	mock.recorder = &MockTwoFactorStorerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTwoFactorStorer) EXPECT() *MockTwoFactorStorerMockRecorder {
+	return m.recorder
+}
+
+// DeleteTwoFactor mocks base method.
+func (m *MockTwoFactorStorer) DeleteTwoFactor(ctx context.Context, userID primitive.ObjectID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTwoFactor", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTwoFactor indicates an expected call of DeleteTwoFactor.
+func (mr *MockTwoFactorStorerMockRecorder) DeleteTwoFactor(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTwoFactor", reflect.TypeOf((*MockTwoFactorStorer)(nil).DeleteTwoFactor), ctx, userID)
+}
+
+// EnableTwoFactor mocks base method.
+func (m *MockTwoFactorStorer) EnableTwoFactor(ctx context.Context, userID primitive.ObjectID, recoveryCodeHashes []string, step int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableTwoFactor", ctx, userID, recoveryCodeHashes, step)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnableTwoFactor indicates an expected call of EnableTwoFactor.
+func (mr *MockTwoFactorStorerMockRecorder) EnableTwoFactor(ctx, userID, recoveryCodeHashes, step any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableTwoFactor", reflect.TypeOf((*MockTwoFactorStorer)(nil).EnableTwoFactor), ctx, userID, recoveryCodeHashes, step)
+}
+
+// FindTwoFactor mocks base method.
+func (m *MockTwoFactorStorer) FindTwoFactor(ctx context.Context, userID primitive.ObjectID) (*models.TwoFactor, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindTwoFactor", ctx, userID)
+	ret0, _ := ret[0].(*models.TwoFactor)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindTwoFactor indicates an expected call of FindTwoFactor.
+func (mr *MockTwoFactorStorerMockRecorder) FindTwoFactor(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindTwoFactor", reflect.TypeOf((*MockTwoFactorStorer)(nil).FindTwoFactor), ctx, userID)
+}
+
+// ReplaceRecoveryCodes mocks base method.
+func (m *MockTwoFactorStorer) ReplaceRecoveryCodes(ctx context.Context, userID primitive.ObjectID, recoveryCodeHashes []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceRecoveryCodes", ctx, userID, recoveryCodeHashes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReplaceRecoveryCodes indicates an expected call of ReplaceRecoveryCodes.
+func (mr *MockTwoFactorStorerMockRecorder) ReplaceRecoveryCodes(ctx, userID, recoveryCodeHashes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceRecoveryCodes", reflect.TypeOf((*MockTwoFactorStorer)(nil).ReplaceRecoveryCodes), ctx, userID, recoveryCodeHashes)
+}
+
+// SavePendingTwoFactor mocks base method.
+func (m *MockTwoFactorStorer) SavePendingTwoFactor(ctx context.Context, userID primitive.ObjectID, encryptedSecret string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SavePendingTwoFactor", ctx, userID, encryptedSecret)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SavePendingTwoFactor indicates an expected call of SavePendingTwoFactor.
+func (mr *MockTwoFactorStorerMockRecorder) SavePendingTwoFactor(ctx, userID, encryptedSecret any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SavePendingTwoFactor", reflect.TypeOf((*MockTwoFactorStorer)(nil).SavePendingTwoFactor), ctx, userID, encryptedSecret)
+}
+
+// UseRecoveryCode mocks base method.
+func (m *MockTwoFactorStorer) UseRecoveryCode(ctx context.Context, userID primitive.ObjectID, codeHash string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseRecoveryCode", ctx, userID, codeHash)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseRecoveryCode indicates an expected call of UseRecoveryCode.
+func (mr *MockTwoFactorStorerMockRecorder) UseRecoveryCode(ctx, userID, codeHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseRecoveryCode", reflect.TypeOf((*MockTwoFactorStorer)(nil).UseRecoveryCode), ctx, userID, codeHash)
+}
+
+// UseTOTPStep mocks base method.
+func (m *MockTwoFactorStorer) UseTOTPStep(ctx context.Context, userID primitive.ObjectID, step int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseTOTPStep", ctx, userID, step)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseTOTPStep indicates an expected call of UseTOTPStep.
+func (mr *MockTwoFactorStorerMockRecorder) UseTOTPStep(ctx, userID, step any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseTOTPStep", reflect.TypeOf((*MockTwoFactorStorer)(nil).UseTOTPStep), ctx, userID, step)
+}
@@ -1,9 +1,9 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: E:\coding\GO\Multi-person chat 2.0\backend\store\user_store.go
+// Source: store/user_store.go
 //
 // Generated by this command:
 //
-//	mockgen -source=E:\coding\GO\Multi-person chat 2.0\backend\store\user_store.go -destination=E:\coding\GO\Multi-person chat 2.0\backend\store/mocks/mock_user_store.go -package=mocks
+//	mockgen -source=store/user_store.go -destination=store/mocks/mock_user_store.go -package=mocks
 //
 
 // Package mocks is a generated GoMock package.
@@ -58,6 +58,20 @@ func (mr *MockUserStorerMockRecorder) CheckUserExists(ctx, email, username any)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckUserExists", reflect.TypeOf((*MockUserStorer)(nil).CheckUserExists), ctx, email, username)
 }
 
+// ClearGoogleID mocks base method.
+func (m *MockUserStorer) ClearGoogleID(ctx context.Context, userID primitive.ObjectID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClearGoogleID", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClearGoogleID indicates an expected call of ClearGoogleID.
+func (mr *MockUserStorerMockRecorder) ClearGoogleID(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClearGoogleID", reflect.TypeOf((*MockUserStorer)(nil).ClearGoogleID), ctx, userID)
+}
+
 // CreateUser mocks base method.
 func (m *MockUserStorer) CreateUser(ctx context.Context, user models.User) (primitive.ObjectID, error) {
 	m.ctrl.T.Helper()
@@ -102,3 +116,46 @@ func (mr *MockUserStorerMockRecorder) FindUserByGoogleID(ctx, googleID any) *gom
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUserByGoogleID", reflect.TypeOf((*MockUserStorer)(nil).FindUserByGoogleID), ctx, googleID)
 }
+
+// FindUserByID mocks base method.
+func (m *MockUserStorer) FindUserByID(ctx context.Context, userID primitive.ObjectID) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindUserByID", ctx, userID)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindUserByID indicates an expected call of FindUserByID.
+func (mr *MockUserStorerMockRecorder) FindUserByID(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUserByID", reflect.TypeOf((*MockUserStorer)(nil).FindUserByID), ctx, userID)
+}
+
+// MarkUserVerified mocks base method.
+func (m *MockUserStorer) MarkUserVerified(ctx context.Context, userID primitive.ObjectID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkUserVerified", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkUserVerified indicates an expected call of MarkUserVerified.
+func (mr *MockUserStorerMockRecorder) MarkUserVerified(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkUserVerified", reflect.TypeOf((*MockUserStorer)(nil).MarkUserVerified), ctx, userID)
+}
+
+// UpdateUserPassword mocks base method.
+func (m *MockUserStorer) UpdateUserPassword(ctx context.Context, userID primitive.ObjectID, hashedPassword string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserPassword", ctx, userID, hashedPassword)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateUserPassword indicates an expected call of UpdateUserPassword.
+func (mr *MockUserStorerMockRecorder) UpdateUserPassword(ctx, userID, hashedPassword any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserPassword", reflect.TypeOf((*MockUserStorer)(nil).UpdateUserPassword), ctx, userID, hashedPassword)
+}
@@ -0,0 +1,176 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: store/webhook_store.go
+//
+// Generated by this command:
+//
+//	mockgen -source=store/webhook_store.go -destination=store/mocks/mock_webhook_store.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	models "go-chat/backend/models"
+	reflect "reflect"
+	time "time"
+
+	primitive "go.mongodb.org/mongo-driver/bson/primitive"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockWebhookStorer is a mock of WebhookStorer interface.
+type MockWebhookStorer struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebhookStorerMockRecorder
+	isgomock struct{}
+}
+
+// MockWebhookStorerMockRecorder is the mock recorder for MockWebhookStorer.
+type MockWebhookStorerMockRecorder struct {
+	mock *MockWebhookStorer
+}
+
+// NewMockWebhookStorer creates a new mock instance.
+func NewMockWebhookStorer(ctrl *gomock.Controller) *MockWebhookStorer {
+	mock := &MockWebhookStorer{ctrl: ctrl}
+	mock.recorder = &MockWebhookStorerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWebhookStorer) EXPECT() *MockWebhookStorerMockRecorder {
+	return m.recorder
+}
+
+// ClaimDueDelivery mocks base method.
+func (m *MockWebhookStorer) ClaimDueDelivery(ctx context.Context, now time.Time, lease time.Duration) (*models.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimDueDelivery", ctx, now, lease)
+	ret0, _ := ret[0].(*models.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimDueDelivery indicates an expected call of ClaimDueDelivery.
+func (mr *MockWebhookStorerMockRecorder) ClaimDueDelivery(ctx, now, lease any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimDueDelivery", reflect.TypeOf((*MockWebhookStorer)(nil).ClaimDueDelivery), ctx, now, lease)
+}
+
+// CreateWebhook mocks base method.
+func (m *MockWebhookStorer) CreateWebhook(ctx context.Context, webhook models.Webhook) (primitive.ObjectID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhook", ctx, webhook)
+	ret0, _ := ret[0].(primitive.ObjectID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebhook indicates an expected call of CreateWebhook.
+func (mr *MockWebhookStorerMockRecorder) CreateWebhook(ctx, webhook any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhook", reflect.TypeOf((*MockWebhookStorer)(nil).CreateWebhook), ctx, webhook)
+}
+
+// DeleteWebhook mocks base method.
+func (m *MockWebhookStorer) DeleteWebhook(ctx context.Context, webhookID primitive.ObjectID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhook", ctx, webhookID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebhook indicates an expected call of DeleteWebhook.
+func (mr *MockWebhookStorerMockRecorder) DeleteWebhook(ctx, webhookID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhook", reflect.TypeOf((*MockWebhookStorer)(nil).DeleteWebhook), ctx, webhookID)
+}
+
+// DeleteWebhooksByRoom mocks base method.
+func (m *MockWebhookStorer) DeleteWebhooksByRoom(ctx context.Context, roomID primitive.ObjectID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhooksByRoom", ctx, roomID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebhooksByRoom indicates an expected call of DeleteWebhooksByRoom.
+func (mr *MockWebhookStorerMockRecorder) DeleteWebhooksByRoom(ctx, roomID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhooksByRoom", reflect.TypeOf((*MockWebhookStorer)(nil).DeleteWebhooksByRoom), ctx, roomID)
+}
+
+// FindWebhookByID mocks base method.
+func (m *MockWebhookStorer) FindWebhookByID(ctx context.Context, webhookID primitive.ObjectID) (*models.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindWebhookByID", ctx, webhookID)
+	ret0, _ := ret[0].(*models.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindWebhookByID indicates an expected call of FindWebhookByID.
+func (mr *MockWebhookStorerMockRecorder) FindWebhookByID(ctx, webhookID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindWebhookByID", reflect.TypeOf((*MockWebhookStorer)(nil).FindWebhookByID), ctx, webhookID)
+}
+
+// ListDeliveries mocks base method.
+func (m *MockWebhookStorer) ListDeliveries(ctx context.Context, webhookID primitive.ObjectID, status models.WebhookDeliveryStatus, limit int64) ([]models.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDeliveries", ctx, webhookID, status, limit)
+	ret0, _ := ret[0].([]models.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDeliveries indicates an expected call of ListDeliveries.
+func (mr *MockWebhookStorerMockRecorder) ListDeliveries(ctx, webhookID, status, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDeliveries", reflect.TypeOf((*MockWebhookStorer)(nil).ListDeliveries), ctx, webhookID, status, limit)
+}
+
+// ListWebhooksByRoom mocks base method.
+func (m *MockWebhookStorer) ListWebhooksByRoom(ctx context.Context, roomID primitive.ObjectID) ([]models.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhooksByRoom", ctx, roomID)
+	ret0, _ := ret[0].([]models.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhooksByRoom indicates an expected call of ListWebhooksByRoom.
+func (mr *MockWebhookStorerMockRecorder) ListWebhooksByRoom(ctx, roomID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhooksByRoom", reflect.TypeOf((*MockWebhookStorer)(nil).ListWebhooksByRoom), ctx, roomID)
+}
+
+// ListWebhooksForEvent mocks base method.
+func (m *MockWebhookStorer) ListWebhooksForEvent(ctx context.Context, roomID primitive.ObjectID, event models.WebhookEvent) ([]models.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhooksForEvent", ctx, roomID, event)
+	ret0, _ := ret[0].([]models.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhooksForEvent indicates an expected call of ListWebhooksForEvent.
+func (mr *MockWebhookStorerMockRecorder) ListWebhooksForEvent(ctx, roomID, event any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhooksForEvent", reflect.TypeOf((*MockWebhookStorer)(nil).ListWebhooksForEvent), ctx, roomID, event)
+}
+
+// SaveDelivery mocks base method.
+func (m *MockWebhookStorer) SaveDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveDelivery", ctx, delivery)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveDelivery indicates an expected call of SaveDelivery.
+func (mr *MockWebhookStorerMockRecorder) SaveDelivery(ctx, delivery any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveDelivery", reflect.TypeOf((*MockWebhookStorer)(nil).SaveDelivery), ctx, delivery)
+}
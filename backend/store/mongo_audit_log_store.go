@@ -0,0 +1,48 @@
+// backend/store/mongo_audit_log_store.go
+package store
+
+import (
+	"context"
+	"go-chat/backend/database"
+	"go-chat/backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoAuditLogStore 是 AuditLogStorer 介面的 MongoDB 實作
+type MongoAuditLogStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongoAuditLogStore 是一個工廠函式，用於建立新的 MongoAuditLogStore
+func NewMongoAuditLogStore() *MongoAuditLogStore {
+	return &MongoAuditLogStore{collection: database.GetCollection("audit_logs")}
+}
+
+// CreateAuditLog 新增一筆稽核紀錄
+func (s *MongoAuditLogStore) CreateAuditLog(ctx context.Context, entry models.AuditLog) error {
+	_, err := s.collection.InsertOne(ctx, entry)
+	return err
+}
+
+// ListAuditLogs 依時間由新到舊列出稽核紀錄
+func (s *MongoAuditLogStore) ListAuditLogs(ctx context.Context, action models.AuditAction, limit int64) ([]models.AuditLog, error) {
+	filter := bson.M{}
+	if action != "" {
+		filter["action"] = action
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}).SetLimit(limit)
+	cursor, err := s.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	entries := []models.AuditLog{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
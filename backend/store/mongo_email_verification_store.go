@@ -0,0 +1,48 @@
+// backend/store/mongo_email_verification_store.go
+package store
+
+import (
+	"context"
+	"go-chat/backend/database"
+	"go-chat/backend/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MongoEmailVerificationStore 是 EmailVerificationStorer 介面的 MongoDB 實作
+type MongoEmailVerificationStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongoEmailVerificationStore 是一個工廠函式，用於建立新的 MongoEmailVerificationStore
+func NewMongoEmailVerificationStore() *MongoEmailVerificationStore {
+	return &MongoEmailVerificationStore{collection: database.GetCollection("email_verifications")}
+}
+
+// CreateEmailVerification 新增一個 Email 驗證 token
+func (s *MongoEmailVerificationStore) CreateEmailVerification(ctx context.Context, verification models.EmailVerification) error {
+	_, err := s.collection.InsertOne(ctx, verification)
+	return err
+}
+
+// FindEmailVerificationByHash 根據雜湊查找 Email 驗證 token
+func (s *MongoEmailVerificationStore) FindEmailVerificationByHash(ctx context.Context, tokenHash string) (*models.EmailVerification, error) {
+	var verification models.EmailVerification
+	if err := s.collection.FindOne(ctx, bson.M{"tokenHash": tokenHash}).Decode(&verification); err != nil {
+		return nil, err
+	}
+	return &verification, nil
+}
+
+// MarkEmailVerificationUsed 只在 token 尚未使用時標記為已使用
+func (s *MongoEmailVerificationStore) MarkEmailVerificationUsed(ctx context.Context, verificationID primitive.ObjectID) (bool, error) {
+	filter := bson.M{"_id": verificationID, "usedAt": bson.M{"$exists": false}}
+	result, err := s.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"usedAt": time.Now()}})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
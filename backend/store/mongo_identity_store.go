@@ -0,0 +1,74 @@
+// backend/store/mongo_identity_store.go
+package store
+
+import (
+	"context"
+	"go-chat/backend/database"
+	"go-chat/backend/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoIdentityStore 是 IdentityStorer 介面的 MongoDB 實作
+type MongoIdentityStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongoIdentityStore 是一個工廠函式，用於建立新的 MongoIdentityStore
+func NewMongoIdentityStore() *MongoIdentityStore {
+	return &MongoIdentityStore{collection: database.GetCollection("identities")}
+}
+
+// FindIdentity 根據提供者與 sub 查找身分
+func (s *MongoIdentityStore) FindIdentity(ctx context.Context, provider, subject string) (*models.Identity, error) {
+	var identity models.Identity
+	if err := s.collection.FindOne(ctx, bson.M{"provider": provider, "subject": subject}).Decode(&identity); err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// CreateIdentity 新增一筆身分，同一個提供者的 sub 已被連結時會回傳重複鍵錯誤
+func (s *MongoIdentityStore) CreateIdentity(ctx context.Context, identity models.Identity) error {
+	_, err := s.collection.InsertOne(ctx, identity)
+	return err
+}
+
+// TouchIdentity 更新身分最後一次登入的時間
+func (s *MongoIdentityStore) TouchIdentity(ctx context.Context, identityID primitive.ObjectID, email string) error {
+	update := bson.M{"$set": bson.M{"lastLoginAt": time.Now(), "email": email}}
+	_, err := s.collection.UpdateOne(ctx, bson.M{"_id": identityID}, update)
+	return err
+}
+
+// ListUserIdentities 列出使用者連結的所有身分，依連結時間排序
+func (s *MongoIdentityStore) ListUserIdentities(ctx context.Context, userID primitive.ObjectID) ([]models.Identity, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}})
+	cursor, err := s.collection.Find(ctx, bson.M{"userId": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	identities := []models.Identity{}
+	if err := cursor.All(ctx, &identities); err != nil {
+		return nil, err
+	}
+	return identities, nil
+}
+
+// DeleteIdentity 刪除屬於使用者的身分
+func (s *MongoIdentityStore) DeleteIdentity(ctx context.Context, userID, identityID primitive.ObjectID) error {
+	result, err := s.collection.DeleteOne(ctx, bson.M{"_id": identityID, "userId": userID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
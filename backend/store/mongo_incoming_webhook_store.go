@@ -0,0 +1,90 @@
+// backend/store/mongo_incoming_webhook_store.go
+package store
+
+import (
+	"context"
+	"errors"
+	"go-chat/backend/database"
+	"go-chat/backend/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MongoIncomingWebhookStore 是 IncomingWebhookStorer 介面的 MongoDB 實作
+type MongoIncomingWebhookStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongoIncomingWebhookStore 是一個工廠函式，用於建立新的 MongoIncomingWebhookStore
+func NewMongoIncomingWebhookStore() *MongoIncomingWebhookStore {
+	return &MongoIncomingWebhookStore{collection: database.GetCollection("incoming_webhooks")}
+}
+
+// CreateIncomingWebhook 新增一個 incoming webhook
+func (s *MongoIncomingWebhookStore) CreateIncomingWebhook(ctx context.Context, webhook models.IncomingWebhook) (primitive.ObjectID, error) {
+	result, err := s.collection.InsertOne(ctx, webhook)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	oid, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return primitive.NilObjectID, errors.New("failed to convert InsertedID to ObjectID")
+	}
+	return oid, nil
+}
+
+// FindIncomingWebhookByID 根據 ID 查找 incoming webhook
+func (s *MongoIncomingWebhookStore) FindIncomingWebhookByID(ctx context.Context, webhookID primitive.ObjectID) (*models.IncomingWebhook, error) {
+	return s.findOne(ctx, bson.M{"_id": webhookID})
+}
+
+// FindIncomingWebhookByTokenHash 根據金鑰雜湊查找 incoming webhook
+func (s *MongoIncomingWebhookStore) FindIncomingWebhookByTokenHash(ctx context.Context, tokenHash string) (*models.IncomingWebhook, error) {
+	return s.findOne(ctx, bson.M{"tokenHash": tokenHash})
+}
+
+func (s *MongoIncomingWebhookStore) findOne(ctx context.Context, filter bson.M) (*models.IncomingWebhook, error) {
+	var webhook models.IncomingWebhook
+	if err := s.collection.FindOne(ctx, filter).Decode(&webhook); err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// ListIncomingWebhooksByRoom 列出聊天室的所有 incoming webhook
+func (s *MongoIncomingWebhookStore) ListIncomingWebhooksByRoom(ctx context.Context, roomID primitive.ObjectID) ([]models.IncomingWebhook, error) {
+	cursor, err := s.collection.Find(ctx, bson.M{"roomId": roomID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	webhooks := []models.IncomingWebhook{}
+	if err := cursor.All(ctx, &webhooks); err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// UpdateIncomingWebhookToken 替換金鑰雜湊，舊的 URL 會立即失效
+func (s *MongoIncomingWebhookStore) UpdateIncomingWebhookToken(ctx context.Context, webhookID primitive.ObjectID, tokenHash string) error {
+	update := bson.M{"$set": bson.M{"tokenHash": tokenHash, "rotatedAt": time.Now()}}
+	result, err := s.collection.UpdateOne(ctx, bson.M{"_id": webhookID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// DeleteIncomingWebhook 刪除 incoming webhook
+func (s *MongoIncomingWebhookStore) DeleteIncomingWebhook(ctx context.Context, webhookID primitive.ObjectID) error {
+	_, err := s.collection.DeleteOne(ctx, bson.M{"_id": webhookID})
+	return err
+}
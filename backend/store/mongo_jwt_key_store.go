@@ -0,0 +1,58 @@
+// backend/store/mongo_jwt_key_store.go
+package store
+
+import (
+	"context"
+	"go-chat/backend/database"
+	"go-chat/backend/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoJWTKeyStore 是 JWTKeyStorer 介面的 MongoDB 實作
+type MongoJWTKeyStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongoJWTKeyStore 是一個工廠函式，用於建立新的 MongoJWTKeyStore
+func NewMongoJWTKeyStore() *MongoJWTKeyStore {
+	return &MongoJWTKeyStore{collection: database.GetCollection("jwt_keys")}
+}
+
+// ListJWTKeys 列出仍可用於驗證的金鑰，最新建立的排在前面
+// 過期的金鑰由 TTL 索引刪除，但刪除有延遲，所以查詢時仍要過濾
+func (s *MongoJWTKeyStore) ListJWTKeys(ctx context.Context, now time.Time) ([]models.JWTKey, error) {
+	filter := bson.M{"$or": []bson.M{
+		{"expiresAt": bson.M{"$exists": false}},
+		{"expiresAt": bson.M{"$gt": now}},
+	}}
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}})
+	cursor, err := s.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	keys := []models.JWTKey{}
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// CreateJWTKey 新增一把金鑰
+func (s *MongoJWTKeyStore) CreateJWTKey(ctx context.Context, key models.JWTKey) error {
+	_, err := s.collection.InsertOne(ctx, key)
+	return err
+}
+
+// RetireJWTKeysBefore 將較早建立且仍在使用中的金鑰標記為已輪替
+func (s *MongoJWTKeyStore) RetireJWTKeysBefore(ctx context.Context, createdBefore, retiredAt, expiresAt time.Time) error {
+	filter := bson.M{"createdAt": bson.M{"$lt": createdBefore}, "retiredAt": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"retiredAt": retiredAt, "expiresAt": expiresAt}}
+	_, err := s.collection.UpdateMany(ctx, filter, update)
+	return err
+}
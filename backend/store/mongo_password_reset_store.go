@@ -0,0 +1,55 @@
+// backend/store/mongo_password_reset_store.go
+package store
+
+import (
+	"context"
+	"go-chat/backend/database"
+	"go-chat/backend/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MongoPasswordResetStore 是 PasswordResetStorer 介面的 MongoDB 實作
+type MongoPasswordResetStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongoPasswordResetStore 是一個工廠函式，用於建立新的 MongoPasswordResetStore
+func NewMongoPasswordResetStore() *MongoPasswordResetStore {
+	return &MongoPasswordResetStore{collection: database.GetCollection("password_resets")}
+}
+
+// CreatePasswordReset 新增一個密碼重設 token
+func (s *MongoPasswordResetStore) CreatePasswordReset(ctx context.Context, reset models.PasswordReset) error {
+	_, err := s.collection.InsertOne(ctx, reset)
+	return err
+}
+
+// FindPasswordResetByHash 根據雜湊查找密碼重設 token
+func (s *MongoPasswordResetStore) FindPasswordResetByHash(ctx context.Context, tokenHash string) (*models.PasswordReset, error) {
+	var reset models.PasswordReset
+	if err := s.collection.FindOne(ctx, bson.M{"tokenHash": tokenHash}).Decode(&reset); err != nil {
+		return nil, err
+	}
+	return &reset, nil
+}
+
+// MarkPasswordResetUsed 只在 token 尚未使用時標記為已使用，避免同一個 token 被並行使用兩次
+func (s *MongoPasswordResetStore) MarkPasswordResetUsed(ctx context.Context, resetID primitive.ObjectID) (bool, error) {
+	filter := bson.M{"_id": resetID, "usedAt": bson.M{"$exists": false}}
+	result, err := s.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"usedAt": time.Now()}})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// InvalidateUserPasswordResets 將使用者所有尚未使用的 token 標記為已使用
+func (s *MongoPasswordResetStore) InvalidateUserPasswordResets(ctx context.Context, userID primitive.ObjectID) error {
+	filter := bson.M{"userId": userID, "usedAt": bson.M{"$exists": false}}
+	_, err := s.collection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"usedAt": time.Now()}})
+	return err
+}
@@ -0,0 +1,66 @@
+// backend/store/mongo_refresh_token_store.go
+package store
+
+import (
+	"context"
+	"go-chat/backend/database"
+	"go-chat/backend/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MongoRefreshTokenStore 是 RefreshTokenStorer 介面的 MongoDB 實作
+type MongoRefreshTokenStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongoRefreshTokenStore 是一個工廠函式，用於建立新的 MongoRefreshTokenStore
+func NewMongoRefreshTokenStore() *MongoRefreshTokenStore {
+	return &MongoRefreshTokenStore{collection: database.GetCollection("refresh_tokens")}
+}
+
+// CreateRefreshToken 新增一個 refresh token
+func (s *MongoRefreshTokenStore) CreateRefreshToken(ctx context.Context, token models.RefreshToken) error {
+	_, err := s.collection.InsertOne(ctx, token)
+	return err
+}
+
+// FindRefreshTokenByHash 根據雜湊查找 refresh token
+func (s *MongoRefreshTokenStore) FindRefreshTokenByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	if err := s.collection.FindOne(ctx, bson.M{"tokenHash": tokenHash}).Decode(&token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MarkRefreshTokenUsed 只在 token 尚未使用且未撤銷時標記為已使用，避免並行換發時同一個 token 被用兩次
+func (s *MongoRefreshTokenStore) MarkRefreshTokenUsed(ctx context.Context, tokenID primitive.ObjectID) (bool, error) {
+	filter := bson.M{
+		"_id":       tokenID,
+		"usedAt":    bson.M{"$exists": false},
+		"revokedAt": bson.M{"$exists": false},
+	}
+	result, err := s.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"usedAt": time.Now()}})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// RevokeRefreshTokenFamily 撤銷同一個 family 中所有尚未撤銷的 token
+func (s *MongoRefreshTokenStore) RevokeRefreshTokenFamily(ctx context.Context, familyID primitive.ObjectID) error {
+	filter := bson.M{"familyId": familyID, "revokedAt": bson.M{"$exists": false}}
+	_, err := s.collection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"revokedAt": time.Now()}})
+	return err
+}
+
+// RevokeUserRefreshTokens 撤銷使用者所有尚未撤銷的 token，讓所有裝置都必須重新登入
+func (s *MongoRefreshTokenStore) RevokeUserRefreshTokens(ctx context.Context, userID primitive.ObjectID) error {
+	filter := bson.M{"userId": userID, "revokedAt": bson.M{"$exists": false}}
+	_, err := s.collection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"revokedAt": time.Now()}})
+	return err
+}
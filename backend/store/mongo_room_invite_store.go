@@ -0,0 +1,118 @@
+// backend/store/mongo_room_invite_store.go
+package store
+
+import (
+	"context"
+	"errors"
+	"go-chat/backend/database"
+	"go-chat/backend/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoRoomInviteStore 是 RoomInviteStorer 介面的 MongoDB 實作
+type MongoRoomInviteStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongoRoomInviteStore 是一個工廠函式，用於建立新的 MongoRoomInviteStore
+func NewMongoRoomInviteStore() *MongoRoomInviteStore {
+	return &MongoRoomInviteStore{collection: database.GetCollection("room_invites")}
+}
+
+// CreateRoomInvite 新增一個邀請
+func (s *MongoRoomInviteStore) CreateRoomInvite(ctx context.Context, invite models.RoomInvite) (primitive.ObjectID, error) {
+	result, err := s.collection.InsertOne(ctx, invite)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	oid, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return primitive.NilObjectID, errors.New("failed to convert InsertedID to ObjectID")
+	}
+	return oid, nil
+}
+
+// FindRoomInviteByID 根據 ID 查找邀請
+func (s *MongoRoomInviteStore) FindRoomInviteByID(ctx context.Context, inviteID primitive.ObjectID) (*models.RoomInvite, error) {
+	return s.findOne(ctx, bson.M{"_id": inviteID})
+}
+
+// FindRoomInviteByCodeHash 根據邀請碼的雜湊查找邀請
+func (s *MongoRoomInviteStore) FindRoomInviteByCodeHash(ctx context.Context, codeHash string) (*models.RoomInvite, error) {
+	return s.findOne(ctx, bson.M{"codeHash": codeHash})
+}
+
+func (s *MongoRoomInviteStore) findOne(ctx context.Context, filter bson.M) (*models.RoomInvite, error) {
+	var invite models.RoomInvite
+	if err := s.collection.FindOne(ctx, filter).Decode(&invite); err != nil {
+		return nil, err
+	}
+	return &invite, nil
+}
+
+// ListActiveRoomInvites 依建立時間列出聊天室仍可使用的邀請
+func (s *MongoRoomInviteStore) ListActiveRoomInvites(ctx context.Context, roomID primitive.ObjectID, now time.Time) ([]models.RoomInvite, error) {
+	filter := usableInviteFilter(now)
+	filter["roomId"] = roomID
+	cursor, err := s.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	invites := []models.RoomInvite{}
+	if err := cursor.All(ctx, &invites); err != nil {
+		return nil, err
+	}
+	return invites, nil
+}
+
+// ConsumeRoomInvite 以單一原子操作檢查並增加使用次數，同時兌換的請求不會超過使用上限
+func (s *MongoRoomInviteStore) ConsumeRoomInvite(ctx context.Context, inviteID primitive.ObjectID, now time.Time) (*models.RoomInvite, error) {
+	filter := usableInviteFilter(now)
+	filter["_id"] = inviteID
+
+	var invite models.RoomInvite
+	err := s.collection.FindOneAndUpdate(
+		ctx,
+		filter,
+		bson.M{"$inc": bson.M{"uses": 1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&invite)
+	if err != nil {
+		return nil, err
+	}
+	return &invite, nil
+}
+
+// ReleaseRoomInvite 將使用次數減一，邀請已被撤銷時不做任何事
+func (s *MongoRoomInviteStore) ReleaseRoomInvite(ctx context.Context, inviteID primitive.ObjectID) error {
+	_, err := s.collection.UpdateOne(ctx, bson.M{"_id": inviteID, "uses": bson.M{"$gt": 0}}, bson.M{"$inc": bson.M{"uses": -1}})
+	return err
+}
+
+// DeleteRoomInvite 刪除 (撤銷) 邀請
+func (s *MongoRoomInviteStore) DeleteRoomInvite(ctx context.Context, inviteID primitive.ObjectID) error {
+	_, err := s.collection.DeleteOne(ctx, bson.M{"_id": inviteID})
+	return err
+}
+
+// usableInviteFilter 篩選在 now 時未過期且未達使用次數上限的邀請，與 models.RoomInvite.IsUsable 一致
+func usableInviteFilter(now time.Time) bson.M {
+	return bson.M{"$and": bson.A{
+		bson.M{"$or": bson.A{
+			bson.M{"expiresAt": bson.M{"$exists": false}},
+			bson.M{"expiresAt": bson.M{"$gt": now}},
+		}},
+		bson.M{"$or": bson.A{
+			bson.M{"maxUses": 0},
+			bson.M{"$expr": bson.M{"$lt": bson.A{"$uses", "$maxUses"}}},
+		}},
+	}}
+}
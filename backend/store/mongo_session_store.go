@@ -0,0 +1,80 @@
+// backend/store/mongo_session_store.go
+package store
+
+import (
+	"context"
+	"go-chat/backend/database"
+	"go-chat/backend/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoSessionStore 是 SessionStorer 介面的 MongoDB 實作
+type MongoSessionStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongoSessionStore 是一個工廠函式，用於建立新的 MongoSessionStore
+func NewMongoSessionStore() *MongoSessionStore {
+	return &MongoSessionStore{collection: database.GetCollection("sessions")}
+}
+
+// CreateSession 新增一筆 session 紀錄
+func (s *MongoSessionStore) CreateSession(ctx context.Context, session models.Session) error {
+	_, err := s.collection.InsertOne(ctx, session)
+	return err
+}
+
+// FindSessionByID 根據 ID 查找 session
+func (s *MongoSessionStore) FindSessionByID(ctx context.Context, sessionID primitive.ObjectID) (*models.Session, error) {
+	var session models.Session
+	if err := s.collection.FindOne(ctx, bson.M{"_id": sessionID}).Decode(&session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// ListActiveSessions 列出使用者尚未撤銷且最近仍有活動的 session
+func (s *MongoSessionStore) ListActiveSessions(ctx context.Context, userID primitive.ObjectID, since time.Time) ([]models.Session, error) {
+	filter := bson.M{
+		"userId":     userID,
+		"revokedAt":  bson.M{"$exists": false},
+		"lastSeenAt": bson.M{"$gt": since},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "lastSeenAt", Value: -1}})
+	cursor, err := s.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	sessions := []models.Session{}
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// TouchSession 更新 session 的最後活動時間
+func (s *MongoSessionStore) TouchSession(ctx context.Context, sessionID primitive.ObjectID) error {
+	_, err := s.collection.UpdateOne(ctx, bson.M{"_id": sessionID}, bson.M{"$set": bson.M{"lastSeenAt": time.Now()}})
+	return err
+}
+
+// RevokeSession 將 session 標記為已撤銷，之後不會再出現在裝置列表中
+func (s *MongoSessionStore) RevokeSession(ctx context.Context, sessionID primitive.ObjectID) error {
+	filter := bson.M{"_id": sessionID, "revokedAt": bson.M{"$exists": false}}
+	_, err := s.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"revokedAt": time.Now()}})
+	return err
+}
+
+// RevokeUserSessions 撤銷使用者所有尚未撤銷的 session
+func (s *MongoSessionStore) RevokeUserSessions(ctx context.Context, userID primitive.ObjectID) error {
+	filter := bson.M{"userId": userID, "revokedAt": bson.M{"$exists": false}}
+	_, err := s.collection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"revokedAt": time.Now()}})
+	return err
+}
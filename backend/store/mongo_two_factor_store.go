@@ -0,0 +1,104 @@
+// backend/store/mongo_two_factor_store.go
+package store
+
+import (
+	"context"
+	"go-chat/backend/database"
+	"go-chat/backend/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoTwoFactorStore 是 TwoFactorStorer 介面的 MongoDB 實作
+type MongoTwoFactorStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongoTwoFactorStore 是一個工廠函式，用於建立新的 MongoTwoFactorStore
+func NewMongoTwoFactorStore() *MongoTwoFactorStore {
+	return &MongoTwoFactorStore{collection: database.GetCollection("two_factor")}
+}
+
+// FindTwoFactor 查找使用者的兩步驟驗證設定
+func (s *MongoTwoFactorStore) FindTwoFactor(ctx context.Context, userID primitive.ObjectID) (*models.TwoFactor, error) {
+	var twoFactor models.TwoFactor
+	if err := s.collection.FindOne(ctx, bson.M{"_id": userID}).Decode(&twoFactor); err != nil {
+		return nil, err
+	}
+	return &twoFactor, nil
+}
+
+// SavePendingTwoFactor 保存尚未確認的密鑰，重新設定時覆蓋先前未確認的密鑰
+func (s *MongoTwoFactorStore) SavePendingTwoFactor(ctx context.Context, userID primitive.ObjectID, encryptedSecret string) (bool, error) {
+	filter := bson.M{"_id": userID, "enabled": bson.M{"$ne": true}}
+	update := bson.M{"$set": bson.M{
+		"encryptedSecret": encryptedSecret,
+		"enabled":         false,
+		"lastUsedStep":    0,
+		"createdAt":       time.Now(),
+	}}
+	_, err := s.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	// 已啟用的文件不符合 filter，upsert 會以相同的 _id 插入而觸發重複鍵錯誤
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// EnableTwoFactor 啟用兩步驟驗證
+func (s *MongoTwoFactorStore) EnableTwoFactor(ctx context.Context, userID primitive.ObjectID, recoveryCodeHashes []string, step int64) error {
+	update := bson.M{"$set": bson.M{
+		"enabled":            true,
+		"recoveryCodeHashes": recoveryCodeHashes,
+		"lastUsedStep":       step,
+		"enabledAt":          time.Now(),
+	}}
+	result, err := s.collection.UpdateOne(ctx, bson.M{"_id": userID, "enabled": false}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// UseTOTPStep 只在 step 大於最後使用的時間步時更新，避免驗證碼被重放
+func (s *MongoTwoFactorStore) UseTOTPStep(ctx context.Context, userID primitive.ObjectID, step int64) (bool, error) {
+	filter := bson.M{"_id": userID, "lastUsedStep": bson.M{"$lt": step}}
+	result, err := s.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"lastUsedStep": step}})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// UseRecoveryCode 移除一個復原碼，並行使用同一個復原碼時只有一個會成功
+func (s *MongoTwoFactorStore) UseRecoveryCode(ctx context.Context, userID primitive.ObjectID, codeHash string) (bool, error) {
+	filter := bson.M{"_id": userID, "enabled": true, "recoveryCodeHashes": codeHash}
+	result, err := s.collection.UpdateOne(ctx, filter, bson.M{"$pull": bson.M{"recoveryCodeHashes": codeHash}})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// ReplaceRecoveryCodes 以新的復原碼取代所有舊的復原碼
+func (s *MongoTwoFactorStore) ReplaceRecoveryCodes(ctx context.Context, userID primitive.ObjectID, recoveryCodeHashes []string) error {
+	filter := bson.M{"_id": userID, "enabled": true}
+	_, err := s.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"recoveryCodeHashes": recoveryCodeHashes}})
+	return err
+}
+
+// DeleteTwoFactor 刪除使用者的兩步驟驗證設定
+func (s *MongoTwoFactorStore) DeleteTwoFactor(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := s.collection.DeleteOne(ctx, bson.M{"_id": userID})
+	return err
+}
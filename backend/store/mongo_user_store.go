@@ -45,6 +45,16 @@ func (s *MongoUserStore) FindUserByGoogleID(ctx context.Context, googleID string
 	return &user, nil
 }
 
+// FindUserByID 根據 ID 查找使用者
+func (s *MongoUserStore) FindUserByID(ctx context.Context, userID primitive.ObjectID) (*models.User, error) {
+	var user models.User
+	err := s.collection.FindOne(ctx, bson.M{"_id": userID}).Decode(&user)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
 // CheckUserExists 檢查 Email 或 Username 是否已存在
 func (s *MongoUserStore) CheckUserExists(ctx context.Context, email, username string) (bool, bool, error) {
 	// 檢查 Email
@@ -76,3 +86,33 @@ func (s *MongoUserStore) CreateUser(ctx context.Context, user models.User) (prim
 
 	return oid, nil
 }
+
+// UpdateUserPassword 更新使用者的密碼雜湊
+func (s *MongoUserStore) UpdateUserPassword(ctx context.Context, userID primitive.ObjectID, hashedPassword string) error {
+	result, err := s.collection.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$set": bson.M{"password": hashedPassword}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// MarkUserVerified 將使用者的 Email 標記為已驗證
+func (s *MongoUserStore) MarkUserVerified(ctx context.Context, userID primitive.ObjectID) error {
+	result, err := s.collection.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$set": bson.M{"verified": true}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// ClearGoogleID 移除使用者的 googleId 欄位
+func (s *MongoUserStore) ClearGoogleID(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := s.collection.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$unset": bson.M{"googleId": ""}})
+	return err
+}
@@ -0,0 +1,150 @@
+// backend/store/mongo_webhook_store.go
+package store
+
+import (
+	"context"
+	"errors"
+	"go-chat/backend/database"
+	"go-chat/backend/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoWebhookStore 是 WebhookStorer 介面的 MongoDB 實作
+type MongoWebhookStore struct {
+	webhooks   *mongo.Collection
+	deliveries *mongo.Collection
+}
+
+// NewMongoWebhookStore 是一個工廠函式，用於建立新的 MongoWebhookStore
+func NewMongoWebhookStore() *MongoWebhookStore {
+	return &MongoWebhookStore{
+		webhooks:   database.GetCollection("webhooks"),
+		deliveries: database.GetCollection("webhook_deliveries"),
+	}
+}
+
+// CreateWebhook 新增一個外送 Webhook
+func (s *MongoWebhookStore) CreateWebhook(ctx context.Context, webhook models.Webhook) (primitive.ObjectID, error) {
+	result, err := s.webhooks.InsertOne(ctx, webhook)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	oid, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return primitive.NilObjectID, errors.New("failed to convert InsertedID to ObjectID")
+	}
+	return oid, nil
+}
+
+// FindWebhookByID 根據 ID 查找 Webhook
+func (s *MongoWebhookStore) FindWebhookByID(ctx context.Context, webhookID primitive.ObjectID) (*models.Webhook, error) {
+	var webhook models.Webhook
+	err := s.webhooks.FindOne(ctx, bson.M{"_id": webhookID}).Decode(&webhook)
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// ListWebhooksByRoom 列出聊天室的所有 Webhook
+func (s *MongoWebhookStore) ListWebhooksByRoom(ctx context.Context, roomID primitive.ObjectID) ([]models.Webhook, error) {
+	return s.findWebhooks(ctx, bson.M{"roomId": roomID})
+}
+
+// ListWebhooksForEvent 列出聊天室中訂閱了指定事件的 Webhook
+func (s *MongoWebhookStore) ListWebhooksForEvent(ctx context.Context, roomID primitive.ObjectID, event models.WebhookEvent) ([]models.Webhook, error) {
+	return s.findWebhooks(ctx, bson.M{"roomId": roomID, "events": event})
+}
+
+func (s *MongoWebhookStore) findWebhooks(ctx context.Context, filter bson.M) ([]models.Webhook, error) {
+	cursor, err := s.webhooks.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	webhooks := []models.Webhook{}
+	if err := cursor.All(ctx, &webhooks); err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// DeleteWebhook 刪除 Webhook 及其投遞紀錄
+func (s *MongoWebhookStore) DeleteWebhook(ctx context.Context, webhookID primitive.ObjectID) error {
+	if _, err := s.webhooks.DeleteOne(ctx, bson.M{"_id": webhookID}); err != nil {
+		return err
+	}
+	_, err := s.deliveries.DeleteMany(ctx, bson.M{"webhookId": webhookID})
+	return err
+}
+
+// DeleteWebhooksByRoom 刪除聊天室的所有 Webhook 與已結束的投遞紀錄
+// 投遞紀錄保存了網址與 secret，尚在 pending 的投遞 (例如 room.deleted) 仍會送出
+func (s *MongoWebhookStore) DeleteWebhooksByRoom(ctx context.Context, roomID primitive.ObjectID) error {
+	if _, err := s.webhooks.DeleteMany(ctx, bson.M{"roomId": roomID}); err != nil {
+		return err
+	}
+	filter := bson.M{"roomId": roomID, "status": bson.M{"$ne": models.WebhookDeliveryPending}}
+	_, err := s.deliveries.DeleteMany(ctx, filter)
+	return err
+}
+
+// SaveDelivery 新增或更新一筆投遞紀錄，新紀錄會回填 ID
+func (s *MongoWebhookStore) SaveDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	if delivery.ID.IsZero() {
+		delivery.ID = primitive.NewObjectID()
+	}
+	_, err := s.deliveries.ReplaceOne(ctx, bson.M{"_id": delivery.ID}, delivery, options.Replace().SetUpsert(true))
+	return err
+}
+
+// ListDeliveries 列出 Webhook 最近的投遞紀錄，status 為空時不篩選狀態
+func (s *MongoWebhookStore) ListDeliveries(ctx context.Context, webhookID primitive.ObjectID, status models.WebhookDeliveryStatus, limit int64) ([]models.WebhookDelivery, error) {
+	filter := bson.M{"webhookId": webhookID}
+	if status != "" {
+		filter["status"] = status
+	}
+	findOptions := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}).SetLimit(limit)
+
+	cursor, err := s.deliveries.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	deliveries := []models.WebhookDelivery{}
+	if err := cursor.All(ctx, &deliveries); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// ClaimDueDelivery 以 FindOneAndUpdate 原子地取出最早到期的 pending 投遞並延後 nextAttemptAt
+// 處理中的程序若在 lease 內沒有寫回結果 (例如重新部署)，投遞會在 lease 到期後被重新取出
+func (s *MongoWebhookStore) ClaimDueDelivery(ctx context.Context, now time.Time, lease time.Duration) (*models.WebhookDelivery, error) {
+	filter := bson.M{
+		"status":        models.WebhookDeliveryPending,
+		"nextAttemptAt": bson.M{"$lte": now},
+	}
+	update := bson.M{"$set": bson.M{"nextAttemptAt": now.Add(lease)}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "nextAttemptAt", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var delivery models.WebhookDelivery
+	err := s.deliveries.FindOneAndUpdate(ctx, filter, update, opts).Decode(&delivery)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
@@ -0,0 +1,19 @@
+// backend/store/password_reset_store.go
+package store
+
+import (
+	"context"
+	"go-chat/backend/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PasswordResetStorer 定義密碼重設 token 的資料操作
+type PasswordResetStorer interface {
+	CreatePasswordReset(ctx context.Context, reset models.PasswordReset) error
+	FindPasswordResetByHash(ctx context.Context, tokenHash string) (*models.PasswordReset, error)
+	// MarkPasswordResetUsed 以原子操作標記 token 已使用，token 已被使用時回傳 false
+	MarkPasswordResetUsed(ctx context.Context, resetID primitive.ObjectID) (bool, error)
+	// InvalidateUserPasswordResets 讓使用者其餘尚未使用的 token 一併失效
+	InvalidateUserPasswordResets(ctx context.Context, userID primitive.ObjectID) error
+}
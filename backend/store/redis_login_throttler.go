@@ -0,0 +1,63 @@
+// backend/store/redis_login_throttler.go
+package store
+
+import (
+	"context"
+	"go-chat/backend/database"
+	"time"
+)
+
+// LoginThrottleLimits 是登入限流與鎖定的參數
+type LoginThrottleLimits struct {
+	IPLimit      int           // 同一個 IP 在 Window 內最多嘗試的次數
+	AccountLimit int           // 同一個帳號在 Window 內最多嘗試的次數
+	Window       time.Duration // 滑動視窗長度，也是連續失敗次數的保留時間
+	MaxFailures  int           // 連續失敗幾次後鎖定帳號
+	LockoutBase  time.Duration // 第一次鎖定的時間，之後每次加倍
+	LockoutMax   time.Duration // 鎖定時間的上限
+}
+
+// RedisLoginThrottler 是 LoginThrottler 介面的 Redis 實作
+type RedisLoginThrottler struct {
+	limits LoginThrottleLimits
+}
+
+// NewRedisLoginThrottler 是一個工廠函式，用於建立新的 RedisLoginThrottler
+func NewRedisLoginThrottler(limits LoginThrottleLimits) *RedisLoginThrottler {
+	return &RedisLoginThrottler{limits: limits}
+}
+
+// CheckLogin 先檢查鎖定狀態，鎖定中的嘗試不會佔用限流的額度
+func (t *RedisLoginThrottler) CheckLogin(ctx context.Context, ip, account string) (bool, time.Duration, error) {
+	lockout, err := database.GetLoginLockout(account)
+	if err != nil {
+		return false, 0, err
+	}
+	if lockout > 0 {
+		return false, lockout, nil
+	}
+
+	allowed, retryAfter, err := database.AllowSlidingWindow("rate-limit:login-ip:"+ip, t.limits.IPLimit, t.limits.Window)
+	if err != nil || !allowed {
+		return false, retryAfter, err
+	}
+	return database.AllowSlidingWindow("rate-limit:login-account:"+account, t.limits.AccountLimit, t.limits.Window)
+}
+
+// RecordFailure 記錄一次登入失敗
+func (t *RedisLoginThrottler) RecordFailure(ctx context.Context, account string) (time.Duration, error) {
+	return database.RecordLoginFailure(account, t.limits.MaxFailures, t.limits.Window, t.limits.LockoutBase, t.limits.LockoutMax)
+}
+
+// RecordSuccess 登入成功時清除連續失敗的紀錄
+func (t *RedisLoginThrottler) RecordSuccess(ctx context.Context, account string) error {
+	return database.ClearLoginFailures(account)
+}
+
+// Unlock 解除帳號的鎖定，同時清除帳號的限流紀錄
+func (t *RedisLoginThrottler) Unlock(ctx context.Context, account string) error {
+	if err := database.UnlockLoginAccount(account); err != nil {
+		return err
+	}
+	return database.ResetRateLimit("rate-limit:login-account:" + account)
+}
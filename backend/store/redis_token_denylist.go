@@ -0,0 +1,40 @@
+// backend/store/redis_token_denylist.go
+package store
+
+import (
+	"context"
+	"go-chat/backend/database"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RedisTokenDenylist 是 TokenDenylist 介面的 Redis 實作
+type RedisTokenDenylist struct {
+	accessTokenTTL time.Duration
+}
+
+// NewRedisTokenDenylist 是一個工廠函式，accessTokenTTL 是 access token 的最長效期
+func NewRedisTokenDenylist(accessTokenTTL time.Duration) *RedisTokenDenylist {
+	return &RedisTokenDenylist{accessTokenTTL: accessTokenTTL}
+}
+
+// RevokeAccessToken 撤銷單一 access token
+func (d *RedisTokenDenylist) RevokeAccessToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	return database.RevokeAccessToken(tokenID, expiresAt)
+}
+
+// RevokeSession 撤銷某次登入已簽發的所有 access token
+func (d *RedisTokenDenylist) RevokeSession(ctx context.Context, sessionID primitive.ObjectID) error {
+	return database.RevokeSessionAccessTokens(sessionID, d.accessTokenTTL)
+}
+
+// RevokeUser 撤銷使用者目前所有的 access token
+func (d *RedisTokenDenylist) RevokeUser(ctx context.Context, userID primitive.ObjectID) error {
+	return database.RevokeUserAccessTokens(userID, d.accessTokenTTL)
+}
+
+// ConsumeToken 標記只能使用一次的 token 已使用
+func (d *RedisTokenDenylist) ConsumeToken(ctx context.Context, tokenID string, expiresAt time.Time) (bool, error) {
+	return database.ConsumeOneTimeToken(tokenID, expiresAt)
+}
@@ -0,0 +1,19 @@
+// backend/store/refresh_token_store.go
+package store
+
+import (
+	"context"
+	"go-chat/backend/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RefreshTokenStorer 定義 refresh token 的資料操作
+type RefreshTokenStorer interface {
+	CreateRefreshToken(ctx context.Context, token models.RefreshToken) error
+	FindRefreshTokenByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error)
+	// MarkRefreshTokenUsed 以原子操作標記 token 已使用，token 已被使用或撤銷時回傳 false
+	MarkRefreshTokenUsed(ctx context.Context, tokenID primitive.ObjectID) (bool, error)
+	RevokeRefreshTokenFamily(ctx context.Context, familyID primitive.ObjectID) error
+	RevokeUserRefreshTokens(ctx context.Context, userID primitive.ObjectID) error
+}
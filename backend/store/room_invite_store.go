@@ -0,0 +1,24 @@
+// backend/store/room_invite_store.go
+package store
+
+import (
+	"context"
+	"go-chat/backend/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RoomInviteStorer 定義聊天室邀請連結的資料操作
+type RoomInviteStorer interface {
+	CreateRoomInvite(ctx context.Context, invite models.RoomInvite) (primitive.ObjectID, error)
+	FindRoomInviteByID(ctx context.Context, inviteID primitive.ObjectID) (*models.RoomInvite, error)
+	FindRoomInviteByCodeHash(ctx context.Context, codeHash string) (*models.RoomInvite, error)
+	// ListActiveRoomInvites 列出聊天室在 now 時仍可使用的邀請
+	ListActiveRoomInvites(ctx context.Context, roomID primitive.ObjectID, now time.Time) ([]models.RoomInvite, error)
+	// ConsumeRoomInvite 在邀請仍可使用時將使用次數加一，已過期、已用完或已撤銷時回傳 mongo.ErrNoDocuments
+	ConsumeRoomInvite(ctx context.Context, inviteID primitive.ObjectID, now time.Time) (*models.RoomInvite, error)
+	// ReleaseRoomInvite 歸還一次使用次數，用於扣除次數後加入聊天室失敗的情況
+	ReleaseRoomInvite(ctx context.Context, inviteID primitive.ObjectID) error
+	DeleteRoomInvite(ctx context.Context, inviteID primitive.ObjectID) error
+}
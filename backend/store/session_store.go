@@ -0,0 +1,21 @@
+// backend/store/session_store.go
+package store
+
+import (
+	"context"
+	"go-chat/backend/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SessionStorer 定義登入 session 紀錄的資料操作
+type SessionStorer interface {
+	CreateSession(ctx context.Context, session models.Session) error
+	FindSessionByID(ctx context.Context, sessionID primitive.ObjectID) (*models.Session, error)
+	// ListActiveSessions 列出使用者尚未撤銷且在 since 之後仍有活動的 session，最近活動的排在前面
+	ListActiveSessions(ctx context.Context, userID primitive.ObjectID, since time.Time) ([]models.Session, error)
+	TouchSession(ctx context.Context, sessionID primitive.ObjectID) error
+	RevokeSession(ctx context.Context, sessionID primitive.ObjectID) error
+	RevokeUserSessions(ctx context.Context, userID primitive.ObjectID) error
+}
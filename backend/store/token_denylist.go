@@ -0,0 +1,18 @@
+// backend/store/token_denylist.go
+package store
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TokenDenylist 定義撤銷 access token 的操作，撤銷紀錄只需保存到 token 過期為止
+type TokenDenylist interface {
+	RevokeAccessToken(ctx context.Context, tokenID string, expiresAt time.Time) error
+	RevokeSession(ctx context.Context, sessionID primitive.ObjectID) error
+	RevokeUser(ctx context.Context, userID primitive.ObjectID) error
+	// ConsumeToken 標記只能使用一次的 token 已使用，已被使用過或已過期時回傳 false
+	ConsumeToken(ctx context.Context, tokenID string, expiresAt time.Time) (bool, error)
+}
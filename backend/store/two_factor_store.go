@@ -0,0 +1,24 @@
+// backend/store/two_factor_store.go
+package store
+
+import (
+	"context"
+	"go-chat/backend/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TwoFactorStorer 定義 TOTP 兩步驟驗證設定的資料操作
+type TwoFactorStorer interface {
+	FindTwoFactor(ctx context.Context, userID primitive.ObjectID) (*models.TwoFactor, error)
+	// SavePendingTwoFactor 保存尚未確認的密鑰，已啟用時不會覆蓋並回傳 false
+	SavePendingTwoFactor(ctx context.Context, userID primitive.ObjectID, encryptedSecret string) (bool, error)
+	// EnableTwoFactor 啟用兩步驟驗證並設定復原碼，step 是確認時使用的時間步
+	EnableTwoFactor(ctx context.Context, userID primitive.ObjectID, recoveryCodeHashes []string, step int64) error
+	// UseTOTPStep 以原子操作記錄使用過的時間步，同一個或更早的時間步再次使用時回傳 false
+	UseTOTPStep(ctx context.Context, userID primitive.ObjectID, step int64) (bool, error)
+	// UseRecoveryCode 以原子操作移除一個復原碼，復原碼不存在或已使用時回傳 false
+	UseRecoveryCode(ctx context.Context, userID primitive.ObjectID, codeHash string) (bool, error)
+	ReplaceRecoveryCodes(ctx context.Context, userID primitive.ObjectID, recoveryCodeHashes []string) error
+	DeleteTwoFactor(ctx context.Context, userID primitive.ObjectID) error
+}
@@ -13,6 +13,11 @@ import (
 type UserStorer interface {
 	FindUserByEmail(ctx context.Context, email string) (*models.User, error)
 	FindUserByGoogleID(ctx context.Context, googleID string) (*models.User, error)
+	FindUserByID(ctx context.Context, userID primitive.ObjectID) (*models.User, error)
 	CheckUserExists(ctx context.Context, email, username string) (bool, bool, error)
 	CreateUser(ctx context.Context, user models.User) (primitive.ObjectID, error)
+	UpdateUserPassword(ctx context.Context, userID primitive.ObjectID, hashedPassword string) error
+	MarkUserVerified(ctx context.Context, userID primitive.ObjectID) error
+	// ClearGoogleID 移除改版前存在使用者上的 Google sub，解除連結後就不會再被自動連回
+	ClearGoogleID(ctx context.Context, userID primitive.ObjectID) error
 }
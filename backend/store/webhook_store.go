@@ -0,0 +1,25 @@
+// backend/store/webhook_store.go
+package store
+
+import (
+	"context"
+	"go-chat/backend/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// WebhookStorer 定義外送 Webhook 與投遞紀錄的資料操作
+type WebhookStorer interface {
+	CreateWebhook(ctx context.Context, webhook models.Webhook) (primitive.ObjectID, error)
+	FindWebhookByID(ctx context.Context, webhookID primitive.ObjectID) (*models.Webhook, error)
+	ListWebhooksByRoom(ctx context.Context, roomID primitive.ObjectID) ([]models.Webhook, error)
+	ListWebhooksForEvent(ctx context.Context, roomID primitive.ObjectID, event models.WebhookEvent) ([]models.Webhook, error)
+	DeleteWebhook(ctx context.Context, webhookID primitive.ObjectID) error
+	// DeleteWebhooksByRoom 刪除聊天室的所有 Webhook 與已結束的投遞紀錄，pending 的投遞保留到送出為止
+	DeleteWebhooksByRoom(ctx context.Context, roomID primitive.ObjectID) error
+	SaveDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	ListDeliveries(ctx context.Context, webhookID primitive.ObjectID, status models.WebhookDeliveryStatus, limit int64) ([]models.WebhookDelivery, error)
+	// ClaimDueDelivery 取出一筆到期的 pending 投遞，並把 nextAttemptAt 延後 lease 避免其他 worker 重複投遞；沒有到期的投遞時回傳 nil
+	ClaimDueDelivery(ctx context.Context, now time.Time, lease time.Duration) (*models.WebhookDelivery, error)
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"go-chat/backend/database"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TestRevokeUserAccessTokens_Integration 測試使用者層級撤銷以毫秒區分撤銷前後簽發的 token
+func TestRevokeUserAccessTokens_Integration(t *testing.T) {
+	userID := primitive.NewObjectID()
+	issuedBefore := time.Now()
+	time.Sleep(2 * time.Millisecond)
+
+	require.NoError(t, database.RevokeUserAccessTokens(userID, time.Minute))
+	time.Sleep(2 * time.Millisecond)
+	issuedAfter := time.Now()
+
+	revoked, err := database.IsAccessTokenRevoked("", primitive.NilObjectID, userID, issuedBefore)
+	require.NoError(t, err)
+	assert.True(t, revoked, "撤銷前簽發的 token 應該失效")
+
+	// 例如修改密碼後馬上重新登入，同一秒內簽發的新 token 仍然有效
+	revoked, err = database.IsAccessTokenRevoked("", primitive.NilObjectID, userID, issuedAfter)
+	require.NoError(t, err)
+	assert.False(t, revoked, "撤銷後簽發的 token 不應該受影響")
+}
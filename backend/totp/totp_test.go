@@ -0,0 +1,65 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfcSecret 是 RFC 6238 附錄 B 的 SHA1 測試密鑰 "12345678901234567890"
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCodeAt(t *testing.T) {
+	// RFC 6238 的測試向量為 8 位數，這裡取後 6 位
+	cases := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1111111111: "050471",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, want := range cases {
+		code, err := CodeAt(rfcSecret, Step(time.Unix(unix, 0)))
+		require.NoError(t, err)
+		assert.Equal(t, want, code, "T=%d", unix)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+
+	t.Run("接受目前與相鄰時間步的驗證碼", func(t *testing.T) {
+		step, ok := Validate(rfcSecret, "081804", now, 1)
+		assert.True(t, ok)
+		assert.Equal(t, Step(now), step)
+
+		previous, _ := CodeAt(rfcSecret, Step(now)-1)
+		step, ok = Validate(rfcSecret, previous, now, 1)
+		assert.True(t, ok)
+		assert.Equal(t, Step(now)-1, step)
+	})
+
+	t.Run("拒絕超出容許範圍或格式錯誤的驗證碼", func(t *testing.T) {
+		old, _ := CodeAt(rfcSecret, Step(now)-2)
+		_, ok := Validate(rfcSecret, old, now, 1)
+		assert.False(t, ok)
+
+		_, ok = Validate(rfcSecret, "12345", now, 1)
+		assert.False(t, ok)
+	})
+}
+
+func TestGenerateSecretAndURI(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+	assert.Len(t, secret, 32, "20 bytes 的 base32 編碼長度應為 32")
+
+	uri := URI("GoChat", "user@example.com", secret)
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/GoChat:user@example.com?"))
+	assert.Contains(t, uri, "secret="+secret)
+	assert.Contains(t, uri, "issuer=GoChat")
+}
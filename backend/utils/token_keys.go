@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"errors"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// SigningKey 是簽發 JWT 使用的金鑰，ID 會寫入 token 標頭的 kid
+type SigningKey struct {
+	ID     string
+	Method jwt.SigningMethod
+	Key    interface{}
+}
+
+// TokenKeys 提供簽發與驗證 JWT 的金鑰
+type TokenKeys interface {
+	// SigningKey 回傳目前用來簽發新 token 的金鑰
+	SigningKey() (*SigningKey, error)
+	// VerificationKey 依 token 標頭的 alg 與 kid 回傳驗證簽章用的金鑰，可直接當作 jwt.Keyfunc
+	VerificationKey(token *jwt.Token) (interface{}, error)
+}
+
+// hmacKeys 以單一共用密鑰簽發與驗證 HS256 token
+type hmacKeys struct {
+	secret []byte
+}
+
+// NewHMACKeys 建立使用 HS256 與共用密鑰的 TokenKeys
+func NewHMACKeys(secret string) TokenKeys {
+	return &hmacKeys{secret: []byte(secret)}
+}
+
+func (k *hmacKeys) SigningKey() (*SigningKey, error) {
+	return &SigningKey{Method: jwt.SigningMethodHS256, Key: k.secret}, nil
+}
+
+func (k *hmacKeys) VerificationKey(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		return nil, errors.New("unexpected signing method")
+	}
+	return k.secret, nil
+}
+
+// signToken 以目前的簽章金鑰簽發 token，並在標頭帶上 kid
+func signToken(keys TokenKeys, claims jwt.MapClaims) (string, error) {
+	signingKey, err := keys.SigningKey()
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(signingKey.Method, claims)
+	if signingKey.ID != "" {
+		token.Header["kid"] = signingKey.ID
+	}
+	tokenString, err := token.SignedString(signingKey.Key)
+	if err != nil {
+		return "", errors.New("failed to sign token")
+	}
+	return tokenString, nil
+}
@@ -2,8 +2,16 @@ package utils
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -15,6 +23,9 @@ type contextKey string
 
 const UserIDKey contextKey = "userID"
 
+// SessionIDKey 是儲存在 context 中的登入 session ID 的鍵，只有帶 sid 的 access token 才會設定
+const SessionIDKey contextKey = "sessionID"
+
 // GetUserIDFromContext 從 context 中提取使用者 ID
 func GetUserIDFromContext(ctx context.Context) (primitive.ObjectID, error) {
 	userID, ok := ctx.Value(UserIDKey).(primitive.ObjectID)
@@ -24,35 +35,166 @@ func GetUserIDFromContext(ctx context.Context) (primitive.ObjectID, error) {
 	return userID, nil
 }
 
-// GetUserIDFromToken 從 JWT token 中提取使用者 ID
-func GetUserIDFromToken(tokenString string, jwtSecret string) (primitive.ObjectID, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
+// GetSessionIDFromContext 從 context 中提取目前請求所屬的登入 session ID，沒有時回傳 NilObjectID
+func GetSessionIDFromContext(ctx context.Context) primitive.ObjectID {
+	sessionID, _ := ctx.Value(SessionIDKey).(primitive.ObjectID)
+	return sessionID
+}
+
+// trustedProxies 是可信任的反向代理，由 main 依設定呼叫 SetTrustedProxies 設定
+var trustedProxies []netip.Prefix
+
+// SetTrustedProxies 設定可信任的反向代理，只有來自這些位址的請求才會讀取 X-Forwarded-For
+func SetTrustedProxies(prefixes []netip.Prefix) {
+	trustedProxies = prefixes
+}
+
+// isTrustedProxy 檢查位址是否屬於可信任的反向代理
+func isTrustedProxy(addr netip.Addr) bool {
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr.Unmap()) {
+			return true
 		}
-		return []byte(jwtSecret), nil
-	})
+	}
+	return false
+}
+
+// GetClientIP 取得發出請求的用戶端 IP，用於限流與登入裝置紀錄
+// 預設使用連線的來源位址；只有連線來自可信任的反向代理時才讀取 X-Forwarded-For，
+// 並由右往左取第一個不是可信任代理的位址，因為最左邊的值可以由用戶端任意偽造
+func GetClientIP(r *http.Request) string {
+	remote := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		remote = host
+	}
+	remoteAddr, err := netip.ParseAddr(remote)
+	if err != nil || !isTrustedProxy(remoteAddr) {
+		return remote
+	}
 
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			// 無法解析的值可能是偽造的，停在最後一個可信任的位址
+			break
+		}
+		if !isTrustedProxy(hop) {
+			return hop.Unmap().String()
+		}
+		remote = hop.Unmap().String()
+	}
+	return remote
+}
+
+// GetBearerToken 從 Authorization 標頭取出 Bearer token，沒有時回傳空字串
+func GetBearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// AccessClaims 是 access token 中與驗證相關的欄位
+type AccessClaims struct {
+	UserID    primitive.ObjectID
+	SessionID primitive.ObjectID // 對應 refresh token family，改版前簽發的 token 沒有這個欄位
+	TokenID   string             // jti，用於單獨撤銷這個 token
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// ParseAccessToken 驗證 access token 的簽章與效期並取出 claims
+func ParseAccessToken(tokenString string, keys TokenKeys) (*AccessClaims, error) {
+	claims, err := parseSignedClaims(tokenString, keys)
 	if err != nil {
-		return primitive.NilObjectID, err
+		return nil, err
+	}
+
+	// 只接受 access token；沒有 typ 的是改版前簽發的 24 小時 token，在過期前仍然有效
+	if typ, exists := claims["typ"]; exists && typ != AccessTokenType {
+		return nil, errors.New("unexpected token type")
+	}
+	return toAccessClaims(claims)
+}
+
+// ParseChallengeToken 驗證登入第二步使用的 challenge token 並取出 claims
+func ParseChallengeToken(tokenString string, keys TokenKeys) (*AccessClaims, error) {
+	claims, err := parseSignedClaims(tokenString, keys)
+	if err != nil {
+		return nil, err
+	}
+	if claims["typ"] != ChallengeTokenType {
+		return nil, errors.New("unexpected token type")
+	}
+	return toAccessClaims(claims)
+}
+
+// parseSignedClaims 驗證簽章與效期並回傳原始 claims，簽章演算法由 keys 依 alg 與 kid 決定
+func parseSignedClaims(tokenString string, keys TokenKeys) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenString, keys.VerificationKey)
+	if err != nil {
+		return nil, err
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok || !token.Valid {
-		return primitive.NilObjectID, errors.New("invalid token claims")
+		return nil, errors.New("invalid token claims")
 	}
+	return claims, nil
+}
 
+// toAccessClaims 從原始 claims 取出使用者、session、jti 與時間欄位
+func toAccessClaims(claims jwt.MapClaims) (*AccessClaims, error) {
 	userIDStr, ok := claims["userId"].(string)
 	if !ok {
-		return primitive.NilObjectID, errors.New("user ID not found in token claims")
+		return nil, errors.New("user ID not found in token claims")
 	}
 
 	userID, err := primitive.ObjectIDFromHex(userIDStr)
 	if err != nil {
-		return primitive.NilObjectID, errors.New("invalid user ID format in token")
+		return nil, errors.New("invalid user ID format in token")
 	}
 
-	return userID, nil
+	accessClaims := &AccessClaims{UserID: userID}
+	if sid, ok := claims["sid"].(string); ok {
+		accessClaims.SessionID, _ = primitive.ObjectIDFromHex(sid)
+	}
+	accessClaims.TokenID, _ = claims["jti"].(string)
+	// jwt 函式庫解析時間時會截到秒，iat 直接從原始數值取出以保留毫秒
+	if iat, ok := claims["iat"].(float64); ok {
+		accessClaims.IssuedAt = time.UnixMilli(int64(math.Round(iat * 1000)))
+	}
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		accessClaims.ExpiresAt = exp.Time
+	}
+	return accessClaims, nil
+}
+
+// GetUserIDFromToken 從 JWT token 中提取使用者 ID
+func GetUserIDFromToken(tokenString string, keys TokenKeys) (primitive.ObjectID, error) {
+	claims, err := ParseAccessToken(tokenString, keys)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	return claims.UserID, nil
+}
+
+// HashToken 計算不透明 token 的 SHA-256 雜湊，資料庫只保存雜湊
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// GenerateOpaqueToken 產生帶有前綴的隨機 token
+func GenerateOpaqueToken(prefix string) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return prefix + hex.EncodeToString(buf), nil
 }
 
 // SortObjectIDs 對 primitive.ObjectID 切片進行排序 (按 Hex 字串)
@@ -62,21 +204,50 @@ func SortObjectIDs(ids []primitive.ObjectID) {
 	})
 }
 
-// GenerateJWT 為用戶生成 JWT Token
-func GenerateJWT(userID primitive.ObjectID, username string, secret string) (string, error) {
+// AccessTokenType 是 access token 的 typ claim，用來和其他用途的 JWT 區分
+const AccessTokenType = "access"
+
+// ChallengeTokenType 是密碼驗證通過後、等待第二步驗證時使用的 challenge token 的 typ claim
+const ChallengeTokenType = "2fa_challenge"
+
+// GenerateJWT 為用戶生成短效的 access token，過期後需以 refresh token 換發
+// sessionID 是這次登入的 refresh token family，登出或遠端登出時用來撤銷同一個 session 的 token
+func GenerateJWT(userID primitive.ObjectID, username string, sessionID primitive.ObjectID, keys TokenKeys, ttl time.Duration) (string, error) {
+	tokenID := make([]byte, 16)
+	if _, err := rand.Read(tokenID); err != nil {
+		return "", err
+	}
+
 	claims := jwt.MapClaims{
 		"userId":   userID.Hex(), // 將 ObjectID 轉換為 Hex 字串儲存
 		"username": username,
-		"exp":      time.Now().Add(time.Hour * 24).Unix(), // Token 24 小時後過期
-		"iat":      time.Now().Unix(), // 獲取當下的時間
+		"typ":      AccessTokenType,
+		"jti":      hex.EncodeToString(tokenID),
+		"exp":      time.Now().Add(ttl).Unix(), // Token 在 ttl 之後過期
+		// iat 精確到毫秒，撤銷後同一秒內重新登入取得的 token 才不會被當成撤銷前簽發的
+		"iat": float64(time.Now().UnixMilli()) / 1000,
+	}
+	if !sessionID.IsZero() {
+		claims["sid"] = sessionID.Hex()
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	// 使用目前的簽章金鑰簽名 (HS256 的 JWT_SECRET，或 RS256/EdDSA 輪替中的金鑰)
+	return signToken(keys, claims)
+}
 
-	// 使用您配置的 JWT_SECRET 簽名
-	tokenString, err := token.SignedString([]byte(secret))
-	if err != nil {
-		return "", errors.New("failed to sign token")
+// GenerateChallengeToken 簽發短效的 challenge token，只能用來完成登入的第二步驗證，不能當作 access token
+func GenerateChallengeToken(userID primitive.ObjectID, keys TokenKeys, ttl time.Duration) (string, error) {
+	tokenID := make([]byte, 16)
+	if _, err := rand.Read(tokenID); err != nil {
+		return "", err
+	}
+
+	claims := jwt.MapClaims{
+		"userId": userID.Hex(),
+		"typ":    ChallengeTokenType,
+		"jti":    hex.EncodeToString(tokenID),
+		"exp":    time.Now().Add(ttl).Unix(),
+		"iat":    time.Now().Unix(),
 	}
-	return tokenString, nil
+	return signToken(keys, claims)
 }
@@ -1,6 +1,9 @@
 package utils
 
 import (
+	"net/http/httptest"
+	"net/netip"
+	"strings"
 	"testing"
 	"time"
 
@@ -16,7 +19,7 @@ func TestGenerateJWT(t *testing.T) {
 	secret := "test-secret"
 
 	// 執行要測試的函式
-	tokenString, err := GenerateJWT(userID, username, secret)
+	tokenString, err := GenerateJWT(userID, username, primitive.NilObjectID, NewHMACKeys(secret), time.Hour)
 
 	// --- 使用 testify/assert 進行斷言 ---
 
@@ -61,11 +64,11 @@ func TestGetUserIDFromToken(t *testing.T) {
 	// --- 測試情境 1: 成功的案例 ---
 	t.Run("成功案例 - 有效的 Token", func(t *testing.T) {
 		// 產生一個有效的 token
-		validToken, err := GenerateJWT(userID, username, secret)
+		validToken, err := GenerateJWT(userID, username, primitive.NilObjectID, NewHMACKeys(secret), time.Hour)
 		assert.NoError(t, err)
 
 		// 執行要測試的函式
-		parsedUserID, err := GetUserIDFromToken(validToken, secret)
+		parsedUserID, err := GetUserIDFromToken(validToken, NewHMACKeys(secret))
 
 		// 斷言結果
 		assert.NoError(t, err, "解析有效的 token 不應該返回錯誤")
@@ -75,11 +78,11 @@ func TestGetUserIDFromToken(t *testing.T) {
 	// --- 測試情境 2: 失敗的案例 (無效簽名) ---
 	t.Run("失敗案例 - 無效的簽名", func(t *testing.T) {
 		// 產生一個有效的 token
-		validToken, err := GenerateJWT(userID, username, secret)
+		validToken, err := GenerateJWT(userID, username, primitive.NilObjectID, NewHMACKeys(secret), time.Hour)
 		assert.NoError(t, err)
 
 		// 嘗試用錯誤的 secret 去解析
-		_, err = GetUserIDFromToken(validToken, "wrong-secret")
+		_, err = GetUserIDFromToken(validToken, NewHMACKeys("wrong-secret"))
 
 		// 斷言結果
 		assert.Error(t, err, "使用錯誤的 secret 解析應該要返回錯誤")
@@ -90,9 +93,153 @@ func TestGetUserIDFromToken(t *testing.T) {
 	// --- 測試情境 3: 失敗的案例 (格式錯誤) ---
 	t.Run("失敗案例 - 格式錯誤的 Token", func(t *testing.T) {
 		// 傳入一個亂寫的字串
-		_, err := GetUserIDFromToken("this-is-not-a-jwt-token", secret)
+		_, err := GetUserIDFromToken("this-is-not-a-jwt-token", NewHMACKeys(secret))
 
 		// 斷言結果
 		assert.Error(t, err, "解析格式錯誤的 token 應該要返回錯誤")
 	})
+
+	// --- 測試情境 4: 失敗的案例 (不是 access token) ---
+	t.Run("失敗案例 - typ 不是 access", func(t *testing.T) {
+		claims := jwt.MapClaims{"userId": userID.Hex(), "typ": "other", "exp": time.Now().Add(time.Hour).Unix()}
+		otherToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+		assert.NoError(t, err)
+
+		_, err = GetUserIDFromToken(otherToken, NewHMACKeys(secret))
+
+		assert.Error(t, err, "其他用途的 token 不應該被當成 access token")
+	})
+
+	// --- 測試情境 5: 失敗的案例 (已過期) ---
+	t.Run("失敗案例 - 已過期的 Token", func(t *testing.T) {
+		expiredToken, err := GenerateJWT(userID, username, primitive.NilObjectID, NewHMACKeys(secret), -time.Minute)
+		assert.NoError(t, err)
+
+		_, err = GetUserIDFromToken(expiredToken, NewHMACKeys(secret))
+
+		assert.Error(t, err, "過期的 access token 應該要返回錯誤")
+	})
+}
+
+func TestParseAccessToken_IssuedAtMillis(t *testing.T) {
+	keys := NewHMACKeys("iat-secret")
+	before := time.Now().Truncate(time.Millisecond)
+
+	token, err := GenerateJWT(primitive.NewObjectID(), "user", primitive.NilObjectID, keys, time.Hour)
+	assert.NoError(t, err)
+	claims, err := ParseAccessToken(token, keys)
+	assert.NoError(t, err)
+
+	// 撤銷以毫秒比較，iat 不能被截到秒
+	assert.False(t, claims.IssuedAt.Before(before), "iat 應該保留毫秒")
+	assert.False(t, claims.IssuedAt.After(time.Now()))
+}
+
+func TestGetBearerToken(t *testing.T) {
+	t.Run("成功取出 Bearer token", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Authorization", "Bearer bot_abc123")
+		assert.Equal(t, "bot_abc123", GetBearerToken(req))
+	})
+
+	t.Run("scheme 不分大小寫", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Authorization", "bearer bot_abc123")
+		assert.Equal(t, "bot_abc123", GetBearerToken(req))
+	})
+
+	t.Run("沒有標頭或不是 Bearer 時回傳空字串", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+		assert.Empty(t, GetBearerToken(req))
+
+		req.Header.Set("Authorization", "Basic dXNlcjpwYXNz")
+		assert.Empty(t, GetBearerToken(req))
+	})
+}
+
+func TestGetClientIP(t *testing.T) {
+	t.Run("直接連線時使用 RemoteAddr", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "203.0.113.7:51234"
+		assert.Equal(t, "203.0.113.7", GetClientIP(req))
+	})
+
+	t.Run("不是來自可信任代理時忽略 X-Forwarded-For", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "203.0.113.7:51234"
+		req.Header.Set("X-Forwarded-For", "198.51.100.1")
+		assert.Equal(t, "203.0.113.7", GetClientIP(req))
+	})
+
+	t.Run("經過可信任代理時使用最右邊的非代理位址", func(t *testing.T) {
+		SetTrustedProxies([]netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")})
+		defer SetTrustedProxies(nil)
+
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "10.0.0.2:443"
+		// 最左邊的位址是用戶端自己加上的，不能採用
+		req.Header.Set("X-Forwarded-For", "1.2.3.4, 198.51.100.1, 10.0.0.3")
+		assert.Equal(t, "198.51.100.1", GetClientIP(req))
+
+		req.Header.Set("X-Forwarded-For", "not-an-ip, 10.0.0.3")
+		assert.Equal(t, "10.0.0.3", GetClientIP(req), "無法解析的位址不能採用")
+
+		req.Header.Del("X-Forwarded-For")
+		assert.Equal(t, "10.0.0.2", GetClientIP(req))
+	})
+}
+
+func TestEncryptString(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+
+	encrypted, err := EncryptString(key, "JBSWY3DPEHPK3PXP")
+	assert.NoError(t, err)
+	assert.NotContains(t, encrypted, "JBSWY3DPEHPK3PXP", "密文中不應該看得到明文")
+
+	decrypted, err := DecryptString(key, encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", decrypted)
+
+	_, err = DecryptString([]byte("fedcba9876543210fedcba9876543210"), encrypted)
+	assert.Error(t, err, "使用錯誤的金鑰解密應該要失敗")
+}
+
+func TestSignString(t *testing.T) {
+	key := []byte("state-signing-key")
+
+	signed := SignString(key, `{"state":"abc"}`)
+	value, err := VerifySignedString(key, signed)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"state":"abc"}`, value)
+
+	_, err = VerifySignedString([]byte("other-key"), signed)
+	assert.Error(t, err, "使用錯誤的金鑰驗證應該要失敗")
+
+	payload, mac, _ := strings.Cut(signed, ".")
+	forged := SignString(key, `{"state":"xyz"}`)
+	forgedPayload, _, _ := strings.Cut(forged, ".")
+	_, err = VerifySignedString(key, forgedPayload+"."+mac)
+	assert.Error(t, err, "竄改內容後簽章應該不符")
+	_, err = VerifySignedString(key, payload)
+	assert.Error(t, err, "缺少簽章時應該要失敗")
+}
+
+func TestChallengeToken(t *testing.T) {
+	userID := primitive.NewObjectID()
+	keys := NewHMACKeys("challenge-secret")
+
+	challenge, err := GenerateChallengeToken(userID, keys, 5*time.Minute)
+	assert.NoError(t, err)
+
+	claims, err := ParseChallengeToken(challenge, keys)
+	assert.NoError(t, err)
+	assert.Equal(t, userID, claims.UserID)
+	assert.NotEmpty(t, claims.TokenID)
+
+	_, err = ParseAccessToken(challenge, keys)
+	assert.Error(t, err, "challenge token 不能當作 access token 使用")
+
+	access, _ := GenerateJWT(userID, "user", primitive.NilObjectID, keys, time.Hour)
+	_, err = ParseChallengeToken(access, keys)
+	assert.Error(t, err, "access token 不能當作 challenge token 使用")
 }
@@ -0,0 +1,371 @@
+// Package webhooks 負責將聊天室事件以簽章過的 JSON 投遞到管理員註冊的外送 Webhook
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go-chat/backend/models"
+	"go-chat/backend/store"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// 投遞時附加的 HTTP 標頭
+const (
+	SignatureHeader = "X-Webhook-Signature" // sha256=<hex(HMAC-SHA256(secret, timestamp + "." + body))>
+	TimestampHeader = "X-Webhook-Timestamp"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+)
+
+// Payload 是送往 Webhook 的 JSON 內容
+type Payload struct {
+	DeliveryID string              `json:"deliveryId"`
+	Event      models.WebhookEvent `json:"event"`
+	RoomID     string              `json:"roomId"`
+	Timestamp  time.Time           `json:"timestamp"`
+	Data       interface{}         `json:"data"`
+}
+
+// Options 是 Dispatcher 的設定
+type Options struct {
+	Workers      int           // 同時投遞的 worker 數量
+	QueueSize    int           // 事件佇列的緩衝大小
+	MaxAttempts  int           // 超過此次數仍失敗就放入 dead-letter
+	BaseBackoff  time.Duration // 第 n 次重試前等待 BaseBackoff * 2^(n-1)
+	MaxBackoff   time.Duration
+	PollInterval time.Duration // worker 輪詢到期投遞的間隔
+	Lease        time.Duration // 取出的投遞在這段時間內不會被其他 worker 重複取出，應大於 HTTP 逾時
+	HTTPClient   *http.Client
+}
+
+// DefaultOptions 回傳正式環境使用的預設設定
+func DefaultOptions() Options {
+	return Options{
+		Workers:      4,
+		QueueSize:    1024,
+		MaxAttempts:  5,
+		BaseBackoff:  2 * time.Second,
+		MaxBackoff:   5 * time.Minute,
+		PollInterval: 5 * time.Second,
+		Lease:        time.Minute,
+		HTTPClient:   NewGuardedHTTPClient(10 * time.Second),
+	}
+}
+
+type event struct {
+	roomID primitive.ObjectID
+	name   models.WebhookEvent
+	data   interface{}
+}
+
+// Dispatcher 非同步投遞 Webhook，投遞紀錄先存入資料庫，worker 輪詢到期的投遞並以指數退避重試
+// 重試時間記錄在投遞的 nextAttemptAt，重新啟動後未完成的投遞會繼續進行
+type Dispatcher struct {
+	store  store.WebhookStorer
+	opts   Options
+	events chan event
+	wake   chan struct{}
+	quit   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewDispatcher 是一個工廠函式，用於建立新的 Dispatcher
+func NewDispatcher(webhookStore store.WebhookStorer, opts Options) *Dispatcher {
+	defaults := DefaultOptions()
+	if opts.Workers <= 0 {
+		opts.Workers = defaults.Workers
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaults.QueueSize
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaults.MaxAttempts
+	}
+	if opts.BaseBackoff <= 0 {
+		opts.BaseBackoff = defaults.BaseBackoff
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = defaults.MaxBackoff
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = defaults.PollInterval
+	}
+	if opts.Lease <= 0 {
+		opts.Lease = defaults.Lease
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = defaults.HTTPClient
+	}
+	return &Dispatcher{
+		store:  webhookStore,
+		opts:   opts,
+		events: make(chan event, opts.QueueSize),
+		wake:   make(chan struct{}, opts.Workers),
+		quit:   make(chan struct{}),
+	}
+}
+
+// Start 啟動事件分派與投遞 worker
+func (d *Dispatcher) Start() {
+	d.wg.Add(1)
+	go d.fanOut()
+	for i := 0; i < d.opts.Workers; i++ {
+		d.wg.Add(1)
+		go d.work()
+	}
+}
+
+// Stop 停止所有 worker，尚未完成的投遞保留 pending 狀態，下次啟動時繼續投遞
+func (d *Dispatcher) Stop() {
+	close(d.quit)
+	d.wg.Wait()
+}
+
+// Emit 將聊天室事件放入佇列，不會阻塞呼叫端；佇列已滿時丟棄並記錄
+func (d *Dispatcher) Emit(roomID primitive.ObjectID, name models.WebhookEvent, data interface{}) {
+	select {
+	case d.events <- event{roomID: roomID, name: name, data: data}:
+	default:
+		log.Printf("Webhook event queue full, dropping %s for room %s", name, roomID.Hex())
+	}
+}
+
+// EmitRoomDeleted 同步建立 room.deleted 的投遞紀錄，之後刪除聊天室的所有 Webhook
+// 投遞紀錄保存了網址與 secret，Webhook 刪除後仍會送出並依排程重試
+func (d *Dispatcher) EmitRoomDeleted(roomID primitive.ObjectID, data interface{}) {
+	d.record(event{roomID: roomID, name: models.WebhookEventRoomDeleted, data: data})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := d.store.DeleteWebhooksByRoom(ctx, roomID); err != nil {
+		log.Printf("Error deleting webhooks of deleted room %s: %v", roomID.Hex(), err)
+	}
+}
+
+// fanOut 從事件佇列取出事件並建立投遞紀錄
+func (d *Dispatcher) fanOut() {
+	defer d.wg.Done()
+	for {
+		select {
+		case <-d.quit:
+			return
+		case ev := <-d.events:
+			d.record(ev)
+		}
+	}
+}
+
+// record 找出訂閱事件的 Webhook，為每個 Webhook 存入一筆立即到期的投遞紀錄並喚醒 worker
+func (d *Dispatcher) record(ev event) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	hooks, err := d.store.ListWebhooksForEvent(ctx, ev.roomID, ev.name)
+	if err != nil {
+		log.Printf("Error listing webhooks for room %s: %v", ev.roomID.Hex(), err)
+		return
+	}
+	for _, hook := range hooks {
+		delivery, err := d.newDelivery(hook, ev)
+		if err != nil {
+			log.Printf("Error preparing webhook delivery for %s: %v", hook.ID.Hex(), err)
+			continue
+		}
+		if err := d.store.SaveDelivery(ctx, delivery); err != nil {
+			log.Printf("Error saving webhook delivery for %s: %v", hook.ID.Hex(), err)
+			continue
+		}
+		d.notify()
+	}
+}
+
+func (d *Dispatcher) newDelivery(hook models.Webhook, ev event) (*models.WebhookDelivery, error) {
+	now := time.Now()
+	delivery := &models.WebhookDelivery{
+		ID:            primitive.NewObjectID(),
+		WebhookID:     hook.ID,
+		URL:           hook.URL,
+		Secret:        hook.Secret,
+		RoomID:        ev.roomID,
+		Event:         ev.name,
+		Status:        models.WebhookDeliveryPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	body, err := json.Marshal(Payload{
+		DeliveryID: delivery.ID.Hex(),
+		Event:      ev.name,
+		RoomID:     ev.roomID.Hex(),
+		Timestamp:  now,
+		Data:       ev.data,
+	})
+	if err != nil {
+		return nil, err
+	}
+	delivery.Payload = string(body)
+	return delivery, nil
+}
+
+// notify 喚醒一個閒置的 worker，所有 worker 都在忙時由輪詢接手
+func (d *Dispatcher) notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// work 在被喚醒或輪詢時間到時取出到期的投遞並執行，直到沒有到期的投遞為止
+func (d *Dispatcher) work() {
+	defer d.wg.Done()
+	ticker := time.NewTicker(d.opts.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.quit:
+			return
+		case <-d.wake:
+		case <-ticker.C:
+		}
+		d.drain()
+	}
+}
+
+func (d *Dispatcher) drain() {
+	for {
+		select {
+		case <-d.quit:
+			return
+		default:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		delivery, err := d.store.ClaimDueDelivery(ctx, time.Now(), d.opts.Lease)
+		cancel()
+		if err != nil {
+			log.Printf("Error claiming due webhook delivery: %v", err)
+			return
+		}
+		if delivery == nil {
+			return
+		}
+		d.attempt(delivery)
+	}
+}
+
+// attempt 執行一次投遞並依結果標記成功、排定下次重試時間或放入 dead-letter
+func (d *Dispatcher) attempt(delivery *models.WebhookDelivery) {
+	delivery.Attempts++
+	statusCode, err := d.post(delivery)
+
+	delivery.StatusCode = statusCode
+	delivery.UpdatedAt = time.Now()
+	delivery.NextAttemptAt = time.Time{}
+	switch {
+	case err == nil:
+		delivery.Status = models.WebhookDeliverySucceeded
+		delivery.Error = ""
+	case delivery.Attempts >= d.opts.MaxAttempts:
+		delivery.Status = models.WebhookDeliveryDeadLetter
+		delivery.Error = err.Error()
+		log.Printf("Webhook delivery %s moved to dead-letter after %d attempts: %v", delivery.ID.Hex(), delivery.Attempts, err)
+	default:
+		delivery.Status = models.WebhookDeliveryPending
+		delivery.Error = err.Error()
+		delivery.NextAttemptAt = time.Now().Add(d.backoff(delivery.Attempts))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := d.store.SaveDelivery(ctx, delivery); err != nil {
+		// 沒寫回的投遞會在 lease 到期後再被取出，可能重複投遞，接收端應以 DeliveryHeader 去重
+		log.Printf("Error saving webhook delivery %s: %v", delivery.ID.Hex(), err)
+	}
+}
+
+// backoff 計算第 attempts 次失敗後的等待時間
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	wait := d.opts.BaseBackoff << (attempts - 1)
+	if wait <= 0 || wait > d.opts.MaxBackoff {
+		return d.opts.MaxBackoff
+	}
+	return wait
+}
+
+// post 將投遞內容簽章後送出，非 2xx 回應視為失敗
+func (d *Dispatcher) post(delivery *models.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequest(http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(delivery.Event))
+	req.Header.Set(DeliveryHeader, delivery.ID.Hex())
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, Sign(delivery.Secret, timestamp, body))
+
+	resp, err := d.opts.HTTPClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign 計算投遞內容的 HMAC-SHA256 簽章，接收端應以相同方式驗證並比對時間戳避免重放
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify 驗證簽章是否正確
+func Verify(secret, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// defaultDispatcher 是全域的 Dispatcher，未設定時 Emit 不做任何事
+var defaultDispatcher *Dispatcher
+
+// SetDefault 設定全域 Dispatcher，通常在 main 啟動時呼叫
+func SetDefault(d *Dispatcher) {
+	defaultDispatcher = d
+}
+
+// Emit 透過全域 Dispatcher 發送聊天室事件
+func Emit(roomID primitive.ObjectID, name models.WebhookEvent, data interface{}) {
+	if defaultDispatcher == nil {
+		return
+	}
+	defaultDispatcher.Emit(roomID, name, data)
+}
+
+// EmitRoomDeleted 透過全域 Dispatcher 發送 room.deleted 事件並刪除聊天室的 Webhook
+func EmitRoomDeleted(roomID primitive.ObjectID, data interface{}) {
+	if defaultDispatcher == nil {
+		return
+	}
+	defaultDispatcher.EmitRoomDeleted(roomID, data)
+}
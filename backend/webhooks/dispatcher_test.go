@@ -0,0 +1,316 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-chat/backend/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// fakeWebhookStore 是測試用的記憶體版 WebhookStorer
+type fakeWebhookStore struct {
+	mu         sync.Mutex
+	webhooks   []models.Webhook
+	deliveries map[primitive.ObjectID]models.WebhookDelivery
+}
+
+func newFakeWebhookStore(hooks ...models.Webhook) *fakeWebhookStore {
+	return &fakeWebhookStore{webhooks: hooks, deliveries: make(map[primitive.ObjectID]models.WebhookDelivery)}
+}
+
+func (s *fakeWebhookStore) CreateWebhook(ctx context.Context, webhook models.Webhook) (primitive.ObjectID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	webhook.ID = primitive.NewObjectID()
+	s.webhooks = append(s.webhooks, webhook)
+	return webhook.ID, nil
+}
+
+func (s *fakeWebhookStore) FindWebhookByID(ctx context.Context, webhookID primitive.ObjectID) (*models.Webhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, hook := range s.webhooks {
+		if hook.ID == webhookID {
+			return &hook, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *fakeWebhookStore) ListWebhooksByRoom(ctx context.Context, roomID primitive.ObjectID) ([]models.Webhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var hooks []models.Webhook
+	for _, hook := range s.webhooks {
+		if hook.RoomID == roomID {
+			hooks = append(hooks, hook)
+		}
+	}
+	return hooks, nil
+}
+
+func (s *fakeWebhookStore) ListWebhooksForEvent(ctx context.Context, roomID primitive.ObjectID, event models.WebhookEvent) ([]models.Webhook, error) {
+	hooks, _ := s.ListWebhooksByRoom(ctx, roomID)
+	var matched []models.Webhook
+	for _, hook := range hooks {
+		for _, e := range hook.Events {
+			if e == event {
+				matched = append(matched, hook)
+				break
+			}
+		}
+	}
+	return matched, nil
+}
+
+func (s *fakeWebhookStore) DeleteWebhook(ctx context.Context, webhookID primitive.ObjectID) error {
+	return nil
+}
+
+func (s *fakeWebhookStore) DeleteWebhooksByRoom(ctx context.Context, roomID primitive.ObjectID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var remaining []models.Webhook
+	for _, hook := range s.webhooks {
+		if hook.RoomID != roomID {
+			remaining = append(remaining, hook)
+		}
+	}
+	s.webhooks = remaining
+	return nil
+}
+
+func (s *fakeWebhookStore) SaveDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deliveries[delivery.ID] = *delivery
+	return nil
+}
+
+func (s *fakeWebhookStore) ListDeliveries(ctx context.Context, webhookID primitive.ObjectID, status models.WebhookDeliveryStatus, limit int64) ([]models.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var deliveries []models.WebhookDelivery
+	for _, delivery := range s.deliveries {
+		if delivery.WebhookID == webhookID && (status == "" || delivery.Status == status) {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	return deliveries, nil
+}
+
+func (s *fakeWebhookStore) ClaimDueDelivery(ctx context.Context, now time.Time, lease time.Duration) (*models.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, delivery := range s.deliveries {
+		if delivery.Status == models.WebhookDeliveryPending && !delivery.NextAttemptAt.After(now) {
+			delivery.NextAttemptAt = now.Add(lease)
+			s.deliveries[id] = delivery
+			return &delivery, nil
+		}
+	}
+	return nil, nil
+}
+
+// waitForDelivery 等待投遞紀錄進入指定狀態
+func waitForDelivery(t *testing.T, s *fakeWebhookStore, hookID primitive.ObjectID, status models.WebhookDeliveryStatus) models.WebhookDelivery {
+	t.Helper()
+	var found models.WebhookDelivery
+	require.Eventually(t, func() bool {
+		deliveries, _ := s.ListDeliveries(context.Background(), hookID, status, 10)
+		if len(deliveries) == 0 {
+			return false
+		}
+		found = deliveries[0]
+		return true
+	}, 3*time.Second, 10*time.Millisecond, "投遞紀錄應該進入 %s 狀態", status)
+	return found
+}
+
+func TestDispatcher(t *testing.T) {
+	roomID := primitive.NewObjectID()
+
+	t.Run("成功投遞並附上可驗證的簽章", func(t *testing.T) {
+		var received atomic.Value
+		receiver := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			received.Store(struct {
+				header http.Header
+				body   []byte
+			}{r.Header.Clone(), body})
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer receiver.Close()
+
+		hook := models.Webhook{
+			ID:     primitive.NewObjectID(),
+			RoomID: roomID,
+			URL:    receiver.URL,
+			Secret: "whsec_test",
+			Events: []models.WebhookEvent{models.WebhookEventMessageCreated},
+		}
+		fakeStore := newFakeWebhookStore(hook)
+		dispatcher := NewDispatcher(fakeStore, Options{HTTPClient: receiver.Client(), BaseBackoff: time.Millisecond, PollInterval: 5 * time.Millisecond})
+		dispatcher.Start()
+		defer dispatcher.Stop()
+
+		dispatcher.Emit(roomID, models.WebhookEventMessageCreated, map[string]string{"content": "hello"})
+		delivery := waitForDelivery(t, fakeStore, hook.ID, models.WebhookDeliverySucceeded)
+
+		assert.Equal(t, 1, delivery.Attempts)
+		assert.Equal(t, http.StatusNoContent, delivery.StatusCode)
+
+		got := received.Load().(struct {
+			header http.Header
+			body   []byte
+		})
+		assert.Equal(t, string(models.WebhookEventMessageCreated), got.header.Get(EventHeader))
+		assert.Equal(t, delivery.ID.Hex(), got.header.Get(DeliveryHeader))
+		assert.True(t, Verify(hook.Secret, got.header.Get(TimestampHeader), got.body, got.header.Get(SignatureHeader)), "簽章應該可以用 secret 驗證")
+		assert.False(t, Verify("wrong-secret", got.header.Get(TimestampHeader), got.body, got.header.Get(SignatureHeader)))
+
+		var payload Payload
+		require.NoError(t, json.Unmarshal(got.body, &payload))
+		assert.Equal(t, roomID.Hex(), payload.RoomID)
+		assert.Equal(t, models.WebhookEventMessageCreated, payload.Event)
+	})
+
+	t.Run("失敗後以退避重試直到成功", func(t *testing.T) {
+		var calls int32
+		receiver := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&calls, 1) < 3 {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer receiver.Close()
+
+		hook := models.Webhook{ID: primitive.NewObjectID(), RoomID: roomID, URL: receiver.URL, Secret: "s", Events: []models.WebhookEvent{models.WebhookEventRoomDeleted}}
+		fakeStore := newFakeWebhookStore(hook)
+		dispatcher := NewDispatcher(fakeStore, Options{HTTPClient: receiver.Client(), BaseBackoff: time.Millisecond, PollInterval: 5 * time.Millisecond, MaxAttempts: 5})
+		dispatcher.Start()
+		defer dispatcher.Stop()
+
+		dispatcher.Emit(roomID, models.WebhookEventRoomDeleted, nil)
+		delivery := waitForDelivery(t, fakeStore, hook.ID, models.WebhookDeliverySucceeded)
+
+		assert.Equal(t, 3, delivery.Attempts, "前兩次失敗後第三次應該成功")
+		assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("超過重試次數後放入 dead-letter", func(t *testing.T) {
+		receiver := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer receiver.Close()
+
+		hook := models.Webhook{ID: primitive.NewObjectID(), RoomID: roomID, URL: receiver.URL, Secret: "s", Events: []models.WebhookEvent{models.WebhookEventMembershipUpdated}}
+		fakeStore := newFakeWebhookStore(hook)
+		dispatcher := NewDispatcher(fakeStore, Options{HTTPClient: receiver.Client(), BaseBackoff: time.Millisecond, PollInterval: 5 * time.Millisecond, MaxAttempts: 3})
+		dispatcher.Start()
+		defer dispatcher.Stop()
+
+		dispatcher.Emit(roomID, models.WebhookEventMembershipUpdated, nil)
+		delivery := waitForDelivery(t, fakeStore, hook.ID, models.WebhookDeliveryDeadLetter)
+
+		assert.Equal(t, 3, delivery.Attempts)
+		assert.Equal(t, http.StatusBadGateway, delivery.StatusCode)
+		assert.NotEmpty(t, delivery.Error)
+	})
+
+	t.Run("重新啟動後繼續投遞資料庫中到期的 pending 紀錄", func(t *testing.T) {
+		var calls int32
+		receiver := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer receiver.Close()
+
+		// 上一個程序已經失敗過一次並排定重試，Webhook 本身也已被刪除
+		hookID := primitive.NewObjectID()
+		fakeStore := newFakeWebhookStore()
+		fakeStore.SaveDelivery(context.Background(), &models.WebhookDelivery{
+			ID:            primitive.NewObjectID(),
+			WebhookID:     hookID,
+			URL:           receiver.URL,
+			Secret:        "s",
+			RoomID:        roomID,
+			Event:         models.WebhookEventRoomDeleted,
+			Payload:       `{}`,
+			Status:        models.WebhookDeliveryPending,
+			Attempts:      1,
+			NextAttemptAt: time.Now().Add(-time.Second),
+		})
+
+		dispatcher := NewDispatcher(fakeStore, Options{HTTPClient: receiver.Client(), PollInterval: 5 * time.Millisecond})
+		dispatcher.Start()
+		defer dispatcher.Stop()
+
+		delivery := waitForDelivery(t, fakeStore, hookID, models.WebhookDeliverySucceeded)
+		assert.Equal(t, 2, delivery.Attempts)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("聊天室刪除後 Webhook 被移除但 room.deleted 仍會送達", func(t *testing.T) {
+		var calls int32
+		receiver := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer receiver.Close()
+
+		deletedRoomID := primitive.NewObjectID()
+		hook := models.Webhook{ID: primitive.NewObjectID(), RoomID: deletedRoomID, URL: receiver.URL, Secret: "s", Events: []models.WebhookEvent{models.WebhookEventRoomDeleted}}
+		fakeStore := newFakeWebhookStore(hook)
+		dispatcher := NewDispatcher(fakeStore, Options{HTTPClient: receiver.Client(), PollInterval: 5 * time.Millisecond})
+		dispatcher.Start()
+		defer dispatcher.Stop()
+
+		dispatcher.EmitRoomDeleted(deletedRoomID, nil)
+
+		hooks, _ := fakeStore.ListWebhooksByRoom(context.Background(), deletedRoomID)
+		assert.Empty(t, hooks)
+		waitForDelivery(t, fakeStore, hook.ID, models.WebhookDeliverySucceeded)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("未訂閱的事件不會投遞", func(t *testing.T) {
+		var calls int32
+		receiver := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+		}))
+		defer receiver.Close()
+
+		hook := models.Webhook{ID: primitive.NewObjectID(), RoomID: roomID, URL: receiver.URL, Secret: "s", Events: []models.WebhookEvent{models.WebhookEventRoomDeleted}}
+		fakeStore := newFakeWebhookStore(hook)
+		dispatcher := NewDispatcher(fakeStore, Options{HTTPClient: receiver.Client()})
+		dispatcher.Start()
+
+		dispatcher.Emit(roomID, models.WebhookEventMessageCreated, nil)
+		time.Sleep(50 * time.Millisecond)
+		dispatcher.Stop()
+
+		assert.Equal(t, int32(0), atomic.LoadInt32(&calls))
+	})
+}
+
+func TestBackoff(t *testing.T) {
+	dispatcher := NewDispatcher(newFakeWebhookStore(), Options{BaseBackoff: time.Second, MaxBackoff: 10 * time.Second})
+
+	assert.Equal(t, time.Second, dispatcher.backoff(1))
+	assert.Equal(t, 2*time.Second, dispatcher.backoff(2))
+	assert.Equal(t, 8*time.Second, dispatcher.backoff(4))
+	assert.Equal(t, 10*time.Second, dispatcher.backoff(5), "超過上限時應該使用 MaxBackoff")
+}
@@ -0,0 +1,101 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// ErrDisallowedAddress 表示 Webhook 網址指向內部網路，不能投遞以免被用來存取內部服務 (SSRF)
+var ErrDisallowedAddress = errors.New("webhook address is not publicly routable")
+
+// disallowedPrefixes 是 net.IP 的分類方法沒有涵蓋、但同樣不能投遞的網段
+var disallowedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // 「這個網路」
+	netip.MustParsePrefix("100.64.0.0/10"), // 電信級 NAT
+	netip.MustParsePrefix("192.0.0.0/24"),  // IETF 協定保留
+	netip.MustParsePrefix("198.18.0.0/15"), // 效能測試
+	netip.MustParsePrefix("240.0.0.0/4"),   // 保留與廣播
+	netip.MustParsePrefix("64:ff9b::/96"),  // NAT64，可能轉換到內部 IPv4
+}
+
+// isDisallowedIP 判斷位址是否為 loopback、私有、link-local (含雲端 metadata 169.254.169.254)、
+// 未指定或多播等不能作為 Webhook 目的地的位址；fd00:ec2::254 屬於私有的 fc00::/7
+func isDisallowedIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return true
+	}
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return true
+	}
+	addr = addr.Unmap()
+	for _, prefix := range disallowedPrefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateURL 檢查 Webhook 網址必須是 https，且主機解析出的每個位址都可以公開路由
+// 投遞時 NewGuardedHTTPClient 會在連線前再檢查一次，避免註冊後 DNS 改指向內部位址
+func ValidateURL(ctx context.Context, rawURL string) (*url.URL, error) {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil || parsedURL.Scheme != "https" || parsedURL.Hostname() == "" {
+		return nil, errors.New("webhook URL must be a valid https URL")
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, parsedURL.Hostname())
+	if err != nil {
+		return nil, fmt.Errorf("resolve webhook host: %w", err)
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("resolve webhook host: no addresses for %s", parsedURL.Hostname())
+	}
+	for _, addr := range addrs {
+		if isDisallowedIP(addr.IP) {
+			return nil, ErrDisallowedAddress
+		}
+	}
+	return parsedURL, nil
+}
+
+// guardDial 在 DNS 解析完成、實際建立連線前檢查目的位址，重新導向與 DNS rebinding 也會經過這裡
+func guardDial(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || isDisallowedIP(ip) {
+		return ErrDisallowedAddress
+	}
+	return nil
+}
+
+// NewGuardedHTTPClient 建立投遞用的 HTTP client，拒絕連線到內部網路並且不使用環境變數中的 proxy
+func NewGuardedHTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   5 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   guardDial,
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			ForceAttemptHTTP2:   true,
+			MaxIdleConns:        100,
+			IdleConnTimeout:     90 * time.Second,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+	}
+}
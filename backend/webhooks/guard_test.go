@@ -0,0 +1,49 @@
+package webhooks
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsDisallowedIP(t *testing.T) {
+	for _, ip := range []string{
+		"127.0.0.1", "10.1.2.3", "172.16.0.1", "192.168.1.1", "169.254.169.254",
+		"0.0.0.0", "100.64.0.1", "224.0.0.1", "::1", "fe80::1", "fd00:ec2::254", "::ffff:127.0.0.1",
+	} {
+		assert.True(t, isDisallowedIP(net.ParseIP(ip)), ip)
+	}
+	for _, ip := range []string{"93.184.216.34", "2606:4700::1111"} {
+		assert.False(t, isDisallowedIP(net.ParseIP(ip)), ip)
+	}
+}
+
+func TestValidateURL(t *testing.T) {
+	ctx := context.Background()
+
+	parsed, err := ValidateURL(ctx, "https://93.184.216.34/hooks")
+	assert.NoError(t, err)
+	assert.Equal(t, "93.184.216.34", parsed.Hostname())
+
+	_, err = ValidateURL(ctx, "http://93.184.216.34/hooks")
+	assert.Error(t, err, "只接受 https")
+
+	for _, rawURL := range []string{"https://127.0.0.1/", "https://169.254.169.254/latest/meta-data", "https://[::1]:8443/", "https://localhost/"} {
+		_, err = ValidateURL(ctx, rawURL)
+		assert.ErrorIs(t, err, ErrDisallowedAddress, rawURL)
+	}
+}
+
+func TestGuardedHTTPClient(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer receiver.Close()
+
+	// 即使通過註冊檢查，連線時仍會拒絕內部位址 (例如 DNS 之後改指向內部網路)
+	_, err := NewGuardedHTTPClient(time.Second).Get(receiver.URL)
+	assert.ErrorIs(t, err, ErrDisallowedAddress)
+}
@@ -0,0 +1,270 @@
+package websocket
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"go-chat/backend/database"
+	"go-chat/backend/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CommandContext 是斜線指令處理函式可取得的執行環境
+type CommandContext struct {
+	SenderID       primitive.ObjectID
+	SenderUsername string
+	Room           *models.ChatRoom
+	Name           string               // 指令名稱 (不含 "/"，已轉小寫)
+	Args           string               // 指令名稱之後的原始參數字串
+	Reply          func(content string) // 私下回覆給發送者，不會儲存也不會廣播
+}
+
+// CommandResult 描述指令執行完後要輸出的內容
+type CommandResult struct {
+	Content   string
+	Broadcast bool // true 代表以系統訊息廣播給整個聊天室，false 代表只回覆給發送者
+}
+
+// CommandHandler 是斜線指令的處理函式，回傳 nil 結果代表沒有額外輸出
+type CommandHandler func(ctx *CommandContext) (*CommandResult, error)
+
+// Command 定義一個可註冊的斜線指令
+type Command struct {
+	Name        string
+	Usage       string
+	Description string
+	Handler     CommandHandler
+}
+
+var (
+	commandsMu sync.RWMutex
+	commands   = make(map[string]Command)
+)
+
+// RegisterCommand 註冊 (或覆蓋) 一個斜線指令
+func RegisterCommand(cmd Command) {
+	commandsMu.Lock()
+	defer commandsMu.Unlock()
+	commands[strings.ToLower(cmd.Name)] = cmd
+}
+
+// BroadcastResult 建立一個以系統訊息廣播給聊天室的指令結果
+func BroadcastResult(content string) *CommandResult {
+	return &CommandResult{Content: content, Broadcast: true}
+}
+
+// ReplyResult 建立一個只回覆給發送者的指令結果
+func ReplyResult(content string) *CommandResult {
+	return &CommandResult{Content: content}
+}
+
+func init() {
+	RegisterCommand(Command{Name: "help", Usage: "/help", Description: "列出所有可用的指令", Handler: helpCommand})
+	RegisterCommand(Command{Name: "me", Usage: "/me <動作>", Description: "以第三人稱描述你的動作", Handler: meCommand})
+	RegisterCommand(Command{Name: "topic", Usage: "/topic [主題]", Description: "查看或設定聊天室主題", Handler: topicCommand})
+	RegisterCommand(Command{Name: "mute", Usage: "/mute [時間長度|off]", Description: "將目前聊天室的通知設為靜音，例如 /mute 30m", Handler: muteCommand})
+}
+
+// parseCommandPrefix 判斷訊息是否為指令
+// 回傳去掉前綴 "/" 的內容；以 "//" 開頭的訊息視為跳脫，會還原成以 "/" 開頭的一般訊息
+func parseCommandPrefix(content string) (string, bool) {
+	if strings.HasPrefix(content, "//") {
+		return content[1:], false
+	}
+	if strings.HasPrefix(content, "/") {
+		return content[1:], true
+	}
+	return content, false
+}
+
+// splitCommand 將指令字串拆成指令名稱與參數
+func splitCommand(input string) (string, string) {
+	input = strings.TrimSpace(input)
+	name, args, _ := strings.Cut(input, " ")
+	return strings.ToLower(name), strings.TrimSpace(args)
+}
+
+// runCommand 從註冊表中找出指令並執行
+func runCommand(ctx *CommandContext) (*CommandResult, error) {
+	commandsMu.RLock()
+	cmd, ok := commands[ctx.Name]
+	commandsMu.RUnlock()
+	if !ok || ctx.Name == "" {
+		return nil, fmt.Errorf("未知的指令 /%s，輸入 /help 查看可用指令", ctx.Name)
+	}
+	return cmd.Handler(ctx)
+}
+
+// executeCommand 執行使用者在聊天室中輸入的指令，並依結果廣播或私下回覆
+// 呼叫前 SendMessage 已確認發送者是聊天室成員
+func executeCommand(sender MessageSender, room *models.ChatRoom, input string) {
+	name, args := splitCommand(input)
+	ctx := &CommandContext{
+		SenderID:       sender.ID,
+		SenderUsername: sender.Username,
+		Room:           room,
+		Name:           name,
+		Args:           args,
+		Reply: func(content string) {
+			SendToUser(sender.ID, newCommandReply(room, content))
+		},
+	}
+
+	result, err := runCommand(ctx)
+	if err != nil {
+		ctx.Reply(err.Error())
+		return
+	}
+	if result == nil || result.Content == "" {
+		return
+	}
+	if !result.Broadcast {
+		ctx.Reply(result.Content)
+		return
+	}
+
+	systemMessage := models.Message{
+		Type:           models.MessageTypeSystem,
+		SenderID:       sender.ID,
+		SenderUsername: "系統訊息",
+		RoomID:         room.ID.Hex(),
+		RoomName:       room.Name,
+		Content:        result.Content,
+		Timestamp:      time.Now(),
+		IsRead:         true,
+	}
+	insertResult, err := database.InsertMessage(systemMessage)
+	if err != nil {
+		log.Printf("Error inserting command system message: %v", err)
+	} else {
+		systemMessage.ID = insertResult.InsertedID.(primitive.ObjectID)
+	}
+	BroadcastMessage(systemMessage)
+}
+
+// newCommandReply 建立只給發送者看的指令回覆訊息
+func newCommandReply(room *models.ChatRoom, content string) models.Message {
+	return models.Message{
+		Type:           models.MessageTypeCommandReply,
+		RoomID:         room.ID.Hex(),
+		RoomName:       room.Name,
+		SenderID:       primitive.NilObjectID,
+		SenderUsername: "系統訊息",
+		Content:        content,
+		Timestamp:      time.Now(),
+		IsRead:         true,
+	}
+}
+
+// isParticipant 檢查使用者是否為聊天室成員
+func isParticipant(room *models.ChatRoom, userID primitive.ObjectID) bool {
+	for _, participantID := range room.Participants {
+		if participantID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// BroadcastRoomStateUpdate 儲存並廣播 room_state_update，通知前端重新抓取聊天室列表
+func BroadcastRoomStateUpdate(room *models.ChatRoom) {
+	roomUpdateMessage := models.Message{
+		Type:           models.MessageTypeUpdate,
+		RoomID:         room.ID.Hex(),
+		RoomName:       room.Name,
+		SenderID:       primitive.NilObjectID,
+		SenderUsername: "系統更新",
+		Content:        "聊天室資訊已更新。",
+		Timestamp:      time.Now(),
+		IsRead:         true,
+	}
+	result, err := database.InsertMessage(roomUpdateMessage)
+	if err != nil {
+		log.Printf("Error inserting system update message: %v", err)
+	} else {
+		roomUpdateMessage.ID = result.InsertedID.(primitive.ObjectID)
+	}
+	GlobalHub.Broadcast <- roomUpdateMessage
+}
+
+// helpCommand 列出所有已註冊的指令
+func helpCommand(ctx *CommandContext) (*CommandResult, error) {
+	commandsMu.RLock()
+	lines := make([]string, 0, len(commands))
+	for _, cmd := range commands {
+		lines = append(lines, cmd.Usage+"："+cmd.Description)
+	}
+	commandsMu.RUnlock()
+
+	sort.Strings(lines)
+	return ReplyResult("可用指令：\n" + strings.Join(lines, "\n")), nil
+}
+
+// meCommand 以第三人稱廣播發送者的動作
+func meCommand(ctx *CommandContext) (*CommandResult, error) {
+	if ctx.Args == "" {
+		return nil, errors.New("用法：/me <動作>")
+	}
+	return BroadcastResult("* " + ctx.SenderUsername + " " + ctx.Args), nil
+}
+
+// topicCommand 查看或設定聊天室主題
+func topicCommand(ctx *CommandContext) (*CommandResult, error) {
+	if ctx.Args == "" {
+		if ctx.Room.Topic == "" {
+			return ReplyResult("此聊天室尚未設定主題"), nil
+		}
+		return ReplyResult("目前主題：" + ctx.Room.Topic), nil
+	}
+	if utf8.RuneCountInString(ctx.Args) > models.MaxRoomTopicLength {
+		return nil, fmt.Errorf("主題長度不可超過 %d 個字", models.MaxRoomTopicLength)
+	}
+
+	updatedRoom, err := database.UpdateChatRoomTopic(ctx.Room.ID, ctx.Args)
+	if err != nil {
+		log.Printf("Error updating topic for room %s: %v", ctx.Room.ID.Hex(), err)
+		return nil, errors.New("設定主題失敗，請稍後再試")
+	}
+	// 主題會顯示在聊天室列表中，所有成員的快取都要失效
+	database.InvalidateMultipleUserChatRoomsCache(updatedRoom.Participants)
+	BroadcastRoomStateUpdate(updatedRoom)
+
+	return BroadcastResult(ctx.SenderUsername + " 將聊天室主題設為：" + ctx.Args), nil
+}
+
+// muteCommand 將發送者在目前聊天室的通知設為靜音或取消靜音
+func muteCommand(ctx *CommandContext) (*CommandResult, error) {
+	if strings.EqualFold(ctx.Args, "off") {
+		if err := database.ClearRoomMute(ctx.Room.ID, ctx.SenderID); err != nil {
+			return nil, errors.New("取消靜音失敗，請稍後再試")
+		}
+		database.InvalidateUserChatRoomsCache(ctx.SenderID)
+		return ReplyResult("已取消此聊天室的靜音"), nil
+	}
+
+	var duration time.Duration
+	if ctx.Args != "" {
+		d, err := time.ParseDuration(ctx.Args)
+		if err != nil || d <= 0 {
+			return nil, errors.New("用法：/mute [時間長度|off]，例如 /mute 30m")
+		}
+		duration = d
+	}
+
+	if err := database.SetRoomMute(ctx.Room.ID, ctx.SenderID, duration); err != nil {
+		return nil, errors.New("設定靜音失敗，請稍後再試")
+	}
+	// 靜音狀態會顯示在聊天室列表中
+	database.InvalidateUserChatRoomsCache(ctx.SenderID)
+	if duration == 0 {
+		return ReplyResult("已將此聊天室設為靜音，輸入 /mute off 可取消"), nil
+	}
+	return ReplyResult("已將此聊天室靜音 " + duration.String()), nil
+}
@@ -0,0 +1,85 @@
+package websocket
+
+import (
+	"testing"
+
+	"go-chat/backend/models"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestParseCommandPrefix(t *testing.T) {
+	t.Run("一般訊息", func(t *testing.T) {
+		content, isCommand := parseCommandPrefix("hello")
+		assert.False(t, isCommand)
+		assert.Equal(t, "hello", content)
+	})
+
+	t.Run("斜線開頭視為指令", func(t *testing.T) {
+		content, isCommand := parseCommandPrefix("/me waves")
+		assert.True(t, isCommand)
+		assert.Equal(t, "me waves", content)
+	})
+
+	t.Run("雙斜線跳脫為一般訊息", func(t *testing.T) {
+		content, isCommand := parseCommandPrefix("//me waves")
+		assert.False(t, isCommand, "// 開頭不應被當成指令")
+		assert.Equal(t, "/me waves", content)
+	})
+}
+
+func TestSplitCommand(t *testing.T) {
+	name, args := splitCommand("Invite   @alice @bob ")
+	assert.Equal(t, "invite", name, "指令名稱應轉為小寫")
+	assert.Equal(t, "@alice @bob", args)
+
+	name, args = splitCommand("leave")
+	assert.Equal(t, "leave", name)
+	assert.Empty(t, args)
+}
+
+func TestRunCommand(t *testing.T) {
+	room := &models.ChatRoom{ID: primitive.NewObjectID(), Name: "測試聊天室"}
+
+	t.Run("執行已註冊的指令", func(t *testing.T) {
+		var received *CommandContext
+		RegisterCommand(Command{Name: "echo-test", Handler: func(ctx *CommandContext) (*CommandResult, error) {
+			received = ctx
+			return ReplyResult(ctx.Args), nil
+		}})
+
+		ctx := &CommandContext{SenderUsername: "alice", Room: room, Name: "echo-test", Args: "hi"}
+		result, err := runCommand(ctx)
+
+		assert.NoError(t, err)
+		assert.Same(t, ctx, received, "處理函式應該收到同一個 context")
+		assert.Equal(t, "hi", result.Content)
+		assert.False(t, result.Broadcast, "ReplyResult 應該只回覆給發送者")
+	})
+
+	t.Run("未知指令回傳錯誤", func(t *testing.T) {
+		_, err := runCommand(&CommandContext{Room: room, Name: "does-not-exist"})
+		assert.Error(t, err)
+	})
+
+	t.Run("/me 廣播第三人稱動作", func(t *testing.T) {
+		result, err := runCommand(&CommandContext{SenderUsername: "alice", Room: room, Name: "me", Args: "揮了揮手"})
+		assert.NoError(t, err)
+		assert.True(t, result.Broadcast)
+		assert.Equal(t, "* alice 揮了揮手", result.Content)
+	})
+
+	t.Run("/me 沒有參數時回傳用法", func(t *testing.T) {
+		_, err := runCommand(&CommandContext{SenderUsername: "alice", Room: room, Name: "me"})
+		assert.Error(t, err)
+	})
+
+	t.Run("/topic 沒有參數時回覆目前主題", func(t *testing.T) {
+		topicRoom := &models.ChatRoom{ID: primitive.NewObjectID(), Topic: "每週同步"}
+		result, err := runCommand(&CommandContext{Room: topicRoom, Name: "topic"})
+		assert.NoError(t, err)
+		assert.False(t, result.Broadcast)
+		assert.Contains(t, result.Content, "每週同步")
+	})
+}
@@ -0,0 +1,140 @@
+package websocket
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go-chat/backend/database"
+	"go-chat/backend/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxMessageContentLength 是單則訊息內容的最大字數
+const maxMessageContentLength = 2000
+
+// 訊息發送流程的錯誤，REST handler 會依此決定 HTTP 狀態碼
+var (
+	ErrInvalidRoomID   = errors.New("invalid room ID")
+	ErrRoomNotFound    = errors.New("chat room not found")
+	ErrNotParticipant  = errors.New("sender is not a participant of the chat room")
+	ErrEmptyMessage    = errors.New("message content is required")
+	ErrMessageTooLong  = errors.New("message content is too long")
+	ErrMessageNotSaved = errors.New("failed to save message")
+	ErrSenderMuted     = errors.New("sender is muted in the chat room")
+	ErrRoomArchived    = errors.New("chat room is archived")
+)
+
+// MessageSender 是訊息發送者的身分，來源可以是 WebSocket 連線或 REST 請求
+type MessageSender struct {
+	ID       primitive.ObjectID
+	Username string
+	IsBot    bool
+}
+
+// SendMessage 驗證、儲存並廣播一則使用者訊息，WebSocket 與 REST 共用這個流程
+// 以 "/" 開頭的內容會交給指令系統處理，此時回傳的訊息為 nil，指令的私人回覆會送到發送者的 WebSocket 連線
+func SendMessage(sender MessageSender, roomIDHex string, content string) (*models.Message, error) {
+	room, err := findRoomForSender(sender, roomIDHex)
+	if err != nil {
+		return nil, err
+	}
+	// 封存的聊天室只能閱讀，指令也可能修改聊天室，一併拒絕
+	if room.IsArchived() {
+		return nil, ErrRoomArchived
+	}
+	// 被禁言的成員在禁言期間不能發送任何訊息，包含會廣播內容的指令
+	muted, err := database.IsRoomMemberMuted(room.ID, sender.ID)
+	if err != nil {
+		return nil, err
+	}
+	if muted {
+		return nil, ErrSenderMuted
+	}
+
+	content, isCommand := parseCommandPrefix(content)
+	if isCommand {
+		executeCommand(sender, room, content)
+		return nil, nil
+	}
+	// "//" 開頭的跳脫訊息已經還原成以 "/" 開頭的一般文字
+
+	return deliverMessage(sender, room, content)
+}
+
+// PostIntegrationMessage 以外部整合的名義發送訊息 (例如 incoming webhook)
+// 整合不是聊天室成員，也不會觸發斜線指令，其餘驗證、儲存與廣播流程與 SendMessage 相同
+func PostIntegrationMessage(room *models.ChatRoom, integrationID primitive.ObjectID, name string, content string) (*models.Message, error) {
+	if room.IsArchived() {
+		return nil, ErrRoomArchived
+	}
+	sender := MessageSender{ID: integrationID, Username: name, IsBot: true}
+	return deliverMessage(sender, room, content)
+}
+
+// deliverMessage 驗證內容後儲存並廣播訊息
+func deliverMessage(sender MessageSender, room *models.ChatRoom, content string) (*models.Message, error) {
+	if err := validateMessageContent(content); err != nil {
+		return nil, err
+	}
+
+	msg := models.Message{
+		Type:           models.MessageTypeNormal,
+		SenderID:       sender.ID,
+		SenderUsername: sender.Username,
+		IsBot:          sender.IsBot,
+		RoomID:         room.ID.Hex(),
+		RoomName:       room.Name, // 為了確保 RoomName 是最新的，使用資料庫中的名稱
+		Content:        content,
+		Timestamp:      time.Now(),
+	}
+
+	// 呼叫端已經確認聊天室未封存，不需要再查詢封存狀態
+	result, err := database.InsertActiveRoomMessage(msg)
+	if err != nil {
+		log.Printf("Error saving message to database: %v", err)
+		return nil, ErrMessageNotSaved
+	}
+	msg.ID = result.InsertedID.(primitive.ObjectID)
+
+	// 將帶有 ID 的完整訊息廣播出去
+	BroadcastMessage(msg)
+	return &msg, nil
+}
+
+// findRoomForSender 找出聊天室並確認發送者是成員
+func findRoomForSender(sender MessageSender, roomIDHex string) (*models.ChatRoom, error) {
+	if roomIDHex == "" {
+		return nil, ErrInvalidRoomID
+	}
+	roomID, err := primitive.ObjectIDFromHex(roomIDHex)
+	if err != nil {
+		return nil, ErrInvalidRoomID
+	}
+
+	room, err := database.FindChatRoomByID(roomID)
+	if err != nil {
+		return nil, err
+	}
+	if room == nil {
+		return nil, ErrRoomNotFound
+	}
+	if !isParticipant(room, sender.ID) {
+		return nil, ErrNotParticipant
+	}
+	return room, nil
+}
+
+// validateMessageContent 檢查訊息內容是否可以發送
+func validateMessageContent(content string) error {
+	if strings.TrimSpace(content) == "" {
+		return ErrEmptyMessage
+	}
+	if utf8.RuneCountInString(content) > maxMessageContentLength {
+		return ErrMessageTooLong
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go-chat/backend/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestValidateMessageContent(t *testing.T) {
+	assert.NoError(t, validateMessageContent("hello"))
+	assert.ErrorIs(t, validateMessageContent(""), ErrEmptyMessage)
+	assert.ErrorIs(t, validateMessageContent("   \n"), ErrEmptyMessage, "只有空白的訊息應視為空訊息")
+	assert.NoError(t, validateMessageContent(strings.Repeat("字", maxMessageContentLength)), "長度以字數計算而不是位元組")
+	assert.ErrorIs(t, validateMessageContent(strings.Repeat("a", maxMessageContentLength+1)), ErrMessageTooLong)
+}
+
+func TestSendMessageRejectsInvalidRoomID(t *testing.T) {
+	sender := MessageSender{ID: primitive.NewObjectID(), Username: "alice"}
+
+	_, err := SendMessage(sender, "", "hi")
+	assert.ErrorIs(t, err, ErrInvalidRoomID)
+
+	_, err = SendMessage(sender, "not-an-object-id", "hi")
+	assert.ErrorIs(t, err, ErrInvalidRoomID)
+}
+
+func TestPostIntegrationMessageRejectsArchivedRoom(t *testing.T) {
+	room := &models.ChatRoom{ID: primitive.NewObjectID(), ArchivedAt: time.Now()}
+
+	_, err := PostIntegrationMessage(room, primitive.NewObjectID(), "CI", "build passed")
+	assert.ErrorIs(t, err, ErrRoomArchived)
+}
+
+func TestNewErrorFrame(t *testing.T) {
+	frame := newErrorFrame("room-1", "此聊天室已封存，無法發送訊息")
+
+	assert.Equal(t, models.MessageTypeError, frame.Type)
+	assert.Equal(t, "room-1", frame.RoomID)
+	assert.Equal(t, "此聊天室已封存，無法發送訊息", frame.Content)
+}
+
+func TestMessageForRecipient(t *testing.T) {
+	now := time.Now()
+	muted, other := primitive.NewObjectID(), primitive.NewObjectID()
+	room := &models.ChatRoom{
+		Participants:   []primitive.ObjectID{muted, other},
+		MemberSettings: map[string]models.RoomMemberSettings{muted.Hex(): {Muted: true}},
+	}
+	message := models.Message{Type: models.MessageTypeNormal, Content: "hi"}
+
+	assert.True(t, messageForRecipient(message, room, muted, now).Silent, "靜音的成員收到的訊息不產生通知")
+	assert.False(t, messageForRecipient(message, room, other, now).Silent)
+	assert.False(t, message.Silent, "不能修改其他成員收到的訊息")
+
+	update := models.Message{Type: models.MessageTypeUpdate}
+	assert.False(t, messageForRecipient(update, room, muted, now).Silent, "狀態更新不是通知")
+}
+
+func TestRejectionReason(t *testing.T) {
+	assert.Contains(t, rejectionReason(ErrMessageTooLong), "2000")
+	assert.Equal(t, rejectionReason(ErrRoomNotFound), rejectionReason(ErrNotParticipant), "非成員不應該能分辨聊天室是否存在")
+	assert.Equal(t, rejectionReason(errors.New("boom")), rejectionReason(ErrMessageNotSaved))
+
+	seen := map[string]error{}
+	for _, err := range []error{ErrSenderMuted, ErrRoomArchived, ErrMessageTooLong, ErrEmptyMessage, ErrInvalidRoomID, ErrRoomNotFound, ErrMessageNotSaved} {
+		reason := rejectionReason(err)
+		assert.NotContains(t, seen, reason, "%v 應該有自己的說明", err)
+		seen[reason] = err
+	}
+}
+
+func TestMaxMessageSizeFitsLongestMessage(t *testing.T) {
+	frame, err := json.Marshal(models.Message{
+		RoomID:  primitive.NewObjectID().Hex(),
+		Content: strings.Repeat("😀", maxMessageContentLength),
+	})
+	require.NoError(t, err)
+	assert.LessOrEqual(t, len(frame), maxMessageSize, "內容長度上限內的訊息不應該被 WebSocket 讀取上限擋下")
+}
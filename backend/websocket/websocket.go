@@ -2,15 +2,19 @@ package websocket
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"go-chat/backend/config"
 	"go-chat/backend/utils"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"go-chat/backend/database"
 	"go-chat/backend/loadtestcontrol"
 	"go-chat/backend/models"
+	"go-chat/backend/webhooks"
 
 	"github.com/gorilla/websocket"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -52,10 +56,13 @@ func HandleChatHistory(w http.ResponseWriter, r *http.Request) {
 }
 
 const (
-	writeWait      = 10 * time.Second
-	pongWait       = 60 * time.Second
-	pingPeriod     = (pongWait * 9) / 10
-	maxMessageSize = 512
+	writeWait  = 10 * time.Second
+	pongWait   = 60 * time.Second
+	pingPeriod = (pongWait * 9) / 10
+	// maxMessageSize 要容納 maxMessageContentLength 個字元：UTF-8 每字最多 4 位元組，再加上 JSON 跳脫與其他欄位的空間
+	maxMessageSize = maxMessageContentLength*6 + 1024
+	// sessionTouchInterval 是更新 session 最後活動時間的最短間隔，避免每則訊息都寫一次資料庫
+	sessionTouchInterval = time.Minute
 )
 
 var upgrader = websocket.Upgrader{
@@ -73,6 +80,11 @@ type Client struct {
 	send     chan models.Message
 	UserID   primitive.ObjectID
 	Username string
+	IsBot    bool
+	// SessionID 是連線時 access token 所屬的登入 session，登出或撤銷時用來找出要中斷的連線
+	SessionID primitive.ObjectID
+	// lastTouchedAt 是上一次更新 session 最後活動時間的時間，只在 readPump 中讀寫
+	lastTouchedAt time.Time
 	// 【修改】RoomID 和 RoomName 不再是必要項，僅表示用戶當前活躍的房間
 	ActiveRoomID   string
 	ActiveRoomName string
@@ -86,7 +98,12 @@ func (c *Client) readPump() {
 	}()
 	c.conn.SetReadLimit(maxMessageSize)
 	c.conn.SetReadDeadline(time.Now().Add(pongWait))
-	c.conn.SetPongHandler(func(string) error { c.conn.SetReadDeadline(time.Now().Add(pongWait)); return nil })
+	c.conn.SetPongHandler(func(string) error {
+		c.conn.SetReadDeadline(time.Now().Add(pongWait))
+		c.touchSession()
+		return nil
+	})
+	c.touchSession()
 
 	for {
 		_, p, err := c.conn.ReadMessage()
@@ -98,6 +115,7 @@ func (c *Client) readPump() {
 			}
 			break
 		}
+		c.touchSession()
 
 		var msg models.Message
 		if err := json.Unmarshal(p, &msg); err != nil {
@@ -105,44 +123,61 @@ func (c *Client) readPump() {
 			continue
 		}
 
-		// 填充發送者資訊和時間戳
-		msg.SenderID = c.UserID
-		msg.SenderUsername = c.Username
-		msg.Timestamp = time.Now()
-		// RoomID 和 RoomName 應該由客戶端發送訊息時提供，因為現在一個連線可能對應多個房間
-		// msg.Type 預設為 normal
-
-		// 檢查 RoomID 是否有效
-		if msg.RoomID == "" {
-			log.Printf("Message from user %s missing RoomID", c.UserID.Hex())
-			continue
-		}
-
-		// 為了確保 RoomName 是最新的，我們從資料庫獲取
-		roomID, err := primitive.ObjectIDFromHex(msg.RoomID)
-		if err != nil {
-			log.Printf("Invalid RoomID format: %s", msg.RoomID)
-			continue
+		// 發送者資訊一律使用連線驗證過的身分；RoomID 由客戶端提供，因為一個連線可能對應多個房間
+		sender := MessageSender{ID: c.UserID, Username: c.Username, IsBot: c.IsBot}
+		if _, err := SendMessage(sender, msg.RoomID, msg.Content); err != nil {
+			log.Printf("Message from user %s to room %q rejected: %v", c.UserID.Hex(), msg.RoomID, err)
+			c.replyRejected(msg.RoomID, rejectionReason(err))
 		}
+	}
+}
 
-		room, err := database.FindChatRoomByID(roomID)
-		if err != nil || room == nil {
-			log.Printf("Room not found or database error for RoomID: %s", msg.RoomID)
-			continue
-		}
-		msg.RoomName = room.Name
+// rejectionReason 將 SendMessage 回傳的錯誤轉成給發送者看的說明
+func rejectionReason(err error) string {
+	switch {
+	case errors.Is(err, ErrSenderMuted):
+		return "你已被禁言，暫時無法在此聊天室發送訊息"
+	case errors.Is(err, ErrRoomArchived):
+		return "此聊天室已封存，無法發送訊息"
+	case errors.Is(err, ErrMessageTooLong):
+		return fmt.Sprintf("訊息不能超過 %d 個字", maxMessageContentLength)
+	case errors.Is(err, ErrEmptyMessage):
+		return "訊息內容不能是空的"
+	case errors.Is(err, ErrInvalidRoomID):
+		return "聊天室 ID 格式錯誤"
+	case errors.Is(err, ErrRoomNotFound), errors.Is(err, ErrNotParticipant):
+		// 與 HTTP API 一致，非成員與聊天室不存在使用相同說明，避免洩漏聊天室是否存在
+		return "找不到此聊天室"
+	default:
+		return "訊息沒有送出，請稍後再試"
+	}
+}
 
-		result, err := database.InsertMessage(msg)
-		if err != nil {
-			log.Printf("Error saving message to database: %v", err)
-			continue
-		}
+// replyRejected 以 error 訊息私下告訴使用者訊息沒有送出及原因
+func (c *Client) replyRejected(roomIDHex string, content string) {
+	c.hub.direct <- directMessage{userID: c.UserID, message: newErrorFrame(roomIDHex, content)}
+}
 
-		msg.ID = result.InsertedID.(primitive.ObjectID)
+// newErrorFrame 建立只給發送者看的 error 訊息，前端以通知顯示，不會加入聊天室的訊息列表
+func newErrorFrame(roomIDHex string, content string) models.Message {
+	return models.Message{
+		Type:           models.MessageTypeError,
+		RoomID:         roomIDHex,
+		SenderID:       primitive.NilObjectID,
+		SenderUsername: "系統訊息",
+		Content:        content,
+		Timestamp:      time.Now(),
+		IsRead:         true,
+	}
+}
 
-		// 將帶有 ID 的完整訊息廣播出去
-		c.hub.Broadcast <- msg
+// touchSession 在連線有活動時更新所屬 session 的最後活動時間，間隔不足 sessionTouchInterval 時略過
+func (c *Client) touchSession() {
+	if c.SessionID.IsZero() || time.Since(c.lastTouchedAt) < sessionTouchInterval {
+		return
 	}
+	c.lastTouchedAt = time.Now()
+	go database.TouchSession(c.SessionID)
 }
 
 // writePump 保持不變
@@ -185,6 +220,23 @@ type Hub struct {
 	Broadcast       chan models.Message
 	register        chan *Client
 	unregister      chan *Client
+	// direct 用於只發送給單一使用者的訊息 (例如斜線指令的私人回覆)
+	direct chan directMessage
+	// disconnect 用於強制中斷使用者的連線 (例如登出或 token 被撤銷)
+	disconnect chan disconnectRequest
+}
+
+// disconnectRequest 要求中斷使用者的連線；sessionID 為零值時不論連線屬於哪個 session 都會中斷
+type disconnectRequest struct {
+	userID    primitive.ObjectID
+	sessionID primitive.ObjectID
+	reason    string
+}
+
+// directMessage 是指定接收者的單播訊息
+type directMessage struct {
+	userID  primitive.ObjectID
+	message models.Message
 }
 
 // NewHub 創建並返回一個新的 Hub 實例
@@ -193,6 +245,9 @@ func NewHub() *Hub {
 		Broadcast:  make(chan models.Message),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
+		direct:     make(chan directMessage),
+		// 預留緩衝，讓 HTTP handler 不必等待 Hub 處理完其他事件
+		disconnect: make(chan disconnectRequest, 64),
 		clients:    make(map[*Client]bool),
 		// 【核心修改】初始化新的 map
 		clientsByUserID: make(map[primitive.ObjectID]*Client),
@@ -258,6 +313,33 @@ func (h *Hub) Run() {
 				log.Printf("Client %s (%s) unregistered. Total clients: %d", client.UserID.Hex(), client.Username, len(h.clients))
 			}
 
+		case dm := <-h.direct:
+			// 只送給指定使用者，使用者不在線時直接丟棄
+			if client, ok := h.clientsByUserID[dm.userID]; ok {
+				select {
+				case client.send <- dm.message:
+				default:
+					log.Printf("Client %s channel full, dropping direct message.", client.UserID.Hex())
+				}
+			}
+
+		case req := <-h.disconnect:
+			client, ok := h.clientsByUserID[req.userID]
+			if !ok || (!req.sessionID.IsZero() && client.SessionID != req.sessionID) {
+				continue
+			}
+			// 與重複登入時相同：先通知客戶端，再關閉 send channel 讓 writePump 送出關閉訊框
+			select {
+			case client.send <- models.Message{Type: models.MessageTypeForceLogout, Content: req.reason}:
+			default:
+				log.Printf("Client %s channel full, cannot send force_logout message.", client.UserID.Hex())
+			}
+			close(client.send)
+			delete(h.clients, client)
+			delete(h.clientsByUserID, client.UserID)
+			loadtestcontrol.SetConnectedClients(len(h.clients))
+			log.Printf("Client %s (%s) disconnected: %s", client.UserID.Hex(), client.Username, req.reason)
+
 		case message := <-h.Broadcast:
 			// 【核心修改】廣播邏輯
 			roomID, err := primitive.ObjectIDFromHex(message.RoomID)
@@ -277,12 +359,21 @@ func (h *Hub) Run() {
 				continue
 			}
 
+			// 通知訂閱此聊天室事件的外送 Webhook (非同步，不會阻塞廣播)
+			switch message.Type {
+			case models.MessageTypeNormal:
+				webhooks.Emit(room.ID, models.WebhookEventMessageCreated, message)
+			case models.MessageTypeUpdate:
+				webhooks.Emit(room.ID, models.WebhookEventMembershipUpdated, room)
+			}
+
 			// 遍歷聊天室的所有參與者
+			now := time.Now()
 			for _, participantID := range room.Participants {
 				// 檢查參與者是否在線
 				if client, ok := h.clientsByUserID[participantID]; ok {
 					select {
-					case client.send <- message:
+					case client.send <- messageForRecipient(message, room, participantID, now):
 					default:
 						// 如果發送失敗（通道已滿或關閉），則認為客戶端已離線
 						log.Printf("Client %s channel full or closed, unregistering.", client.UserID.Hex())
@@ -296,6 +387,15 @@ func (h *Hub) Run() {
 	}
 }
 
+// messageForRecipient 依收件者的個人設定調整要推送的訊息
+// 將聊天室設為靜音的成員收到的一般訊息會標記為 silent，不會產生通知
+func messageForRecipient(message models.Message, room *models.ChatRoom, recipientID primitive.ObjectID, now time.Time) models.Message {
+	if message.Type == models.MessageTypeNormal && room.SettingsFor(recipientID).IsMuted(now) {
+		message.Silent = true
+	}
+	return message
+}
+
 // 全局 Hub 實例
 var GlobalHub = NewHub()
 
@@ -304,36 +404,101 @@ func BroadcastMessage(message models.Message) {
 	GlobalHub.Broadcast <- message
 }
 
-// HandleConnections 處理 WebSocket 連線請求
-func HandleConnections(w http.ResponseWriter, r *http.Request) {
-	// 步驟 1: 從 Cookie 獲取 token
+// tokenKeys 是驗證 access token 使用的金鑰，未設定時使用 JWT_SECRET 的 HS256
+var tokenKeys utils.TokenKeys
+
+// SetTokenKeys 設定驗證 access token 使用的金鑰，通常在 main 啟動時呼叫
+func SetTokenKeys(keys utils.TokenKeys) {
+	tokenKeys = keys
+}
+
+// authenticateConnection 驗證 WebSocket 連線請求的身分
+// 機器人帶 Authorization: Bearer <API 金鑰>，一般使用者帶 token cookie；失敗時回傳對應的 HTTP 狀態碼
+// 一般使用者會一併回傳 access token 所屬的 session ID，機器人沒有 session
+func authenticateConnection(r *http.Request) (*models.User, primitive.ObjectID, int, error) {
+	if apiToken := utils.GetBearerToken(r); strings.HasPrefix(apiToken, database.APITokenPrefix) {
+		bot, err := database.FindUserByAPIToken(apiToken)
+		if err != nil {
+			return nil, primitive.NilObjectID, http.StatusInternalServerError, err
+		}
+		if bot == nil || !bot.IsBot {
+			return nil, primitive.NilObjectID, http.StatusUnauthorized, errors.New("invalid API token")
+		}
+		return bot, primitive.NilObjectID, http.StatusOK, nil
+	}
+
+	// 從 Cookie 獲取 token
 	cookie, err := r.Cookie("token")
 	if err != nil {
 		if err == http.ErrNoCookie {
-			log.Println("ERROR: Connection rejected. Reason: Token cookie not found.")
-			http.Error(w, "Authorization cookie required", http.StatusUnauthorized)
-			return
+			return nil, primitive.NilObjectID, http.StatusUnauthorized, errors.New("token cookie not found")
 		}
-		log.Printf("ERROR: Connection rejected. Reason: Error reading cookie. Error: %v", err)
-		http.Error(w, "Bad request", http.StatusBadRequest)
-		return
+		return nil, primitive.NilObjectID, http.StatusBadRequest, fmt.Errorf("error reading cookie: %w", err)
 	}
-	tokenString := cookie.Value
 
-	// 步驟 2: 載入設定並驗證 JWT Token
+	// 載入設定並驗證 JWT Token
 	cfg := config.LoadConfig()
-	userID, err := utils.GetUserIDFromToken(tokenString, cfg.JWTSecret)
+	keys := tokenKeys
+	if keys == nil {
+		keys = utils.NewHMACKeys(cfg.JWTSecret)
+	}
+	claims, err := utils.ParseAccessToken(cookie.Value, keys)
 	if err != nil {
-		log.Printf("ERROR: Connection rejected. Reason: Invalid Token. Error: %v", err)
-		http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
-		return
+		return nil, primitive.NilObjectID, http.StatusUnauthorized, fmt.Errorf("invalid token: %w", err)
 	}
 
-	// 步驟 3: (更安全) 根據驗證後的 userID 從資料庫獲取使用者資訊
-	user, err := database.GetUserByID(userID)
+	// 與 JWTMiddleware 相同，已撤銷的 token 不能建立連線
+	revoked, err := database.IsAccessTokenRevoked(claims.TokenID, claims.SessionID, claims.UserID, claims.IssuedAt)
+	if err != nil {
+		return nil, primitive.NilObjectID, http.StatusInternalServerError, fmt.Errorf("error checking token denylist: %w", err)
+	}
+	if revoked {
+		return nil, primitive.NilObjectID, http.StatusUnauthorized, errors.New("token has been revoked")
+	}
+
+	// (更安全) 根據驗證後的 userID 從資料庫獲取使用者資訊
+	user, err := database.GetUserByID(claims.UserID)
+	if err != nil {
+		return nil, primitive.NilObjectID, http.StatusNotFound, fmt.Errorf("user not found in DB for ID %s: %w", claims.UserID.Hex(), err)
+	}
+	if cfg.RequireEmailVerification && !user.Verified {
+		return nil, primitive.NilObjectID, http.StatusForbidden, fmt.Errorf("email of user %s is not verified", user.ID.Hex())
+	}
+	return user, claims.SessionID, http.StatusOK, nil
+}
+
+// SendToUser 將訊息只發送給指定使用者目前的連線
+func SendToUser(userID primitive.ObjectID, message models.Message) {
+	GlobalHub.direct <- directMessage{userID: userID, message: message}
+}
+
+// NotifyRoomRemoved 通知使用者已被移出聊天室，前端收到後會將聊天室從列表中移除
+func NotifyRoomRemoved(userID primitive.ObjectID, room *models.ChatRoom, content string) {
+	SendToUser(userID, models.Message{
+		Type:           models.MessageTypeRoomRemoved,
+		RoomID:         room.ID.Hex(),
+		RoomName:       room.Name,
+		SenderID:       primitive.NilObjectID,
+		SenderUsername: "系統訊息",
+		Content:        content,
+		Timestamp:      time.Now(),
+		IsRead:         true,
+	})
+}
+
+// DisconnectUser 強制中斷使用者的 WebSocket 連線並送出 force_logout 訊息
+// sessionID 不為零值時，只中斷屬於該 session 的連線
+func DisconnectUser(userID, sessionID primitive.ObjectID, reason string) {
+	GlobalHub.disconnect <- disconnectRequest{userID: userID, sessionID: sessionID, reason: reason}
+}
+
+// HandleConnections 處理 WebSocket 連線請求
+func HandleConnections(w http.ResponseWriter, r *http.Request) {
+	// 步驟 1~3: 驗證身分並從資料庫獲取使用者資訊 (一般使用者用 cookie，機器人用 API 金鑰)
+	user, sessionID, status, err := authenticateConnection(r)
 	if err != nil {
-		log.Printf("ERROR: Connection rejected. Reason: User not found in DB for ID %s. Error: %v", userID.Hex(), err)
-		http.Error(w, "User not found", http.StatusNotFound)
+		log.Printf("ERROR: Connection rejected. Reason: %v", err)
+		http.Error(w, http.StatusText(status), status)
 		return
 	}
 
@@ -348,11 +513,13 @@ func HandleConnections(w http.ResponseWriter, r *http.Request) {
 
 	// 步驟 5: 建立 Client 物件，並使用從資料庫中獲得的、可信的資訊
 	client := &Client{
-		hub:      GlobalHub,
-		conn:     conn,
-		send:     make(chan models.Message, 256),
-		UserID:   user.ID,       // 使用驗證過的 ID
-		Username: user.Username, // 使用從資料庫來的 Username
+		hub:       GlobalHub,
+		conn:      conn,
+		send:      make(chan models.Message, 256),
+		UserID:    user.ID,       // 使用驗證過的 ID
+		Username:  user.Username, // 使用從資料庫來的 Username
+		IsBot:     user.IsBot,
+		SessionID: sessionID,
 	}
 	client.hub.register <- client
 
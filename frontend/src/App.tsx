@@ -3,6 +3,9 @@ import { useEffect } from "react";
 import { Routes, Route, useNavigate, useLocation } from "react-router-dom";
 import AuthPage from "./pages/AuthPage";
 import HomePage from "./pages/HomePage";
+import ResetPasswordPage from "./pages/ResetPasswordPage";
+import VerifyEmailPage from "./pages/VerifyEmailPage";
+import InvitePage from "./pages/InvitePage";
 import { isAuthenticated, saveUserSession } from "./utils/utils_auth";
 import Cookies from "js-cookie"; 
 
@@ -39,8 +42,8 @@ function App() {
     // --- 處理所有其他的路由情況 ---
     const isAuth = isAuthenticated();
 
-    // 情況1: 如果使用者「未登入」，但他目前「不在登入頁」，就將他導向登入頁
-    if (!isAuth && location.pathname !== "/auth") {
+    // 情況1: 如果使用者「未登入」，但他目前「不在登入頁」，就將他導向登入頁 (重設密碼與 Email 驗證頁不需要登入)
+    if (!isAuth && !["/auth", "/reset-password", "/verify-email"].includes(location.pathname)) {
       navigate("/auth");
     }
 
@@ -56,6 +59,9 @@ function App() {
     <Routes>
       <Route path="/auth" element={<AuthPage />} />
       <Route path="/home" element={<HomePage />} />
+      <Route path="/reset-password" element={<ResetPasswordPage />} />
+      <Route path="/verify-email" element={<VerifyEmailPage />} />
+      <Route path="/invite/:code" element={<InvitePage />} />
       {/* 任意未匹配路徑導向到認證頁面 (可選，但有助於初始導向) */}
       <Route path="*" element={<AuthPage />} />
     </Routes>
@@ -18,6 +18,14 @@ interface AuthResponse {
   id?: string;
   username?: string;
   token?: string;  // 添加 token 欄位
+  twoFactorRequired?: boolean; // 啟用兩步驟驗證時為 true，需要再呼叫 loginTwoFactor
+  challengeToken?: string;
+}
+
+interface TwoFactorLoginPayload {
+  challengeToken: string;
+  code?: string;
+  recoveryCode?: string;
 }
 
 // 註冊
@@ -85,13 +93,23 @@ export async function login(payload: LoginPayload): Promise<AuthResponse> {
     if (!response.ok) {
       notifications.show({
         title: "登入失敗",
-        message: data.message || "電子郵件或密碼不正確",
+        message:
+          response.status === 403
+            ? "請先點擊驗證信中的連結完成 Email 驗證。"
+            : response.status === 429
+              ? `嘗試次數過多，請在 ${response.headers.get("Retry-After") ?? "數"} 秒後再試。`
+              : data.message || "電子郵件或密碼不正確",
         color: "red",
         autoClose: 2000,
       });
       throw new Error(data.message || "登入失敗");
     }
 
+    // 密碼正確但需要兩步驟驗證，由呼叫端顯示驗證碼輸入畫面
+    if (data.twoFactorRequired) {
+      return data;
+    }
+
     notifications.show({
       title: "登入成功",
       message: `歡迎回來，${data.username}！`,
@@ -113,6 +131,228 @@ export async function login(payload: LoginPayload): Promise<AuthResponse> {
   }
 }
 
+// 以驗證器 App 的驗證碼或復原碼完成登入的第二步
+export async function loginTwoFactor(
+  payload: TwoFactorLoginPayload
+): Promise<AuthResponse> {
+  const response = await fetch(`${API_BASE_URL}/login/2fa`, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    credentials: "include",
+    body: JSON.stringify(payload),
+  });
+  const data = await response.json();
+  if (!response.ok) {
+    notifications.show({
+      title: "驗證失敗",
+      message:
+        response.status === 429
+          ? "嘗試次數過多，請稍後再試。"
+          : data.message || "驗證碼不正確",
+      color: "red",
+      autoClose: 2000,
+    });
+    throw new Error(data.message || "兩步驟驗證失敗");
+  }
+  notifications.show({
+    title: "登入成功",
+    message: `歡迎回來，${data.username}！`,
+    color: "green",
+    autoClose: 1500,
+  });
+  return data;
+}
+
+interface IdentityLinkPayload {
+  linkToken: string;
+  password: string;
+  code?: string;
+  recoveryCode?: string;
+}
+
+// 外部登入的 Email 已被註冊時，以原帳號的密碼確認並連結外部身分
+export async function confirmIdentityLink(
+  payload: IdentityLinkPayload
+): Promise<AuthResponse> {
+  const response = await fetch(`${API_BASE_URL}/auth/link/confirm`, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    credentials: "include",
+    body: JSON.stringify(payload),
+  });
+  const data = await response.json();
+  if (!response.ok) {
+    notifications.show({
+      title: "連結失敗",
+      message:
+        response.status === 429
+          ? "嘗試次數過多，請稍後再試。"
+          : data.message || "密碼不正確",
+      color: "red",
+      autoClose: 2000,
+    });
+    throw new Error(data.message || "連結帳號失敗");
+  }
+  if (data.id) {
+    notifications.show({
+      title: "連結成功",
+      message: `歡迎回來，${data.username}！`,
+      color: "green",
+      autoClose: 1500,
+    });
+  }
+  return data;
+}
+
+export interface LoginProvider {
+  id: string;
+  displayName: string;
+}
+
+// 取得可用於登入的外部提供者 (Google 與其他 OpenID Connect 提供者)
+export async function getLoginProviders(): Promise<LoginProvider[]> {
+  try {
+    const response = await fetch(`${API_BASE_URL}/auth/providers`);
+    if (!response.ok) {
+      return [];
+    }
+    return await response.json();
+  } catch (error) {
+    console.error("Failed to load login providers:", error);
+    return [];
+  }
+}
+
+// 同一時間只送出一個換發請求，避免多個 401 同時用同一個 refresh token 觸發重複使用偵測
+let refreshPromise: Promise<boolean> | null = null;
+
+// 以 refresh token cookie 換發新的 access token，成功回傳 true
+export function refreshSession(): Promise<boolean> {
+  if (!refreshPromise) {
+    refreshPromise = fetch(`${API_BASE_URL}/auth/refresh`, {
+      method: "POST",
+      credentials: "include",
+    })
+      .then((response) => response.ok)
+      .catch(() => false)
+      .finally(() => {
+        refreshPromise = null;
+      });
+  }
+  return refreshPromise;
+}
+
+// authFetch 與 fetch 相同，但在 access token 過期 (401) 時會先換發再重試一次
+export async function authFetch(
+  input: string,
+  init: RequestInit = {}
+): Promise<Response> {
+  const options: RequestInit = { ...init, credentials: "include" };
+  const response = await fetch(input, options);
+  if (response.status !== 401) {
+    return response;
+  }
+  if (!(await refreshSession())) {
+    return response;
+  }
+  return fetch(input, options);
+}
+
+// 申請重設密碼，不論 Email 是否存在後端都回傳相同的訊息
+export async function requestPasswordReset(email: string): Promise<void> {
+  const response = await fetch(`${API_BASE_URL}/password-reset/request`, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ email }),
+  });
+  const data = await response.json();
+  if (!response.ok) {
+    notifications.show({
+      title: "申請失敗",
+      message: data.message || "發生未知錯誤",
+      color: "red",
+      autoClose: 2000,
+    });
+    throw new Error(data.message || "申請重設密碼失敗");
+  }
+  notifications.show({
+    title: "已送出申請",
+    message: "如果這個電子郵件已註冊，您將會收到重設密碼的連結。",
+    color: "green",
+    autoClose: 3000,
+  });
+}
+
+// 以信中的 token 設定新密碼，成功後所有裝置都需要重新登入
+export async function confirmPasswordReset(
+  token: string,
+  password: string
+): Promise<void> {
+  const response = await fetch(`${API_BASE_URL}/password-reset/confirm`, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    credentials: "include",
+    body: JSON.stringify({ token, password }),
+  });
+  const data = await response.json();
+  if (!response.ok) {
+    notifications.show({
+      title: "重設失敗",
+      message: data.message || "連結無效或已過期",
+      color: "red",
+      autoClose: 2000,
+    });
+    throw new Error(data.message || "重設密碼失敗");
+  }
+  notifications.show({
+    title: "密碼已重設",
+    message: "請使用新密碼登入。",
+    color: "green",
+    autoClose: 2000,
+  });
+}
+
+// 以驗證信中的 token 完成 Email 驗證
+export async function confirmEmailVerification(token: string): Promise<void> {
+  const response = await fetch(`${API_BASE_URL}/email-verification/confirm`, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ token }),
+  });
+  const data = await response.json();
+  if (!response.ok) {
+    throw new Error(data.message || "Email 驗證失敗");
+  }
+}
+
+// 重新寄送驗證信，不論 Email 是否存在後端都回傳相同的訊息
+export async function resendEmailVerification(email: string): Promise<void> {
+  const response = await fetch(`${API_BASE_URL}/email-verification/resend`, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ email }),
+  });
+  const data = await response.json();
+  if (!response.ok) {
+    notifications.show({
+      title: "寄送失敗",
+      message:
+        response.status === 429
+          ? "寄送次數過多，請稍後再試。"
+          : data.message || "發生未知錯誤",
+      color: "red",
+      autoClose: 2000,
+    });
+    throw new Error(data.message || "重新寄送驗證信失敗");
+  }
+  notifications.show({
+    title: "已送出申請",
+    message: "如果這個電子郵件已註冊且尚未驗證，您將會收到新的驗證信。",
+    color: "green",
+    autoClose: 3000,
+  });
+}
+
 export async function logout(): Promise<void> {
   try {
     await fetch(`${API_BASE_URL}/logout`, {
@@ -1,6 +1,12 @@
 // frontend/src/api/chatroom.ts
 import { notifications } from "@mantine/notifications";
-import type { ChatRoom } from "../types/index";
+import type {
+  ChannelDirectoryPage,
+  ChatRoom,
+  RoomKind,
+  RoomMemberSettings,
+} from "../types/index";
+import { authFetch } from "./api_auth";
 const API_BASE_URL = "http://localhost:8080";
 
 /**
@@ -19,7 +25,7 @@ export async function getUserChatRooms(): Promise<ChatRoom[]> {
   // }
 
   try {
-    const response = await fetch(`${API_BASE_URL}/user-chatrooms`, {
+    const response = await authFetch(`${API_BASE_URL}/user-chatrooms`, {
       method: "GET",
       headers: {
         "Content-Type": "application/json",
@@ -52,15 +58,22 @@ export async function getUserChatRooms(): Promise<ChatRoom[]> {
   }
 }
 
+// 更新聊天室時省略的欄位維持不變；名稱與說明只有聊天室管理員可以修改，成員需透過邀請與移除變動
+export interface UpdateChatRoomPayload {
+  name?: string; // 空字串表示改回自動產生的名稱
+  topic?: string;
+  description?: string;
+}
+
 /**
  * 更新聊天室
  * @param {string} roomId - 聊天室 ID
- * @param {string[]} participantIds - 新的參與者列表
+ * @param {UpdateChatRoomPayload} changes - 要更新的欄位
  * @returns {Promise<ChatRoom | null>} 更新後的聊天室物件
  */
 export async function updateChatRoom(
   roomId: string,
-  participantIds: string[]
+  changes: UpdateChatRoomPayload
 ): Promise<ChatRoom | null> {
   // const userSession = getUserSession();
   // if (!userSession || !userSession.token) {
@@ -73,18 +86,18 @@ export async function updateChatRoom(
   // }
 
   try {
-    const response = await fetch(`${API_BASE_URL}/chatrooms/${roomId}/update`, {
+    const response = await authFetch(`${API_BASE_URL}/chatrooms/${roomId}/update`, {
       method: "PUT",
       headers: {
         "Content-Type": "application/json",
       },
       credentials: "include",
-      body: JSON.stringify({ participantIds }),
+      body: JSON.stringify(changes),
     });
 
     if (!response.ok) {
-      const errorData = await response.json();
-      throw new Error(errorData.error || "無法更新聊天室");
+      // 後端以純文字回應錯誤訊息
+      throw new Error((await response.text()).trim() || "無法更新聊天室");
     }
 
     const data: ChatRoom = await response.json();
@@ -104,6 +117,72 @@ export async function updateChatRoom(
   }
 }
 
+// 更新個人聊天室設定時省略的欄位維持不變
+export interface UpdateRoomSettingsPayload {
+  muted?: boolean;
+  muteDurationSeconds?: number; // muted 為 true 時的靜音時間，0 代表直到取消靜音
+  favorite?: boolean;
+  sortOrder?: number;
+  hidden?: boolean;
+  nickname?: string; // 空字串表示移除暱稱
+}
+
+/**
+ * 更新目前使用者對聊天室的個人設定（靜音、我的最愛、隱藏、暱稱）
+ * @param {string} roomId - 聊天室 ID
+ * @param {UpdateRoomSettingsPayload} changes - 要更新的欄位
+ * @returns {Promise<RoomMemberSettings | null>} 更新後的設定
+ */
+export async function updateRoomSettings(
+  roomId: string,
+  changes: UpdateRoomSettingsPayload
+): Promise<RoomMemberSettings | null> {
+  try {
+    const response = await authFetch(`${API_BASE_URL}/chatrooms/${roomId}/settings`, {
+      method: "PUT",
+      headers: {
+        "Content-Type": "application/json",
+      },
+      credentials: "include",
+      body: JSON.stringify(changes),
+    });
+    if (!response.ok) {
+      throw new Error((await response.text()).trim() || "無法更新設定");
+    }
+    return (await response.json()) as RoomMemberSettings;
+  } catch (error: unknown) {
+    console.error("Error updating room settings:", error);
+    notifications.show({
+      title: "錯誤",
+      message: error instanceof Error ? `更新設定失敗: ${error.message}` : "更新設定失敗",
+      color: "red",
+    });
+    return null;
+  }
+}
+
+/**
+ * 將聊天室標記為已讀，聊天室列表中的未讀數會歸零
+ * 在背景呼叫，失敗時不打擾使用者
+ * @param {string} roomId - 聊天室 ID
+ * @returns {Promise<boolean>} 成功返回true，失敗返回false
+ */
+export async function markRoomRead(roomId: string): Promise<boolean> {
+  try {
+    const response = await authFetch(`${API_BASE_URL}/chatrooms/${roomId}/read`, {
+      method: "POST",
+      credentials: "include",
+    });
+    if (!response.ok) {
+      throw new Error((await response.text()).trim() || "無法標記為已讀");
+    }
+    return true;
+  } catch (error: unknown) {
+    console.error(`Error marking room ${roomId} as read:`, error);
+    return false;
+  }
+}
+
 /**
  * 退出聊天室
  * @param {string} roomId - 要退出的聊天室ID
@@ -121,7 +200,7 @@ export async function leaveChatRoom(roomId: string): Promise<boolean> {
   // }
 
   try {
-    const response = await fetch(`${API_BASE_URL}/chatrooms/${roomId}/leave`, {
+    const response = await authFetch(`${API_BASE_URL}/chatrooms/${roomId}/leave`, {
       method: "POST",
       headers: {
         "Content-Type": "application/json",
@@ -153,10 +232,14 @@ export async function leaveChatRoom(roomId: string): Promise<boolean> {
 /**
  * 創建或獲取一個聊天室
  * @param {string[]} participantIds - 參與者的 ID 列表 (例如 [使用者A的ID, 使用者B的ID])
+ * @param {RoomKind} [kind] - 省略時兩人視為私訊 (已存在時回傳原本的私訊)，其他為新的群組
+ * @param {string} [name] - 群組與頻道的自訂名稱，頻道必填
  * @returns {Promise<ChatRoom | null>} 創建或獲取的聊天室物件
  */
 export async function createChatRoom( //
-  participantIds: string[] //
+  participantIds: string[], //
+  kind?: RoomKind,
+  name?: string
 ): Promise<ChatRoom | null> {
   // const userSession = getUserSession();
   // if (!userSession || !userSession.token) {
@@ -169,7 +252,7 @@ export async function createChatRoom( //
   // }
 
   try {
-    const response = await fetch(`${API_BASE_URL}/create-chatrooms`, {
+    const response = await authFetch(`${API_BASE_URL}/create-chatrooms`, {
       //
       method: "POST", //
       headers: {
@@ -178,6 +261,8 @@ export async function createChatRoom( //
       credentials: "include",
       body: JSON.stringify({
         participantIds,
+        kind,
+        name,
       }),
     });
 
@@ -219,7 +304,7 @@ export const addParticipantsToChatRoom = async (
   //   throw new Error("Authentication token not found. Please log in.");
   // }
 
-  const response = await fetch(
+  const response = await authFetch(
     `${API_BASE_URL}/chatrooms/${roomId}/participants`,
     {
       method: "PUT", // 後端定義的是 PUT 方法
@@ -238,3 +323,79 @@ export const addParticipantsToChatRoom = async (
 
   return response.json(); // 後端應返回更新後的 ChatRoom 物件
 };
+
+/**
+ * 搜尋公開頻道目錄
+ * @param {string} query - 搜尋名稱或主題，空字串列出全部
+ * @param {number} page - 頁碼，從 1 開始
+ * @returns {Promise<ChannelDirectoryPage | null>} 這一頁的頻道與符合條件的總數
+ */
+export async function listPublicChannels(
+  query: string,
+  page: number
+): Promise<ChannelDirectoryPage | null> {
+  try {
+    const params = new URLSearchParams({ q: query, page: String(page) });
+    const response = await authFetch(`${API_BASE_URL}/channels?${params}`, {
+      method: "GET",
+      credentials: "include",
+    });
+    if (!response.ok) {
+      throw new Error((await response.text()).trim() || "無法取得頻道目錄");
+    }
+    return (await response.json()) as ChannelDirectoryPage;
+  } catch (error: unknown) {
+    console.error("Error listing public channels:", error);
+    notifications.show({
+      title: "錯誤",
+      message: error instanceof Error ? error.message : "無法取得頻道目錄",
+      color: "red",
+    });
+    return null;
+  }
+}
+
+/**
+ * 不經邀請加入公開頻道
+ * @param {string} roomId - 頻道 ID
+ * @returns {Promise<ChatRoom | null>} 加入後的頻道
+ */
+export async function joinChannel(roomId: string): Promise<ChatRoom | null> {
+  try {
+    const response = await authFetch(`${API_BASE_URL}/chatrooms/${roomId}/join`, {
+      method: "POST",
+      credentials: "include",
+    });
+    if (!response.ok) {
+      throw new Error((await response.text()).trim() || "無法加入頻道");
+    }
+    return (await response.json()) as ChatRoom;
+  } catch (error: unknown) {
+    console.error("Error joining channel:", error);
+    notifications.show({
+      title: "錯誤",
+      message: error instanceof Error ? `加入頻道失敗: ${error.message}` : "加入頻道失敗",
+      color: "red",
+    });
+    return null;
+  }
+}
+
+/**
+ * 兌換聊天室邀請連結，加入邀請所屬的聊天室
+ * @param {string} code - 邀請連結中的邀請碼
+ * @returns {Promise<ChatRoom>} 加入後的聊天室
+ */
+export async function redeemRoomInvite(code: string): Promise<ChatRoom> {
+  const response = await authFetch(
+    `${API_BASE_URL}/invites/${encodeURIComponent(code)}/redeem`,
+    { method: "POST", credentials: "include" }
+  );
+  if (response.status === 410) {
+    throw new Error("邀請連結已過期或已達使用次數上限");
+  }
+  if (!response.ok) {
+    throw new Error("邀請連結無效或已被撤銷");
+  }
+  return (await response.json()) as ChatRoom;
+}
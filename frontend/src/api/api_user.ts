@@ -1,6 +1,7 @@
 // src/api/user.ts
 import { notifications } from "@mantine/notifications";
 import type { User } from "../types/index";
+import { authFetch } from "./api_auth";
 
 const API_BASE_URL = "http://localhost:8080";
 
@@ -13,7 +14,7 @@ export const getAllUsers = async (): Promise<User[]> => {
     //   throw new Error("未登入或 token 無效");
     // }
 
-    const response = await fetch(`${API_BASE_URL}/all-users`, {
+    const response = await authFetch(`${API_BASE_URL}/all-users`, {
       method: "GET",
       headers: {
         "Content-Type": "application/json",
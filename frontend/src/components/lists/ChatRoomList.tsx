@@ -10,15 +10,27 @@ import {
   Group,
   Menu,
   ActionIcon,
+  Badge,
 } from "@mantine/core";
 import {
   IconMessageCircle,
   IconLogout,
   IconDotsVertical,
   IconUserPlus, 
+  IconStar,
+  IconStarFilled,
+  IconBell,
+  IconBellOff,
+  IconEyeOff,
 } from "@tabler/icons-react";
 
 import type { ChatRoom } from "../../types"; // 注意路徑，確保 ChatRoom 類型已定義
+import type { UpdateRoomSettingsPayload } from "../../api/api_chatroom";
+
+// isRoomMuted 檢查目前使用者是否將聊天室設為靜音 (沒有期限或尚未到期)
+const isRoomMuted = (room: ChatRoom) =>
+  !!room.settings?.muted &&
+  (!room.settings.mutedUntil || new Date(room.settings.mutedUntil) > new Date());
 
 interface ChatRoomListProps {
   chatRooms: ChatRoom[];
@@ -27,6 +39,8 @@ interface ChatRoomListProps {
   onLeaveRoom: (room: ChatRoom) => void;
   // 新增 onInviteClick 屬性
   onInviteClick: (room: ChatRoom) => void;
+  // 更新個人設定：我的最愛、靜音、隱藏
+  onUpdateSettings: (room: ChatRoom, changes: UpdateRoomSettingsPayload) => void;
 }
 
 const ChatRoomList: React.FC<ChatRoomListProps> = ({
@@ -35,6 +49,7 @@ const ChatRoomList: React.FC<ChatRoomListProps> = ({
   onSelectRoom,
   onLeaveRoom,
   onInviteClick, // 接收 onInviteClick
+  onUpdateSettings,
 }) => {
   return (
     <div>
@@ -67,9 +82,32 @@ const ChatRoomList: React.FC<ChatRoomListProps> = ({
                 <Avatar color="blue" radius="xl">
                   <IconMessageCircle size={24} />
                 </Avatar>
-                <Text ml="md" fw={500}>
-                  {room.name}
-                </Text>
+                <div style={{ marginLeft: rem(16), flex: 1, minWidth: 0 }}>
+                  <Group gap={6} wrap="nowrap">
+                    <Text fw={room.unreadCount ? 700 : 500} truncate title={room.settings?.nickname ? room.name : undefined}>
+                      {room.settings?.nickname || room.name}
+                    </Text>
+                    {room.settings?.favorite && (
+                      <IconStarFilled size={14} color="var(--mantine-color-yellow-6)" />
+                    )}
+                    {isRoomMuted(room) && (
+                      <IconBellOff size={14} color="var(--mantine-color-gray-6)" />
+                    )}
+                  </Group>
+                  {/* 最後一則訊息的預覽，系統訊息不顯示發送者 */}
+                  {room.lastMessage && (
+                    <Text size="xs" c="dimmed" truncate>
+                      {room.lastMessage.type === "system"
+                        ? room.lastMessage.content
+                        : `${room.lastMessage.senderUsername}：${room.lastMessage.content}`}
+                    </Text>
+                  )}
+                </div>
+                {!!room.unreadCount && (
+                  <Badge size="sm" circle={room.unreadCount < 10} color={isRoomMuted(room) ? "gray" : "red"} ml="xs">
+                    {room.unreadCount > 99 ? "99+" : room.unreadCount}
+                  </Badge>
+                )}
               </UnstyledButton>
               <Menu>
                 <Menu.Target>
@@ -89,6 +127,24 @@ const ChatRoomList: React.FC<ChatRoomListProps> = ({
                   >
                     邀請
                   </Menu.Item>
+                  <Menu.Item
+                    leftSection={room.settings?.favorite ? <IconStar size={14} /> : <IconStarFilled size={14} />}
+                    onClick={() => onUpdateSettings(room, { favorite: !room.settings?.favorite })}
+                  >
+                    {room.settings?.favorite ? "移除我的最愛" : "加入我的最愛"}
+                  </Menu.Item>
+                  <Menu.Item
+                    leftSection={isRoomMuted(room) ? <IconBell size={14} /> : <IconBellOff size={14} />}
+                    onClick={() => onUpdateSettings(room, { muted: !isRoomMuted(room) })}
+                  >
+                    {isRoomMuted(room) ? "取消靜音" : "靜音通知"}
+                  </Menu.Item>
+                  <Menu.Item
+                    leftSection={<IconEyeOff size={14} />}
+                    onClick={() => onUpdateSettings(room, { hidden: true })}
+                  >
+                    隱藏
+                  </Menu.Item>
                   <Menu.Item
                     color="red"
                     leftSection={<IconLogout size={14} />}
@@ -0,0 +1,123 @@
+// src/components/modals/ChannelDirectoryModal.tsx
+import React, { useEffect, useState } from "react";
+import {
+  Modal,
+  TextInput,
+  ScrollArea,
+  Stack,
+  Text,
+  Group,
+  Button,
+  Badge,
+  Pagination,
+  Loader,
+} from "@mantine/core";
+import { IconSearch } from "@tabler/icons-react";
+import type { ChannelDirectoryPage, ChatRoom } from "../../types";
+import { joinChannel, listPublicChannels } from "../../api/api_chatroom";
+
+interface ChannelDirectoryModalProps {
+  opened: boolean;
+  onClose: () => void;
+  onJoined: (room: ChatRoom) => void; // 加入頻道後由父組件切換到該頻道
+}
+
+const ChannelDirectoryModal: React.FC<ChannelDirectoryModalProps> = ({
+  opened,
+  onClose,
+  onJoined,
+}) => {
+  const [searchTerm, setSearchTerm] = useState("");
+  const [page, setPage] = useState(1);
+  const [result, setResult] = useState<ChannelDirectoryPage | null>(null);
+  const [isLoading, setIsLoading] = useState(false);
+  const [joiningId, setJoiningId] = useState<string | null>(null);
+
+  useEffect(() => {
+    if (!opened) return;
+    // 輸入搜尋字詞時稍等一下再查詢，避免每個字都送出請求
+    const timer = setTimeout(async () => {
+      setIsLoading(true);
+      setResult(await listPublicChannels(searchTerm.trim(), page));
+      setIsLoading(false);
+    }, 300);
+    return () => clearTimeout(timer);
+  }, [opened, searchTerm, page]);
+
+  const handleJoin = async (channelId: string) => {
+    setJoiningId(channelId);
+    const room = await joinChannel(channelId);
+    setJoiningId(null);
+    if (room) {
+      onJoined(room);
+      onClose();
+    }
+  };
+
+  const totalPages = result ? Math.ceil(result.total / result.limit) : 0;
+
+  return (
+    <Modal opened={opened} onClose={onClose} title="瀏覽公開頻道" size="lg">
+      <TextInput
+        placeholder="搜尋頻道名稱或主題"
+        leftSection={<IconSearch size={16} />}
+        value={searchTerm}
+        onChange={(event) => {
+          setSearchTerm(event.currentTarget.value);
+          setPage(1);
+        }}
+        mb="md"
+      />
+
+      <ScrollArea h={360}>
+        {isLoading && !result ? (
+          <Group justify="center" mt="md">
+            <Loader size="sm" />
+          </Group>
+        ) : !result || result.channels.length === 0 ? (
+          <Text c="dimmed" ta="center" mt="md">
+            沒有符合的公開頻道。
+          </Text>
+        ) : (
+          <Stack gap="sm">
+            {result.channels.map((channel) => (
+              <Group key={channel.id} justify="space-between" wrap="nowrap">
+                <div style={{ minWidth: 0 }}>
+                  <Group gap="xs">
+                    <Text fw={500} truncate>
+                      {channel.name}
+                    </Text>
+                    <Badge variant="light" size="sm">
+                      {channel.memberCount} 位成員
+                    </Badge>
+                  </Group>
+                  {channel.topic && (
+                    <Text size="sm" c="dimmed" truncate>
+                      {channel.topic}
+                    </Text>
+                  )}
+                </div>
+                <Button
+                  size="xs"
+                  variant={channel.joined ? "light" : "filled"}
+                  loading={joiningId === channel.id}
+                  onClick={() => handleJoin(channel.id)}
+                >
+                  {channel.joined ? "開啟" : "加入"}
+                </Button>
+              </Group>
+            ))}
+          </Stack>
+        )}
+      </ScrollArea>
+
+      {totalPages > 1 && (
+        <Group justify="center" mt="md">
+          <Pagination total={totalPages} value={page} onChange={setPage} />
+        </Group>
+      )}
+    </Modal>
+  );
+};
+
+export default ChannelDirectoryModal;
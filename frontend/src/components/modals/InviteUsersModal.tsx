@@ -15,7 +15,10 @@ import {
 import { IconSearch } from "@tabler/icons-react";
 import { notifications } from "@mantine/notifications";
 import type { User, ChatRoom } from "../../types"; // 引入 User 和 ChatRoom 類型
-import { addParticipantsToChatRoom } from "../../api/api_chatroom";
+import {
+  addParticipantsToChatRoom,
+  createChatRoom,
+} from "../../api/api_chatroom";
 
 interface InviteUsersModalProps {
   opened: boolean;
@@ -83,6 +86,25 @@ const InviteUsersModal: React.FC<InviteUsersModalProps> = ({
 
     setIsLoading(true);
     try {
+      // 私訊不能加入其他成員，改為以目前的成員與被邀請者建立新的群組
+      if (chatRoom.kind === "dm") {
+        const newGroup = await createChatRoom(
+          [...chatRoom.participants, ...selectedUserIds],
+          "group"
+        );
+        if (!newGroup) return;
+        notifications.show({
+          title: "成功",
+          message: "已建立新的群組。",
+          color: "green",
+        });
+        onInviteSuccess(newGroup);
+        onClose();
+        setSelectedUserIds([]);
+        setSearchTerm("");
+        return;
+      }
+
       // 呼叫後端 API
       const updatedRoom = await addParticipantsToChatRoom(
         chatRoom.id,
@@ -119,7 +141,11 @@ const InviteUsersModal: React.FC<InviteUsersModalProps> = ({
     <Modal
       opened={opened}
       onClose={onClose}
-      title={`邀請成員至：${chatRoom.name}`}
+      title={
+        chatRoom.kind === "dm"
+          ? `以 ${chatRoom.name} 的成員建立新群組`
+          : `邀請成員至：${chatRoom.name}`
+      }
       size="lg"
       overlayProps={{
         backgroundOpacity: 0.55,
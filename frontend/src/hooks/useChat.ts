@@ -1,13 +1,35 @@
 // src/hooks/useChat.ts
-import { useState, useCallback } from "react";
+import { useState, useCallback, useRef, useEffect } from "react";
 import {
   getUserChatRooms,
   createChatRoom,
   leaveChatRoom,
+  markRoomRead,
 } from "../api/api_chatroom";
 import type { ChatRoom, User, Message } from "../types";
 import { notifications } from "@mantine/notifications";
 import { API_BASE_URL } from "../config";
+import { authFetch } from "../api/api_auth";
+
+// MARK_READ_DELAY_MS 是開啟中的聊天室收到新訊息後，合併成一次標記已讀請求的等待時間
+const MARK_READ_DELAY_MS = 3000;
+
+// lastActivityAt 回傳聊天室最後的活動時間：最後一則訊息或聊天室資訊更新的時間，取較晚者
+const lastActivityAt = (room: ChatRoom) =>
+  Math.max(
+    new Date(room.updatedAt).getTime(),
+    room.lastMessage ? new Date(room.lastMessage.timestamp).getTime() : 0
+  );
+
+// compareChatRooms 依個人設定排序：我的最愛依自訂順序置頂，其餘依最後活動時間
+const compareChatRooms = (a: ChatRoom, b: ChatRoom) => {
+  const aFavorite = !!a.settings?.favorite;
+  const bFavorite = !!b.settings?.favorite;
+  if (aFavorite !== bFavorite) return aFavorite ? -1 : 1;
+  const orderDiff = (a.settings?.sortOrder ?? 0) - (b.settings?.sortOrder ?? 0);
+  if (aFavorite && orderDiff !== 0) return orderDiff;
+  return lastActivityAt(b) - lastActivityAt(a);
+};
 
 export const useChat = (
   userSession: ReturnType<typeof import("../utils/utils_auth").getUserSession>
@@ -15,6 +37,36 @@ export const useChat = (
   const [chatRooms, setChatRooms] = useState<ChatRoom[]>([]);
   const [selectedRoom, setSelectedRoom] = useState<ChatRoom | null>(null);
   const [messages, setMessages] = useState(new Map<string, Message[]>());
+  // 收到訊息時用來判斷是否需要通知，避免 updateChatState 隨選取的聊天室改變
+  const selectedRoomIdRef = useRef<string | null>(null);
+
+  // 每個聊天室等待送出的標記已讀請求，避免每則訊息都呼叫一次後端
+  const markReadTimersRef = useRef(new Map<string, ReturnType<typeof setTimeout>>());
+
+  useEffect(() => {
+    selectedRoomIdRef.current = selectedRoom?.id ?? null;
+  }, [selectedRoom]);
+
+  useEffect(() => {
+    const timers = markReadTimersRef.current;
+    return () => {
+      timers.forEach((timer) => clearTimeout(timer));
+      timers.clear();
+    };
+  }, []);
+
+  // scheduleMarkRead 在等待時間後標記已讀，期間收到的訊息合併成同一個請求
+  const scheduleMarkRead = useCallback((roomId: string) => {
+    const timers = markReadTimersRef.current;
+    if (timers.has(roomId)) return;
+    timers.set(
+      roomId,
+      setTimeout(() => {
+        timers.delete(roomId);
+        markRoomRead(roomId);
+      }, MARK_READ_DELAY_MS)
+    );
+  }, []);
 
   const fetchUserChatRooms = useCallback(async () => {
     const rooms = await getUserChatRooms();
@@ -22,17 +74,13 @@ export const useChat = (
       setChatRooms([]);
       return;
     }
-    const sortedRooms = rooms.sort(
-      (a, b) =>
-        new Date(b.updatedAt).getTime() - new Date(a.updatedAt).getTime()
-    );
-    setChatRooms(sortedRooms);
+    setChatRooms(rooms.sort(compareChatRooms));
   }, []);
 
   const fetchChatHistory = useCallback(
     async (roomId: string) => {
       try {
-        const response = await fetch(
+        const response = await authFetch(
           `${API_BASE_URL}/chat-history?roomId=${roomId}`,
           {
             credentials: "include",
@@ -49,13 +97,22 @@ export const useChat = (
     []
   );
 
+  // markSelectedRoomRead 將聊天室的未讀數歸零，並通知後端標記為已讀，只在有未讀訊息時呼叫
+  const markSelectedRoomRead = useCallback((roomId: string) => {
+    setChatRooms((prev) =>
+      prev.map((room) => (room.id === roomId ? { ...room, unreadCount: 0 } : room))
+    );
+    markRoomRead(roomId);
+  }, []);
+
   const handleSelectRoom = useCallback(
     async (room: ChatRoom) => {
       setSelectedRoom(room);
+      if (room.unreadCount) markSelectedRoomRead(room.id);
       const history = await fetchChatHistory(room.id);
       setMessages((prev) => new Map(prev).set(room.id, history));
     },
-    [fetchChatHistory]
+    [fetchChatHistory, markSelectedRoomRead]
   );
 
   const startChatWithUser = useCallback(
@@ -89,6 +146,12 @@ export const useChat = (
     [selectedRoom]
   );
 
+  // removeRoom 將聊天室從列表中移除，例如被管理員移出時
+  const removeRoom = useCallback((roomId: string) => {
+    setChatRooms((prev) => prev.filter((r) => r.id !== roomId));
+    setSelectedRoom((prev) => (prev?.id === roomId ? null : prev));
+  }, []);
+
   const exitChat = () => {
     setSelectedRoom(null);
   };
@@ -107,21 +170,55 @@ export const useChat = (
         }
 
         const updatedChatRooms = prevChatRooms.map((room) => {
-          if (room.id === message.roomId) {
-            return {
-              ...room,
-              name: message.roomName,
-              updatedAt: message.timestamp,
-            };
+          if (room.id !== message.roomId) return room;
+          if (message.type !== "normal" && message.type !== "system") {
+            return { ...room, name: message.roomName, updatedAt: message.timestamp };
           }
-          return room;
+          // 與後端相同：正在閱讀的聊天室與自己的訊息不算未讀
+          const unread =
+            message.roomId !== selectedRoomIdRef.current &&
+            message.senderId !== userSession?.id;
+          return {
+            ...room,
+            name: message.roomName,
+            updatedAt: message.timestamp,
+            lastMessage: {
+              id: message.id ?? "",
+              type: message.type,
+              senderId: message.senderId,
+              senderUsername: message.senderUsername,
+              content: message.content,
+              timestamp: message.timestamp,
+            },
+            unreadCount: (room.unreadCount ?? 0) + (unread ? 1 : 0),
+          };
         });
-        return updatedChatRooms.sort(
-          (a, b) =>
-            new Date(b.updatedAt).getTime() - new Date(a.updatedAt).getTime()
-        );
+        return updatedChatRooms.sort(compareChatRooms);
       });
 
+      // 正在閱讀的聊天室收到其他人的訊息時，讓後端也記錄為已讀 (合併短時間內的多則訊息)
+      if (
+        (message.type === "normal" || message.type === "system") &&
+        message.roomId === selectedRoomIdRef.current &&
+        message.senderId !== userSession?.id
+      ) {
+        scheduleMarkRead(message.roomId);
+      }
+
+      // 其他聊天室的新訊息以通知提醒，已設為靜音的聊天室 (silent) 不提醒
+      if (
+        message.type === "normal" &&
+        !message.silent &&
+        message.roomId !== selectedRoomIdRef.current &&
+        message.senderId !== userSession?.id
+      ) {
+        notifications.show({
+          title: message.roomName,
+          message: `${message.senderUsername}：${message.content}`,
+          color: "blue",
+        });
+      }
+
       // 更新當前聊天室的標題
       setSelectedRoom((prev) =>
         prev && prev.id === message.roomId
@@ -141,7 +238,7 @@ export const useChat = (
         });
       }
     },
-    [fetchUserChatRooms]
+    [fetchUserChatRooms, userSession, scheduleMarkRead]
   );
 
   return {
@@ -151,6 +248,7 @@ export const useChat = (
     handleSelectRoom,
     startChatWithUser,
     handleLeaveRoom,
+    removeRoom,
     fetchUserChatRooms,
     exitChat,
     updateChatState,
@@ -3,12 +3,14 @@ import { useState, useEffect, useRef, useCallback } from "react";
 import { notifications } from "@mantine/notifications";
 import { WEBSOCKET_URL } from "../config";
 import type { Message } from "../types";
+import { refreshSession } from "../api/api_auth";
 
 export const useWebSocket = (
   userSession: ReturnType<typeof import("../utils/utils_auth").getUserSession>,
   onMessageReceived: (message: Message) => void,
   onForceLogout: () => void,
-  onStateUpdate: () => void
+  onStateUpdate: () => void,
+  onRoomRemoved: (roomId: string) => void
 ) => {
   const ws = useRef<WebSocket | null>(null);
   const [isConnected, setIsConnected] = useState(false);
@@ -19,43 +21,75 @@ export const useWebSocket = (
       return;
     }
 
-    const websocketUrl = WEBSOCKET_URL;
-    const newWs = new WebSocket(websocketUrl);
+    // access token 的效期很短，連線前先換發一次，避免以過期的 cookie 握手失敗
+    let cancelled = false;
+    let newWs: WebSocket | null = null;
 
-    newWs.onopen = () => setIsConnected(true);
-    newWs.onclose = () => setIsConnected(false);
-    newWs.onerror = () => setIsConnected(false);
+    const connect = () => {
+      if (cancelled) return;
+      const websocketUrl = WEBSOCKET_URL;
+      const socket = new WebSocket(websocketUrl);
+      newWs = socket;
 
-    newWs.onmessage = (event: MessageEvent) => {
-      const receivedMessage: Message = JSON.parse(event.data);
+      socket.onopen = () => setIsConnected(true);
+      socket.onclose = () => setIsConnected(false);
+      socket.onerror = () => setIsConnected(false);
 
-      if (receivedMessage.type === "force_logout") {
-        notifications.show({
-          title: "登出通知",
-          message:
-            receivedMessage.content || "您的帳號已在另一台裝置登入，您已被登出",
-          color: "orange",
-          autoClose: 5000,
-        });
-        onForceLogout();
-        return;
-      }
+      socket.onmessage = (event: MessageEvent) => {
+        const receivedMessage: Message = JSON.parse(event.data);
 
-      if (receivedMessage.type === "room_state_update") {
-        onStateUpdate();
-      }
+        if (receivedMessage.type === "force_logout") {
+          notifications.show({
+            title: "登出通知",
+            message:
+              receivedMessage.content || "您的帳號已在另一台裝置登入，您已被登出",
+            color: "orange",
+            autoClose: 5000,
+          });
+          onForceLogout();
+          return;
+        }
+
+        // 被管理員移出或封鎖，只有當事人會收到
+        if (receivedMessage.type === "room_removed") {
+          notifications.show({
+            title: "已被移出聊天室",
+            message: receivedMessage.content,
+            color: "orange",
+          });
+          onRoomRemoved(receivedMessage.roomId);
+          return;
+        }
+
+        // 訊息被伺服器拒絕 (例如被禁言或聊天室已封存)，只有發送者會收到
+        if (receivedMessage.type === "error") {
+          notifications.show({
+            title: "訊息未送出",
+            message: receivedMessage.content,
+            color: "red",
+          });
+          return;
+        }
+
+        if (receivedMessage.type === "room_state_update") {
+          onStateUpdate();
+        }
+
+        onMessageReceived(receivedMessage);
+      };
 
-      onMessageReceived(receivedMessage);
+      ws.current = socket;
     };
 
-    ws.current = newWs;
+    refreshSession().finally(connect);
 
     return () => {
-      if (newWs.readyState < 2) {
+      cancelled = true;
+      if (newWs && newWs.readyState < 2) {
         newWs.close();
       }
     };
-  }, [userSession, onMessageReceived, onForceLogout, onStateUpdate]);
+  }, [userSession, onMessageReceived, onForceLogout, onStateUpdate, onRoomRemoved]);
 
   const sendMessage = useCallback(
     (messageToSend: Partial<Message>) => {
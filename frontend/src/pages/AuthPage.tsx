@@ -1,6 +1,6 @@
 // src/pages/AuthPage.tsx
-import { useState } from "react";
-import { useNavigate } from "react-router-dom";
+import { useEffect, useState, type FormEvent } from "react";
+import { useNavigate, useSearchParams } from "react-router-dom";
 import {
   Paper,
   TextInput,
@@ -12,15 +12,51 @@ import {
   Group,
   Stack,
   Flex,
+  Text,
 } from "@mantine/core";
 import { useForm } from "@mantine/form";
-import { register, login } from "../api/api_auth";
+import {
+  register,
+  login,
+  loginTwoFactor,
+  confirmIdentityLink,
+  getLoginProviders,
+  type LoginProvider,
+} from "../api/api_auth";
+import { API_BASE_URL } from "../config";
 import { saveUserSession } from "../utils/utils_auth";
 
 function AuthPage() {
   const [isRegister, setIsRegister] = useState(false);
+  // 密碼正確但需要兩步驟驗證時保存 challenge token
+  const [challengeToken, setChallengeToken] = useState<string | null>(null);
+  const [twoFactorCode, setTwoFactorCode] = useState("");
+  // 後端設定的外部登入提供者 (Google 與其他 OpenID Connect 提供者)
+  const [providers, setProviders] = useState<LoginProvider[]>([]);
+  // 外部登入的 Email 已被註冊時，後端帶回待確認的連結請求
+  const [searchParams, setSearchParams] = useSearchParams();
+  const linkToken = searchParams.get("link");
+  const linkProvider = providers.find(
+    (provider) => provider.id === searchParams.get("provider")
+  );
+  const [linkPassword, setLinkPassword] = useState("");
+  const [linkNeedsCode, setLinkNeedsCode] = useState(false);
   const navigate = useNavigate();
 
+  useEffect(() => {
+    getLoginProviders().then(setProviders);
+  }, []);
+
+  // 外部登入的帳號啟用兩步驟驗證時，後端帶著 challenge token 導回這裡完成第二步
+  useEffect(() => {
+    const challenge = searchParams.get("challenge");
+    if (!challenge) return;
+    setChallengeToken(challenge);
+    // 取出後從網址移除，避免 token 留在瀏覽紀錄中
+    searchParams.delete("challenge");
+    setSearchParams(searchParams, { replace: true });
+  }, [searchParams, setSearchParams]);
+
   const registerForm = useForm({
     initialValues: {
       email: "",
@@ -63,6 +99,10 @@ function AuthPage() {
   const handleLogin = async (values: typeof loginForm.values) => {
     try {
       const response = await login(values);
+      if (response.twoFactorRequired && response.challengeToken) {
+        setChallengeToken(response.challengeToken);
+        return;
+      }
       if (response.id && response.username) {
         saveUserSession({
           id: response.id,
@@ -76,9 +116,65 @@ function AuthPage() {
     }
   };
 
-  const handleGoogleLogin = () => {
-    // 直接導向後端處理，後端會再導向 Google
-    window.location.href = "http://localhost:8080/auth/google/login";
+  const handleTwoFactor = async (event: FormEvent) => {
+    event.preventDefault();
+    if (!challengeToken) return;
+    // 六位數字視為驗證碼，其他格式視為復原碼
+    const value = twoFactorCode.trim();
+    const payload = /^\d{6}$/.test(value)
+      ? { challengeToken, code: value }
+      : { challengeToken, recoveryCode: value };
+    try {
+      const response = await loginTwoFactor(payload);
+      if (response.id && response.username) {
+        saveUserSession({
+          id: response.id,
+          username: response.username,
+        });
+        navigate("/home");
+      }
+    } catch (error) {
+      console.error("兩步驟驗證失敗:", error);
+    }
+  };
+
+  const handleConfirmLink = async (event: FormEvent) => {
+    event.preventDefault();
+    if (!linkToken) return;
+    const value = twoFactorCode.trim();
+    const payload = !linkNeedsCode
+      ? { linkToken, password: linkPassword }
+      : /^\d{6}$/.test(value)
+        ? { linkToken, password: linkPassword, code: value }
+        : { linkToken, password: linkPassword, recoveryCode: value };
+    try {
+      const response = await confirmIdentityLink(payload);
+      if (response.twoFactorRequired) {
+        setLinkNeedsCode(true);
+        return;
+      }
+      if (response.id && response.username) {
+        saveUserSession({
+          id: response.id,
+          username: response.username,
+        });
+        navigate("/home");
+      }
+    } catch (error) {
+      console.error("連結帳號失敗:", error);
+    }
+  };
+
+  const cancelLink = () => {
+    setSearchParams({});
+    setLinkPassword("");
+    setLinkNeedsCode(false);
+    setTwoFactorCode("");
+  };
+
+  const handleProviderLogin = (providerId: string) => {
+    // 直接導向後端處理，後端會再導向提供者的登入頁面
+    window.location.href = `${API_BASE_URL}/auth/${providerId}/login`;
   };
 
   return (
@@ -90,10 +186,88 @@ function AuthPage() {
     >
       <Paper radius="md" p="xl" withBorder w={400}>
         <Title order={2} size="h1" fw={900} ta="center" mt="md" mb={30}>
-          {isRegister ? "註冊帳號" : "登入您的帳號"}
+          {linkToken
+            ? "連結既有帳號"
+            : challengeToken
+              ? "兩步驟驗證"
+              : isRegister ? "註冊帳號" : "登入您的帳號"}
         </Title>
 
-        {isRegister ? (
+        {linkToken ? (
+          <form onSubmit={handleConfirmLink}>
+            <Stack>
+              <Text size="sm">
+                已經有帳號使用這個電子郵件。請輸入該帳號的密碼，確認後會將
+                {linkProvider?.displayName ?? "外部登入"}連結到這個帳號。
+              </Text>
+              <PasswordInput
+                label="密碼"
+                placeholder="既有帳號的密碼"
+                value={linkPassword}
+                onChange={(event) => setLinkPassword(event.currentTarget.value)}
+                radius="md"
+              />
+              {linkNeedsCode && (
+                <TextInput
+                  label="驗證碼"
+                  description="請輸入驗證器 App 中的六位數驗證碼，或一組復原碼"
+                  placeholder="123456"
+                  value={twoFactorCode}
+                  onChange={(event) =>
+                    setTwoFactorCode(event.currentTarget.value)
+                  }
+                  autoComplete="one-time-code"
+                  radius="md"
+                />
+              )}
+            </Stack>
+            <Group justify="space-between" mt="xl">
+              <Anchor
+                component="button"
+                type="button"
+                c="dimmed"
+                size="xs"
+                onClick={cancelLink}
+              >
+                返回登入
+              </Anchor>
+              <Button type="submit" radius="md">
+                確認連結
+              </Button>
+            </Group>
+          </form>
+        ) : challengeToken ? (
+          <form onSubmit={handleTwoFactor}>
+            <Stack>
+              <TextInput
+                label="驗證碼"
+                description="請輸入驗證器 App 中的六位數驗證碼，或一組復原碼"
+                placeholder="123456"
+                value={twoFactorCode}
+                onChange={(event) => setTwoFactorCode(event.currentTarget.value)}
+                autoComplete="one-time-code"
+                radius="md"
+              />
+            </Stack>
+            <Group justify="space-between" mt="xl">
+              <Anchor
+                component="button"
+                type="button"
+                c="dimmed"
+                size="xs"
+                onClick={() => {
+                  setChallengeToken(null);
+                  setTwoFactorCode("");
+                }}
+              >
+                返回登入
+              </Anchor>
+              <Button type="submit" radius="md">
+                驗證
+              </Button>
+            </Group>
+          </form>
+        ) : isRegister ? (
           <form onSubmit={registerForm.onSubmit(handleRegister)}>
             <Stack>
               <TextInput
@@ -172,16 +346,33 @@ function AuthPage() {
                 error={loginForm.errors.password}
                 radius="md"
               />
+              <Anchor
+                component="button"
+                type="button"
+                c="dimmed"
+                size="xs"
+                ta="right"
+                onClick={() => navigate("/reset-password")}
+              >
+                忘記密碼？
+              </Anchor>
+            </Stack>
+            {providers.length > 0 && (
+              <Divider label="或" labelPosition="center" my="lg" />
+            )}
+            <Stack gap="xs">
+              {providers.map((provider) => (
+                <Button
+                  key={provider.id}
+                  fullWidth
+                  variant="outline"
+                  color="gray"
+                  onClick={() => handleProviderLogin(provider.id)}
+                >
+                  使用{provider.displayName}登入
+                </Button>
+              ))}
             </Stack>
-            <Divider label="或" labelPosition="center" my="lg" />
-            <Button
-              fullWidth
-              variant="outline"
-              color="gray"
-              onClick={handleGoogleLogin}
-            >
-              使用Google登入
-            </Button>
 
             <Group justify="space-between" mt="xl">
               <Anchor
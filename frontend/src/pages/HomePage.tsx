@@ -10,6 +10,7 @@ import {
   Title,
   Button,
   Stack,
+  Text,
 } from "@mantine/core";
 import { useDisclosure } from "@mantine/hooks";
 import { useAuth } from "../hooks/useAuth";
@@ -24,7 +25,9 @@ import MessageInput from "../components/chat/MessageInput";
 import AppHeader from "../components/common/AppHeader";
 import WelcomeMessage from "../components/common/WelcomeMessage";
 import InviteUsersModal from "../components/modals/InviteUsersModal";
+import ChannelDirectoryModal from "../components/modals/ChannelDirectoryModal";
 import type { ChatRoom } from "../types";
+import { updateRoomSettings, type UpdateRoomSettingsPayload } from "../api/api_chatroom";
 
 function HomePage() {
   const [opened, { toggle }] = useDisclosure();
@@ -37,6 +40,7 @@ function HomePage() {
     handleSelectRoom,
     startChatWithUser,
     handleLeaveRoom,
+    removeRoom,
     fetchUserChatRooms,
     exitChat,
     updateChatState,
@@ -47,7 +51,8 @@ function HomePage() {
     userSession,
     updateChatState, // onMessageReceived
     handleLogout, // onForceLogout
-    fetchUserChatRooms // onStateUpdate
+    fetchUserChatRooms, // onStateUpdate
+    removeRoom // onRoomRemoved
   );
 
   const [messageInput, setMessageInput] = useState("");
@@ -57,6 +62,10 @@ function HomePage() {
     isInviteModalOpen,
     { open: openInviteModal, close: closeInviteModal },
   ] = useDisclosure(false);
+  const [
+    isChannelDirectoryOpen,
+    { open: openChannelDirectory, close: closeChannelDirectory },
+  ] = useDisclosure(false);
   const [chatRoomToInvite, setChatRoomToInvite] = useState<ChatRoom | null>(null);
 
   useEffect(() => {
@@ -99,6 +108,27 @@ function HomePage() {
     [selectedRoom, fetchUserChatRooms, setSelectedRoom]
   );
 
+  const handleUpdateRoomSettings = useCallback(
+    async (room: ChatRoom, changes: UpdateRoomSettingsPayload) => {
+      const settings = await updateRoomSettings(room.id, changes);
+      if (!settings) return;
+      // 隱藏的聊天室會從列表中消失，若正在查看則回到首頁
+      if (settings.hidden && selectedRoom?.id === room.id) {
+        exitChat();
+      }
+      fetchUserChatRooms();
+    },
+    [selectedRoom, exitChat, fetchUserChatRooms]
+  );
+
+  const handleChannelJoined = useCallback(
+    async (room: ChatRoom) => {
+      await fetchUserChatRooms();
+      handleSelectRoom(room);
+    },
+    [fetchUserChatRooms, handleSelectRoom]
+  );
+
   if (!userSession) {
     return null; // 或者顯示一個載入中的畫面
   }
@@ -119,12 +149,16 @@ function HomePage() {
       <AppShell.Navbar p="md">
         <ScrollArea h="calc(100vh - var(--app-shell-header-height) - var(--app-shell-footer-height, 0px))">
           <Stack gap="md">
+            <Button variant="light" onClick={openChannelDirectory}>
+              瀏覽頻道
+            </Button>
             <ChatRoomList
               chatRooms={chatRooms}
               selectedRoomId={selectedRoom?.id || null}
               onSelectRoom={handleSelectRoom}
               onLeaveRoom={handleLeaveRoom}
               onInviteClick={handleInviteClick}
+              onUpdateSettings={handleUpdateRoomSettings}
             />
             <UserList users={allUsers} onStartChat={startChatWithUser} />
           </Stack>
@@ -144,7 +178,17 @@ function HomePage() {
             }}
           >
             <Group justify="space-between" align="center" mb="md">
-              <Title order={3}>聊天室：{selectedRoom.name}</Title>
+              <Stack gap={0}>
+                <Title order={3}>
+                  聊天室：{selectedRoom.settings?.nickname || selectedRoom.name}
+                  {selectedRoom.archivedAt && "（已封存）"}
+                </Title>
+                {selectedRoom.topic && (
+                  <Text size="sm" c="dimmed" title={selectedRoom.description}>
+                    {selectedRoom.topic}
+                  </Text>
+                )}
+              </Stack>
               <Button variant="light" color="green" onClick={exitChat}>
                 回到首頁
               </Button>
@@ -159,7 +203,7 @@ function HomePage() {
               messageInput={messageInput}
               onMessageInputChange={setMessageInput}
               onSendMessage={handleSendMessage}
-              isDisabled={!isConnected}
+              isDisabled={!isConnected || !!selectedRoom.archivedAt}
             />
           </Paper>
         ) : (
@@ -176,6 +220,12 @@ function HomePage() {
           onInviteSuccess={handleInviteSuccess}
         />
       )}
+
+      <ChannelDirectoryModal
+        opened={isChannelDirectoryOpen}
+        onClose={closeChannelDirectory}
+        onJoined={handleChannelJoined}
+      />
     </AppShell>
   );
 }
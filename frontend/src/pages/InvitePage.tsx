@@ -0,0 +1,53 @@
+// src/pages/InvitePage.tsx
+import { useEffect, useState } from "react";
+import { useNavigate, useParams } from "react-router-dom";
+import { Paper, Button, Title, Text, Group, Flex, Loader } from "@mantine/core";
+import { redeemRoomInvite } from "../api/api_chatroom";
+
+// 從邀請連結進來時自動兌換邀請，成功後回到首頁就能在聊天室列表中看到該聊天室
+function InvitePage() {
+  const { code } = useParams<{ code: string }>();
+  const navigate = useNavigate();
+  const [error, setError] = useState<string | null>(null);
+
+  useEffect(() => {
+    if (!code) return;
+    let cancelled = false;
+    redeemRoomInvite(code)
+      .then(() => !cancelled && navigate("/home", { replace: true }))
+      .catch((err: unknown) => {
+        if (cancelled) return;
+        setError(err instanceof Error ? err.message : "無法使用邀請連結");
+      });
+    return () => {
+      cancelled = true;
+    };
+  }, [code, navigate]);
+
+  return (
+    <Flex mih="100vh" justify="center" align="center" bg="var(--mantine-color-gray-0)">
+      <Paper radius="md" p="xl" withBorder w={400}>
+        <Title order={2} size="h1" fw={900} ta="center" mt="md" mb={30}>
+          加入聊天室
+        </Title>
+
+        {error ? (
+          <>
+            <Text ta="center">{error}</Text>
+            <Group justify="center" mt="xl">
+              <Button radius="md" onClick={() => navigate("/home", { replace: true })}>
+                回到首頁
+              </Button>
+            </Group>
+          </>
+        ) : (
+          <Flex justify="center">
+            <Loader />
+          </Flex>
+        )}
+      </Paper>
+    </Flex>
+  );
+}
+
+export default InvitePage;
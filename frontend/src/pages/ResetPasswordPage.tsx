@@ -0,0 +1,115 @@
+// src/pages/ResetPasswordPage.tsx
+import { useNavigate, useSearchParams } from "react-router-dom";
+import {
+  Paper,
+  TextInput,
+  PasswordInput,
+  Button,
+  Title,
+  Anchor,
+  Group,
+  Stack,
+  Flex,
+} from "@mantine/core";
+import { useForm } from "@mantine/form";
+import { requestPasswordReset, confirmPasswordReset } from "../api/api_auth";
+
+// 沒有 token 時顯示申請重設的表單；從信中的連結進來時顯示設定新密碼的表單
+function ResetPasswordPage() {
+  const [searchParams] = useSearchParams();
+  const token = searchParams.get("token");
+  const navigate = useNavigate();
+
+  const requestForm = useForm({
+    initialValues: { email: "" },
+    validate: {
+      email: (value) => (/^\S+@\S+$/.test(value) ? null : "無效的電子郵件"),
+    },
+  });
+
+  const confirmForm = useForm({
+    initialValues: { password: "", confirmPassword: "" },
+    validate: {
+      password: (value) => (value.length >= 6 ? null : "密碼至少6個字元"),
+      confirmPassword: (value, values) =>
+        value === values.password ? null : "兩次輸入的密碼不一致",
+    },
+  });
+
+  const handleRequest = async (values: typeof requestForm.values) => {
+    try {
+      await requestPasswordReset(values.email);
+    } catch (error) {
+      console.error("申請重設密碼失敗:", error);
+    }
+  };
+
+  const handleConfirm = async (values: typeof confirmForm.values) => {
+    if (!token) return;
+    try {
+      await confirmPasswordReset(token, values.password);
+      navigate("/auth", { replace: true });
+    } catch (error) {
+      console.error("重設密碼失敗:", error);
+    }
+  };
+
+  return (
+    <Flex mih="100vh" justify="center" align="center" bg="var(--mantine-color-gray-0)">
+      <Paper radius="md" p="xl" withBorder w={400}>
+        <Title order={2} size="h1" fw={900} ta="center" mt="md" mb={30}>
+          {token ? "設定新密碼" : "忘記密碼"}
+        </Title>
+
+        {token ? (
+          <form onSubmit={confirmForm.onSubmit(handleConfirm)}>
+            <Stack>
+              <PasswordInput
+                label="新密碼"
+                placeholder="您的新密碼"
+                radius="md"
+                {...confirmForm.getInputProps("password")}
+              />
+              <PasswordInput
+                label="確認新密碼"
+                placeholder="再輸入一次新密碼"
+                radius="md"
+                {...confirmForm.getInputProps("confirmPassword")}
+              />
+            </Stack>
+            <Group justify="flex-end" mt="xl">
+              <Button type="submit" radius="md">
+                重設密碼
+              </Button>
+            </Group>
+          </form>
+        ) : (
+          <form onSubmit={requestForm.onSubmit(handleRequest)}>
+            <TextInput
+              label="電子郵件"
+              placeholder="註冊時使用的電子郵件"
+              radius="md"
+              {...requestForm.getInputProps("email")}
+            />
+            <Group justify="space-between" mt="xl">
+              <Anchor
+                component="button"
+                type="button"
+                c="dimmed"
+                size="xs"
+                onClick={() => navigate("/auth")}
+              >
+                返回登入
+              </Anchor>
+              <Button type="submit" radius="md">
+                寄送重設連結
+              </Button>
+            </Group>
+          </form>
+        )}
+      </Paper>
+    </Flex>
+  );
+}
+
+export default ResetPasswordPage;
@@ -0,0 +1,112 @@
+// src/pages/VerifyEmailPage.tsx
+import { useEffect, useState } from "react";
+import { useNavigate, useSearchParams } from "react-router-dom";
+import {
+  Paper,
+  TextInput,
+  Button,
+  Title,
+  Text,
+  Anchor,
+  Group,
+  Flex,
+  Loader,
+} from "@mantine/core";
+import { useForm } from "@mantine/form";
+import {
+  confirmEmailVerification,
+  resendEmailVerification,
+} from "../api/api_auth";
+
+type VerifyStatus = "verifying" | "verified" | "failed";
+
+// 從驗證信的連結進來時自動完成驗證；沒有 token 或驗證失敗時可以重新寄送驗證信
+function VerifyEmailPage() {
+  const [searchParams] = useSearchParams();
+  const token = searchParams.get("token");
+  const navigate = useNavigate();
+  const [status, setStatus] = useState<VerifyStatus>(token ? "verifying" : "failed");
+
+  const resendForm = useForm({
+    initialValues: { email: "" },
+    validate: {
+      email: (value) => (/^\S+@\S+$/.test(value) ? null : "無效的電子郵件"),
+    },
+  });
+
+  useEffect(() => {
+    if (!token) return;
+    let cancelled = false;
+    confirmEmailVerification(token)
+      .then(() => !cancelled && setStatus("verified"))
+      .catch(() => !cancelled && setStatus("failed"));
+    return () => {
+      cancelled = true;
+    };
+  }, [token]);
+
+  const handleResend = async (values: typeof resendForm.values) => {
+    try {
+      await resendEmailVerification(values.email);
+    } catch (error) {
+      console.error("重新寄送驗證信失敗:", error);
+    }
+  };
+
+  return (
+    <Flex mih="100vh" justify="center" align="center" bg="var(--mantine-color-gray-0)">
+      <Paper radius="md" p="xl" withBorder w={400}>
+        <Title order={2} size="h1" fw={900} ta="center" mt="md" mb={30}>
+          Email 驗證
+        </Title>
+
+        {status === "verifying" && (
+          <Flex justify="center">
+            <Loader />
+          </Flex>
+        )}
+
+        {status === "verified" && (
+          <>
+            <Text ta="center">您的 Email 已完成驗證。</Text>
+            <Group justify="center" mt="xl">
+              <Button radius="md" onClick={() => navigate("/auth", { replace: true })}>
+                前往登入
+              </Button>
+            </Group>
+          </>
+        )}
+
+        {status === "failed" && (
+          <form onSubmit={resendForm.onSubmit(handleResend)}>
+            <Text size="sm" c="dimmed" mb="md">
+              {token ? "驗證連結無效或已過期，" : ""}輸入註冊時使用的電子郵件以重新寄送驗證信。
+            </Text>
+            <TextInput
+              label="電子郵件"
+              placeholder="註冊時使用的電子郵件"
+              radius="md"
+              {...resendForm.getInputProps("email")}
+            />
+            <Group justify="space-between" mt="xl">
+              <Anchor
+                component="button"
+                type="button"
+                c="dimmed"
+                size="xs"
+                onClick={() => navigate("/auth")}
+              >
+                返回登入
+              </Anchor>
+              <Button type="submit" radius="md">
+                重新寄送驗證信
+              </Button>
+            </Group>
+          </form>
+        )}
+      </Paper>
+    </Flex>
+  );
+}
+
+export default VerifyEmailPage;
@@ -1,10 +1,43 @@
+// 聊天室類型：私訊每對使用者只有一個且不能加入其他成員，頻道只有管理員可以邀請
+export type RoomKind = "dm" | "group" | "channel";
+
 export interface ChatRoom {
   id: string;
   name: string;
+  kind: RoomKind;
+  customName?: string; // 自訂名稱，未設定時 name 依成員自動產生
+  topic?: string;
+  description?: string;
+  public?: boolean; // 公開頻道，任何使用者都能在頻道目錄中加入
   creatorId: string;
+  ownerId?: string; // 擁有者，舊聊天室沒有時以 creatorId 為擁有者
+  archivedAt?: string; // 封存時間，封存的聊天室只能閱讀
   participants: string[];
   createdAt: string;
   updatedAt: string; // Add updatedAt
+  settings?: RoomMemberSettings; // 目前使用者的個人設定，只出現在自己的聊天室列表中
+  lastMessage?: MessagePreview; // 最後一則訊息，聊天室列表不需要再讀取聊天記錄
+  unreadCount?: number; // 目前使用者的未讀訊息數
+}
+
+// 聊天室列表中的最後一則訊息，內容過長時已被截斷
+export interface MessagePreview {
+  id: string;
+  type: "normal" | "system";
+  senderId: string;
+  senderUsername: string;
+  content: string;
+  timestamp: string;
+}
+
+// 使用者對單一聊天室的個人設定，不會影響其他成員
+export interface RoomMemberSettings {
+  muted?: boolean;
+  mutedUntil?: string; // 靜音結束時間，未設定代表直到取消靜音
+  favorite?: boolean; // 我的最愛會置頂顯示
+  sortOrder?: number; // 我的最愛之間的順序，數字小的在前
+  hidden?: boolean; // 隱藏的聊天室不會出現在聊天室列表中
+  nickname?: string; // 只有自己看得到的聊天室名稱
 }
 
 export interface User {
@@ -16,11 +49,29 @@ export interface User {
 // 定義訊息類型，與後端 models.Message 保持一致
 export interface Message {
   id?: string; // 後端生成
-  type?: "normal" | "system" | "room_state_update" | "force_logout"; // 消息類型，新增 room_state_update
+  type?: "normal" | "system" | "room_state_update" | "force_logout" | "command_response" | "room_removed" | "error"; // 消息類型，新增 room_state_update
   senderId: string;
   senderUsername: string;
   roomId: string; // 聊天室ID
   roomName: string; // 聊天室名稱
   content: string;
   timestamp: string; // ISO 格式日期字串
+  silent?: boolean; // 已將聊天室設為靜音時為 true，不顯示通知
+}
+
+// 頻道目錄中的公開頻道
+export interface ChannelDirectoryEntry {
+  id: string;
+  name: string;
+  topic?: string;
+  description?: string;
+  memberCount: number;
+  joined: boolean;
+}
+
+export interface ChannelDirectoryPage {
+  channels: ChannelDirectoryEntry[];
+  total: number;
+  page: number;
+  limit: number;
 }
@@ -143,6 +143,7 @@ function New-Room {
 
     $body = @{
         participantIds = $ParticipantIds
+        kind           = "group"
     }
 
     $response = Invoke-JsonRequest -Method "POST" -Url "$BaseUrl/create-chatrooms" -Body $body -WebSession $session